@@ -0,0 +1,108 @@
+// Package aisarchive stores AIS history in a compact binary file. Every record contains the packed
+// payload as consumed by ais.Codec.DecodePacket64, the receive time, a source ID and the channel.
+//
+// Records are grouped in blocks that are compressed with DEFLATE. An index at the end of the file
+// contains the time range and the MMSIs of every block, so a Reader only decompresses the blocks
+// that can contain records matching a query.
+//
+// The file layout is:
+//
+//	magic "AISARCv1"
+//	blocks, each a DEFLATE stream of records:
+//	  zigzag varint: time in microseconds relative to the previous record (the first record is
+//	                 relative to the block base time in the index)
+//	  uvarint: source ID
+//	  byte: channel
+//	  uvarint: number of payload bits
+//	  payload bytes, most significant bit first
+//	index, a DEFLATE stream:
+//	  uvarint: number of source names, followed by uvarint ID, uvarint length and name for each
+//	  uvarint: number of blocks, followed for each by:
+//	    uvarint offset, uvarint compressed length, uvarint number of records
+//	    zigzag varint base time, zigzag varint start time - base, uvarint end time - start
+//	    uvarint number of MMSIs, followed by the sorted MMSIs as uvarint differences
+//	trailer: uint64 index offset, uint32 index length (little endian), magic "AISARCv1"
+//
+// Times are stored with microsecond resolution in UTC. A zero time.Time is stored as the Unix epoch
+// and read back as a zero time.Time. A payload has at most 8192 bits and the uncompressed index is
+// at most 1 GiB, so a Reader can limit the memory used to decompress a damaged file.
+package aisarchive
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+var magic = []byte("AISARCv1")
+
+const trailerLength = 8 + 4 + 8
+
+/* The longest message that fits in nine sentences is much shorter than maxPayloadBits */
+const maxPayloadBits = 8192
+
+/* The uncompressed length of a record can not exceed maxRecordLength */
+const maxRecordLength = 3*binary.MaxVarintLen64 + 1 + maxPayloadBits/8
+
+const maxIndexLength = 1 << 30
+
+// Record is a packet stored in the archive
+type Record struct {
+	Time    time.Time
+	Source  uint32
+	Channel byte
+
+	// Payload and NumBits are the packet as passed to ais.Codec.DecodePacket64
+	Payload []uint64
+	NumBits int
+}
+
+// SetBits stores a payload given as one bit per byte, the format of ais.Codec.DecodePacket
+func (r *Record) SetBits(bits []byte) {
+	r.Payload = make([]uint64, (len(bits)+63)/64)
+	r.NumBits = len(bits)
+
+	for i, b := range bits {
+		if b > 0 {
+			r.Payload[i/64] |= 1 << uint(63-i%64)
+		}
+	}
+}
+
+// Bits returns the payload as one bit per byte
+func (r *Record) Bits() []byte {
+	bits := make([]byte, r.NumBits)
+	for i := range bits {
+		bits[i] = byte(r.Payload[i/64]>>uint(63-i%64)) & 1
+	}
+	return bits
+}
+
+// MMSI returns the user ID from the header of the payload, or 0 if the payload is too short
+func (r *Record) MMSI() uint32 {
+	if r.NumBits < 38 || len(r.Payload) == 0 {
+		return 0
+	}
+	return uint32(r.Payload[0]>>26) & (1<<30 - 1)
+}
+
+func toMicros(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano() / 1000
+}
+
+func fromMicros(us int64) time.Time {
+	if us == 0 {
+		return time.Time{}
+	}
+	return time.Unix(us/1000000, us%1000000*1000).UTC()
+}
+
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+func unzigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
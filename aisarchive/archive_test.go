@@ -0,0 +1,300 @@
+package aisarchive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+func readTestBits(t *testing.T) [][]byte {
+	var result [][]byte
+	for msgID := 1; msgID <= 27; msgID++ {
+		f, err := os.Open(fmt.Sprintf("../testmsg/%d.msg", msgID))
+		if err != nil {
+			continue
+		}
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			bits := []byte(strings.TrimSpace(scanner.Text()))
+			for i := range bits {
+				bits[i] -= '0'
+			}
+			result = append(result, bits)
+		}
+		f.Close()
+	}
+
+	if len(result) == 0 {
+		t.Fatal("No test packets")
+	}
+	return result
+}
+
+var testStart = time.Date(2021, 3, 4, 5, 6, 7, 123456000, time.UTC)
+
+func testRecords(t *testing.T) []*Record {
+	var records []*Record
+	for i, bits := range readTestBits(t) {
+		r := &Record{
+			Time:    testStart.Add(time.Duration(i) * 1500 * time.Millisecond),
+			Source:  uint32(i % 3),
+			Channel: "AB"[i%2],
+		}
+		if i%50 == 7 {
+			/* Receive times are not always in order */
+			r.Time = r.Time.Add(-time.Minute)
+		}
+		r.SetBits(bits)
+		records = append(records, r)
+	}
+	return records
+}
+
+func writeArchive(t *testing.T, records []*Record, blockSize int) []byte {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.BlockSize = blockSize
+	w.SetSourceName(1, "receiver one")
+
+	for _, r := range records {
+		if err := w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func queryAll(t *testing.T, a *Reader, q Query) []*Record {
+	var result []*Record
+	if err := a.Query(q, func(r *Record) error {
+		result = append(result, r)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestRoundTrip(t *testing.T) {
+	records := testRecords(t)
+	data := writeArchive(t, records, 4096)
+
+	a, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(a.Blocks()) < 2 {
+		t.Fatal("Expected multiple blocks", len(a.Blocks()))
+	}
+	if a.SourceName(1) != "receiver one" || a.SourceName(2) != "" {
+		t.Error("Wrong source names")
+	}
+
+	got := queryAll(t, a, Query{})
+	if len(got) != len(records) {
+		t.Fatal("Wrong number of records", len(got), len(records))
+	}
+
+	c := ais.CodecNew(false, false)
+	for i, r := range got {
+		if !reflect.DeepEqual(r, records[i]) {
+			t.Fatal("Record changed", i, r, records[i])
+		}
+		if !reflect.DeepEqual(c.DecodePacket64(r.Payload, r.NumBits), c.DecodePacket(records[i].Bits())) {
+			t.Fatal("Packet changed", i)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	records := testRecords(t)
+	data := writeArchive(t, records, 2048)
+	a, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from := testStart.Add(100 * time.Second)
+	to := testStart.Add(400 * time.Second)
+	mmsi := records[200].MMSI()
+
+	for _, q := range []Query{
+		{From: from},
+		{To: to},
+		{From: from, To: to},
+		{MMSI: []uint32{mmsi}},
+		{From: from, To: to, MMSI: []uint32{mmsi, records[300].MMSI()}},
+		{From: to, To: from},
+	} {
+		m := newMatcher(&q)
+		var want []*Record
+		for _, r := range records {
+			if m.record(toMicros(r.Time), r) {
+				want = append(want, r)
+			}
+		}
+
+		got := queryAll(t, a, q)
+		if len(got) != len(want) {
+			t.Fatal("Wrong number of results", q, len(got), len(want))
+		}
+		for i := range got {
+			if !reflect.DeepEqual(got[i], want[i]) {
+				t.Fatal("Wrong result", q, i)
+			}
+		}
+	}
+
+	/* Errors returned by the callback stop the query */
+	stop := errors.New("stop")
+	count := 0
+	if err := a.ReadAll(func(r *Record) error {
+		count++
+		return stop
+	}); err != stop || count != 1 {
+		t.Error("Query did not stop", err, count)
+	}
+}
+
+func TestEmptyAndCorrupt(t *testing.T) {
+	data := writeArchive(t, nil, 0)
+	a, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.Blocks()) != 0 || len(queryAll(t, a, Query{})) != 0 {
+		t.Error("Empty archive contains records")
+	}
+
+	data = writeArchive(t, testRecords(t)[:20], 0)
+	for _, corrupt := range [][]byte{
+		data[:len(data)-1],
+		data[1:],
+		append(append([]byte(nil), data[:len(data)-20]...), 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x7F, 0, 0, 0, 0, 'A', 'I', 'S', 'A', 'R', 'C', 'v', '1'),
+		[]byte("short"),
+	} {
+		if _, err := NewReader(bytes.NewReader(corrupt), int64(len(corrupt))); err == nil {
+			t.Error("Corrupt archive accepted")
+		}
+	}
+
+	/* Damage the first block */
+	damaged := append([]byte(nil), data...)
+	for i := len(magic); i < len(magic)+16; i++ {
+		damaged[i] ^= 0x55
+	}
+	a, err = NewReader(bytes.NewReader(damaged), int64(len(damaged)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.ReadAll(func(r *Record) error { return nil }); err == nil {
+		t.Error("Damaged block accepted")
+	}
+}
+
+func deflate(data []byte) []byte {
+	var buf bytes.Buffer
+	fw, _ := flate.NewWriter(&buf, flate.DefaultCompression)
+	fw.Write(data)
+	fw.Close()
+	return buf.Bytes()
+}
+
+// rawArchive creates an archive with a compressed block and index
+func rawArchive(block []byte, index []byte) []byte {
+	data := append(append([]byte(nil), magic...), block...)
+	indexOffset := len(data)
+	data = append(data, index...)
+
+	trailer := make([]byte, trailerLength)
+	binary.LittleEndian.PutUint64(trailer, uint64(indexOffset))
+	binary.LittleEndian.PutUint32(trailer[8:], uint32(len(index)))
+	copy(trailer[12:], magic)
+	return append(data, trailer...)
+}
+
+// allocated returns the number of bytes allocated by fn
+func allocated(fn func()) uint64 {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	fn()
+	runtime.ReadMemStats(&after)
+	return after.TotalAlloc - before.TotalAlloc
+}
+
+func TestCorruptIndex(t *testing.T) {
+	/* One source with a name length of 1 GiB that is not in the index */
+	data := rawArchive(nil, deflate([]byte{1, 0, 0x80, 0x80, 0x80, 0x80, 0x04, 'A'}))
+
+	if alloc := allocated(func() {
+		if _, err := NewReader(bytes.NewReader(data), int64(len(data))); err != errCorrupt {
+			t.Error("Corrupt index accepted", err)
+		}
+	}); alloc > 1<<20 {
+		t.Error("Name allocated before checking its length", alloc)
+	}
+}
+
+func TestCorruptBlock(t *testing.T) {
+	/* A block with a single record that decompresses to 64 MiB */
+	block := deflate(make([]byte, 64<<20))
+
+	/* No sources, one block with one record */
+	var index []byte
+	buf := make([]byte, binary.MaxVarintLen64)
+	for _, v := range []uint64{0, 1, uint64(len(magic)), uint64(len(block)), 1, 0, 0, 0, 0} {
+		index = append(index, buf[:binary.PutUvarint(buf, v)]...)
+	}
+	data := rawArchive(block, deflate(index))
+
+	a, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if alloc := allocated(func() {
+		if err := a.ReadAll(func(r *Record) error { return nil }); err != errCorrupt {
+			t.Error("Block longer than its records accepted", err)
+		}
+	}); alloc > 1<<20 {
+		t.Error("Block decompressed without limit", alloc)
+	}
+}
+
+func TestPayloadTooLong(t *testing.T) {
+	r := &Record{}
+	r.SetBits(make([]byte, maxPayloadBits+1))
+	if err := NewWriter(ioutil.Discard).Write(r); err == nil {
+		t.Error("Payload longer than the maximum accepted")
+	}
+}
+
+func TestZeroTime(t *testing.T) {
+	r := &Record{Source: 5}
+	r.SetBits([]byte{0, 0, 0, 0, 0, 1, 1})
+
+	data := writeArchive(t, []*Record{r}, 0)
+	a, _ := NewReader(bytes.NewReader(data), int64(len(data)))
+	got := queryAll(t, a, Query{})
+	if len(got) != 1 || !got[0].Time.IsZero() || !reflect.DeepEqual(got[0].Bits(), r.Bits()) {
+		t.Error("Wrong record", got)
+	}
+}
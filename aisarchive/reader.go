@@ -0,0 +1,323 @@
+package aisarchive
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"time"
+)
+
+// BlockInfo describes a block of the archive
+type BlockInfo struct {
+	Start   time.Time
+	End     time.Time
+	Records int
+	MMSIs   []uint32
+}
+
+// Query selects records from an archive
+type Query struct {
+	// From and To limit the receive time of the records to [From, To). A zero value does not limit
+	// the time.
+	From time.Time
+	To   time.Time
+
+	// MMSI selects the records of these stations, all records if it is empty
+	MMSI []uint32
+}
+
+// Reader provides random access to an archive
+type Reader struct {
+	r       io.ReaderAt
+	sources map[uint32]string
+	blocks  []blockIndex
+}
+
+var errCorrupt = errors.New("aisarchive: corrupt archive")
+
+// decompress reads a DEFLATE stream. errCorrupt is returned if it is longer than limit.
+func decompress(r io.ReaderAt, offset int64, length int64, limit int64) ([]byte, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(flate.NewReader(io.NewSectionReader(r, offset, length)), limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, errCorrupt
+	}
+	return data, nil
+}
+
+// NewReader opens the archive in r, which is size bytes long
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	if size < int64(len(magic))+trailerLength {
+		return nil, errCorrupt
+	}
+
+	header := make([]byte, len(magic))
+	trailer := make([]byte, trailerLength)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, err
+	}
+	if _, err := r.ReadAt(trailer, size-trailerLength); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(header, magic) || !bytes.Equal(trailer[12:], magic) {
+		return nil, errors.New("aisarchive: not an archive")
+	}
+
+	indexOffset := int64(binary.LittleEndian.Uint64(trailer))
+	indexLength := int64(binary.LittleEndian.Uint32(trailer[8:]))
+	if indexOffset < int64(len(magic)) || indexOffset+indexLength > size-trailerLength {
+		return nil, errCorrupt
+	}
+
+	index, err := decompress(r, indexOffset, indexLength, maxIndexLength)
+	if err == errCorrupt {
+		return nil, err
+	} else if err != nil {
+		return nil, fmt.Errorf("aisarchive: failed to read index [%v]", err)
+	}
+
+	a := &Reader{
+		r:       r,
+		sources: make(map[uint32]string),
+	}
+	if err := a.parseIndex(bytes.NewReader(index), indexOffset); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// indexReader decodes varints and remembers the first error
+type indexReader struct {
+	r   io.ByteReader
+	err error
+}
+
+func (ir *indexReader) uvarint() uint64 {
+	if ir.err != nil {
+		return 0
+	}
+	v, err := binary.ReadUvarint(ir.r)
+	if err != nil {
+		ir.err = errCorrupt
+	}
+	return v
+}
+
+func (ir *indexReader) count() int {
+	v := ir.uvarint()
+	if v > 1<<31 {
+		ir.err = errCorrupt
+		return 0
+	}
+	return int(v)
+}
+
+func (a *Reader) parseIndex(r *bytes.Reader, indexOffset int64) error {
+	ir := &indexReader{r: r}
+
+	for i := ir.count(); i > 0 && ir.err == nil; i-- {
+		id := uint32(ir.uvarint())
+		n := ir.count()
+		if ir.err != nil {
+			break
+		}
+
+		/* The length is checked before allocating, a corrupt index can contain any value */
+		if n > r.Len() {
+			return errCorrupt
+		}
+		name := make([]byte, n)
+		if _, err := io.ReadFull(r, name); err != nil {
+			return errCorrupt
+		}
+		a.sources[id] = string(name)
+	}
+
+	for i := ir.count(); i > 0 && ir.err == nil; i-- {
+		var b blockIndex
+		b.offset = int64(ir.uvarint())
+		b.length = int64(ir.uvarint())
+		b.records = ir.count()
+		b.base = unzigzag(ir.uvarint())
+		b.start = b.base + unzigzag(ir.uvarint())
+		b.end = b.start + int64(ir.uvarint())
+
+		mmsi := uint32(0)
+		for j := ir.count(); j > 0 && ir.err == nil; j-- {
+			mmsi += uint32(ir.uvarint())
+			b.mmsis = append(b.mmsis, mmsi)
+		}
+
+		if b.offset < int64(len(magic)) || b.offset+b.length > indexOffset {
+			return errCorrupt
+		}
+		a.blocks = append(a.blocks, b)
+	}
+
+	return ir.err
+}
+
+// Blocks returns the index of the archive
+func (a *Reader) Blocks() []BlockInfo {
+	result := make([]BlockInfo, len(a.blocks))
+	for i, b := range a.blocks {
+		result[i] = BlockInfo{
+			Start:   fromMicros(b.start),
+			End:     fromMicros(b.end),
+			Records: b.records,
+			MMSIs:   append([]uint32(nil), b.mmsis...),
+		}
+	}
+	return result
+}
+
+// SourceName returns the name stored for a source ID, or an empty string if there is none
+func (a *Reader) SourceName(id uint32) string {
+	return a.sources[id]
+}
+
+// matcher checks records against a query
+type matcher struct {
+	from, to int64
+	hasFrom  bool
+	hasTo    bool
+	mmsi     map[uint32]struct{}
+}
+
+func newMatcher(q *Query) *matcher {
+	m := &matcher{
+		from:    toMicros(q.From),
+		to:      toMicros(q.To),
+		hasFrom: !q.From.IsZero(),
+		hasTo:   !q.To.IsZero(),
+	}
+	if len(q.MMSI) > 0 {
+		m.mmsi = make(map[uint32]struct{})
+		for _, mmsi := range q.MMSI {
+			m.mmsi[mmsi] = struct{}{}
+		}
+	}
+	return m
+}
+
+func (m *matcher) time(t int64) bool {
+	return (!m.hasFrom || t >= m.from) && (!m.hasTo || t < m.to)
+}
+
+func (m *matcher) block(b *blockIndex) bool {
+	if m.hasFrom && b.end < m.from {
+		return false
+	}
+	if m.hasTo && b.start >= m.to {
+		return false
+	}
+
+	if m.mmsi == nil {
+		return true
+	}
+	for mmsi := range m.mmsi {
+		i := sort.Search(len(b.mmsis), func(i int) bool { return b.mmsis[i] >= mmsi })
+		if i < len(b.mmsis) && b.mmsis[i] == mmsi {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *matcher) record(t int64, r *Record) bool {
+	if !m.time(t) {
+		return false
+	}
+	if m.mmsi != nil {
+		if _, ok := m.mmsi[r.MMSI()]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// readBlock calls fn for the records of a block that match the query
+func (a *Reader) readBlock(b *blockIndex, m *matcher, fn func(r *Record) error) error {
+	data, err := decompress(a.r, b.offset, b.length, int64(b.records)*maxRecordLength)
+	if err == errCorrupt {
+		return err
+	} else if err != nil {
+		return fmt.Errorf("aisarchive: failed to read block [%v]", err)
+	}
+
+	br := bytes.NewReader(data)
+	ir := &indexReader{r: br}
+	t := b.base
+
+	for i := 0; i < b.records; i++ {
+		t += unzigzag(ir.uvarint())
+		source := uint32(ir.uvarint())
+		channel, err := br.ReadByte()
+		if err != nil {
+			return errCorrupt
+		}
+		numBits := ir.count()
+		if ir.err != nil {
+			return ir.err
+		}
+		if numBits > maxPayloadBits {
+			return errCorrupt
+		}
+
+		n := (numBits + 7) / 8
+		if n > br.Len() {
+			return errCorrupt
+		}
+
+		r := &Record{
+			Time:    fromMicros(t),
+			Source:  source,
+			Channel: channel,
+			Payload: make([]uint64, (numBits+63)/64),
+			NumBits: numBits,
+		}
+		for j := 0; j < n; j++ {
+			v, _ := br.ReadByte()
+			r.Payload[j/8] |= uint64(v) << uint(56-8*(j%8))
+		}
+
+		if m.record(t, r) {
+			if err := fn(r); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Query calls fn for every record matching q, in the order they were written. Only the blocks that
+// can contain matching records are read. If fn returns an error, the query stops and returns it.
+func (a *Reader) Query(q Query, fn func(r *Record) error) error {
+	m := newMatcher(&q)
+
+	for i := range a.blocks {
+		b := &a.blocks[i]
+		if !m.block(b) {
+			continue
+		}
+		if err := a.readBlock(b, m, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadAll calls fn for every record in the archive
+func (a *Reader) ReadAll(fn func(r *Record) error) error {
+	return a.Query(Query{}, fn)
+}
@@ -0,0 +1,260 @@
+package aisarchive
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sort"
+)
+
+// DefaultBlockSize is the uncompressed size of a block if Writer.BlockSize is not set
+const DefaultBlockSize = 256 * 1024
+
+// blockIndex is the index entry of a block
+type blockIndex struct {
+	offset  int64
+	length  int64
+	records int
+	base    int64
+	start   int64
+	end     int64
+	mmsis   []uint32
+}
+
+// Writer creates an archive. Close must be called to write the index.
+type Writer struct {
+	// BlockSize is the uncompressed size after which a block is completed
+	BlockSize int
+
+	// CompressionLevel is the DEFLATE level used for the blocks, zero selects the default level
+	CompressionLevel int
+
+	w      io.Writer
+	offset int64
+	err    error
+	closed bool
+
+	sources map[uint32]string
+	blocks  []blockIndex
+
+	/* The block that is being built */
+	buf     bytes.Buffer
+	current blockIndex
+	prev    int64
+	mmsis   map[uint32]struct{}
+	scratch []byte
+}
+
+// NewWriter creates a Writer that writes an archive to w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		w:       w,
+		sources: make(map[uint32]string),
+		mmsis:   make(map[uint32]struct{}),
+		scratch: make([]byte, binary.MaxVarintLen64),
+	}
+}
+
+func (w *Writer) write(data []byte) {
+	if w.err != nil {
+		return
+	}
+
+	n, err := w.w.Write(data)
+	w.offset += int64(n)
+	w.err = err
+}
+
+func (w *Writer) writeHeader() {
+	if w.offset == 0 {
+		w.write(magic)
+	}
+}
+
+func (w *Writer) compressionLevel() int {
+	if w.CompressionLevel == 0 {
+		return flate.DefaultCompression
+	}
+	return w.CompressionLevel
+}
+
+func (w *Writer) compress(data []byte) {
+	fw, err := flate.NewWriter(writerFunc(w.write), w.compressionLevel())
+	if err != nil {
+		w.err = err
+		return
+	}
+	fw.Write(data)
+	if err := fw.Close(); err != nil && w.err == nil {
+		w.err = err
+	}
+}
+
+// writerFunc allows using a function as io.Writer. Errors are reported through Writer.err.
+type writerFunc func([]byte)
+
+func (f writerFunc) Write(data []byte) (int, error) {
+	f(data)
+	return len(data), nil
+}
+
+// SetSourceName stores a name for a source ID in the archive
+func (w *Writer) SetSourceName(id uint32, name string) {
+	w.sources[id] = name
+}
+
+func (w *Writer) putUvarint(v uint64) {
+	n := binary.PutUvarint(w.scratch[:binary.MaxVarintLen64], v)
+	w.buf.Write(w.scratch[:n])
+}
+
+// Write adds a record to the archive
+func (w *Writer) Write(r *Record) error {
+	if w.closed {
+		return errors.New("aisarchive: writer is closed")
+	}
+	if w.err != nil {
+		return w.err
+	}
+	if r.NumBits < 0 || (r.NumBits+63)/64 > len(r.Payload) {
+		return errors.New("aisarchive: payload shorter than number of bits")
+	}
+	if r.NumBits > maxPayloadBits {
+		return errors.New("aisarchive: payload too long")
+	}
+
+	t := toMicros(r.Time)
+	if w.current.records == 0 {
+		w.current.base = t
+		w.current.start = t
+		w.current.end = t
+		w.prev = t
+	}
+
+	if t < w.current.start {
+		w.current.start = t
+	}
+	if t > w.current.end {
+		w.current.end = t
+	}
+
+	w.putUvarint(zigzag(t - w.prev))
+	w.prev = t
+	w.putUvarint(uint64(r.Source))
+	w.buf.WriteByte(r.Channel)
+	w.putUvarint(uint64(r.NumBits))
+
+	n := (r.NumBits + 7) / 8
+	for i := 0; i < n; i++ {
+		b := byte(r.Payload[i/8] >> uint(56-8*(i%8)))
+		if i == n-1 && r.NumBits%8 != 0 {
+			/* Bits after the end of the payload are not stored */
+			b &= 0xFF << uint(8-r.NumBits%8)
+		}
+		w.buf.WriteByte(b)
+	}
+
+	w.current.records++
+	w.mmsis[r.MMSI()] = struct{}{}
+
+	blockSize := w.BlockSize
+	if blockSize <= 0 {
+		blockSize = DefaultBlockSize
+	}
+	if w.buf.Len() >= blockSize {
+		return w.Flush()
+	}
+
+	return nil
+}
+
+// Flush completes the current block. Blocks are also completed automatically when they reach
+// BlockSize.
+func (w *Writer) Flush() error {
+	if w.current.records == 0 || w.err != nil {
+		return w.err
+	}
+
+	w.writeHeader()
+	w.current.offset = w.offset
+	w.compress(w.buf.Bytes())
+	w.current.length = w.offset - w.current.offset
+
+	for mmsi := range w.mmsis {
+		w.current.mmsis = append(w.current.mmsis, mmsi)
+		delete(w.mmsis, mmsi)
+	}
+	sort.Slice(w.current.mmsis, func(i, j int) bool {
+		return w.current.mmsis[i] < w.current.mmsis[j]
+	})
+
+	w.blocks = append(w.blocks, w.current)
+	w.current = blockIndex{}
+	w.buf.Reset()
+
+	return w.err
+}
+
+func (w *Writer) encodeIndex() []byte {
+	w.buf.Reset()
+
+	ids := make([]uint32, 0, len(w.sources))
+	for id := range w.sources {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	w.putUvarint(uint64(len(ids)))
+	for _, id := range ids {
+		w.putUvarint(uint64(id))
+		w.putUvarint(uint64(len(w.sources[id])))
+		w.buf.WriteString(w.sources[id])
+	}
+
+	w.putUvarint(uint64(len(w.blocks)))
+	for _, b := range w.blocks {
+		w.putUvarint(uint64(b.offset))
+		w.putUvarint(uint64(b.length))
+		w.putUvarint(uint64(b.records))
+		w.putUvarint(zigzag(b.base))
+		w.putUvarint(zigzag(b.start - b.base))
+		w.putUvarint(uint64(b.end - b.start))
+
+		w.putUvarint(uint64(len(b.mmsis)))
+		prev := uint32(0)
+		for _, mmsi := range b.mmsis {
+			w.putUvarint(uint64(mmsi - prev))
+			prev = mmsi
+		}
+	}
+
+	return w.buf.Bytes()
+}
+
+// Close completes the last block and writes the index. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.Flush()
+	w.writeHeader()
+	w.closed = true
+
+	index := w.encodeIndex()
+	if len(index) > maxIndexLength {
+		return errors.New("aisarchive: index too long")
+	}
+
+	indexOffset := w.offset
+	w.compress(index)
+
+	trailer := make([]byte, trailerLength)
+	binary.LittleEndian.PutUint64(trailer, uint64(indexOffset))
+	binary.LittleEndian.PutUint32(trailer[8:], uint32(w.offset-indexOffset))
+	copy(trailer[12:], magic)
+	w.write(trailer)
+
+	return w.err
+}
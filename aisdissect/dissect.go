@@ -0,0 +1,156 @@
+// Package aisdissect renders the output of ais.Dissect as text or HTML, showing which bits of a
+// payload belong to which field. Problems found by the dissector are flagged in both renderings.
+package aisdissect
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+// DefaultMaxBits is the number of bits shown for a single field if Renderer.MaxBits is zero
+const DefaultMaxBits = 48
+
+// Renderer renders the fields of a dissected payload. The zero value uses the defaults, the
+// package level functions use a zero Renderer.
+type Renderer struct {
+	// MaxBits is the number of bits shown for a single field, longer fields are abbreviated. Zero
+	// selects DefaultMaxBits, a negative value shows all bits.
+	MaxBits int
+}
+
+// Bits returns the bits of a field as a string of 0 and 1. Missing bits of an overrun field are
+// shown as '-'.
+func Bits(f ais.FieldSlice) string {
+	return Renderer{}.Bits(f)
+}
+
+// Bits returns the bits of a field as a string of 0 and 1. Missing bits of an overrun field are
+// shown as '-'.
+func (r Renderer) Bits(f ais.FieldSlice) string {
+	var sb strings.Builder
+
+	maxBits := r.MaxBits
+	if maxBits == 0 {
+		maxBits = DefaultMaxBits
+	}
+
+	n := f.End - f.Start
+	for i := 0; i < n; i++ {
+		if i == maxBits {
+			sb.WriteString("...")
+			break
+		}
+		if i < len(f.Bits) {
+			sb.WriteByte('0' + f.Bits[i]&1)
+		} else {
+			sb.WriteByte('-')
+		}
+	}
+
+	return sb.String()
+}
+
+// Range returns the bit range of a field, like "38-41"
+func Range(f ais.FieldSlice) string {
+	if f.End-f.Start <= 1 {
+		return strconv.Itoa(f.Start)
+	}
+	return strconv.Itoa(f.Start) + "-" + strconv.Itoa(f.End-1)
+}
+
+// Value returns the converted value of a field as text
+func Value(f ais.FieldSlice) string {
+	switch x := f.Value.(type) {
+	case string:
+		return strconv.Quote(x)
+	case []byte:
+		return strconv.Itoa(len(x)) + " bits"
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	}
+	return fmt.Sprint(f.Value)
+}
+
+// Raw returns the raw integer of a field as text, or an empty string if it has none
+func Raw(f ais.FieldSlice) string {
+	switch f.Type {
+	case ais.FieldTypeUnsigned, ais.FieldTypeSigned, ais.FieldTypeBool:
+		return strconv.FormatInt(f.Raw, 10)
+	}
+	return ""
+}
+
+// Flags returns the problems found in a field
+func Flags(f ais.FieldSlice) []string {
+	var flags []string
+	if f.Overrun {
+		flags = append(flags, "overrun")
+	}
+	if f.SpareViolation {
+		flags = append(flags, "spare violation")
+	}
+	if f.Trailing {
+		flags = append(flags, "trailing")
+	}
+	return flags
+}
+
+// WriteText writes the fields as an aligned table
+func WriteText(w io.Writer, fields []ais.FieldSlice) error {
+	return Renderer{}.WriteText(w, fields)
+}
+
+// WriteText writes the fields as an aligned table
+func (r Renderer) WriteText(w io.Writer, fields []ais.FieldSlice) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "BITS\tFIELD\tRAW BITS\tRAW\tVALUE\tFLAGS")
+	for _, f := range fields {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			Range(f), f.Name, r.Bits(f), Raw(f), Value(f), strings.Join(Flags(f), ", "))
+	}
+
+	return tw.Flush()
+}
+
+var htmlTemplate = template.Must(template.New("dissect").Funcs(template.FuncMap{
+	"bitrange": Range,
+	"raw":      Raw,
+	"value":    Value,
+	"flags":    func(f ais.FieldSlice) string { return strings.Join(Flags(f), ", ") },
+	"class": func(f ais.FieldSlice) string {
+		if len(Flags(f)) > 0 {
+			return "ais-problem"
+		}
+		return "ais-ok"
+	},
+}).Parse(`<table class="ais-dissect">
+<thead><tr><th>Bits</th><th>Field</th><th>Raw bits</th><th>Raw</th><th>Value</th><th>Flags</th></tr></thead>
+<tbody>
+{{- range .Fields}}
+<tr class="{{class .}}"><td>{{bitrange .}}</td><td>{{.Name}}</td><td><code>{{call $.Bits .}}</code></td><td>{{raw .}}</td><td>{{value .}}</td><td>{{flags .}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+`))
+
+// WriteHTML writes the fields as an HTML table that can be embedded in a page. Rows of fields
+// with problems have the class "ais-problem", the others "ais-ok".
+func WriteHTML(w io.Writer, fields []ais.FieldSlice) error {
+	return Renderer{}.WriteHTML(w, fields)
+}
+
+// WriteHTML writes the fields as an HTML table that can be embedded in a page. Rows of fields
+// with problems have the class "ais-problem", the others "ais-ok".
+func (r Renderer) WriteHTML(w io.Writer, fields []ais.FieldSlice) error {
+	return htmlTemplate.Execute(w, struct {
+		Fields []ais.FieldSlice
+		Bits   func(ais.FieldSlice) string
+	}{fields, r.Bits})
+}
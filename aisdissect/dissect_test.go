@@ -0,0 +1,78 @@
+package aisdissect
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+func testFields() []ais.FieldSlice {
+	p := ais.StaticDataReport{
+		Header:  ais.Header{MessageID: 24, UserID: 244660561},
+		Valid:   true,
+		ReportA: ais.StaticDataReportA{Valid: true, Name: "A<B"},
+	}
+	bits := ais.CodecNew(false, false).EncodePacket(p)
+
+	/* Set the reserved bit and cut off the end of the name */
+	bits[38] = 1
+	return ais.Dissect(bits[:150])
+}
+
+func TestText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteText(&buf, testFields()); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 7 {
+		t.Fatal("Wrong number of lines", len(lines), buf.String())
+	}
+	if !strings.HasPrefix(lines[0], "BITS") {
+		t.Error("Missing header", lines[0])
+	}
+	if f := strings.Fields(lines[3]); f[0] != "8-37" || f[1] != "UserID" || f[3] != "244660561" {
+		t.Error("Wrong UserID line", lines[3])
+	}
+	if !strings.Contains(lines[4], "spare violation") {
+		t.Error("Spare violation not shown", lines[4])
+	}
+	if !strings.Contains(lines[6], "overrun") ||
+		!strings.Contains(lines[6], `"A<B@@@@@@@@@@@@@@@"`) {
+		t.Error("Overrun not shown", lines[6])
+	}
+}
+
+func TestHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, testFields()); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if strings.Count(out, "<tr class=") != 6 || strings.Count(out, `class="ais-problem"`) != 2 {
+		t.Error("Wrong rows", out)
+	}
+	if strings.Contains(out, "A<B") || !strings.Contains(out, "A&lt;B") {
+		t.Error("Value not escaped", out)
+	}
+}
+
+func TestBits(t *testing.T) {
+	f := ais.FieldSlice{Start: 10, End: 70, Bits: make([]byte, 60)}
+	if s := Bits(f); len(s) != DefaultMaxBits+3 || !strings.HasSuffix(s, "...") {
+		t.Error("Long field not abbreviated", s)
+	}
+	if s := (Renderer{MaxBits: 8}).Bits(f); s != "00000000..." {
+		t.Error("Field not abbreviated to MaxBits", s)
+	}
+	if s := (Renderer{MaxBits: -1}).Bits(f); len(s) != 60 {
+		t.Error("Field abbreviated without limit", s)
+	}
+	if r := Range(ais.FieldSlice{Start: 5, End: 6}); r != "5" {
+		t.Error("Wrong range", r)
+	}
+}
@@ -0,0 +1,369 @@
+// Package aisexport converts a stream of decoded AIS packets into formats used by mapping tools:
+// GeoJSON, KML and GPX. The packets are first gathered in a Collector, which keeps the track and the
+// latest static data of every station and the areas announced by base stations.
+package aisexport
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+// StationKind describes what kind of station sent the packets
+type StationKind int
+
+const (
+	// KindVessel is used for stations sending ship position reports
+	KindVessel StationKind = iota
+	// KindAircraft is used for search and rescue aircraft
+	KindAircraft
+	// KindAtoN is used for aids to navigation
+	KindAtoN
+	// KindBaseStation is used for base stations
+	KindBaseStation
+)
+
+func (k StationKind) String() string {
+	switch k {
+	case KindVessel:
+		return "vessel"
+	case KindAircraft:
+		return "aircraft"
+	case KindAtoN:
+		return "aton"
+	case KindBaseStation:
+		return "basestation"
+	}
+	return "unknown"
+}
+
+// Fix is a reported position of a station
+type Fix struct {
+	Time      time.Time // Zero if unknown
+	Latitude  float64
+	Longitude float64
+
+	Sog     float64 // Speed over ground in knots, negative if unknown
+	Cog     float64 // Course over ground in degrees, negative if unknown
+	Heading int     // True heading in degrees, negative if unknown
+}
+
+// StaticData contains the latest static information of a station. Empty fields are unknown.
+type StaticData struct {
+	Name        string
+	CallSign    string
+	ImoNumber   uint32
+	ShipType    uint8
+	Destination string
+	Dimension   ais.FieldDimension
+}
+
+// Station contains everything known about one MMSI
+type Station struct {
+	MMSI   uint32
+	Kind   StationKind
+	Static StaticData
+	Track  []Fix // In the order the packets were added
+}
+
+// Area is a rectangular region announced by a base station
+type Area struct {
+	MMSI      uint32 // Station that sent the area
+	MessageID uint8  // 22 for channel management, 23 for group assignment
+	Time      time.Time
+
+	NorthEastLatitude  float64
+	NorthEastLongitude float64
+	SouthWestLatitude  float64
+	SouthWestLongitude float64
+
+	Packet ais.Packet
+}
+
+// Collector gathers the positions, static data and areas of a stream of packets. It is not safe
+// for concurrent use.
+type Collector struct {
+	// MaxTrackPoints limits the number of positions stored per station, the oldest are removed
+	// first. Zero keeps all positions.
+	MaxTrackPoints int
+
+	stations map[uint32]*Station
+	areas    []Area
+}
+
+// NewCollector creates an empty Collector
+func NewCollector() *Collector {
+	return &Collector{
+		stations: make(map[uint32]*Station),
+	}
+}
+
+func positionValid(lat float64, lon float64) bool {
+	return lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180
+}
+
+func (c *Collector) station(mmsi uint32, kind StationKind) *Station {
+	s, ok := c.stations[mmsi]
+	if !ok {
+		s = &Station{MMSI: mmsi, Kind: kind}
+		c.stations[mmsi] = s
+	} else if kind != KindVessel {
+		/* The kind of station is only known from some packets */
+		s.Kind = kind
+	}
+	return s
+}
+
+func (c *Collector) addFix(mmsi uint32, kind StationKind, f Fix) {
+	if !positionValid(f.Latitude, f.Longitude) {
+		c.station(mmsi, kind)
+		return
+	}
+
+	if f.Sog >= 102.3 {
+		f.Sog = -1
+	}
+	if f.Cog >= 360 {
+		f.Cog = -1
+	}
+	if f.Heading >= 360 {
+		f.Heading = -1
+	}
+
+	s := c.station(mmsi, kind)
+	s.Track = append(s.Track, f)
+	if c.MaxTrackPoints > 0 && len(s.Track) > c.MaxTrackPoints {
+		s.Track = append(s.Track[:0], s.Track[len(s.Track)-c.MaxTrackPoints:]...)
+	}
+}
+
+func trim(s string) string {
+	return strings.TrimRight(s, " ")
+}
+
+func (c *Collector) addArea(mmsi uint32, msgID uint8, t time.Time, p ais.Packet, lat1 ais.FieldLatLonCoarse, lon1 ais.FieldLatLonCoarse, lat2 ais.FieldLatLonCoarse, lon2 ais.FieldLatLonCoarse) {
+	a := Area{
+		MMSI:               mmsi,
+		MessageID:          msgID,
+		Time:               t,
+		NorthEastLatitude:  float64(lat1),
+		NorthEastLongitude: float64(lon1),
+		SouthWestLatitude:  float64(lat2),
+		SouthWestLongitude: float64(lon2),
+		Packet:             p,
+	}
+
+	if !positionValid(a.NorthEastLatitude, a.NorthEastLongitude) || !positionValid(a.SouthWestLatitude, a.SouthWestLongitude) {
+		return
+	}
+
+	/* A repeated announcement of the same region replaces the previous one, a station can
+	   announce several regions */
+	for i := range c.areas {
+		if o := &c.areas[i]; o.MMSI == mmsi && o.MessageID == msgID &&
+			o.NorthEastLatitude == a.NorthEastLatitude && o.NorthEastLongitude == a.NorthEastLongitude &&
+			o.SouthWestLatitude == a.SouthWestLatitude && o.SouthWestLongitude == a.SouthWestLongitude {
+			c.areas[i] = a
+			return
+		}
+	}
+	c.areas = append(c.areas, a)
+}
+
+// Add processes a decoded packet received at time t. Packets without position, static data or
+// area are ignored.
+func (c *Collector) Add(p ais.Packet, t time.Time) {
+	h := p.GetHeader()
+
+	switch x := p.(type) {
+	case ais.PositionReport:
+		c.addFix(h.UserID, KindVessel, Fix{t, float64(x.Latitude), float64(x.Longitude), float64(x.Sog), float64(x.Cog), int(x.TrueHeading)})
+
+	case ais.StandardClassBPositionReport:
+		c.addFix(h.UserID, KindVessel, Fix{t, float64(x.Latitude), float64(x.Longitude), float64(x.Sog), float64(x.Cog), int(x.TrueHeading)})
+
+	case ais.ExtendedClassBPositionReport:
+		c.addFix(h.UserID, KindVessel, Fix{t, float64(x.Latitude), float64(x.Longitude), float64(x.Sog), float64(x.Cog), int(x.TrueHeading)})
+
+		s := &c.station(h.UserID, KindVessel).Static
+		s.Name = trim(x.Name)
+		s.ShipType = x.Type
+		s.Dimension = x.Dimension
+
+	case ais.StandardSearchAndRescueAircraftReport:
+		sog := float64(x.Sog)
+		if x.Sog >= 1023 {
+			sog = -1
+		}
+		c.addFix(h.UserID, KindAircraft, Fix{t, float64(x.Latitude), float64(x.Longitude), sog, float64(x.Cog), -1})
+
+	case ais.AidsToNavigationReport:
+		c.addFix(h.UserID, KindAtoN, Fix{t, float64(x.Latitude), float64(x.Longitude), -1, -1, -1})
+
+		s := &c.station(h.UserID, KindAtoN).Static
+		s.Name = trim(x.Name + x.NameExtension)
+		s.Dimension = x.Dimension
+
+	case ais.BaseStationReport:
+		kind := KindBaseStation
+		if h.MessageID == 11 {
+			/* UTC/date response, sent by mobile stations */
+			kind = KindVessel
+		}
+		c.addFix(h.UserID, kind, Fix{t, float64(x.Latitude), float64(x.Longitude), -1, -1, -1})
+
+	case ais.LongRangeAisBroadcastMessage:
+		sog, cog := float64(x.Sog), float64(x.Cog)
+		if x.Sog >= 63 {
+			sog = -1
+		}
+		c.addFix(h.UserID, KindVessel, Fix{t, float64(x.Latitude), float64(x.Longitude), sog, cog, -1})
+
+	case ais.ShipStaticData:
+		s := &c.station(h.UserID, KindVessel).Static
+		s.Name = trim(x.Name)
+		s.CallSign = trim(x.CallSign)
+		s.ImoNumber = x.ImoNumber
+		s.ShipType = x.Type
+		s.Destination = trim(x.Destination)
+		s.Dimension = x.Dimension
+
+	case ais.StaticDataReport:
+		s := &c.station(h.UserID, KindVessel).Static
+		if x.ReportA.Valid {
+			s.Name = trim(x.ReportA.Name)
+		}
+		if x.ReportB.Valid {
+			s.CallSign = trim(x.ReportB.CallSign)
+			s.ShipType = x.ReportB.ShipType
+			s.Dimension = x.ReportB.Dimension
+		}
+
+	case ais.GroupAssignmentCommand:
+		c.station(h.UserID, KindBaseStation)
+		c.addArea(h.UserID, h.MessageID, t, p, x.Latitude1, x.Longitude1, x.Latitude2, x.Longitude2)
+
+	case ais.ChannelManagement:
+		c.station(h.UserID, KindBaseStation)
+		if !x.IsAddressed {
+			c.addArea(h.UserID, h.MessageID, t, p, x.Area.Latitude1, x.Area.Longitude1, x.Area.Latitude2, x.Area.Longitude2)
+		}
+	}
+}
+
+// Stations returns the stations ordered by MMSI
+func (c *Collector) Stations() []*Station {
+	result := make([]*Station, 0, len(c.stations))
+	for _, s := range c.stations {
+		result = append(result, s)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].MMSI < result[j].MMSI
+	})
+
+	return result
+}
+
+// Areas returns the latest announcement of every region of every station, in the order they were
+// first received
+func (c *Collector) Areas() []Area {
+	return append([]Area(nil), c.areas...)
+}
+
+// properties returns the known static data of a station
+func (s *Station) properties() map[string]interface{} {
+	p := map[string]interface{}{
+		"mmsi": s.MMSI,
+		"kind": s.Kind.String(),
+	}
+
+	st := &s.Static
+	if st.Name != "" {
+		p["name"] = st.Name
+	}
+	if st.CallSign != "" {
+		p["callsign"] = st.CallSign
+	}
+	if st.ImoNumber != 0 {
+		p["imo"] = st.ImoNumber
+	}
+	if st.ShipType != 0 {
+		p["shiptype"] = st.ShipType
+	}
+	if st.Destination != "" {
+		p["destination"] = st.Destination
+	}
+	if d := st.Dimension; d.A+d.B > 0 {
+		p["length"] = d.A + d.B
+		p["beam"] = uint16(d.C) + uint16(d.D)
+	}
+
+	return p
+}
+
+// displayName returns the name of a station, or its MMSI if the name is unknown
+func (s *Station) displayName() string {
+	if s.Static.Name != "" {
+		return s.Static.Name
+	}
+	return fmt.Sprintf("%09d", s.MMSI)
+}
+
+// properties returns the parameters commanded for an area, together with the static data of the
+// station that sent it
+func (a *Area) properties(s *Station) map[string]interface{} {
+	p := s.properties()
+	p["msgtype"] = a.MessageID
+
+	switch x := a.Packet.(type) {
+	case ais.ChannelManagement:
+		p["channela"] = x.ChannelA
+		p["channelb"] = x.ChannelB
+		p["txrxmode"] = x.TxRxMode
+		p["lowpower"] = x.LowPower
+		p["zonesize"] = x.TransitionalZoneSize
+
+	case ais.GroupAssignmentCommand:
+		p["stationtype"] = x.StationType
+		p["shiptype"] = x.ShipType
+		p["txrxmode"] = x.TxRxMode
+		p["interval"] = x.ReportingInterval
+		p["quiettime"] = x.QuietTime
+	}
+
+	return p
+}
+
+// fixProperties adds the known dynamic data of a position to p
+func fixProperties(p map[string]interface{}, f *Fix) {
+	if !f.Time.IsZero() {
+		p["time"] = formatTime(f.Time)
+	}
+	if f.Sog >= 0 {
+		p["sog"] = f.Sog
+	}
+	if f.Cog >= 0 {
+		p["cog"] = f.Cog
+	}
+	if f.Heading >= 0 {
+		p["heading"] = f.Heading
+	}
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// sortedKeys returns the keys of a property map in alphabetical order
+func sortedKeys(p map[string]interface{}) []string {
+	keys := make([]string, 0, len(p))
+	for k := range p {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,278 @@
+package aisexport
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+var testStart = time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func position(mmsi uint32, lat float64, lon float64) ais.PositionReport {
+	return ais.PositionReport{
+		Header:      ais.Header{MessageID: 1, UserID: mmsi},
+		Valid:       true,
+		Latitude:    ais.FieldLatLonFine(lat),
+		Longitude:   ais.FieldLatLonFine(lon),
+		Sog:         12.5,
+		Cog:         360,
+		TrueHeading: 90,
+	}
+}
+
+func testCollector() *Collector {
+	c := NewCollector()
+
+	c.Add(position(244660561, 51.0, 3.0), testStart)
+	c.Add(ais.ShipStaticData{
+		Header:   ais.Header{MessageID: 5, UserID: 244660561},
+		Valid:    true,
+		Name:     "OLD NAME",
+		CallSign: "PD1234 ",
+	}, testStart.Add(time.Second))
+	c.Add(position(244660561, 91, 181), testStart.Add(5*time.Second))
+	c.Add(position(244660561, 51.1, 3.1), testStart.Add(10*time.Second))
+	c.Add(ais.StaticDataReport{
+		Header:  ais.Header{MessageID: 24, UserID: 244660561},
+		Valid:   true,
+		ReportA: ais.StaticDataReportA{Valid: true, Name: "NEW NAME    "},
+	}, testStart.Add(11*time.Second))
+
+	c.Add(ais.AidsToNavigationReport{
+		Header:    ais.Header{MessageID: 21, UserID: 992446000},
+		Valid:     true,
+		Name:      "BUOY",
+		Latitude:  51.2,
+		Longitude: 3.2,
+	}, testStart)
+
+	c.Add(ais.GroupAssignmentCommand{
+		Header:            ais.Header{MessageID: 23, UserID: 2442000},
+		Valid:             true,
+		Longitude1:        4,
+		Latitude1:         52,
+		Longitude2:        3,
+		Latitude2:         51,
+		ReportingInterval: 9,
+	}, testStart)
+	c.Add(ais.ChannelManagement{
+		Header:      ais.Header{MessageID: 22, UserID: 2442000},
+		Valid:       true,
+		IsAddressed: true,
+	}, testStart)
+
+	return c
+}
+
+func TestCollector(t *testing.T) {
+	c := testCollector()
+
+	stations := c.Stations()
+	if len(stations) != 3 {
+		t.Fatal("Wrong number of stations", len(stations))
+	}
+
+	s := stations[1]
+	if s.MMSI != 244660561 || s.Kind != KindVessel || len(s.Track) != 2 {
+		t.Fatal("Wrong vessel", s)
+	}
+	if s.Static.Name != "NEW NAME" || s.Static.CallSign != "PD1234" {
+		t.Error("Static data not updated", s.Static)
+	}
+	if s.Track[0].Sog != 12.5 || s.Track[0].Cog >= 0 || s.Track[0].Heading != 90 {
+		t.Error("Wrong dynamic data", s.Track[0])
+	}
+	if stations[0].Kind != KindBaseStation || stations[2].Kind != KindAtoN {
+		t.Error("Wrong station kinds", stations[0].Kind, stations[2].Kind)
+	}
+
+	areas := c.Areas()
+	if len(areas) != 1 || areas[0].NorthEastLatitude != 52 || areas[0].SouthWestLongitude != 3 {
+		t.Error("Wrong areas", areas)
+	}
+
+	c.MaxTrackPoints = 2
+	c.Add(position(244660561, 51.2, 3.2), testStart.Add(20*time.Second))
+	if s.Track[0].Latitude != 51.1 || len(s.Track) != 2 {
+		t.Error("Track not limited", s.Track)
+	}
+}
+
+func TestGeoJSON(t *testing.T) {
+	data, err := testCollector().GeoJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fc struct {
+		Type     string
+		Features []struct {
+			Geometry struct {
+				Type        string
+				Coordinates json.RawMessage
+			}
+			Properties map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal(data, &fc); err != nil {
+		t.Fatal(err)
+	}
+
+	var types []string
+	for _, f := range fc.Features {
+		types = append(types, f.Geometry.Type)
+	}
+	/* Base station has no position, vessel point and track, AtoN point, area */
+	if fc.Type != "FeatureCollection" || len(types) != 4 || types[0] != "Point" || types[1] != "LineString" ||
+		types[2] != "Point" || types[3] != "Polygon" {
+		t.Fatal("Wrong features", types)
+	}
+
+	point := fc.Features[0]
+	if string(point.Geometry.Coordinates) != "[3.1,51.1]" || point.Properties["name"] != "NEW NAME" ||
+		point.Properties["time"] != "2020-05-01T12:00:10Z" || point.Properties["sog"] != 12.5 {
+		t.Error("Wrong point", string(point.Geometry.Coordinates), point.Properties)
+	}
+
+	line := fc.Features[1]
+	if string(line.Geometry.Coordinates) != "[[3,51],[3.1,51.1]]" || line.Properties["name"] != "NEW NAME" {
+		t.Error("Wrong track", string(line.Geometry.Coordinates), line.Properties)
+	}
+	if times, _ := line.Properties["coordTimes"].([]interface{}); len(times) != 2 || times[0] != "2020-05-01T12:00:00Z" {
+		t.Error("Wrong track times", line.Properties["coordTimes"])
+	}
+
+	area := fc.Features[3]
+	if string(area.Geometry.Coordinates) != "[[[3,51],[4,51],[4,52],[3,52],[3,51]]]" ||
+		area.Properties["msgtype"] != float64(23) || area.Properties["interval"] != float64(9) {
+		t.Error("Wrong area", string(area.Geometry.Coordinates), area.Properties)
+	}
+}
+
+func TestMultipleAreas(t *testing.T) {
+	c := NewCollector()
+
+	area := func(lat float64, interval uint8) ais.GroupAssignmentCommand {
+		return ais.GroupAssignmentCommand{
+			Header:            ais.Header{MessageID: 23, UserID: 2442000},
+			Valid:             true,
+			Longitude1:        4,
+			Latitude1:         ais.FieldLatLonCoarse(lat + 1),
+			Longitude2:        3,
+			Latitude2:         ais.FieldLatLonCoarse(lat),
+			ReportingInterval: interval,
+		}
+	}
+
+	c.Add(area(51, 9), testStart)
+	c.Add(area(53, 9), testStart)
+	c.Add(area(51, 6), testStart.Add(time.Minute))
+
+	areas := c.Areas()
+	if len(areas) != 2 || areas[0].SouthWestLatitude != 51 || areas[1].SouthWestLatitude != 53 {
+		t.Fatal("Wrong areas", areas)
+	}
+	if !areas[0].Time.Equal(testStart.Add(time.Minute)) {
+		t.Error("Repeated area not replaced", areas[0])
+	}
+}
+
+func TestGeoJSONUntimed(t *testing.T) {
+	c := NewCollector()
+	c.Add(position(244660561, 51.0, 3.0), testStart)
+	c.Add(position(244660561, 51.1, 3.1), time.Time{})
+	c.Add(position(244660561, 51.2, 3.2), testStart.Add(time.Minute))
+
+	data, err := c.GeoJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fc struct {
+		Features []struct {
+			Geometry struct {
+				Coordinates json.RawMessage
+			}
+			Properties map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal(data, &fc); err != nil {
+		t.Fatal(err)
+	}
+	if len(fc.Features) != 2 {
+		t.Fatal("Wrong features", string(data))
+	}
+
+	line := fc.Features[1]
+	times, _ := line.Properties["coordTimes"].([]interface{})
+	if string(line.Geometry.Coordinates) != "[[3,51],[3.2,51.2]]" || len(times) != 2 || times[1] != "2020-05-01T12:01:00Z" {
+		t.Error("Untimed position in track", string(line.Geometry.Coordinates), times)
+	}
+}
+
+func TestKML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testCollector().WriteKML(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc kmlDocument
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err, buf.String())
+	}
+
+	if len(doc.Folders) != 2 || len(doc.Folders[0].Folders) != 2 || len(doc.Folders[1].Placemarks) != 1 {
+		t.Fatal("Wrong structure", buf.String())
+	}
+
+	vessel := doc.Folders[0].Folders[0]
+	if vessel.Name != "NEW NAME" || len(vessel.Placemarks) != 3 {
+		t.Fatal("Wrong vessel folder", vessel)
+	}
+
+	first := vessel.Placemarks[0]
+	if first.Point == nil || first.Point.Coordinates != "3,51" || first.TimeSpan == nil ||
+		first.TimeSpan.Begin != "2020-05-01T12:00:00Z" || first.TimeSpan.End != "2020-05-01T12:00:10Z" {
+		t.Error("Wrong first position", first)
+	}
+	if last := vessel.Placemarks[1]; last.TimeSpan == nil || last.TimeSpan.End != "" {
+		t.Error("Latest position must not end", last.TimeSpan)
+	}
+	if track := vessel.Placemarks[2]; track.LineString == nil || track.LineString.Coordinates != "3,51 3.1,51.1" {
+		t.Error("Wrong track", track)
+	}
+
+	area := doc.Folders[1].Placemarks[0]
+	if area.Polygon == nil || area.Polygon.Coordinates != "3,51 4,51 4,52 3,52 3,51" {
+		t.Error("Wrong area", area)
+	}
+}
+
+func TestGPX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testCollector().WriteGPX(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc gpxDocument
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err, buf.String())
+	}
+
+	if len(doc.Waypoints) != 1 || doc.Waypoints[0].Name != "BUOY" || doc.Waypoints[0].Type != "aton" {
+		t.Error("Wrong waypoints", doc.Waypoints)
+	}
+
+	if len(doc.Tracks) != 1 {
+		t.Fatal("Wrong number of tracks", len(doc.Tracks))
+	}
+	trk := doc.Tracks[0]
+	if trk.Name != "NEW NAME" || len(trk.Points) != 2 || trk.Points[1].Latitude != 51.1 ||
+		trk.Points[1].Time != "2020-05-01T12:00:10Z" {
+		t.Error("Wrong track", trk)
+	}
+}
@@ -0,0 +1,132 @@
+package aisexport
+
+import (
+	"encoding/json"
+	"io"
+)
+
+type geoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   geoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+func geoJSONPosition(lat float64, lon float64) [2]float64 {
+	return [2]float64{lon, lat}
+}
+
+// GeoJSON returns all collected data as a GeoJSON FeatureCollection. Every station results in a
+// Point at its latest position and, if it moved, a LineString with its track. The times of the track
+// positions are stored in the coordTimes property, positions without a time are then left out of
+// the track. Areas are exported as Polygons.
+func (c *Collector) GeoJSON() ([]byte, error) {
+	fc := geoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: []geoJSONFeature{},
+	}
+
+	for _, s := range c.Stations() {
+		if len(s.Track) == 0 {
+			continue
+		}
+
+		last := &s.Track[len(s.Track)-1]
+		p := s.properties()
+		fixProperties(p, last)
+		fc.Features = append(fc.Features, geoJSONFeature{
+			Type: "Feature",
+			Geometry: geoJSONGeometry{
+				Type:        "Point",
+				Coordinates: geoJSONPosition(last.Latitude, last.Longitude),
+			},
+			Properties: p,
+		})
+
+		if len(s.Track) < 2 {
+			continue
+		}
+
+		hasTime := false
+		for _, f := range s.Track {
+			if !f.Time.IsZero() {
+				hasTime = true
+			}
+		}
+
+		/* If the track has times, positions without one are left out so every coordinate has a time */
+		var coords [][2]float64
+		var times []string
+		for _, f := range s.Track {
+			if hasTime && f.Time.IsZero() {
+				continue
+			}
+			coords = append(coords, geoJSONPosition(f.Latitude, f.Longitude))
+			if hasTime {
+				times = append(times, formatTime(f.Time))
+			}
+		}
+		if len(coords) < 2 {
+			continue
+		}
+
+		p = s.properties()
+		if hasTime {
+			p["coordTimes"] = times
+		}
+		fc.Features = append(fc.Features, geoJSONFeature{
+			Type: "Feature",
+			Geometry: geoJSONGeometry{
+				Type:        "LineString",
+				Coordinates: coords,
+			},
+			Properties: p,
+		})
+	}
+
+	for i := range c.areas {
+		a := &c.areas[i]
+		p := a.properties(c.stations[a.MMSI])
+		if !a.Time.IsZero() {
+			p["time"] = formatTime(a.Time)
+		}
+
+		/* Counterclockwise exterior ring as required by RFC 7946 */
+		ring := [][2]float64{
+			geoJSONPosition(a.SouthWestLatitude, a.SouthWestLongitude),
+			geoJSONPosition(a.SouthWestLatitude, a.NorthEastLongitude),
+			geoJSONPosition(a.NorthEastLatitude, a.NorthEastLongitude),
+			geoJSONPosition(a.NorthEastLatitude, a.SouthWestLongitude),
+			geoJSONPosition(a.SouthWestLatitude, a.SouthWestLongitude),
+		}
+		fc.Features = append(fc.Features, geoJSONFeature{
+			Type: "Feature",
+			Geometry: geoJSONGeometry{
+				Type:        "Polygon",
+				Coordinates: [][][2]float64{ring},
+			},
+			Properties: p,
+		})
+	}
+
+	return json.Marshal(fc)
+}
+
+// WriteGeoJSON writes the output of GeoJSON to w
+func (c *Collector) WriteGeoJSON(w io.Writer) error {
+	data, err := c.GeoJSON()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,103 @@
+package aisexport
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type gpxPoint struct {
+	Latitude  float64 `xml:"lat,attr"`
+	Longitude float64 `xml:"lon,attr"`
+	Time      string  `xml:"time,omitempty"`
+	Name      string  `xml:"name,omitempty"`
+	Desc      string  `xml:"desc,omitempty"`
+	Type      string  `xml:"type,omitempty"`
+}
+
+type gpxTrack struct {
+	Name   string     `xml:"name"`
+	Desc   string     `xml:"desc,omitempty"`
+	Type   string     `xml:"type"`
+	Points []gpxPoint `xml:"trkseg>trkpt"`
+}
+
+type gpxDocument struct {
+	XMLName   xml.Name   `xml:"gpx"`
+	Xmlns     string     `xml:"xmlns,attr"`
+	Version   string     `xml:"version,attr"`
+	Creator   string     `xml:"creator,attr"`
+	Waypoints []gpxPoint `xml:"wpt"`
+	Tracks    []gpxTrack `xml:"trk"`
+}
+
+// gpxDescription formats the properties of a station as text, since GPX has no structured fields
+func gpxDescription(p map[string]interface{}) string {
+	var parts []string
+	for _, k := range sortedKeys(p) {
+		parts = append(parts, fmt.Sprintf("%s=%v", k, p[k]))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func gpxFix(f *Fix) gpxPoint {
+	pt := gpxPoint{
+		Latitude:  f.Latitude,
+		Longitude: f.Longitude,
+	}
+	if !f.Time.IsZero() {
+		pt.Time = formatTime(f.Time)
+	}
+	return pt
+}
+
+// WriteGPX writes all collected data as a GPX 1.1 document to w. Moving stations are written as
+// tracks, aids to navigation and base stations as a waypoint at their latest position. GPX cannot
+// describe areas, so these are not exported.
+func (c *Collector) WriteGPX(w io.Writer) error {
+	doc := gpxDocument{
+		Xmlns:   "http://www.topografix.com/GPX/1/1",
+		Version: "1.1",
+		Creator: "go-ais",
+	}
+
+	for _, s := range c.Stations() {
+		if len(s.Track) == 0 {
+			continue
+		}
+
+		desc := gpxDescription(s.properties())
+
+		if s.Kind == KindAtoN || s.Kind == KindBaseStation {
+			pt := gpxFix(&s.Track[len(s.Track)-1])
+			pt.Name = s.displayName()
+			pt.Desc = desc
+			pt.Type = s.Kind.String()
+			doc.Waypoints = append(doc.Waypoints, pt)
+			continue
+		}
+
+		trk := gpxTrack{
+			Name: s.displayName(),
+			Desc: desc,
+			Type: s.Kind.String(),
+		}
+		for i := range s.Track {
+			trk.Points = append(trk.Points, gpxFix(&s.Track[i]))
+		}
+		doc.Tracks = append(doc.Tracks, trk)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
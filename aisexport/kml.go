@@ -0,0 +1,176 @@
+package aisexport
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type kmlTimeSpan struct {
+	Begin string `xml:"begin,omitempty"`
+	End   string `xml:"end,omitempty"`
+}
+
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type kmlPoint struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlLineString struct {
+	Tessellate  int    `xml:"tessellate"`
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlPolygon struct {
+	Coordinates string `xml:"outerBoundaryIs>LinearRing>coordinates"`
+}
+
+type kmlPlacemark struct {
+	Name         string         `xml:"name"`
+	TimeSpan     *kmlTimeSpan   `xml:"TimeSpan,omitempty"`
+	ExtendedData []kmlData      `xml:"ExtendedData>Data,omitempty"`
+	Point        *kmlPoint      `xml:"Point,omitempty"`
+	LineString   *kmlLineString `xml:"LineString,omitempty"`
+	Polygon      *kmlPolygon    `xml:"Polygon,omitempty"`
+}
+
+type kmlFolder struct {
+	Name       string         `xml:"name"`
+	Folders    []kmlFolder    `xml:"Folder,omitempty"`
+	Placemarks []kmlPlacemark `xml:"Placemark,omitempty"`
+}
+
+type kmlDocument struct {
+	XMLName xml.Name    `xml:"kml"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	Folders []kmlFolder `xml:"Document>Folder"`
+}
+
+func kmlCoordinate(lat float64, lon float64) string {
+	return strconv.FormatFloat(lon, 'f', -1, 64) + "," + strconv.FormatFloat(lat, 'f', -1, 64)
+}
+
+func kmlExtendedData(p map[string]interface{}) []kmlData {
+	var result []kmlData
+	for _, k := range sortedKeys(p) {
+		result = append(result, kmlData{Name: k, Value: fmt.Sprint(p[k])})
+	}
+	return result
+}
+
+func kmlSpan(begin time.Time, end time.Time) *kmlTimeSpan {
+	if begin.IsZero() {
+		return nil
+	}
+
+	span := &kmlTimeSpan{Begin: formatTime(begin)}
+	if !end.IsZero() {
+		span.End = formatTime(end)
+	}
+	return span
+}
+
+func (s *Station) kmlFolder() kmlFolder {
+	folder := kmlFolder{Name: s.displayName()}
+
+	/* Every timed position is valid until the next one, so a time slider shows the station moving */
+	last := len(s.Track) - 1
+	for i := range s.Track {
+		f := &s.Track[i]
+		if i != last && f.Time.IsZero() {
+			continue
+		}
+
+		var end time.Time
+		if i != last {
+			end = s.Track[i+1].Time
+		}
+
+		p := s.properties()
+		fixProperties(p, f)
+		folder.Placemarks = append(folder.Placemarks, kmlPlacemark{
+			Name:         s.displayName(),
+			TimeSpan:     kmlSpan(f.Time, end),
+			ExtendedData: kmlExtendedData(p),
+			Point:        &kmlPoint{Coordinates: kmlCoordinate(f.Latitude, f.Longitude)},
+		})
+	}
+
+	if len(s.Track) >= 2 {
+		coords := make([]string, len(s.Track))
+		for i, f := range s.Track {
+			coords[i] = kmlCoordinate(f.Latitude, f.Longitude)
+		}
+
+		folder.Placemarks = append(folder.Placemarks, kmlPlacemark{
+			Name:         s.displayName() + " track",
+			TimeSpan:     kmlSpan(s.Track[0].Time, s.Track[last].Time),
+			ExtendedData: kmlExtendedData(s.properties()),
+			LineString: &kmlLineString{
+				Tessellate:  1,
+				Coordinates: strings.Join(coords, " "),
+			},
+		})
+	}
+
+	return folder
+}
+
+func (a *Area) kmlPlacemark(s *Station) kmlPlacemark {
+	coords := []string{
+		kmlCoordinate(a.SouthWestLatitude, a.SouthWestLongitude),
+		kmlCoordinate(a.SouthWestLatitude, a.NorthEastLongitude),
+		kmlCoordinate(a.NorthEastLatitude, a.NorthEastLongitude),
+		kmlCoordinate(a.NorthEastLatitude, a.SouthWestLongitude),
+		kmlCoordinate(a.SouthWestLatitude, a.SouthWestLongitude),
+	}
+
+	return kmlPlacemark{
+		Name:         fmt.Sprintf("%s area (message %d)", s.displayName(), a.MessageID),
+		TimeSpan:     kmlSpan(a.Time, time.Time{}),
+		ExtendedData: kmlExtendedData(a.properties(s)),
+		Polygon:      &kmlPolygon{Coordinates: strings.Join(coords, " ")},
+	}
+}
+
+// WriteKML writes all collected data as a KML document to w. Every station gets a folder with a
+// Point for each timed position, whose TimeSpan lasts until the next position, and a LineString
+// spanning the whole track. Areas are written as Polygons in a separate folder.
+func (c *Collector) WriteKML(w io.Writer) error {
+	stations := kmlFolder{Name: "Stations"}
+	for _, s := range c.Stations() {
+		if len(s.Track) > 0 {
+			stations.Folders = append(stations.Folders, s.kmlFolder())
+		}
+	}
+
+	areas := kmlFolder{Name: "Areas"}
+	for i := range c.areas {
+		a := &c.areas[i]
+		areas.Placemarks = append(areas.Placemarks, a.kmlPlacemark(c.stations[a.MMSI]))
+	}
+
+	doc := kmlDocument{
+		Xmlns:   "http://www.opengis.net/kml/2.2",
+		Folders: []kmlFolder{stations, areas},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
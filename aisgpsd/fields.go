@@ -0,0 +1,341 @@
+package aisgpsd
+
+// conversion describes how a packet field is represented in gpsd JSON
+type conversion int
+
+const (
+	convPlain        conversion = iota // Numbers, booleans and strings are copied
+	convFlag                           // Booleans that gpsd writes as 0 or 1
+	convLatLonFine                     // Degrees when scaled, 1/10000 minute otherwise
+	convLatLonCoarse                   // Degrees when scaled, 1/10 minute otherwise
+	convSpeed                          // Knots when scaled, 1/10 knot otherwise
+	convTenth                          // Field10 values: the value when scaled, tenths otherwise
+	convTurn                           // Rate of turn: degrees per minute when scaled, the raw indicator otherwise
+	convRadio20                        // ITDMA flag followed by the 19 bit communication state
+	convData                           // Bits written as <number of bits>:<hex>
+	convAppID                          // Application identifier written as DAC << 6 | FI
+	convETA                            // MM-DDTHH:MMZ when scaled, month, day, hour and minute otherwise
+	convTimestamp                      // Base station time: YYYY-MM-DDTHH:MM:SSZ when scaled, separate fields otherwise
+	convAtoNName                       // Name and name extension of an AtoN as one string
+	convLegend                         // Description of a value, only written when scaled
+)
+
+// field maps a gpsd JSON member to a field of a packet
+type field struct {
+	name string
+	path string // Dotted path of the packet field, array elements are selected by number
+	conv conversion
+
+	// depends names a boolean field of the packet that must be true for this field to be
+	// written. A leading ~ negates it.
+	depends string
+
+	legend func(v int) string
+}
+
+var dimensionFields = []field{
+	{name: "to_bow", path: "Dimension.A"},
+	{name: "to_stern", path: "Dimension.B"},
+	{name: "to_port", path: "Dimension.C"},
+	{name: "to_starboard", path: "Dimension.D"},
+}
+
+func join(parts ...[]field) []field {
+	var result []field
+	for _, p := range parts {
+		result = append(result, p...)
+	}
+	return result
+}
+
+func prefix(p string, fields []field) []field {
+	result := make([]field, len(fields))
+	for i, f := range fields {
+		f.path = p + "." + f.path
+		result[i] = f
+	}
+	return result
+}
+
+var positionReportFields = []field{
+	{name: "status", path: "NavigationalStatus"},
+	{name: "status_text", path: "NavigationalStatus", conv: convLegend, legend: statusText},
+	{name: "turn", path: "RateOfTurn", conv: convTurn},
+	{name: "speed", path: "Sog", conv: convSpeed},
+	{name: "accuracy", path: "PositionAccuracy"},
+	{name: "lon", path: "Longitude", conv: convLatLonFine},
+	{name: "lat", path: "Latitude", conv: convLatLonFine},
+	{name: "course", path: "Cog", conv: convTenth},
+	{name: "heading", path: "TrueHeading"},
+	{name: "second", path: "Timestamp"},
+	{name: "maneuver", path: "SpecialManoeuvreIndicator"},
+	{name: "raim", path: "Raim"},
+	{name: "radio", path: "CommunicationState"},
+}
+
+var baseStationReportFields = []field{
+	{name: "timestamp", conv: convTimestamp},
+	{name: "accuracy", path: "PositionAccuracy"},
+	{name: "lon", path: "Longitude", conv: convLatLonFine},
+	{name: "lat", path: "Latitude", conv: convLatLonFine},
+	{name: "epfd", path: "FixType"},
+	{name: "epfd_text", path: "FixType", conv: convLegend, legend: epfdText},
+	{name: "raim", path: "Raim"},
+	{name: "radio", path: "CommunicationState"},
+}
+
+var shipStaticDataFields = join([]field{
+	{name: "ais_version", path: "AisVersion"},
+	{name: "imo", path: "ImoNumber"},
+	{name: "callsign", path: "CallSign"},
+	{name: "shipname", path: "Name"},
+	{name: "shiptype", path: "Type"},
+	{name: "shiptype_text", path: "Type", conv: convLegend, legend: shipTypeText},
+}, dimensionFields, []field{
+	{name: "epfd", path: "FixType"},
+	{name: "epfd_text", path: "FixType", conv: convLegend, legend: epfdText},
+	{name: "eta", path: "Eta", conv: convETA},
+	{name: "draught", path: "MaximumStaticDraught", conv: convTenth},
+	{name: "destination", path: "Destination"},
+	{name: "dte", path: "Dte", conv: convFlag},
+})
+
+var addressedBinaryMessageFields = []field{
+	{name: "seqno", path: "SequenceNumber"},
+	{name: "dest_mmsi", path: "DestinationID"},
+	{name: "retransmit", path: "Retransmission"},
+	{name: "dac", path: "ApplicationID.DesignatedAreaCode"},
+	{name: "fid", path: "ApplicationID.FunctionIdentifier"},
+	{name: "data", path: "BinaryData", conv: convData},
+}
+
+var binaryAcknowledgeFields = []field{
+	{name: "mmsi1", path: "Destinations.0.DestinationID"},
+	{name: "mmsi2", path: "Destinations.1.DestinationID"},
+	{name: "mmsi3", path: "Destinations.2.DestinationID"},
+	{name: "mmsi4", path: "Destinations.3.DestinationID"},
+}
+
+var binaryBroadcastMessageFields = []field{
+	{name: "dac", path: "ApplicationID.DesignatedAreaCode"},
+	{name: "fid", path: "ApplicationID.FunctionIdentifier"},
+	{name: "data", path: "BinaryData", conv: convData},
+}
+
+var searchAndRescueAircraftReportFields = []field{
+	{name: "alt", path: "Altitude"},
+	{name: "speed", path: "Sog"},
+	{name: "accuracy", path: "PositionAccuracy"},
+	{name: "lon", path: "Longitude", conv: convLatLonFine},
+	{name: "lat", path: "Latitude", conv: convLatLonFine},
+	{name: "course", path: "Cog", conv: convTenth},
+	{name: "second", path: "Timestamp"},
+	{name: "dte", path: "Dte", conv: convFlag},
+	{name: "assigned", path: "AssignedMode"},
+	{name: "raim", path: "Raim"},
+	{name: "radio", path: "CommunicationStateItdma", conv: convRadio20},
+}
+
+var utcInquiryFields = []field{
+	{name: "dest_mmsi", path: "DestinationID"},
+}
+
+var addressedSafetyMessageFields = []field{
+	{name: "seqno", path: "SequenceNumber"},
+	{name: "dest_mmsi", path: "DestinationID"},
+	{name: "retransmit", path: "Retransmission"},
+	{name: "text", path: "Text"},
+}
+
+var safetyBroadcastMessageFields = []field{
+	{name: "text", path: "Text"},
+}
+
+var interrogationFields = []field{
+	{name: "mmsi1", path: "Station1Msg1.StationID"},
+	{name: "type1_1", path: "Station1Msg1.MessageID"},
+	{name: "offset1_1", path: "Station1Msg1.SlotOffset"},
+	{name: "type1_2", path: "Station1Msg2.MessageID"},
+	{name: "offset1_2", path: "Station1Msg2.SlotOffset"},
+	{name: "mmsi2", path: "Station2.StationID"},
+	{name: "type2_1", path: "Station2.MessageID"},
+	{name: "offset2_1", path: "Station2.SlotOffset"},
+}
+
+var assignedModeCommandFields = []field{
+	{name: "mmsi1", path: "Commands.0.DestinationID"},
+	{name: "offset1", path: "Commands.0.Offset"},
+	{name: "increment1", path: "Commands.0.Increment"},
+	{name: "mmsi2", path: "Commands.1.DestinationID"},
+	{name: "offset2", path: "Commands.1.Offset"},
+	{name: "increment2", path: "Commands.1.Increment"},
+}
+
+var gnssBroadcastFields = []field{
+	{name: "lon", path: "Longitude", conv: convLatLonCoarse},
+	{name: "lat", path: "Latitude", conv: convLatLonCoarse},
+	{name: "data", path: "Data", conv: convData},
+}
+
+var classBPositionReportFields = []field{
+	{name: "speed", path: "Sog", conv: convSpeed},
+	{name: "accuracy", path: "PositionAccuracy"},
+	{name: "lon", path: "Longitude", conv: convLatLonFine},
+	{name: "lat", path: "Latitude", conv: convLatLonFine},
+	{name: "course", path: "Cog", conv: convTenth},
+	{name: "heading", path: "TrueHeading"},
+	{name: "second", path: "Timestamp"},
+	{name: "cs", path: "ClassBUnit"},
+	{name: "display", path: "ClassBDisplay"},
+	{name: "dsc", path: "ClassBDsc"},
+	{name: "band", path: "ClassBBand"},
+	{name: "msg22", path: "ClassBMsg22"},
+	{name: "assigned", path: "AssignedMode"},
+	{name: "raim", path: "Raim"},
+	{name: "radio", path: "CommunicationStateItdma", conv: convRadio20},
+}
+
+var extendedClassBPositionReportFields = join([]field{
+	{name: "speed", path: "Sog", conv: convSpeed},
+	{name: "accuracy", path: "PositionAccuracy"},
+	{name: "lon", path: "Longitude", conv: convLatLonFine},
+	{name: "lat", path: "Latitude", conv: convLatLonFine},
+	{name: "course", path: "Cog", conv: convTenth},
+	{name: "heading", path: "TrueHeading"},
+	{name: "second", path: "Timestamp"},
+	{name: "shipname", path: "Name"},
+	{name: "shiptype", path: "Type"},
+	{name: "shiptype_text", path: "Type", conv: convLegend, legend: shipTypeText},
+}, dimensionFields, []field{
+	{name: "epfd", path: "FixType"},
+	{name: "epfd_text", path: "FixType", conv: convLegend, legend: epfdText},
+	{name: "raim", path: "Raim"},
+	{name: "dte", path: "Dte", conv: convFlag},
+	{name: "assigned", path: "AssignedMode"},
+})
+
+var dataLinkManagementFields = func() []field {
+	var result []field
+	for i, n := range []string{"1", "2", "3", "4"} {
+		p := "Data." + string('0'+byte(i)) + "."
+		result = append(result,
+			field{name: "offset" + n, path: p + "Offset"},
+			field{name: "number" + n, path: p + "NumberOfSlots"},
+			field{name: "timeout" + n, path: p + "TimeOut"},
+			field{name: "increment" + n, path: p + "Increment"})
+	}
+	return result
+}()
+
+var aidsToNavigationReportFields = join([]field{
+	{name: "aid_type", path: "Type"},
+	{name: "aid_type_text", path: "Type", conv: convLegend, legend: aidTypeText},
+	{name: "name", conv: convAtoNName},
+	{name: "accuracy", path: "PositionAccuracy"},
+	{name: "lon", path: "Longitude", conv: convLatLonFine},
+	{name: "lat", path: "Latitude", conv: convLatLonFine},
+}, dimensionFields, []field{
+	{name: "epfd", path: "Fixtype"},
+	{name: "epfd_text", path: "Fixtype", conv: convLegend, legend: epfdText},
+	{name: "second", path: "Timestamp"},
+	{name: "off_position", path: "OffPosition"},
+	{name: "regional", path: "AtoN"},
+	{name: "raim", path: "Raim"},
+	{name: "virtual_aid", path: "VirtualAtoN"},
+	{name: "assigned", path: "AssignedMode"},
+})
+
+var channelManagementFields = []field{
+	{name: "channel_a", path: "ChannelA"},
+	{name: "channel_b", path: "ChannelB"},
+	{name: "txrx", path: "TxRxMode"},
+	{name: "power", path: "LowPower"},
+	{name: "ne_lon", path: "Area.Longitude1", conv: convLatLonCoarse, depends: "~IsAddressed"},
+	{name: "ne_lat", path: "Area.Latitude1", conv: convLatLonCoarse, depends: "~IsAddressed"},
+	{name: "sw_lon", path: "Area.Longitude2", conv: convLatLonCoarse, depends: "~IsAddressed"},
+	{name: "sw_lat", path: "Area.Latitude2", conv: convLatLonCoarse, depends: "~IsAddressed"},
+	{name: "dest1", path: "Unicast.AddressStation1", depends: "IsAddressed"},
+	{name: "dest2", path: "Unicast.AddressStation2", depends: "IsAddressed"},
+	{name: "addressed", path: "IsAddressed"},
+	{name: "band_a", path: "BwA"},
+	{name: "band_b", path: "BwB"},
+	{name: "zonesize", path: "TransitionalZoneSize"},
+}
+
+var groupAssignmentCommandFields = []field{
+	{name: "ne_lon", path: "Longitude1", conv: convLatLonCoarse},
+	{name: "ne_lat", path: "Latitude1", conv: convLatLonCoarse},
+	{name: "sw_lon", path: "Longitude2", conv: convLatLonCoarse},
+	{name: "sw_lat", path: "Latitude2", conv: convLatLonCoarse},
+	{name: "station_type", path: "StationType"},
+	{name: "ship_type", path: "ShipType"},
+	{name: "txrx", path: "TxRxMode"},
+	{name: "interval", path: "ReportingInterval"},
+	{name: "quiet", path: "QuietTime"},
+}
+
+var staticDataReportFields = join([]field{
+	{name: "partno", path: "PartNumber", conv: convFlag},
+	{name: "shipname", path: "ReportA.Name", depends: "~PartNumber"},
+	{name: "shiptype", path: "ReportB.ShipType", depends: "PartNumber"},
+	{name: "shiptype_text", path: "ReportB.ShipType", conv: convLegend, legend: shipTypeText, depends: "PartNumber"},
+	{name: "vendorid", path: "ReportB.VendorIDName", depends: "PartNumber"},
+	{name: "model", path: "ReportB.VenderIDModel", depends: "PartNumber"},
+	{name: "serial", path: "ReportB.VenderIDSerial", depends: "PartNumber"},
+	{name: "callsign", path: "ReportB.CallSign", depends: "PartNumber"},
+}, prefix("ReportB", dimensionFields))
+
+var singleSlotBinaryMessageFields = []field{
+	{name: "addressed", path: "DestinationIDValid"},
+	{name: "structured", path: "ApplicationIDValid"},
+	{name: "dest_mmsi", path: "DestinationID", depends: "DestinationIDValid"},
+	{name: "app_id", path: "ApplicationID", conv: convAppID, depends: "ApplicationIDValid"},
+	{name: "data", path: "Payload", conv: convData},
+}
+
+var multiSlotBinaryMessageFields = join(singleSlotBinaryMessageFields, []field{
+	{name: "radio", path: "CommunicationStateItdma", conv: convRadio20},
+})
+
+var longRangeBroadcastFields = []field{
+	{name: "accuracy", path: "PositionAccuracy"},
+	{name: "raim", path: "Raim"},
+	{name: "status", path: "NavigationalStatus"},
+	{name: "status_text", path: "NavigationalStatus", conv: convLegend, legend: statusText},
+	{name: "lon", path: "Longitude", conv: convLatLonCoarse},
+	{name: "lat", path: "Latitude", conv: convLatLonCoarse},
+	{name: "speed", path: "Sog"},
+	{name: "course", path: "Cog"},
+	{name: "gnss", path: "PositionLatency"},
+}
+
+// messageFields contains the gpsd fields of every message ID
+var messageFields = map[uint8][]field{
+	1:  positionReportFields,
+	2:  positionReportFields,
+	3:  positionReportFields,
+	4:  baseStationReportFields,
+	5:  shipStaticDataFields,
+	6:  addressedBinaryMessageFields,
+	7:  binaryAcknowledgeFields,
+	8:  binaryBroadcastMessageFields,
+	9:  searchAndRescueAircraftReportFields,
+	10: utcInquiryFields,
+	11: baseStationReportFields,
+	12: addressedSafetyMessageFields,
+	13: binaryAcknowledgeFields,
+	14: safetyBroadcastMessageFields,
+	15: interrogationFields,
+	16: assignedModeCommandFields,
+	17: gnssBroadcastFields,
+	18: classBPositionReportFields,
+	19: extendedClassBPositionReportFields,
+	20: dataLinkManagementFields,
+	21: aidsToNavigationReportFields,
+	22: channelManagementFields,
+	23: groupAssignmentCommandFields,
+	24: staticDataReportFields,
+	25: singleSlotBinaryMessageFields,
+	26: multiSlotBinaryMessageFields,
+	27: longRangeBroadcastFields,
+}
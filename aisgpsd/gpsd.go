@@ -0,0 +1,606 @@
+// Package aisgpsd converts AIS packets to and from the JSON objects that gpsd produces for AIVDM
+// sentences. Both the scaled representation, using the units of the ais package and adding text
+// descriptions, and the unscaled one, containing the raw values of the message, are supported.
+//
+// The conversion expects packets decoded with the default unit conversions of ais.Codec, not with
+// FloatWithoutConversion. Fields that gpsd does not report, like spare bits and the sequence numbers
+// of binary acknowledgements, are lost.
+package aisgpsd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+// objectWriter produces a JSON object with the members in the order they are added
+type objectWriter struct {
+	buf bytes.Buffer
+	err error
+}
+
+func (w *objectWriter) add(name string, value interface{}) {
+	if w.err != nil {
+		return
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		w.err = err
+		return
+	}
+
+	if w.buf.Len() == 0 {
+		w.buf.WriteByte('{')
+	} else {
+		w.buf.WriteByte(',')
+	}
+
+	w.buf.WriteString(strconv.Quote(name))
+	w.buf.WriteByte(':')
+	w.buf.Write(encoded)
+}
+
+func (w *objectWriter) bytes() ([]byte, error) {
+	if w.err != nil {
+		return nil, w.err
+	}
+
+	w.buf.WriteByte('}')
+	return w.buf.Bytes(), nil
+}
+
+// lookup returns the field of a packet at a dotted path. When reading, nested structures with a
+// false Valid field are treated as absent. When writing, the Valid fields are set instead.
+func lookup(v reflect.Value, path string, write bool) (reflect.Value, bool) {
+	if path == "" {
+		return v, true
+	}
+
+	for i, name := range strings.Split(path, ".") {
+		if v.Kind() == reflect.Struct && i > 0 {
+			if valid := v.FieldByName("Valid"); valid.IsValid() && valid.Kind() == reflect.Bool {
+				if write {
+					valid.SetBool(true)
+				} else if !valid.Bool() {
+					return reflect.Value{}, false
+				}
+			}
+		}
+
+		if index, err := strconv.Atoi(name); err == nil {
+			v = v.Index(index)
+		} else {
+			v = v.FieldByName(name)
+		}
+
+		if !v.IsValid() {
+			return reflect.Value{}, false
+		}
+	}
+
+	if write && v.Kind() == reflect.Struct {
+		if valid := v.FieldByName("Valid"); valid.IsValid() && valid.Kind() == reflect.Bool {
+			valid.SetBool(true)
+		}
+	}
+
+	return v, true
+}
+
+// dependsMet checks the condition of a field
+func dependsMet(v reflect.Value, depends string) bool {
+	if depends == "" {
+		return true
+	}
+
+	target := true
+	if depends[0] == '~' {
+		target = false
+		depends = depends[1:]
+	}
+
+	return v.FieldByName(depends).Bool() == target
+}
+
+func roundInt(v float64) int64 {
+	return int64(math.Round(v))
+}
+
+// packBits converts one bit per byte into the gpsd <number of bits>:<hex> notation
+func packBits(bits []byte) string {
+	packed := make([]byte, (len(bits)+7)/8)
+	for i, b := range bits {
+		packed[i/8] |= (b & 1) << uint(7-i%8)
+	}
+	return fmt.Sprintf("%d:%x", len(bits), packed)
+}
+
+// unpackBits converts the gpsd <number of bits>:<hex> notation into one bit per byte
+func unpackBits(s string) ([]byte, error) {
+	i := strings.IndexByte(s, ':')
+	if i < 0 {
+		return nil, fmt.Errorf("aisgpsd: data is not in bits:hex notation [%s]", s)
+	}
+
+	n, err := strconv.Atoi(s[:i])
+	hex := s[i+1:]
+	if err != nil || n < 0 || n > 4*len(hex) {
+		return nil, fmt.Errorf("aisgpsd: invalid number of bits in data [%s]", s)
+	}
+
+	bits := make([]byte, n)
+	for k := range bits {
+		nibble, err := strconv.ParseUint(hex[k/4:k/4+1], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("aisgpsd: data is not hexadecimal [%s]", s)
+		}
+		bits[k] = byte(nibble>>uint(3-k%4)) & 1
+	}
+
+	return bits, nil
+}
+
+// turnScaled converts the rate of turn indicator into degrees per minute as gpsd does
+func turnScaled(raw int64) interface{} {
+	switch raw {
+	case -128:
+		return "nan"
+	case -127:
+		return "fastleft"
+	case 127:
+		return "fastright"
+	}
+
+	rot := float64(raw) / 4.733
+	rot *= rot
+	if raw < 0 {
+		rot = -rot
+	}
+	return roundInt(rot)
+}
+
+func turnRaw(scaled float64) int64 {
+	raw := roundInt(4.733 * math.Sqrt(math.Abs(scaled)))
+	if raw > 126 {
+		raw = 126
+	}
+	if scaled < 0 {
+		raw = -raw
+	}
+	return raw
+}
+
+// plainValue returns the JSON value for an integer, boolean or string field
+func plainValue(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	}
+	return v.Interface()
+}
+
+func (f *field) marshal(w *objectWriter, v reflect.Value, scaled bool) {
+	switch f.conv {
+	case convPlain:
+		if v.Kind() == reflect.String {
+			/* gpsd removes the padding */
+			w.add(f.name, strings.TrimRight(v.String(), " "))
+		} else {
+			w.add(f.name, plainValue(v))
+		}
+
+	case convFlag:
+		flag := 0
+		if v.Bool() {
+			flag = 1
+		}
+		w.add(f.name, flag)
+
+	case convLatLonFine, convLatLonCoarse:
+		if scaled {
+			w.add(f.name, v.Float())
+		} else if f.conv == convLatLonFine {
+			w.add(f.name, roundInt(v.Float()*600000))
+		} else {
+			w.add(f.name, roundInt(v.Float()*600))
+		}
+
+	case convSpeed, convTenth:
+		raw := roundInt(v.Float() * 10)
+		if !scaled {
+			w.add(f.name, raw)
+		} else if f.conv == convSpeed && raw == 1023 {
+			w.add(f.name, "nan")
+		} else {
+			w.add(f.name, float64(raw)/10)
+		}
+
+	case convTurn:
+		if scaled {
+			w.add(f.name, turnScaled(v.Int()))
+		} else {
+			w.add(f.name, v.Int())
+		}
+
+	case convRadio20:
+		state := v.Interface().(ais.CommunicationStateItdma)
+		radio := state.CommunicationState
+		if state.CommunicationStateIsItdma {
+			radio |= 1 << 19
+		}
+		w.add(f.name, radio)
+
+	case convData:
+		w.add(f.name, packBits(v.Bytes()))
+
+	case convAppID:
+		id := v.Interface().(ais.FieldApplicationIdentifier)
+		w.add(f.name, uint(id.DesignatedAreaCode)<<6|uint(id.FunctionIdentifier))
+
+	case convETA:
+		eta := v.Interface().(ais.FieldETA)
+		if scaled {
+			w.add(f.name, fmt.Sprintf("%02d-%02dT%02d:%02dZ", eta.Month, eta.Day, eta.Hour, eta.Minute))
+		} else {
+			w.add("month", eta.Month)
+			w.add("day", eta.Day)
+			w.add("hour", eta.Hour)
+			w.add("minute", eta.Minute)
+		}
+
+	case convTimestamp:
+		r := v.Interface().(ais.BaseStationReport)
+		if scaled {
+			w.add(f.name, fmt.Sprintf("%04d-%02d-%02dT%02d:%02d:%02dZ", r.UtcYear, r.UtcMonth, r.UtcDay, r.UtcHour, r.UtcMinute, r.UtcSecond))
+		} else {
+			w.add("year", r.UtcYear)
+			w.add("month", r.UtcMonth)
+			w.add("day", r.UtcDay)
+			w.add("hour", r.UtcHour)
+			w.add("minute", r.UtcMinute)
+			w.add("second", r.UtcSecond)
+		}
+
+	case convAtoNName:
+		r := v.Interface().(ais.AidsToNavigationReport)
+		w.add(f.name, strings.TrimRight(r.Name+r.NameExtension, " "))
+
+	case convLegend:
+		if scaled {
+			w.add(f.name, f.legend(int(v.Uint())))
+		}
+	}
+}
+
+// Marshal converts a packet into a gpsd AIS JSON object. If scaled is true, the values are written
+// in the units gpsd uses for scaled output and text descriptions are added.
+func Marshal(p ais.Packet, scaled bool) ([]byte, error) {
+	v := reflect.Indirect(reflect.ValueOf(p))
+	h := p.GetHeader()
+
+	fields, ok := messageFields[h.MessageID]
+	if !ok || v.Type() != ais.MessageType(h.MessageID) {
+		return nil, fmt.Errorf("aisgpsd: unsupported packet [%T, %d]", p, h.MessageID)
+	}
+
+	/* The fields are read through an addressable copy */
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+
+	var w objectWriter
+	w.add("class", "AIS")
+	w.add("type", h.MessageID)
+	w.add("repeat", h.RepeatIndicator)
+	w.add("mmsi", h.UserID)
+	w.add("scaled", scaled)
+
+	for i := range fields {
+		f := &fields[i]
+		if !dependsMet(c, f.depends) {
+			continue
+		}
+
+		fv, ok := lookup(c, f.path, false)
+		if !ok {
+			continue
+		}
+
+		f.marshal(&w, fv, scaled)
+	}
+
+	return w.bytes()
+}
+
+// object is a decoded gpsd JSON object
+type object map[string]json.RawMessage
+
+func (o object) number(name string) (float64, bool, error) {
+	raw, ok := o[name]
+	if !ok {
+		return 0, false, nil
+	}
+
+	var v float64
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return 0, false, fmt.Errorf("aisgpsd: %s is not a number [%s]", name, raw)
+	}
+	return v, true, nil
+}
+
+// numberOrString returns a number, or the string if a string was given
+func (o object) numberOrString(name string) (float64, string, error) {
+	var s string
+	if json.Unmarshal(o[name], &s) == nil {
+		return 0, s, nil
+	}
+
+	v, _, err := o.number(name)
+	return v, "", err
+}
+
+func setNumber(v reflect.Value, n float64) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(roundInt(n))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(roundInt(n)))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(n)
+	case reflect.Bool:
+		v.SetBool(n != 0)
+	}
+}
+
+// setTimeParts reads the integer members of an unscaled time
+func (o object) setTimeParts(names []string, targets ...reflect.Value) error {
+	for i, name := range names {
+		n, ok, err := o.number(name)
+		if err != nil {
+			return err
+		}
+		if ok {
+			setNumber(targets[i], n)
+		}
+	}
+	return nil
+}
+
+func (f *field) unmarshal(o object, v reflect.Value, scaled bool) error {
+	raw := o[f.name]
+
+	switch f.conv {
+	case convPlain, convFlag:
+		if v.Kind() == reflect.String {
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return fmt.Errorf("aisgpsd: %s is not a string [%s]", f.name, raw)
+			}
+			v.SetString(s)
+			return nil
+		}
+
+		var b bool
+		if v.Kind() == reflect.Bool && json.Unmarshal(raw, &b) == nil {
+			v.SetBool(b)
+			return nil
+		}
+
+		n, _, err := o.number(f.name)
+		if err != nil {
+			return err
+		}
+		setNumber(v, n)
+
+	case convLatLonFine, convLatLonCoarse:
+		n, _, err := o.number(f.name)
+		if err != nil {
+			return err
+		}
+		if !scaled {
+			if f.conv == convLatLonFine {
+				n /= 600000
+			} else {
+				n /= 600
+			}
+		}
+		v.SetFloat(n)
+
+	case convSpeed, convTenth:
+		n, s, err := o.numberOrString(f.name)
+		if err != nil {
+			return err
+		}
+		if s != "" {
+			if s != "nan" || f.conv != convSpeed {
+				return fmt.Errorf("aisgpsd: %s has invalid value [%s]", f.name, s)
+			}
+			n = 102.3
+		} else if !scaled {
+			n /= 10
+		}
+		v.SetFloat(n)
+
+	case convTurn:
+		n, s, err := o.numberOrString(f.name)
+		if err != nil {
+			return err
+		}
+		switch {
+		case s == "nan":
+			v.SetInt(-128)
+		case s == "fastleft":
+			v.SetInt(-127)
+		case s == "fastright":
+			v.SetInt(127)
+		case s != "":
+			return fmt.Errorf("aisgpsd: %s has invalid value [%s]", f.name, s)
+		case scaled:
+			v.SetInt(turnRaw(n))
+		default:
+			v.SetInt(roundInt(n))
+		}
+
+	case convRadio20:
+		n, _, err := o.number(f.name)
+		if err != nil {
+			return err
+		}
+		radio := uint32(n)
+		v.Set(reflect.ValueOf(ais.CommunicationStateItdma{
+			CommunicationStateIsItdma: radio&(1<<19) != 0,
+			CommunicationState:        radio & (1<<19 - 1),
+		}))
+
+	case convData:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return fmt.Errorf("aisgpsd: %s is not a string [%s]", f.name, raw)
+		}
+		bits, err := unpackBits(s)
+		if err != nil {
+			return err
+		}
+		v.SetBytes(bits)
+
+	case convAppID:
+		n, _, err := o.number(f.name)
+		if err != nil {
+			return err
+		}
+		id := uint(n)
+		v.Set(reflect.ValueOf(ais.FieldApplicationIdentifier{
+			Valid:              true,
+			DesignatedAreaCode: uint16(id >> 6),
+			FunctionIdentifier: uint8(id & 63),
+		}))
+
+	case convETA:
+		eta := v.Addr().Interface().(*ais.FieldETA)
+		if !scaled {
+			return o.setTimeParts([]string{"month", "day", "hour", "minute"},
+				v.FieldByName("Month"), v.FieldByName("Day"), v.FieldByName("Hour"), v.FieldByName("Minute"))
+		}
+
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return fmt.Errorf("aisgpsd: %s is not a string [%s]", f.name, raw)
+		}
+		if _, err := fmt.Sscanf(s, "%02d-%02dT%02d:%02dZ", &eta.Month, &eta.Day, &eta.Hour, &eta.Minute); err != nil {
+			return fmt.Errorf("aisgpsd: %s is not a valid ETA [%s]", f.name, s)
+		}
+
+	case convTimestamp:
+		r := v.Addr().Interface().(*ais.BaseStationReport)
+		if !scaled {
+			return o.setTimeParts([]string{"year", "month", "day", "hour", "minute", "second"},
+				v.FieldByName("UtcYear"), v.FieldByName("UtcMonth"), v.FieldByName("UtcDay"),
+				v.FieldByName("UtcHour"), v.FieldByName("UtcMinute"), v.FieldByName("UtcSecond"))
+		}
+
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return fmt.Errorf("aisgpsd: %s is not a string [%s]", f.name, raw)
+		}
+		if _, err := fmt.Sscanf(s, "%04d-%02d-%02dT%02d:%02d:%02dZ", &r.UtcYear, &r.UtcMonth, &r.UtcDay, &r.UtcHour, &r.UtcMinute, &r.UtcSecond); err != nil {
+			return fmt.Errorf("aisgpsd: %s is not a valid timestamp [%s]", f.name, s)
+		}
+
+	case convAtoNName:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return fmt.Errorf("aisgpsd: %s is not a string [%s]", f.name, raw)
+		}
+
+		/* The name field holds 20 characters, the rest goes into the extension */
+		r := v.Addr().Interface().(*ais.AidsToNavigationReport)
+		r.Name, r.NameExtension = s, ""
+		if len(s) > 20 {
+			r.Name, r.NameExtension = s[:20], s[20:]
+		}
+	}
+
+	return nil
+}
+
+// present returns true if the object contains the member(s) of a field
+func (f *field) present(o object, scaled bool) bool {
+	if !scaled {
+		switch f.conv {
+		case convETA:
+			_, ok := o["month"]
+			return ok
+		case convTimestamp:
+			_, ok := o["year"]
+			return ok
+		}
+	}
+
+	_, ok := o[f.name]
+	return ok && f.conv != convLegend
+}
+
+// Unmarshal converts a gpsd AIS JSON object into a packet. Both the scaled and unscaled forms are
+// accepted, text descriptions are ignored.
+func Unmarshal(data []byte) (ais.Packet, error) {
+	var o object
+	if err := json.Unmarshal(data, &o); err != nil {
+		return nil, fmt.Errorf("aisgpsd: invalid JSON object [%s]", err)
+	}
+
+	var class string
+	if json.Unmarshal(o["class"], &class) != nil || class != "AIS" {
+		return nil, fmt.Errorf("aisgpsd: object is not of class AIS [%s]", o["class"])
+	}
+
+	msgID, _, err1 := o.number("type")
+	repeat, _, err2 := o.number("repeat")
+	mmsi, _, err3 := o.number("mmsi")
+	if err1 != nil || err2 != nil || err3 != nil {
+		return nil, fmt.Errorf("aisgpsd: invalid header")
+	}
+
+	var scaled bool
+	if raw, ok := o["scaled"]; ok {
+		if err := json.Unmarshal(raw, &scaled); err != nil {
+			return nil, fmt.Errorf("aisgpsd: scaled is not a boolean [%s]", raw)
+		}
+	}
+
+	fields, ok := messageFields[uint8(msgID)]
+	if !ok || msgID != math.Trunc(msgID) {
+		return nil, fmt.Errorf("aisgpsd: unsupported message type [%v]", msgID)
+	}
+
+	v := reflect.New(ais.MessageType(uint8(msgID))).Elem()
+	v.FieldByName("Header").Set(reflect.ValueOf(ais.Header{
+		MessageID:       uint8(msgID),
+		RepeatIndicator: uint8(repeat),
+		UserID:          uint32(mmsi),
+	}))
+	v.FieldByName("Valid").SetBool(true)
+
+	for i := range fields {
+		f := &fields[i]
+		if !f.present(o, scaled) {
+			continue
+		}
+
+		fv, _ := lookup(v, f.path, true)
+		if err := f.unmarshal(o, fv, scaled); err != nil {
+			return nil, err
+		}
+	}
+
+	return v.Interface().(ais.Packet), nil
+}
@@ -0,0 +1,183 @@
+package aisgpsd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/BertoldVdb/go-ais"
+	"github.com/BertoldVdb/go-ais/aisnmea"
+)
+
+func readLines(t *testing.T, name string) []string {
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal("Failed to open", name, err)
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && line[0] != '#' {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// compareObject checks that every member of want, except device, has the same value in got
+func compareObject(t *testing.T, desc string, got []byte, want string) {
+	var g, w map[string]interface{}
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatal(desc, "invalid JSON produced", err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatal(desc, "invalid test vector", err)
+	}
+
+	for k, wv := range w {
+		if k == "device" {
+			continue
+		}
+
+		gv, ok := g[k]
+		if !ok {
+			t.Error(desc, "missing member", k)
+			continue
+		}
+
+		if wf, ok := wv.(float64); ok {
+			if gf, ok := gv.(float64); !ok || math.Abs(gf-wf) > 1e-5 {
+				t.Error(desc, "member", k, "is", gv, "instead of", wv)
+			}
+		} else if gv != wv {
+			t.Error(desc, "member", k, "is", gv, "instead of", wv)
+		}
+	}
+}
+
+// gpsdWithoutVectors lists the message types for which gpsd's test/sample.aivdm has no packet.
+// A type may only be added here if gpsd itself has no vector for it.
+var gpsdWithoutVectors = map[uint8]bool{}
+
+func TestSampleVectors(t *testing.T) {
+	nm := aisnmea.NMEACodecNew(ais.CodecNew(false, false))
+
+	var packets []ais.Packet
+	for _, line := range readLines(t, "testdata/sample.aivdm") {
+		p, err := nm.ParseSentence(line)
+		if err != nil {
+			t.Fatal("Failed to parse", line, err)
+		}
+		if p != nil {
+			packets = append(packets, p.Packet)
+		}
+	}
+
+	/* Every message type must be covered by the vectors of gpsd (test/sample.aivdm) */
+	covered := make(map[uint8]bool)
+	for _, p := range packets {
+		covered[p.GetHeader().MessageID] = true
+	}
+	for msgID := uint8(1); msgID <= 27; msgID++ {
+		if _, ok := messageFields[msgID]; ok && !covered[msgID] && !gpsdWithoutVectors[msgID] {
+			t.Error("No test vectors for message type", msgID)
+		}
+	}
+
+	for _, scaled := range []bool{true, false} {
+		name := "testdata/sample.aivdm.js-unscaled"
+		if scaled {
+			name = "testdata/sample.aivdm.js-scaled"
+		}
+
+		vectors := readLines(t, name)
+		if len(vectors) != len(packets) {
+			t.Fatal("Number of packets does not match", name, len(packets), len(vectors))
+		}
+
+		for i, want := range vectors {
+			desc := fmt.Sprintf("%s:%d", name, i+1)
+
+			got, err := Marshal(packets[i], scaled)
+			if err != nil {
+				t.Fatal(desc, "Marshal failed", err)
+			}
+			compareObject(t, desc, got, want)
+
+			/* Reading the vector must result in the same object */
+			p, err := Unmarshal([]byte(want))
+			if err != nil {
+				t.Fatal(desc, "Unmarshal failed", err)
+			}
+			got, _ = Marshal(p, scaled)
+			compareObject(t, desc+" (reread)", got, want)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	c := ais.CodecNew(false, false)
+
+	for msgID := 1; msgID <= 27; msgID++ {
+		f, err := os.Open(fmt.Sprintf("../testmsg/%d.msg", msgID))
+		if err != nil {
+			continue
+		}
+
+		scanner := bufio.NewScanner(f)
+		for index := 0; scanner.Scan(); index++ {
+			bits := []byte(strings.TrimSpace(scanner.Text()))
+			for i := range bits {
+				bits[i] -= '0'
+			}
+
+			p := c.DecodePacket(bits)
+			if p == nil {
+				continue
+			}
+
+			for _, scaled := range []bool{true, false} {
+				first, err := Marshal(p, scaled)
+				if err != nil {
+					t.Fatal("Marshal failed", msgID, index, err)
+				}
+
+				p2, err := Unmarshal(first)
+				if err != nil {
+					t.Fatal("Unmarshal failed", msgID, index, err, string(first))
+				}
+
+				if c.EncodePacket(p2) == nil {
+					t.Error("Converted packet cannot be encoded", msgID, index, string(first))
+				}
+
+				second, _ := Marshal(p2, scaled)
+				if string(first) != string(second) {
+					t.Error("Round trip changed object", msgID, index, "\n", string(first), "\n", string(second))
+				}
+			}
+		}
+		f.Close()
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	for _, s := range []string{
+		`{"class":"TPV"}`,
+		`{"class":"AIS","type":99,"repeat":0,"mmsi":1}`,
+		`{"class":"AIS","type":1,"repeat":0,"mmsi":1,"scaled":true,"speed":"fast"}`,
+		`{"class":"AIS","type":8,"repeat":0,"mmsi":1,"data":"12:x"}`,
+		`not json`,
+	} {
+		if _, err := Unmarshal([]byte(s)); err == nil {
+			t.Error("Invalid object accepted", s)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package aisgpsd
+
+var statusLegends = [...]string{
+	"Under way using engine",
+	"At anchor",
+	"Not under command",
+	"Restricted manoeuverability",
+	"Constrained by her draught",
+	"Moored",
+	"Aground",
+	"Engaged in fishing",
+	"Under way sailing",
+	"Reserved for HSC",
+	"Reserved for WIG",
+	"Power-driven vessel towing astern",
+	"Power-driven vessel pushing ahead or towing alongside",
+	"Reserved",
+	"AIS-SART is active",
+	"Not defined",
+}
+
+func statusText(v int) string {
+	if v < 0 || v >= len(statusLegends) {
+		return "Not defined"
+	}
+	return statusLegends[v]
+}
+
+var epfdLegends = [...]string{
+	"Undefined",
+	"GPS",
+	"GLONASS",
+	"Combined GPS/GLONASS",
+	"Loran-C",
+	"Chayka",
+	"Integrated navigation system",
+	"Surveyed",
+	"Galileo",
+}
+
+func epfdText(v int) string {
+	if v == 15 {
+		return "Internal GNSS"
+	}
+	if v < 0 || v >= len(epfdLegends) {
+		return "Reserved"
+	}
+	return epfdLegends[v]
+}
+
+var shipTypeLegends = map[int]string{
+	0:  "Not available",
+	30: "Fishing",
+	31: "Towing",
+	32: "Towing: length exceeds 200m or breadth exceeds 25m",
+	33: "Dredging or underwater ops",
+	34: "Diving ops",
+	35: "Military ops",
+	36: "Sailing",
+	37: "Pleasure Craft",
+	50: "Pilot Vessel",
+	51: "Search and Rescue vessel",
+	52: "Tug",
+	53: "Port Tender",
+	54: "Anti-pollution equipment",
+	55: "Law Enforcement",
+	56: "Spare - Local Vessel",
+	57: "Spare - Local Vessel",
+	58: "Medical Transport",
+	59: "Noncombatant ship according to RR Resolution No. 18",
+}
+
+/* Ship types 20-29 and 40-99 are a category followed by a qualifier */
+var shipCategoryLegends = map[int]string{
+	2: "Wing in ground (WIG)",
+	4: "High speed craft (HSC)",
+	6: "Passenger",
+	7: "Cargo",
+	8: "Tanker",
+	9: "Other Type",
+}
+
+var shipQualifierLegends = [...]string{
+	"all ships of this type",
+	"Hazardous category A",
+	"Hazardous category B",
+	"Hazardous category C",
+	"Hazardous category D",
+	"Reserved for future use",
+	"Reserved for future use",
+	"Reserved for future use",
+	"Reserved for future use",
+	"No additional information",
+}
+
+func shipTypeText(v int) string {
+	if s, ok := shipTypeLegends[v]; ok {
+		return s
+	}
+	if s, ok := shipCategoryLegends[v/10]; ok && v < 100 {
+		return s + ", " + shipQualifierLegends[v%10]
+	}
+	return "Reserved for future use"
+}
+
+var aidTypeLegends = [...]string{
+	"Unspecified",
+	"Reference point",
+	"RACON",
+	"Fixed offshore structure",
+	"Spare, Reserved for future use.",
+	"Light, without sectors",
+	"Light, with sectors",
+	"Leading Light Front",
+	"Leading Light Rear",
+	"Beacon, Cardinal N",
+	"Beacon, Cardinal E",
+	"Beacon, Cardinal S",
+	"Beacon, Cardinal W",
+	"Beacon, Port hand",
+	"Beacon, Starboard hand",
+	"Beacon, Preferred Channel port hand",
+	"Beacon, Preferred Channel starboard hand",
+	"Beacon, Isolated danger",
+	"Beacon, Safe water",
+	"Beacon, Special mark",
+	"Cardinal Mark N",
+	"Cardinal Mark E",
+	"Cardinal Mark S",
+	"Cardinal Mark W",
+	"Port hand Mark",
+	"Starboard hand Mark",
+	"Preferred Channel Port hand",
+	"Preferred Channel Starboard hand",
+	"Isolated danger",
+	"Safe Water",
+	"Special Mark",
+	"Light Vessel / LANBY / Rigs",
+}
+
+func aidTypeText(v int) string {
+	if v < 0 || v >= len(aidTypeLegends) {
+		return "Unspecified"
+	}
+	return aidTypeLegends[v]
+}
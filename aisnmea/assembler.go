@@ -2,6 +2,7 @@ package aisnmea
 
 import (
 	"sync"
+	"time"
 
 	"github.com/BertoldVdb/go-ais"
 	nmea "github.com/adrianmo/go-nmea"
@@ -14,16 +15,96 @@ type VdmPacket struct {
 	MessageType string
 	Payload     []byte
 	Packet      ais.Packet
-	TagBlock    nmea.TagBlock
+
+	// TagBlock is the TAG Block in the representation of go-nmea, which has no unknown parameters
+	// and keeps the group (g:) as text. It contains the same parameters as Tags.
+	TagBlock nmea.TagBlock
+
+	// Tags is the complete TAG Block. When encoding it is used instead of TagBlock unless it is blank.
+	Tags TagBlock
+
+	// ReceivedAt is the time the packet was received. It is taken from the TAG block (c:) if
+	// present, otherwise from receiver metadata or the ReceiveInfo given by the caller. Metadata
+	// with only a time of day replaces the time of the ReceiveInfo. It is zero when unknown.
+	ReceivedAt time.Time
+
+	// Source identifies the receiver or feed the packet came from. It is taken from the TAG block (s:)
+	// if present, otherwise from the ReceiveInfo given by the caller.
+	Source string
+
+	// Fragments contains the raw sentences the packet was assembled from, ordered by fragment
+	// number. It is only filled if NMEACodec.KeepRawSentences is set.
+	Fragments []string
+
+	// SignalStrength is the received signal level in dBm, zero if unknown
+	SignalStrength float64
+
+	// SNR is the signal to noise ratio in dB, zero if unknown
+	SNR float64
+
+	// FrequencyOffset is the frequency offset of the receiver in ppm, zero if unknown
+	FrequencyOffset float64
+
+	// Slot is the slot number in which the packet was received. It is only known if a VSI
+	// sentence or receiver metadata was received for it.
+	SlotValid bool
+	Slot      uint16
+
+	// OwnShip is set for VDO sentences, which contain the reports of the receiving vessel itself.
+	// When encoding it forces the message type to VDO.
+	OwnShip bool
+
+	// Relaxations contains the deviations from the standard that were accepted to decode the packet,
+	// see NMEACodec.Leniency
+	Relaxations Relaxation
+}
+
+// ReceiveInfo contains metadata about the reception of a sentence that is not part of the
+// sentence itself. It can be passed to NMEACodec.ParseSentenceWithInfo.
+type ReceiveInfo struct {
+	ReceivedAt     time.Time
+	Source         string
+	SignalStrength float64
+	SNR            float64
+}
+
+// merge fills the fields of info that are not yet known from src. For the reception time
+// the earliest one is kept.
+func (info *ReceiveInfo) merge(src *ReceiveInfo) {
+	if !src.ReceivedAt.IsZero() && (info.ReceivedAt.IsZero() || src.ReceivedAt.Before(info.ReceivedAt)) {
+		info.ReceivedAt = src.ReceivedAt
+	}
+
+	if info.Source == "" {
+		info.Source = src.Source
+	}
+
+	if info.SignalStrength == 0 {
+		info.SignalStrength = src.SignalStrength
+	}
+
+	if info.SNR == 0 {
+		info.SNR = src.SNR
+	}
+}
+
+type vdmFragment struct {
+	vdm      *nmea.VDMVDO
+	tagBlock TagBlock
+	raw      string
+	info     ReceiveInfo
+	relax    Relaxation
 }
 
 type vdmAssemblyWork struct {
 	expiryCounter uint64
 	received      uint32
-	vdms          []*nmea.VDMVDO
+	fragments     []vdmFragment
 }
 
-// VdmAssembler reassmbles split VDO/VDM messages
+// VdmAssembler reassmbles split VDO/VDM messages. It can be fed from multiple goroutines without
+// data races, but fragments are only matched by channel and sequential message identifier, so the
+// sentences of different streams can be combined.
 type vdmAssembler struct {
 	lastChannel     byte
 	msgCounter      uint64
@@ -34,10 +115,8 @@ type vdmAssembler struct {
 	msgMutex sync.RWMutex
 }
 
+// cleanup removes expired work. The caller must hold msgMutex.
 func (v *vdmAssembler) cleanup() {
-	v.msgMutex.Lock()
-	defer v.msgMutex.Unlock()
-
 	for key, value := range v.msgMap {
 		if v.msgCounter >= value.expiryCounter {
 			delete(v.msgMap, key)
@@ -51,12 +130,12 @@ func (v *vdmAssembler) bufferedMessages() int {
 
 	result := 0
 	for _, k := range v.msgMap {
-		result += len(k.vdms)
+		result += len(k.fragments)
 	}
 	return result
 }
 
-func (v *vdmAssembler) process(vdm *nmea.VDMVDO) (VdmPacket, bool) {
+func (v *vdmAssembler) process(vdm *nmea.VDMVDO, tagBlock TagBlock, raw string, info ReceiveInfo, relax Relaxation) (VdmPacket, bool) {
 	if vdm.NumFragments <= 0 ||
 		vdm.NumFragments >= 10 ||
 		vdm.FragmentNumber > vdm.NumFragments ||
@@ -64,6 +143,11 @@ func (v *vdmAssembler) process(vdm *nmea.VDMVDO) (VdmPacket, bool) {
 		return VdmPacket{}, false
 	}
 
+	/* All state, including the counters and the last channel, is protected by the mutex
+	   so a single assembler can be fed from multiple goroutines */
+	v.msgMutex.Lock()
+	defer v.msgMutex.Unlock()
+
 	v.msgCounter++
 
 	if v.msgCounter >= v.nextCleanup {
@@ -79,13 +163,23 @@ func (v *vdmAssembler) process(vdm *nmea.VDMVDO) (VdmPacket, bool) {
 
 	/* Is this message a single sentence? */
 	if vdm.NumFragments == 1 {
-		return VdmPacket{
-			Channel:     v.lastChannel,
-			TalkerID:    vdm.BaseSentence.TalkerID(),
-			MessageType: vdm.BaseSentence.DataType(),
-			Payload:     vdm.Payload,
-			TagBlock:    vdm.TagBlock,
-		}, true
+		p := VdmPacket{
+			Channel:        v.lastChannel,
+			TalkerID:       vdm.BaseSentence.TalkerID(),
+			MessageType:    vdm.BaseSentence.DataType(),
+			Payload:        vdm.Payload,
+			TagBlock:       tagBlockToNMEA(tagBlock),
+			Tags:           tagBlock,
+			ReceivedAt:     info.ReceivedAt,
+			Source:         info.Source,
+			SignalStrength: info.SignalStrength,
+			SNR:            info.SNR,
+			Relaxations:    relax,
+		}
+		if raw != "" {
+			p.Fragments = []string{raw}
+		}
+		return p, true
 	}
 
 	/* Try to reassemble */
@@ -96,17 +190,14 @@ func (v *vdmAssembler) process(vdm *nmea.VDMVDO) (VdmPacket, bool) {
 		key |= uint32(1) << 31
 	}
 
-	v.msgMutex.Lock()
-	defer v.msgMutex.Unlock()
-
 	workMsg, ok := v.msgMap[key]
 	if !ok {
 		workMsg = &vdmAssemblyWork{}
 		workMsg.expiryCounter = v.msgCounter + v.cleanupInterval
-		workMsg.vdms = make([]*nmea.VDMVDO, 0, vdm.NumFragments)
+		workMsg.fragments = make([]vdmFragment, 0, vdm.NumFragments)
 	}
 
-	workMsg.vdms = append(workMsg.vdms, vdm)
+	workMsg.fragments = append(workMsg.fragments, vdmFragment{vdm: vdm, tagBlock: tagBlock, raw: raw, info: info, relax: relax})
 	workMsg.received |= 1 << uint32(vdm.FragmentNumber-1)
 	allMsg := uint32(1)<<uint32(vdm.NumFragments) - 1
 
@@ -114,34 +205,46 @@ func (v *vdmAssembler) process(vdm *nmea.VDMVDO) (VdmPacket, bool) {
 		v.msgMap[key] = workMsg
 	}
 
-	if len(workMsg.vdms) >= int(vdm.NumFragments) && (workMsg.received&allMsg == allMsg) {
+	if len(workMsg.fragments) >= int(vdm.NumFragments) && (workMsg.received&allMsg == allMsg) {
 		var fullPayload []byte
+		var fragments []string
+		var composedTagBlock TagBlock
+		var composedInfo ReceiveInfo
+		var composedRelax Relaxation
 
-		/* Ok, we have all parts, reassemble */
+		/* Ok, we have all parts, reassemble. Multiple TAG Blocks and receive information
+		   are merged into a single one */
 		for i := 0; i < int(vdm.NumFragments); i++ {
-			for j := 0; j < len(workMsg.vdms); j++ {
-				if workMsg.vdms[j].FragmentNumber-1 == int64(i) {
-					fullPayload = append(fullPayload, workMsg.vdms[j].Payload...)
+			for j := 0; j < len(workMsg.fragments); j++ {
+				if f := &workMsg.fragments[j]; f.vdm.FragmentNumber-1 == int64(i) {
+					fullPayload = append(fullPayload, f.vdm.Payload...)
+					if f.raw != "" {
+						fragments = append(fragments, f.raw)
+					}
+					mergeTagBlocks(&composedTagBlock, &f.tagBlock)
+					composedInfo.merge(&f.info)
+					composedRelax |= f.relax
 					break
 				}
 			}
 		}
 
-		/* Merge multiple TAG Blocks into a single one */
-		var composedTagBlock nmea.TagBlock
-		for _, vdm := range workMsg.vdms {
-			mergeTagBlocks(&composedTagBlock, &vdm.TagBlock)
-		}
-
 		delete(v.msgMap, key)
 
 		/* Full payload is assembled */
 		return VdmPacket{
-			Channel:     v.lastChannel,
-			TalkerID:    vdm.BaseSentence.TalkerID(),
-			MessageType: vdm.BaseSentence.DataType(),
-			Payload:     fullPayload,
-			TagBlock:    composedTagBlock,
+			Channel:        v.lastChannel,
+			TalkerID:       vdm.BaseSentence.TalkerID(),
+			MessageType:    vdm.BaseSentence.DataType(),
+			Payload:        fullPayload,
+			TagBlock:       tagBlockToNMEA(composedTagBlock),
+			Tags:           composedTagBlock,
+			ReceivedAt:     composedInfo.ReceivedAt,
+			Source:         composedInfo.Source,
+			Fragments:      fragments,
+			SignalStrength: composedInfo.SignalStrength,
+			SNR:            composedInfo.SNR,
+			Relaxations:    composedRelax,
 		}, true
 	}
 
@@ -0,0 +1,129 @@
+package aisnmea
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+func TestConcurrentParse(t *testing.T) {
+	nm := NMEACodecNew(ais.CodecNew(false, false))
+
+	/* A goroutine can be preempted between its fragments, do not let them expire */
+	nm.assembler.cleanupInterval = 1 << 20
+
+	const goroutines = 8
+	const rounds = 200
+
+	/* Every goroutine sends its own multi-sentence message with its own sequential message
+	   identifier and channel, so the result shows if fragments were mixed up or lost */
+	sentences := make([][]string, goroutines)
+	for i := range sentences {
+		p := VdmPacket{
+			Channel: "AB"[i%2],
+			Packet: ais.ShipStaticData{
+				Header:      ais.Header{MessageID: 5, UserID: uint32(244000000 + i)},
+				Valid:       true,
+				CallSign:    "PD1234",
+				Name:        "CONCURRENCY",
+				Destination: "RACE DETECTOR",
+			},
+		}
+		sentences[i] = nm.EncodeSentenceWithSequenceID(p, i)
+		if len(sentences[i]) < 2 {
+			t.Fatal("Message does not need multiple sentences", sentences[i])
+		}
+	}
+
+	decoded := make([]int, goroutines)
+
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < rounds; j++ {
+				for _, s := range sentences[i] {
+					p, err := nm.ParseSentence(s)
+					if err != nil {
+						t.Error("Decoding failed", err)
+						return
+					}
+					if p == nil {
+						continue
+					}
+
+					if p.Channel != byte(1+i%2) || p.Packet.GetHeader().UserID != uint32(244000000+i) {
+						t.Error("Fragments of different messages assembled", p.Channel, p.Packet.GetHeader().UserID)
+						return
+					}
+					decoded[i]++
+				}
+				nm.BufferedMessages()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i, n := range decoded {
+		if n != rounds {
+			t.Error("Wrong number of packets", i, n)
+		}
+	}
+	if nm.BufferedMessages() != 0 {
+		t.Error("Fragments left in the assembler", nm.BufferedMessages())
+	}
+}
+
+func TestConcurrentEncode(t *testing.T) {
+	nm := NMEACodecNew(ais.CodecNew(false, false))
+
+	/* Long enough to always need multiple sentences */
+	p := VdmPacket{
+		TalkerID:    "AI",
+		MessageType: "VDM",
+		Packet: ais.ShipStaticData{
+			Header:      ais.Header{MessageID: 5, UserID: 244123456},
+			Valid:       true,
+			CallSign:    "PD1234",
+			Name:        "CONCURRENCY",
+			Destination: "RACE DETECTOR",
+		},
+	}
+
+	results := make(chan []string, 64)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 8; j++ {
+				results <- nm.EncodeSentence(p)
+			}
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	expected := nm.codec.EncodePacket(p.Packet)
+	for encoded := range results {
+		/* Use a fresh decoder to make sure a message is not completed by parts of another one */
+		nm2 := NMEACodecNew(ais.CodecNew(false, false))
+
+		var decoded *VdmPacket
+		for _, l := range encoded {
+			var err error
+			decoded, err = nm2.ParseSentence(l)
+			if err != nil {
+				t.Fatal("Could not decode sentence we just encoded", err)
+			}
+		}
+
+		if decoded == nil || !bytes.Equal(decoded.Payload, expected) {
+			t.Error("Encoded output does not decode to the input", encoded)
+		}
+	}
+}
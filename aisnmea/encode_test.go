@@ -0,0 +1,169 @@
+package aisnmea
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/BertoldVdb/go-ais"
+	nmea "github.com/adrianmo/go-nmea"
+)
+
+func randomPayload(r *rand.Rand, numBits int) []byte {
+	payload := make([]byte, numBits)
+	for i := range payload {
+		payload[i] = byte(r.Intn(2))
+	}
+	return payload
+}
+
+/* Every encoded output must respect the line length, use a correct fragment count and decode to the input */
+func TestEncodeSentenceProperties(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	talkers := []string{"AI", "AB", "BS"}
+	types := []string{"VDM", "VDO"}
+	tagBlocks := []nmea.TagBlock{{}, {Time: 1560234814}, {Time: 1560234814, Source: "station123", Text: "hello"}}
+
+	for i := 0; i < 5000; i++ {
+		nm := NMEACodecNew(ais.CodecNew(false, false))
+		nm.MaxLineLength = 50 + r.Intn(100)
+
+		p := VdmPacket{
+			Channel:     byte(1 + r.Intn(2)),
+			TalkerID:    talkers[r.Intn(len(talkers))],
+			MessageType: types[r.Intn(len(types))],
+			Payload:     randomPayload(r, 1+r.Intn(1100)),
+			TagBlock:    tagBlocks[r.Intn(len(tagBlocks))],
+		}
+
+		seqID := r.Intn(11) - 1
+
+		var encoded []string
+		if seqID < 0 {
+			encoded = nm.EncodeSentence(p)
+		} else {
+			encoded = nm.EncodeSentenceWithSequenceID(p, seqID)
+		}
+
+		if encoded == nil {
+			/* Only allowed if the message really needs more than 9 sentences */
+			tags := tagBlockFromNMEA(p.TagBlock)
+			overhead := len("!AIVDM,9,1,0,A,,0*00\r\n") + len(encodeTagBlock(&tags, 1, 9, 0, true))
+			if (len(p.Payload)+5)/6 <= 9*(nm.MaxLineLength-overhead) {
+				t.Fatal("Encoding failed", nm.MaxLineLength, len(p.Payload))
+			}
+			continue
+		}
+
+		decoder := NMEACodecNew(ais.CodecNew(false, false))
+		var decoded *VdmPacket
+		for j, l := range encoded {
+			if len(l)+2 > nm.MaxLineLength {
+				t.Fatal("Line too long", nm.MaxLineLength, l)
+			}
+
+			s, err := nmea.Parse(l)
+			if err != nil {
+				t.Fatal("Could not parse encoded sentence", l, err)
+			}
+			vdm := s.(nmea.VDMVDO)
+			if vdm.NumFragments != int64(len(encoded)) || vdm.FragmentNumber != int64(j+1) {
+				t.Fatal("Wrong fragment numbering", encoded)
+			}
+			if seqID >= 0 && vdm.MessageID != int64(seqID) {
+				t.Fatal("Sequence ID not used", seqID, l)
+			}
+			if vdm.Channel != encodeChannel(p.Channel) {
+				t.Fatal("Wrong channel", l)
+			}
+
+			if decoded, err = decoder.ParseSentence(l); err != nil {
+				t.Fatal("Could not decode sentence we just encoded", l, err)
+			}
+			if decoded != nil && j != len(encoded)-1 {
+				t.Fatal("Message completed early", encoded)
+			}
+		}
+
+		if decoded == nil {
+			t.Fatal("Encoded sentences did not produce a message", encoded)
+		}
+
+		if !bytes.Equal(p.Payload, decoded.Payload[:len(p.Payload)]) || len(decoded.Payload)-len(p.Payload) >= 6 {
+			t.Fatal("Payload not identical", encoded)
+		}
+
+		/* One sentence less must not have been enough */
+		if len(encoded) > 1 {
+			spare := 0
+			for _, l := range encoded[:len(encoded)-1] {
+				spare += nm.MaxLineLength - len(l) - 2
+			}
+			if spare >= len(strings.Split(encoded[len(encoded)-1], ",")[5]) {
+				t.Fatal("Too many sentences used", encoded)
+			}
+		}
+	}
+}
+
+func TestEncodeSentenceExactMultiple(t *testing.T) {
+	nm := NMEACodecNew(ais.CodecNew(false, false))
+
+	/* 120 characters fit in exactly two sentences of 60 with the default line length */
+	p := VdmPacket{TalkerID: "AI", MessageType: "VDM", Payload: make([]byte, 120*6)}
+
+	encoded := nm.EncodeSentence(p)
+	if len(encoded) != 2 {
+		t.Fatal("Expected two sentences", encoded)
+	}
+
+	for _, l := range encoded {
+		if len(l)+2 != nm.MaxLineLength {
+			t.Error("Sentence does not use full line length", l)
+		}
+	}
+}
+
+func TestEncodeSentenceTooLong(t *testing.T) {
+	nm := NMEACodecNew(ais.CodecNew(false, false))
+	nm.MaxLineLength = 30
+
+	p := VdmPacket{TalkerID: "AI", MessageType: "VDM", Payload: make([]byte, 1008)}
+	if encoded := nm.EncodeSentence(p); encoded != nil {
+		t.Error("Produced more than 9 sentences", encoded)
+	}
+
+	if encoded := nm.EncodeSentenceWithSequenceID(p, 10); encoded != nil {
+		t.Error("Invalid sequence ID accepted", encoded)
+	}
+}
+
+func TestEncodeOwnShip(t *testing.T) {
+	nm := NMEACodecNew(ais.CodecNew(false, false))
+
+	p := VdmPacket{
+		OwnShip: true,
+		Packet: ais.PositionReport{
+			Header: ais.Header{MessageID: 1, UserID: 244123456},
+			Valid:  true,
+		},
+	}
+
+	encoded := nm.EncodeSentence(p)
+	if len(encoded) != 1 || !strings.HasPrefix(encoded[0], "!AIVDO,") {
+		t.Fatal("Own ship report not encoded as VDO", encoded)
+	}
+
+	decoded, err := nm.ParseSentence(encoded[0])
+	if err != nil || !decoded.OwnShip || decoded.MessageType != "VDO" {
+		t.Error("Own ship flag not set", decoded, err)
+	}
+
+	p.OwnShip = false
+	encoded = nm.EncodeSentence(p)
+	if decoded, err = nm.ParseSentence(encoded[0]); err != nil || decoded.OwnShip || !strings.HasPrefix(encoded[0], "!AIVDM,") {
+		t.Error("Report from other ship flagged as own ship", encoded, err)
+	}
+}
@@ -0,0 +1,95 @@
+package aisnmea
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Leniency selects which deviations from the standard a decoder accepts. The levels are ordered:
+// every level accepts what the levels below it accept. The zero value is LeniencyStrict.
+type Leniency int8
+
+const (
+	// LeniencyStrict only accepts sentences with an uppercase checksum. This is the default.
+	LeniencyStrict Leniency = iota
+	// LeniencyAcceptLowercase also accepts checksums written in lowercase hexadecimal
+	LeniencyAcceptLowercase
+	// LeniencyAcceptMissingChecksum also accepts sentences without a checksum
+	LeniencyAcceptMissingChecksum
+	// LeniencyTryAlternateFillBits also retries decoding with one or two fill bits more or less if
+	// the payload cannot be decoded
+	LeniencyTryAlternateFillBits
+)
+
+// AcceptLowercase returns true if lowercase checksums are accepted
+func (l Leniency) AcceptLowercase() bool {
+	return l >= LeniencyAcceptLowercase
+}
+
+// AcceptMissingChecksum returns true if sentences without checksum are accepted
+func (l Leniency) AcceptMissingChecksum() bool {
+	return l >= LeniencyAcceptMissingChecksum
+}
+
+// AlternateFillBits returns the changes to the number of payload bits that should be tried, in
+// order, if a payload cannot be decoded. It is empty unless LeniencyTryAlternateFillBits is used.
+func (l Leniency) AlternateFillBits() []int {
+	if l < LeniencyTryAlternateFillBits {
+		return nil
+	}
+	return fillBitAlternatives[:]
+}
+
+var fillBitAlternatives = [...]int{-1, 1, -2, 2}
+
+// Relaxation records which deviations from the standard were accepted for a packet
+type Relaxation uint8
+
+const (
+	// RelaxedLowercaseChecksum is set if a sentence had a lowercase checksum
+	RelaxedLowercaseChecksum Relaxation = 1 << iota
+	// RelaxedMissingChecksum is set if a sentence had no checksum
+	RelaxedMissingChecksum
+	// RelaxedFillBits is set if the payload was only decoded after changing the number of fill bits
+	RelaxedFillBits
+)
+
+func (r Relaxation) String() string {
+	var names []string
+	if r&RelaxedLowercaseChecksum != 0 {
+		names = append(names, "lowercase checksum")
+	}
+	if r&RelaxedMissingChecksum != 0 {
+		names = append(names, "missing checksum")
+	}
+	if r&RelaxedFillBits != 0 {
+		names = append(names, "fill bits")
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
+// applyLeniency checks the checksum of a sentence (without TAG block) against the leniency
+// policy. A missing checksum is added if it is accepted.
+func applyLeniency(sentence string, l Leniency) (string, Relaxation, error) {
+	sumSepIndex := strings.LastIndex(sentence, "*")
+	if sumSepIndex < 0 || len(sentence)-sumSepIndex != 3 {
+		if strings.Contains(sentence, "*") || !l.AcceptMissingChecksum() {
+			return "", 0, fmt.Errorf("aisnmea: sentence does not contain a checksum [%s]", sentence)
+		}
+		return addChecksum(sentence), RelaxedMissingChecksum, nil
+	}
+
+	checksum := sentence[sumSepIndex+1:]
+	if checksum == strings.ToUpper(checksum) {
+		return sentence, 0, nil
+	}
+
+	if !l.AcceptLowercase() {
+		return "", 0, fmt.Errorf("aisnmea: sentence checksum is not uppercase [%s]", sentence)
+	}
+
+	return sentence[:sumSepIndex+1] + strings.ToUpper(checksum), RelaxedLowercaseChecksum, nil
+}
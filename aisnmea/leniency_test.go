@@ -0,0 +1,99 @@
+package aisnmea
+
+import (
+	"testing"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+func TestLeniency(t *testing.T) {
+	tests := []struct {
+		sentence string
+		leniency Leniency
+		ok       bool
+		relax    Relaxation
+	}{
+		{"!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3D", LeniencyStrict, true, 0},
+		{"!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3d", LeniencyStrict, false, 0},
+		{"!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3d", LeniencyAcceptLowercase, true, RelaxedLowercaseChecksum},
+		{"!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0", LeniencyAcceptLowercase, false, 0},
+		{"!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0", LeniencyAcceptMissingChecksum, true, RelaxedMissingChecksum},
+		{"!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3C", LeniencyAcceptMissingChecksum, false, 0},
+		{"!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,2*3F", LeniencyAcceptMissingChecksum, false, 0},
+		{"!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,2*3F", LeniencyTryAlternateFillBits, true, RelaxedFillBits},
+		{"\\s:2156*49\\!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,2", LeniencyTryAlternateFillBits, true, RelaxedMissingChecksum | RelaxedFillBits},
+	}
+
+	for i, test := range tests {
+		nm := NMEACodecNew(ais.CodecNew(false, false))
+		nm.Leniency = test.leniency
+
+		msg, err := nm.ParseSentence(test.sentence)
+		if !test.ok {
+			if err == nil && msg != nil && msg.Packet != nil {
+				t.Error("Test", i, "accepted invalid sentence")
+			}
+			continue
+		}
+
+		if err != nil || msg == nil || msg.Packet == nil {
+			t.Error("Test", i, "rejected sentence", err)
+			continue
+		}
+
+		if msg.Relaxations != test.relax {
+			t.Error("Test", i, "wrong relaxations:", msg.Relaxations)
+		}
+
+		if msg.Packet.GetHeader().UserID != 244660561 {
+			t.Error("Test", i, "wrong packet", msg.Packet)
+		}
+
+		/* The payload is the one the packet was decoded from */
+		if ais.CodecNew(false, false).DecodePacket(msg.Payload) == nil {
+			t.Error("Test", i, "payload cannot be decoded", len(msg.Payload))
+		}
+	}
+}
+
+func TestLeniencyOrder(t *testing.T) {
+	var zero Leniency
+	if zero != LeniencyStrict {
+		t.Error("Wrong default leniency", zero)
+	}
+
+	nm := NMEACodecNew(ais.CodecNew(false, false))
+	if _, err := nm.ParseSentence("!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3d"); err == nil {
+		t.Error("Lowercase checksum accepted by default")
+	}
+
+	levels := []Leniency{LeniencyStrict, LeniencyAcceptLowercase, LeniencyAcceptMissingChecksum, LeniencyTryAlternateFillBits}
+	for i := 1; i < len(levels); i++ {
+		lower, higher := levels[i-1], levels[i]
+		if lower >= higher ||
+			(lower.AcceptLowercase() && !higher.AcceptLowercase()) ||
+			(lower.AcceptMissingChecksum() && !higher.AcceptMissingChecksum()) ||
+			len(lower.AlternateFillBits()) > len(higher.AlternateFillBits()) {
+			t.Error("Leniency levels not ordered", lower, higher)
+		}
+	}
+}
+
+func TestLeniencyMultiSentence(t *testing.T) {
+	nm := NMEACodecNew(ais.CodecNew(false, false))
+	nm.Leniency = LeniencyAcceptMissingChecksum
+
+	msg, err := nm.ParseSentence("!AIVDM,2,1,7,A,8h3OwjQKP@5UUEPPP121IoCol54cd0Wws7wwjp:@`P1UUFD9e2B94oCPH54M`3kw,0*7A")
+	if err != nil || msg != nil {
+		t.Fatal("First fragment not accepted", err)
+	}
+
+	msg, err = nm.ParseSentence("!AIVDM,2,2,7,A,sUwwjt;HvP1,2")
+	if err != nil || msg == nil || msg.Packet == nil {
+		t.Fatal("Message not decoded", err)
+	}
+
+	if msg.Relaxations != RelaxedMissingChecksum {
+		t.Error("Relaxation of second fragment lost", msg.Relaxations)
+	}
+}
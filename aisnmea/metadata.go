@@ -0,0 +1,307 @@
+package aisnmea
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metadata contains information about the reception of a packet that some receivers send in
+// proprietary sentences or after the checksum of the VDM/VDO sentence
+type Metadata struct {
+	ReceivedAt      time.Time
+	SignalStrength  float64 // Received signal level in dBm, zero if unknown
+	FrequencyOffset float64 // Frequency offset in ppm, zero if unknown
+
+	SlotValid bool
+	Slot      uint16
+
+	// TimeOfDay is the reception time since midnight UTC, for receivers that do not send a date.
+	// It is only valid if TimeOfDayValid is set. The date is taken from the receive time of the
+	// packet, see VdmPacket.ReceivedAt.
+	TimeOfDayValid bool
+	TimeOfDay      time.Duration
+}
+
+// Merge copies the known fields of src into md
+func (md *Metadata) Merge(src *Metadata) {
+	if !src.ReceivedAt.IsZero() {
+		md.ReceivedAt = src.ReceivedAt
+	}
+
+	if src.SignalStrength != 0 {
+		md.SignalStrength = src.SignalStrength
+	}
+
+	if src.FrequencyOffset != 0 {
+		md.FrequencyOffset = src.FrequencyOffset
+	}
+
+	if src.SlotValid {
+		md.SlotValid = true
+		md.Slot = src.Slot
+	}
+
+	if src.TimeOfDayValid {
+		md.TimeOfDayValid = true
+		md.TimeOfDay = src.TimeOfDay
+	}
+}
+
+// MetadataParser recognizes receiver metadata. The line is either a complete line that is not a
+// VDM/VDO sentence or the text following the checksum of a VDM/VDO sentence. It returns false if
+// it does not recognize the line. The metadata is attached to the next decoded packet.
+type MetadataParser func(line string) (Metadata, bool)
+
+// DefaultMetadataParsers contains all metadata parsers provided by this package
+var DefaultMetadataParsers = []MetadataParser{
+	ParsePGHP,
+	ParsePSTT,
+	ParseAISCatcherJSON,
+	ParseTrailingFields,
+}
+
+// ParsePGHP parses the Gatehouse $PGHP,1 sentence that precedes a VDM sentence:
+// $PGHP,1,<year>,<month>,<day>,<hour>,<minute>,<second>,<millisecond>,<country>,<region>,<source>,<online>,<checksum>
+func ParsePGHP(line string) (Metadata, bool) {
+	if !strings.HasPrefix(line, "$PGHP,1,") {
+		return Metadata{}, false
+	}
+
+	r, err := parseRawSentence(line)
+	if err != nil || len(r.Fields) < 8 {
+		return Metadata{}, false
+	}
+
+	var v [7]int
+	for i := range v {
+		n, err := r.intField(i+1, -1)
+		if err != nil || n < 0 {
+			return Metadata{}, false
+		}
+		v[i] = int(n)
+	}
+
+	return Metadata{
+		ReceivedAt: time.Date(v[0], time.Month(v[1]), v[2], v[3], v[4], v[5], v[6]*int(time.Millisecond), time.UTC),
+	}, true
+}
+
+// ParsePSTT parses the SRT $PSTT,10A sentence with the time, slot number and signal level of the
+// following VDM sentence: $PSTT,10A,<hhmmss.ss>,<slot>,<signal level>. The time does not contain a
+// date, so it is returned in TimeOfDay.
+func ParsePSTT(line string) (Metadata, bool) {
+	if !strings.HasPrefix(line, "$PSTT,10A,") {
+		return Metadata{}, false
+	}
+
+	r, err := parseRawSentence(line)
+	if err != nil || len(r.Fields) < 4 {
+		return Metadata{}, false
+	}
+
+	var md Metadata
+	if t, err := r.timeField(1); err == nil && t.Valid {
+		md.TimeOfDayValid = true
+		md.TimeOfDay = time.Duration(t.Hour)*time.Hour + time.Duration(t.Minute)*time.Minute +
+			time.Duration(t.Second)*time.Second + time.Duration(t.Millisecond)*time.Millisecond
+	}
+
+	slot, err1 := r.intField(2, -1)
+	signal, err2 := r.floatField(3, 0)
+	if err1 != nil || err2 != nil || slot > 2249 {
+		return Metadata{}, false
+	}
+
+	md.SignalStrength = signal
+	if slot >= 0 {
+		md.SlotValid = true
+		md.Slot = uint16(slot)
+	}
+
+	return md, true
+}
+
+// aisCatcherJSON contains the fields used from the JSON output of AIS-catcher
+type aisCatcherJSON struct {
+	Class       string   `json:"class"`
+	RxTime      string   `json:"rxtime"`
+	SignalPower *float64 `json:"signalpower"`
+	PPM         *float64 `json:"ppm"`
+	NMEA        []string `json:"nmea"`
+}
+
+func parseAISCatcherJSON(line string) (aisCatcherJSON, bool) {
+	var msg aisCatcherJSON
+	if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &msg) != nil || msg.Class != "AIS" {
+		return aisCatcherJSON{}, false
+	}
+
+	return msg, true
+}
+
+// ParseAISCatcherJSON parses a line of JSON output of AIS-catcher. The sentences in the nmea array are
+// not decoded by this function, NMEACodec.ParseSentence does this for lines recognized by a parser.
+func ParseAISCatcherJSON(line string) (Metadata, bool) {
+	msg, ok := parseAISCatcherJSON(line)
+	if !ok {
+		return Metadata{}, false
+	}
+
+	var md Metadata
+	if t, err := time.Parse("20060102150405", msg.RxTime); err == nil {
+		md.ReceivedAt = t
+	}
+	if msg.SignalPower != nil {
+		md.SignalStrength = *msg.SignalPower
+	}
+	if msg.PPM != nil {
+		md.FrequencyOffset = *msg.PPM
+	}
+
+	return md, true
+}
+
+// trailingNumber returns the number following key in text. The key may be followed by quotes, a colon
+// or an equals sign, as in 'signalpower: -47.1', 'ppm=0.2' and '"signalpower":-47.1'.
+func trailingNumber(text string, key string) (float64, bool) {
+	i := strings.Index(text, key)
+	if i < 0 {
+		return 0, false
+	}
+
+	text = strings.TrimLeft(text[i+len(key):], "\"' :=")
+
+	end := 0
+	for end < len(text) && strings.IndexByte("+-.0123456789", text[end]) >= 0 {
+		end++
+	}
+
+	v, err := strconv.ParseFloat(text[:end], 64)
+	return v, err == nil
+}
+
+// ParseTrailingFields parses the signal power and frequency offset that dAISy and AIS-catcher can
+// write after the checksum of a VDM sentence, for example ( MSG: 1, signalpower: -47.1, ppm: 0.2).
+// The rest of an AIS-catcher JSON line following the nmea array is also recognized.
+func ParseTrailingFields(line string) (Metadata, bool) {
+	if line == "" || strings.IndexByte("!$\\", line[0]) >= 0 {
+		return Metadata{}, false
+	}
+
+	var md Metadata
+	signal, ok1 := trailingNumber(line, "signalpower")
+	if ok1 {
+		md.SignalStrength = signal
+	}
+	ppm, ok2 := trailingNumber(line, "ppm")
+	if ok2 {
+		md.FrequencyOffset = ppm
+	}
+
+	return md, ok1 || ok2
+}
+
+// parseMetadata runs the metadata parsers on a line and stores the result for the next packet
+func (nc *NMEACodec) parseMetadata(line string) bool {
+	for _, parser := range nc.MetadataParsers {
+		if md, ok := parser(line); ok {
+			nc.metadataMutex.Lock()
+			nc.metadata.Merge(&md)
+			nc.metadataMutex.Unlock()
+			return true
+		}
+	}
+
+	return false
+}
+
+// takeMetadata returns and clears the stored metadata
+func (nc *NMEACodec) takeMetadata() Metadata {
+	nc.metadataMutex.Lock()
+	defer nc.metadataMutex.Unlock()
+
+	md := nc.metadata
+	nc.metadata = Metadata{}
+	return md
+}
+
+// parseMetadataLine handles lines that only contain metadata. The sentences in the nmea array of
+// JSON lines are decoded, the last resulting packet is returned.
+func (nc *NMEACodec) parseMetadataLine(line string, info ReceiveInfo) (*VdmPacket, bool, error) {
+	if line == "" || (line[0] != '$' && line[0] != '{') || !nc.parseMetadata(line) {
+		return nil, false, nil
+	}
+
+	var result *VdmPacket
+	if msg, ok := parseAISCatcherJSON(line); ok {
+		for _, sentence := range msg.NMEA {
+			p, err := nc.ParseSentenceWithInfo(sentence, info)
+			if err != nil {
+				return nil, true, err
+			}
+			if p != nil {
+				result = p
+			}
+		}
+	}
+
+	return result, true, nil
+}
+
+// stripTrailingMetadata removes the text following the checksum of a sentence if it is recognized
+// as metadata
+func (nc *NMEACodec) stripTrailingMetadata(line string) string {
+	_, rest := splitTagBlock(line)
+
+	sumSepIndex := strings.IndexByte(rest, '*')
+	if sumSepIndex < 0 || len(rest)-sumSepIndex <= 3 {
+		return line
+	}
+
+	end := len(line) - len(rest) + sumSepIndex + 3
+	if !nc.parseMetadata(strings.TrimSpace(line[end:])) {
+		return line
+	}
+
+	return line[:end]
+}
+
+func (p *VdmPacket) applyMetadata(md *Metadata) {
+	if !md.ReceivedAt.IsZero() {
+		p.ReceivedAt = md.ReceivedAt
+	}
+
+	if md.SignalStrength != 0 {
+		p.SignalStrength = md.SignalStrength
+	}
+
+	if md.FrequencyOffset != 0 {
+		p.FrequencyOffset = md.FrequencyOffset
+	}
+
+	if md.SlotValid {
+		p.SlotValid = true
+		p.Slot = md.Slot
+	}
+
+	if md.TimeOfDayValid && !p.ReceivedAt.IsZero() {
+		p.ReceivedAt = withTimeOfDay(p.ReceivedAt, md.TimeOfDay)
+	}
+}
+
+// withTimeOfDay replaces the time of day of ref. The day before or after is used if that is closer
+// to ref, for packets received around midnight.
+func withTimeOfDay(ref time.Time, timeOfDay time.Duration) time.Time {
+	ref = ref.UTC()
+	y, m, d := ref.Date()
+	t := time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Add(timeOfDay)
+
+	if diff := t.Sub(ref); diff > 12*time.Hour {
+		t = t.AddDate(0, 0, -1)
+	} else if diff < -12*time.Hour {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t
+}
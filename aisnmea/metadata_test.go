@@ -0,0 +1,116 @@
+package aisnmea
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+func TestMetadataParsers(t *testing.T) {
+	md, ok := ParsePGHP("$PGHP,1,2008,5,9,0,0,0,10,338,2,,1,09*17")
+	if !ok || !md.ReceivedAt.Equal(time.Date(2008, 5, 9, 0, 0, 0, 10*int(time.Millisecond), time.UTC)) {
+		t.Error("PGHP not parsed", md, ok)
+	}
+
+	if _, ok := ParsePGHP("$PGHP,1,2008,5,9,0,0,0,10,338,2,,1,09*18"); ok {
+		t.Error("PGHP with wrong checksum accepted")
+	}
+
+	md, ok = ParsePSTT("$PSTT,10A,123456.50,1234,-78.5*52")
+	if !ok || !md.SlotValid || md.Slot != 1234 || md.SignalStrength != -78.5 ||
+		!md.TimeOfDayValid || md.TimeOfDay != 12*time.Hour+34*time.Minute+56500*time.Millisecond || !md.ReceivedAt.IsZero() {
+		t.Error("PSTT not parsed", md, ok)
+	}
+
+	md, ok = ParseTrailingFields("( MSG: 2, REPEAT: 0, MMSI: 244660561, signalpower: -47.1, ppm: 0.2)")
+	if !ok || md.SignalStrength != -47.1 || md.FrequencyOffset != 0.2 {
+		t.Error("Trailing fields not parsed", md, ok)
+	}
+
+	if _, ok := ParseTrailingFields("( MSG: 2, REPEAT: 0, MMSI: 244660561)"); ok {
+		t.Error("Trailing fields without metadata accepted")
+	}
+
+	md, ok = ParseAISCatcherJSON(`{"class":"AIS","device":"AIS-catcher","rxtime":"20230427132406","nmea":[],"signalpower":-40.5,"ppm":-1.2}`)
+	if !ok || md.SignalStrength != -40.5 || md.FrequencyOffset != -1.2 || !md.ReceivedAt.Equal(time.Date(2023, 4, 27, 13, 24, 6, 0, time.UTC)) {
+		t.Error("AIS-catcher JSON not parsed", md, ok)
+	}
+}
+
+func TestMetadataAttached(t *testing.T) {
+	nm := NMEACodecNew(ais.CodecNew(false, false))
+
+	/* Metadata parsers are only used if enabled */
+	if _, err := nm.ParseSentence("$PGHP,1,2008,5,9,0,0,0,10,338,2,,1,09*17"); err == nil {
+		t.Error("Metadata sentence accepted without parsers")
+	}
+	nm.MetadataParsers = DefaultMetadataParsers
+
+	msg, err := nm.ParseSentence("$PGHP,1,2008,5,9,0,0,0,10,338,2,,1,09*17")
+	if err != nil || msg != nil {
+		t.Fatal("Metadata sentence not accepted", err)
+	}
+
+	msg, err = nm.ParseSentence("!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3D")
+	if err != nil || msg == nil || !msg.ReceivedAt.Equal(time.Date(2008, 5, 9, 0, 0, 0, 10*int(time.Millisecond), time.UTC)) {
+		t.Fatal("PGHP time not attached", msg, err)
+	}
+
+	msg, err = nm.ParseSentence("$PSTT,10A,123456.50,1234,-78.5*52")
+	if err != nil || msg != nil {
+		t.Fatal("Metadata sentence not accepted", err)
+	}
+
+	/* The time of day is combined with the date given by the caller */
+	info := ReceiveInfo{ReceivedAt: time.Date(2020, 1, 1, 12, 34, 58, 0, time.UTC)}
+	msg, err = nm.ParseSentenceWithInfo("!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3D ( MSG: 2, signalpower: -47.1, ppm: 0.2)", info)
+	if err != nil || msg == nil || msg.Packet == nil {
+		t.Fatal("Sentence with trailing metadata not decoded", err)
+	}
+
+	if !msg.ReceivedAt.Equal(time.Date(2020, 1, 1, 12, 34, 56, 500*int(time.Millisecond), time.UTC)) ||
+		!msg.SlotValid || msg.Slot != 1234 || msg.SignalStrength != -47.1 || msg.FrequencyOffset != 0.2 {
+		t.Error("Metadata not attached", msg)
+	}
+
+	/* The metadata is only used once */
+	msg, err = nm.ParseSentence("!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3D")
+	if err != nil || msg == nil || msg.SlotValid || msg.SignalStrength != 0 || !msg.ReceivedAt.IsZero() {
+		t.Error("Metadata attached twice", msg, err)
+	}
+
+	/* Without a date the time of day is not used */
+	nm.ParseSentence("$PSTT,10A,123456.50,1234,-78.5*52")
+	msg, err = nm.ParseSentence("!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3D")
+	if err != nil || msg == nil || !msg.ReceivedAt.IsZero() || msg.Slot != 1234 {
+		t.Error("Time of day used without a date", msg, err)
+	}
+
+	msg, err = nm.ParseSentence(`{"class":"AIS","device":"AIS-catcher","rxtime":"20230427132406","channel":"B",` +
+		`"nmea":["!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>` + "`" + `<,0*3D"],"signalpower":-40.5,"ppm":-1.2}`)
+	if err != nil || msg == nil || msg.Packet == nil {
+		t.Fatal("AIS-catcher JSON not decoded", err)
+	}
+
+	if !msg.ReceivedAt.Equal(time.Date(2023, 4, 27, 13, 24, 6, 0, time.UTC)) || msg.SignalStrength != -40.5 {
+		t.Error("AIS-catcher metadata not attached", msg)
+	}
+}
+
+func TestWithTimeOfDay(t *testing.T) {
+	tests := []struct {
+		ref       time.Time
+		timeOfDay time.Duration
+		want      time.Time
+	}{
+		{time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC), time.Hour, time.Date(2020, 1, 1, 1, 0, 0, 0, time.UTC)},
+		{time.Date(2020, 1, 1, 0, 0, 1, 0, time.UTC), 24*time.Hour - time.Second, time.Date(2019, 12, 31, 23, 59, 59, 0, time.UTC)},
+		{time.Date(2019, 12, 31, 23, 59, 59, 0, time.UTC), time.Second, time.Date(2020, 1, 1, 0, 0, 1, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		if got := withTimeOfDay(test.ref, test.timeOfDay); !got.Equal(test.want) {
+			t.Error("Wrong time", test.ref, got)
+		}
+	}
+}
@@ -3,6 +3,9 @@ package aisnmea
 import (
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/BertoldVdb/go-ais"
 
@@ -15,13 +18,41 @@ const (
 )
 
 // NMEACodec is a convenience code that allows easy encoding and decoding of NMEA as produced by
-// an AIS receiver
+// an AIS receiver. Parsing and encoding from multiple goroutines does not cause data races, but the
+// configuration fields should not be changed while doing so. The codec is not meant to decode
+// several independent streams at once: the fragments of multi-sentence messages are only matched
+// by channel and sequential message identifier, sentences without a channel inherit it from the
+// previous sentence, and receiver metadata and the last packet that VSI sentences refer to are
+// kept for a single stream. Use one codec per stream.
 type NMEACodec struct {
 	assembler      *vdmAssembler
 	codec          *ais.Codec
 	MaxLineLength  int
 	seqNo          int
+	seqNoMutex     sync.Mutex
 	AppendChecksum bool
+
+	transmitAssembler fragmentAssembler
+
+	// KeepRawSentences stores the sentences a packet was assembled from in VdmPacket.Fragments.
+	// This is useful for audit trails, but costs some memory for every packet.
+	KeepRawSentences bool
+
+	// Leniency selects which deviations from the standard are accepted when parsing sentences
+	Leniency Leniency
+
+	// MetadataParsers recognize receiver metadata in proprietary sentences and after the checksum
+	// of VDM/VDO sentences, for example DefaultMetadataParsers. It is nil by default. The metadata
+	// is attached to the next decoded packet, so a codec with metadata parsers can only decode a
+	// single stream of sentences and must not be shared between streams.
+	MetadataParsers []MetadataParser
+	metadata        Metadata
+	metadataMutex   sync.Mutex
+
+	/* The sequential message identifier of the last decoded packet, VSI sentences refer to it */
+	lastPacketSeen  bool
+	lastPacketSeqID int64
+	lastPacketMutex sync.Mutex
 }
 
 // NMEACodecNew creates a NMEACodec. You need to provide a configured ais.Codec
@@ -45,6 +76,37 @@ func (nc *NMEACodec) handleAssembledMessage(assembled *VdmPacket) {
 
 	assembled.Packet = nc.codec.DecodePacket(assembled.Payload)
 	assembled.Channel = channel
+
+	/* Some transmitters get the number of fill bits wrong */
+	if assembled.Packet == nil {
+		for _, delta := range nc.Leniency.AlternateFillBits() {
+			n := len(assembled.Payload) + delta
+			if n <= 0 {
+				continue
+			}
+
+			payload := make([]byte, n)
+			copy(payload, assembled.Payload)
+
+			if assembled.Packet = nc.codec.DecodePacket(payload); assembled.Packet != nil {
+				assembled.Payload = payload
+				assembled.Relaxations |= RelaxedFillBits
+				break
+			}
+		}
+	}
+	assembled.OwnShip = assembled.MessageType == nmea.TypeVDO
+
+	md := nc.takeMetadata()
+	assembled.applyMetadata(&md)
+
+	/* Metadata in the TAG block is preferred over what the caller provided */
+	if assembled.Tags.Time != 0 {
+		assembled.ReceivedAt = assembled.Tags.Timestamp()
+	}
+	if assembled.Tags.Source != "" {
+		assembled.Source = assembled.Tags.Source
+	}
 }
 
 // BufferedMessages return the number of messages buffered in the reassembler
@@ -52,27 +114,73 @@ func (nc *NMEACodec) BufferedMessages() int {
 	return nc.assembler.bufferedMessages()
 }
 
-// ParseVDMVDO parses a message contained in a nmea.VDMVDO struct
-func (nc *NMEACodec) ParseVDMVDO(m *nmea.VDMVDO) (*VdmPacket, error) {
-	assembled, ok := nc.assembler.process(m)
+func (nc *NMEACodec) parseVDMVDO(m *nmea.VDMVDO, tagBlock TagBlock, raw string, info ReceiveInfo, relax Relaxation) (*VdmPacket, error) {
+	if !nc.KeepRawSentences {
+		raw = ""
+	}
+
+	assembled, ok := nc.assembler.process(m, tagBlock, raw, info, relax)
 	if ok {
 		nc.handleAssembledMessage(&assembled)
+
+		nc.lastPacketMutex.Lock()
+		nc.lastPacketSeen = true
+		nc.lastPacketSeqID = -1
+		if m.NumFragments > 1 {
+			nc.lastPacketSeqID = m.MessageID
+		}
+		nc.lastPacketMutex.Unlock()
+
 		return &assembled, nil
 	}
 
 	return nil, nil
 }
 
+// ParseVDMVDO parses a message contained in a nmea.VDMVDO struct. Unknown TAG Block parameters are
+// not available in this case, use ParseSentence to keep them.
+func (nc *NMEACodec) ParseVDMVDO(m *nmea.VDMVDO) (*VdmPacket, error) {
+	return nc.parseVDMVDO(m, tagBlockFromNMEA(m.TagBlock), m.Raw, ReceiveInfo{}, 0)
+}
+
 // ParseSentence decodes a NMEA sentence containing an AIS message
 func (nc *NMEACodec) ParseSentence(sentence string) (*VdmPacket, error) {
-	s, err := nmea.Parse(sentence)
+	return nc.ParseSentenceWithInfo(sentence, ReceiveInfo{})
+}
+
+// ParseSentenceWithInfo decodes a NMEA sentence containing an AIS message. The provided
+// information is attached to the resulting packet, unless the TAG block contains the same field.
+func (nc *NMEACodec) ParseSentenceWithInfo(sentence string, info ReceiveInfo) (*VdmPacket, error) {
+	raw := strings.TrimSpace(sentence)
+
+	if len(nc.MetadataParsers) > 0 {
+		if p, ok, err := nc.parseMetadataLine(raw, info); ok {
+			return p, err
+		}
+		raw = nc.stripTrailingMetadata(raw)
+	}
+
+	tagBlockRaw, rest := splitTagBlock(raw)
+	body, relax, err := applyLeniency(rest, nc.Leniency)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := nmea.Parse(raw[:len(raw)-len(rest)] + body)
 	if err != nil {
 		return nil, err
 	}
 
 	switch m := s.(type) {
 	case nmea.VDMVDO:
-		return nc.ParseVDMVDO(&m)
+		var tagBlock TagBlock
+		if tagBlockRaw != "" {
+			if tagBlock, err = ParseTagBlock(tagBlockRaw); err != nil {
+				return nil, err
+			}
+		}
+
+		return nc.parseVDMVDO(&m, tagBlock, raw, info, relax)
 	}
 
 	return nil, errors.New(SentenceNotVDMVDO)
@@ -97,27 +205,36 @@ func addChecksum(sentence string) string {
 	return fmt.Sprintf("%s*%02X", sentence, checksum)
 }
 
-// EncodeSentence encodes the provided packet into zero or more NMEA sentences
-func (nc *NMEACodec) EncodeSentence(p VdmPacket) []string {
-	if p.Payload == nil && p.Packet != nil {
-		p.Payload = nc.codec.EncodePacket(p.Packet)
-	}
+// nextSeqNo returns the sequential message identifier for the next multi-sentence message
+func (nc *NMEACodec) nextSeqNo() int {
+	nc.seqNoMutex.Lock()
+	defer nc.seqNoMutex.Unlock()
 
-	if p.Payload == nil {
-		return nil
+	seqNo := nc.seqNo
+	nc.seqNo++
+	if nc.seqNo == 10 {
+		nc.seqNo = 0
 	}
 
-	asciiPayload := make([]byte, 0, len(p.Payload)/6*8+8)
+	return seqNo
+}
+
+// maxFragments is the highest number of sentences a message can be split into, the field is a single digit
+const maxFragments = 9
+
+// armorPayload converts a payload with one bit per byte into the six bit ASCII armoring and the number of fill bits
+func armorPayload(payload []byte) ([]byte, int) {
+	asciiPayload := make([]byte, 0, len(payload)/6*8+8)
 
 	value := byte(0)
 	bitsUsed := 0
-	for i := 0; i < len(p.Payload); i++ {
-		if p.Payload[i] > 1 {
-			return nil
+	for i := 0; i < len(payload); i++ {
+		if payload[i] > 1 {
+			return nil, 0
 		}
 
 		value <<= 1
-		value += p.Payload[i]
+		value += payload[i]
 		bitsUsed++
 		if bitsUsed >= 6 {
 			asciiPayload = append(asciiPayload, valueToChar(value))
@@ -135,67 +252,180 @@ func (nc *NMEACodec) EncodeSentence(p VdmPacket) []string {
 		asciiPayload = append(asciiPayload, valueToChar(value))
 	}
 
-	channel := 'A'
-	if p.Channel == 2 {
-		channel = 'B'
-	}
+	return asciiPayload, fillBits
+}
 
-	maxDataLength := nc.MaxLineLength - 3 - 2 - 1 - 16 //Subtract: *CRC, \r\n, !, AIVDM,x,y,z,c,,f
-	if maxDataLength < 1 {
-		maxDataLength = 1
+// encodeChannel returns the channel character used in the sentence. The values 1 and 2 are
+// translated to A and B, other printable characters are used as is.
+func encodeChannel(channel byte) string {
+	switch {
+	case channel == 0 || channel == 1:
+		return "A"
+	case channel == 2:
+		return "B"
+	case channel > ' ' && channel < 127 && channel != ',' && channel != '*':
+		return string(channel)
 	}
 
-	var output []string
+	return "A"
+}
 
-	/* Does the packet fit in one sentence? */
-	if nc.MaxLineLength <= 0 || len(asciiPayload) <= maxDataLength {
-		output = make([]string, 1)
-		output[0] = fmt.Sprintf("!%s%s,1,1,,%c,%s,%d", p.TalkerID, p.MessageType, channel, asciiPayload, fillBits)
-		output[0] = addChecksum(output[0])
+// EncodeSentence encodes the provided packet into zero or more NMEA sentences. When the packet does
+// not fit in a single sentence of MaxLineLength characters (including TAG block, checksum and line ending)
+// it is split over multiple sentences using an automatically incrementing sequential message identifier.
+// nil is returned if the packet cannot be encoded in at most 9 sentences. An empty TalkerID and
+// MessageType default to AI and VDM, or VDO if OwnShip is set.
+func (nc *NMEACodec) EncodeSentence(p VdmPacket) []string {
+	return nc.encodeSentence(p, -1)
+}
 
-		tagBlock := encodeTagBlock(&p.TagBlock, 1,1,0, false)
-		if tagBlock != "" {
-			output[0] = fmt.Sprintf("%s%s", tagBlock, output[0])
-		}
-	} else {
-		dataIndex := 0
-		msgIndex := 1
+// EncodeSentenceWithSequenceID works like EncodeSentence, but uses the provided sequential message
+// identifier (0-9) instead of the automatic one. The identifier is also included if the packet fits in
+// a single sentence.
+func (nc *NMEACodec) EncodeSentenceWithSequenceID(p VdmPacket, seqID int) []string {
+	if seqID < 0 || seqID > 9 {
+		return nil
+	}
 
-		msgNum := len(asciiPayload)/maxDataLength + 1
+	return nc.encodeSentence(p, seqID)
+}
 
-		for dataIndex < len(asciiPayload) {
-			bytesUsed := maxDataLength
+type sentenceLayout struct {
+	msgNum    int
+	dataSizes []int
+}
 
-			if dataIndex+bytesUsed > len(asciiPayload) {
-				bytesUsed = len(asciiPayload) - dataIndex
+// layoutFragments finds the smallest amount of sentences that can carry dataLength characters,
+// and how many characters go in every sentence. The overhead function returns the length of a
+// sentence without data.
+func layoutFragments(maxLineLength int, dataLength int, overhead func(msgNum, msgIndex int) int) (sentenceLayout, bool) {
+	if maxLineLength <= 0 {
+		return sentenceLayout{msgNum: 1, dataSizes: []int{dataLength}}, true
+	}
+
+	for msgNum := 1; msgNum <= maxFragments; msgNum++ {
+		layout := sentenceLayout{msgNum: msgNum}
+		remaining := dataLength
+
+		for msgIndex := 1; msgIndex <= msgNum; msgIndex++ {
+			capacity := maxLineLength - overhead(msgNum, msgIndex)
+			if capacity < 1 {
+				return sentenceLayout{}, false
+			}
+			if capacity > remaining {
+				capacity = remaining
 			}
 
-			sub := asciiPayload[dataIndex : dataIndex+bytesUsed]
-			dataIndex += bytesUsed
+			layout.dataSizes = append(layout.dataSizes, capacity)
+			remaining -= capacity
 
-			suffix := 0
-			if dataIndex >= len(asciiPayload) {
-				suffix = fillBits
+			if remaining == 0 && msgIndex < msgNum {
+				/* A smaller number of sentences would have fit */
+				break
 			}
+		}
 
-			sentence := fmt.Sprintf("!%s%s,%d,%d,%d,%c,%s,%d", p.TalkerID, p.MessageType, msgNum, msgIndex, nc.seqNo, channel, sub, suffix)
+		if remaining == 0 && len(layout.dataSizes) == msgNum {
+			return layout, true
+		}
+	}
 
-			sentence = addChecksum(sentence)
+	return sentenceLayout{}, false
+}
 
-			tagBlock := encodeTagBlock(&p.TagBlock, msgIndex, msgNum, nc.seqNo, true)
-			if tagBlock != "" {
-				sentence = fmt.Sprintf("%s%s", tagBlock, sentence)
-			}
+// layoutSentences calculates the layout of a VDM/VDO message, including the TAG Blocks
+func (nc *NMEACodec) layoutSentences(p *VdmPacket, dataLength int, channel string, seqField string) (sentenceLayout, bool) {
+	return layoutFragments(nc.MaxLineLength, dataLength, func(msgNum, msgIndex int) int {
+		sf := seqField
+		if msgNum > 1 && sf == "" {
+			/* The automatic identifier is a single digit, the exact value does not matter here */
+			sf = "0"
+		}
+		groupID, _ := strconv.Atoi(sf)
+
+		/* The fill bits field is always a single digit */
+		overhead := len(fmt.Sprintf("!%s%s,%d,%d,%s,%s,,0*00\r\n", p.TalkerID, p.MessageType, msgNum, msgIndex, sf, channel))
+		return overhead + len(encodeTagBlock(&p.Tags, msgIndex, msgNum, groupID, true))
+	})
+}
+
+func (nc *NMEACodec) encodeSentence(p VdmPacket, seqID int) []string {
+	if p.Payload == nil && p.Packet != nil {
+		p.Payload = nc.codec.EncodePacket(p.Packet)
+	}
+
+	if p.Payload == nil {
+		return nil
+	}
+
+	/* Callers that only know the go-nmea type fill TagBlock */
+	if p.Tags == (TagBlock{}) {
+		p.Tags = tagBlockFromNMEA(p.TagBlock)
+	}
 
-			output = append(output, sentence)
+	/* The group of a decoded multi-sentence message does not describe the new sentences, a new
+	   group is added if they are split again */
+	if p.Tags.Group.Total > 1 {
+		p.Tags.Group = TagBlockGroup{}
+	}
+
+	/* Own ship reports are always sent as VDO */
+	p.TalkerID = talkerOrDefault(p.TalkerID)
+	if p.OwnShip {
+		p.MessageType = nmea.TypeVDO
+	} else if p.MessageType == "" {
+		p.MessageType = nmea.TypeVDM
+	}
+
+	asciiPayload, fillBits := armorPayload(p.Payload)
+	if asciiPayload == nil {
+		return nil
+	}
+
+	channel := encodeChannel(p.Channel)
+
+	seqField := ""
+	if seqID >= 0 {
+		seqField = strconv.Itoa(seqID)
+	}
 
-			msgIndex++
+	layout, ok := nc.layoutSentences(&p, len(asciiPayload), channel, seqField)
+	if !ok {
+		return nil
+	}
+
+	if layout.msgNum > 1 && seqField == "" {
+		seqID = nc.nextSeqNo()
+		seqField = strconv.Itoa(seqID)
+	}
+
+	/* The tag block does not use the sequence number for single sentence messages */
+	groupID := seqID
+	if groupID < 0 {
+		groupID = 0
+	}
+
+	output := make([]string, 0, layout.msgNum)
+	dataIndex := 0
+
+	for msgIndex := 1; msgIndex <= layout.msgNum; msgIndex++ {
+		sub := asciiPayload[dataIndex : dataIndex+layout.dataSizes[msgIndex-1]]
+		dataIndex += len(sub)
+
+		suffix := 0
+		if msgIndex == layout.msgNum {
+			suffix = fillBits
 		}
 
-		nc.seqNo++
-		if nc.seqNo == 10 {
-			nc.seqNo = 0
+		sentence := fmt.Sprintf("!%s%s,%d,%d,%s,%s,%s,%d", p.TalkerID, p.MessageType, layout.msgNum, msgIndex, seqField, channel, sub, suffix)
+		sentence = addChecksum(sentence)
+
+		tagBlock := encodeTagBlock(&p.Tags, msgIndex, layout.msgNum, groupID, layout.msgNum > 1)
+		if tagBlock != "" {
+			sentence = fmt.Sprintf("%s%s", tagBlock, sentence)
 		}
+
+		output = append(output, sentence)
 	}
 
 	return output
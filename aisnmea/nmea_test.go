@@ -7,6 +7,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/BertoldVdb/go-ais"
 	"github.com/adrianmo/go-nmea"
@@ -170,7 +171,129 @@ func TestNMEATagBlockDecodeMultiSentence(t *testing.T) {
 		t.Error("TAG block Time not parsed")
 	}
 
-	if msg.TagBlock.Grouping != "" {
-		t.Error("TAG block Grouping parsed (should be ignored)")
+	if msg.Tags.Group != (TagBlockGroup{Sentence: 1, Total: 2, ID: 2449555}) || msg.TagBlock.Grouping != "1-2-2449555" {
+		t.Error("TAG block Group not taken from the first sentence", msg.Tags.Group)
+	}
+}
+
+func TestNMEATagBlockReencodeMultiSentence(t *testing.T) {
+	nm := NMEACodecNew(ais.CodecNew(false, false))
+	nm.ParseSentence("\\g:1-2-2449555,s:2251,c:1560234814*7E\\!AIVDM,2,1,7,A," +
+		"8h3OwjQKP@5UUEPPP121IoCol54cd0Wws7wwjp:@`P1UUFD9e2B94oCPH54M`3kw,0*7A")
+	msg, _ := nm.ParseSentence("\\g:2-2-2449555*63\\!AIVDM,2,2,7,A,sUwwjt;HvP1,2*4F")
+	if msg == nil {
+		t.Fatal("Message not decoded")
+	}
+
+	/* A single sentence must not refer to a group of two */
+	nm.MaxLineLength = 0
+	encoded := nm.EncodeSentence(*msg)
+	if len(encoded) != 1 || !strings.HasPrefix(encoded[0], "\\s:2251,c:1560234814*") {
+		t.Error("Group not removed", encoded)
+	}
+}
+
+func TestNMEATagBlockGoNMEAType(t *testing.T) {
+	nm := NMEACodecNew(ais.CodecNew(false, false))
+	encoded := nm.EncodeSentence(VdmPacket{
+		Payload:  make([]byte, 168),
+		TagBlock: nmea.TagBlock{Time: 1560234814, Source: "2251"},
+	})
+	if len(encoded) != 1 || !strings.HasPrefix(encoded[0], "\\s:2251,c:1560234814*32\\!AIVDM") {
+		t.Error("TAG block of go-nmea type not encoded", encoded)
+	}
+}
+
+func TestNMEAReceiveInfo(t *testing.T) {
+	nm := NMEACodecNew(ais.CodecNew(false, false))
+	nm.KeepRawSentences = true
+
+	at := time.Unix(1560234000, 0)
+	info := ReceiveInfo{ReceivedAt: at, Source: "caller", SignalStrength: -80, SNR: 12}
+
+	msg, err := nm.ParseSentenceWithInfo("!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3D", info)
+	if err != nil || msg == nil {
+		t.Fatal("Valid message not decoded", err)
+	}
+
+	if !msg.ReceivedAt.Equal(at) || msg.Source != "caller" || msg.SignalStrength != -80 || msg.SNR != 12 {
+		t.Error("Caller provided information not used", msg)
+	}
+
+	if len(msg.Fragments) != 1 || msg.Fragments[0] != "!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3D" {
+		t.Error("Raw sentence not kept", msg.Fragments)
+	}
+
+	/* The TAG block takes precedence */
+	msg, err = nm.ParseSentenceWithInfo("\\s:2156,c:1560234814*36\\!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3D", info)
+	if err != nil || msg == nil {
+		t.Fatal("Valid message not decoded", err)
+	}
+
+	if !msg.ReceivedAt.Equal(time.Unix(1560234814, 0)) || msg.Source != "2156" {
+		t.Error("TAG block information not used", msg.ReceivedAt, msg.Source)
+	}
+}
+
+func TestNMEAReceiveInfoMultiSentence(t *testing.T) {
+	nm := NMEACodecNew(ais.CodecNew(false, false))
+	nm.KeepRawSentences = true
+
+	sentences := []string{
+		"!AIVDM,2,1,7,A,8h3OwjQKP@5UUEPPP121IoCol54cd0Wws7wwjp:@`P1UUFD9e2B94oCPH54M`3kw,0*7A",
+		"!AIVDM,2,2,7,A,sUwwjt;HvP1,2*4F",
+	}
+
+	first := time.Unix(1560234000, 0)
+
+	msg, _ := nm.ParseSentenceWithInfo(sentences[1], ReceiveInfo{ReceivedAt: first.Add(time.Second)})
+	if msg != nil {
+		t.Fatal("Premature return of message")
+	}
+
+	msg, _ = nm.ParseSentenceWithInfo(sentences[0], ReceiveInfo{ReceivedAt: first, SignalStrength: -90})
+	if msg == nil {
+		t.Fatal("No message for multi-sentence message")
+	}
+
+	if !msg.ReceivedAt.Equal(first) || msg.SignalStrength != -90 {
+		t.Error("Receive information not merged", msg.ReceivedAt, msg.SignalStrength)
+	}
+
+	if len(msg.Fragments) != 2 || msg.Fragments[0] != sentences[0] || msg.Fragments[1] != sentences[1] {
+		t.Error("Raw sentences not kept in order", msg.Fragments)
+	}
+
+	/* Without the option nothing is kept */
+	nm.KeepRawSentences = false
+	nm.ParseSentence(sentences[0])
+	msg, _ = nm.ParseSentence(sentences[1])
+	if msg == nil || msg.Fragments != nil {
+		t.Error("Raw sentences kept although not requested")
+	}
+}
+
+func TestNMEATagBlockUnknownParameters(t *testing.T) {
+	nm := NMEACodecNew(ais.CodecNew(false, false))
+	msg, err := nm.ParseSentence("\\s:2156,c:1560234814123,x:42*6E\\!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3D")
+	if err != nil || msg == nil {
+		t.Fatal("Valid message not decoded", err)
+	}
+
+	if msg.Tags.Unknown != "x:42" || !msg.Tags.TimeMilliseconds {
+		t.Error("TAG block not fully parsed", msg.Tags)
+	}
+
+	if !msg.ReceivedAt.Equal(time.Unix(1560234814, 123000000)) {
+		t.Error("Millisecond timestamp not used", msg.ReceivedAt)
+	}
+
+	encoded := nm.EncodeSentence(*msg)
+	if len(encoded) != 1 || !strings.HasPrefix(encoded[0], "\\s:2156,c:1560234814123,x:42*") {
+		t.Error("TAG block not re-encoded", encoded)
+	}
+
+	if _, err := nm.ParseSentence("\\s:2156,c:1560234814*37\\!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3D"); err == nil {
+		t.Error("TAG block with invalid checksum accepted")
 	}
 }
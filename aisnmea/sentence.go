@@ -0,0 +1,182 @@
+package aisnmea
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// rawSentence is a sentence split into its fields. It is used for the sentence types that go-nmea
+// does not support.
+type rawSentence struct {
+	TagBlock TagBlock
+	Start    byte
+	Talker   string
+	Type     string
+	Fields   []string
+}
+
+// parseRawSentence verifies the checksum of a sentence and splits it into fields
+func parseRawSentence(sentence string) (rawSentence, error) {
+	var r rawSentence
+	var err error
+
+	tagBlock, sentence := splitTagBlock(strings.TrimSpace(sentence))
+	if tagBlock != "" {
+		if r.TagBlock, err = ParseTagBlock(tagBlock); err != nil {
+			return rawSentence{}, err
+		}
+	}
+
+	sumSepIndex := strings.LastIndex(sentence, "*")
+	if len(sentence) < 7 || (sentence[0] != '!' && sentence[0] != '$') || sumSepIndex < 0 || len(sentence)-sumSepIndex != 3 {
+		return rawSentence{}, fmt.Errorf("aisnmea: sentence is malformed [%s]", sentence)
+	}
+
+	body := sentence[:sumSepIndex]
+	if addChecksum(body) != body+strings.ToUpper(sentence[sumSepIndex:]) {
+		return rawSentence{}, fmt.Errorf("aisnmea: sentence checksum mismatch [%s]", sentence)
+	}
+
+	fields := strings.Split(body[1:], ",")
+	r.Start = sentence[0]
+	r.Fields = fields[1:]
+
+	/* Proprietary sentences start with P and a manufacturer code instead of a talker */
+	if len(fields[0]) >= 4 && fields[0][0] == 'P' {
+		r.Talker = "P"
+		r.Type = fields[0][1:]
+	} else if len(fields[0]) == 5 {
+		r.Talker = fields[0][:2]
+		r.Type = fields[0][2:]
+	} else {
+		return rawSentence{}, fmt.Errorf("aisnmea: sentence address is malformed [%s]", sentence)
+	}
+
+	return r, nil
+}
+
+// field returns field i, or an empty string if the sentence is too short
+func (r *rawSentence) field(i int) string {
+	if i >= len(r.Fields) {
+		return ""
+	}
+	return r.Fields[i]
+}
+
+// intField parses field i as an integer. Empty fields are returned as def.
+func (r *rawSentence) intField(i int, def int64) (int64, error) {
+	f := r.field(i)
+	if f == "" {
+		return def, nil
+	}
+
+	v, err := strconv.ParseInt(f, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("aisnmea: %s field %d is not a number [%s]", r.Type, i+1, f)
+	}
+	return v, nil
+}
+
+// floatField parses field i as a floating point number. Empty fields are returned as def.
+func (r *rawSentence) floatField(i int, def float64) (float64, error) {
+	f := r.field(i)
+	if f == "" {
+		return def, nil
+	}
+
+	v, err := strconv.ParseFloat(f, 64)
+	if err != nil {
+		return 0, fmt.Errorf("aisnmea: %s field %d is not a number [%s]", r.Type, i+1, f)
+	}
+	return v, nil
+}
+
+// encodeRawSentence joins the fields into a sentence and adds the checksum
+func encodeRawSentence(start byte, talker string, typ string, fields ...string) string {
+	return addChecksum(string(start) + talker + typ + "," + strings.Join(fields, ","))
+}
+
+func charToValue(c byte) (byte, bool) {
+	if c < 48 || c > 119 || (c > 87 && c < 96) {
+		return 0, false
+	}
+
+	c -= 48
+	if c > 40 {
+		c -= 8
+	}
+
+	return c, true
+}
+
+// dearmorPayload converts six bit ASCII armored data into a payload with one bit per byte
+func dearmorPayload(data string, fillBits int) ([]byte, error) {
+	if fillBits < 0 || fillBits > 5 || len(data)*6 < fillBits {
+		return nil, fmt.Errorf("aisnmea: invalid number of fill bits [%d]", fillBits)
+	}
+
+	payload := make([]byte, 0, len(data)*6)
+	for i := 0; i < len(data); i++ {
+		value, ok := charToValue(data[i])
+		if !ok {
+			return nil, fmt.Errorf("aisnmea: invalid character in payload [%c]", data[i])
+		}
+
+		for j := 5; j >= 0; j-- {
+			payload = append(payload, (value>>uint(j))&1)
+		}
+	}
+
+	return payload[:len(payload)-fillBits], nil
+}
+
+// textReserved contains the characters that cannot appear in a text field and are sent as ^hh instead
+const textReserved = "\r\n$*,!\\^~\x7f"
+
+// escapeText encodes the reserved characters in a text field
+func escapeText(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if c < 0x20 || strings.IndexByte(textReserved, c) >= 0 {
+			fmt.Fprintf(&b, "^%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// unescapeText decodes the ^hh sequences in a text field
+func unescapeText(text string) (string, error) {
+	if strings.IndexByte(text, '^') < 0 {
+		return text, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] != '^' {
+			b.WriteByte(text[i])
+			continue
+		}
+
+		if i+2 >= len(text) {
+			return "", fmt.Errorf("aisnmea: truncated escape sequence in text [%s]", text)
+		}
+
+		c, err := strconv.ParseUint(text[i+1:i+3], 16, 8)
+		if err != nil {
+			return "", fmt.Errorf("aisnmea: invalid escape sequence in text [%s]", text)
+		}
+		b.WriteByte(byte(c))
+		i += 2
+	}
+
+	return b.String(), nil
+}
+
+// textField returns field i with the escape sequences decoded
+func (r *rawSentence) textField(i int) (string, error) {
+	return unescapeText(r.field(i))
+}
@@ -0,0 +1,533 @@
+package aisnmea
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/BertoldVdb/go-ais"
+	nmea "github.com/adrianmo/go-nmea"
+)
+
+// AckType is the type of acknowledgement reported in an ABK sentence
+type AckType uint8
+
+const (
+	// AckReceived means the addressed message (6 or 12) was acknowledged by the destination
+	AckReceived AckType = 0
+	// AckNotReceived means the addressed message was transmitted but not acknowledged
+	AckNotReceived AckType = 1
+	// AckNotBroadcast means the message could not be transmitted
+	AckNotBroadcast AckType = 2
+	// AckBroadcastCompleted means the requested broadcast (8, 14 or 15) was transmitted
+	AckBroadcastCompleted AckType = 3
+	// AckLate means the acknowledgement (7 or 13) of an addressed message was received late
+	AckLate AckType = 4
+)
+
+// AbkPacket is an addressed and binary broadcast acknowledgement (ABK) sentence. The transponder
+// sends it in response to ABM, BBM and AIR sentences and when message 7 or 13 is received.
+type AbkPacket struct {
+	TalkerID      string
+	DestinationID uint32 // MMSI of the addressed station, zero for broadcasts
+	Channel       byte   // Channel of reception of the acknowledgement, 0: none, 1: A, 2: B
+	MessageID     uint8  // ITU-R M.1371 message ID of the acknowledged message
+	SequenceID    int    // Sequential message identifier of the ABM or BBM sentence
+	Type          AckType
+}
+
+// SsdPacket is a ship static data (SSD) sentence. It is used to configure the static data of
+// a transponder. Empty fields are decoded as zero values.
+type SsdPacket struct {
+	TalkerID  string
+	CallSign  string
+	Name      string
+	Dimension ais.FieldDimension // Position of the reference point for reported positions
+	Dte       bool               // Data terminal not available
+	SourceID  string             // Talker ID of the equipment the reference point belongs to, usually AI
+}
+
+// VsdPacket is a voyage static data (VSD) sentence. It is used to configure the voyage related
+// data of a transponder.
+type VsdPacket struct {
+	TalkerID             string
+	Type                 uint8 // Type of ship and cargo
+	MaximumStaticDraught ais.Field10
+	PersonsOnBoard       uint16 // 0 is not available
+	Destination          string
+	Eta                  ais.FieldETA
+	NavigationalStatus   uint8 // 15 is not defined
+	RegionalFlags        uint8 // Regional application flags
+}
+
+// VsiPacket is a VDL signal information (VSI) sentence. It contains reception details of the
+// VDM sentence that precedes it.
+type VsiPacket struct {
+	TalkerID    string
+	Description string    // Description of the source of the VDM sentence
+	SequenceID  int       // Sequential message identifier of the VDM sentence, -1 if not present
+	Time        nmea.Time // Start of the slot in which the message was received
+
+	SlotValid      bool
+	Slot           uint16
+	SignalStrength float64 // Received signal level in dBm, zero if unknown
+	SNR            float64 // Signal to noise ratio in dB, zero if unknown
+
+	// LastPacket is set by ParseVSI if the sentence refers to the last packet decoded by the codec.
+	// The information can be added to that packet with Apply.
+	LastPacket bool
+}
+
+// Apply copies the reception details that the VSI sentence contains to the packet
+func (p *VsiPacket) Apply(vdm *VdmPacket) {
+	if p.SlotValid {
+		vdm.SlotValid = true
+		vdm.Slot = p.Slot
+	}
+	if p.SignalStrength != 0 {
+		vdm.SignalStrength = p.SignalStrength
+	}
+	if p.SNR != 0 {
+		vdm.SNR = p.SNR
+	}
+}
+
+// TxtPacket is a text transmission (TXT) sentence, used by the transponder to report its status
+type TxtPacket struct {
+	TalkerID string
+	Total    int // Total number of sentences
+	Number   int // Sentence number
+	ID       int // Text identifier
+	Text     string
+}
+
+// AlrPacket is an alarm (ALR) sentence
+type AlrPacket struct {
+	TalkerID     string
+	Time         nmea.Time // Time of the last change of the alarm condition
+	ID           int       // Local alarm number
+	Active       bool      // Alarm threshold exceeded
+	Acknowledged bool
+	Text         string
+}
+
+// formatTime encodes a time as hhmmss.ss, an invalid time is encoded as an empty field
+func formatTime(t nmea.Time) string {
+	if !t.Valid {
+		return ""
+	}
+	return fmt.Sprintf("%02d%02d%02d.%02d", t.Hour, t.Minute, t.Second, t.Millisecond/10)
+}
+
+// timeField parses field i as a time
+func (r *rawSentence) timeField(i int) (nmea.Time, error) {
+	t, err := nmea.ParseTime(r.field(i))
+	if err != nil {
+		return nmea.Time{}, fmt.Errorf("aisnmea: %s field %d is not a time [%s]", r.Type, i+1, r.field(i))
+	}
+	return t, nil
+}
+
+func formatFlag(value bool) string {
+	if value {
+		return "A"
+	}
+	return "V"
+}
+
+// parseStatusSentence parses a sentence and checks its type and minimum number of fields
+func parseStatusSentence(sentence string, typ string, numFields int) (*rawSentence, error) {
+	r, err := parseRawSentence(sentence)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Type != typ || len(r.Fields) < numFields {
+		return nil, fmt.Errorf("aisnmea: sentence is not a valid %s sentence", typ)
+	}
+
+	return &r, nil
+}
+
+// EncodeABK encodes an ABK sentence. An empty string is returned if encoding failed.
+func (nc *NMEACodec) EncodeABK(p AbkPacket) string {
+	if p.Channel > 2 || p.SequenceID < 0 || p.SequenceID > 9 || p.Type > AckLate {
+		return ""
+	}
+
+	destination := ""
+	if p.DestinationID != 0 {
+		destination = strconv.FormatUint(uint64(p.DestinationID), 10)
+	}
+
+	channel := ""
+	if p.Channel != 0 {
+		channel = encodeChannel(p.Channel)
+	}
+
+	return encodeRawSentence('$', talkerOrDefault(p.TalkerID), "ABK",
+		destination,
+		channel,
+		strconv.Itoa(int(p.MessageID)),
+		strconv.Itoa(p.SequenceID),
+		strconv.Itoa(int(p.Type)))
+}
+
+// ParseABK decodes an ABK sentence
+func (nc *NMEACodec) ParseABK(sentence string) (*AbkPacket, error) {
+	r, err := parseStatusSentence(sentence, "ABK", 5)
+	if err != nil {
+		return nil, err
+	}
+
+	destinationID, err1 := r.intField(0, 0)
+	messageID, err2 := r.intField(2, 0)
+	seqID, err3 := r.intField(3, 0)
+	ackType, err4 := r.intField(4, -1)
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil || ackType < 0 || AckType(ackType) > AckLate {
+		return nil, fmt.Errorf("aisnmea: ABK sentence has invalid fields")
+	}
+
+	p := &AbkPacket{
+		TalkerID:      r.Talker,
+		DestinationID: uint32(destinationID),
+		MessageID:     uint8(messageID),
+		SequenceID:    int(seqID),
+		Type:          AckType(ackType),
+	}
+
+	switch r.field(1) {
+	case "A":
+		p.Channel = 1
+	case "B":
+		p.Channel = 2
+	}
+
+	return p, nil
+}
+
+// SsdPacketFromShipStaticData creates an SSD sentence containing the static data of a message 5
+func SsdPacketFromShipStaticData(s *ais.ShipStaticData) SsdPacket {
+	return SsdPacket{
+		CallSign:  s.CallSign,
+		Name:      s.Name,
+		Dimension: s.Dimension,
+		Dte:       s.Dte,
+		SourceID:  "AI",
+	}
+}
+
+// ApplyTo copies the fields of the sentence into a message 5
+func (p *SsdPacket) ApplyTo(s *ais.ShipStaticData) {
+	s.CallSign = p.CallSign
+	s.Name = p.Name
+	s.Dimension = p.Dimension
+	s.Dte = p.Dte
+}
+
+// EncodeSSD encodes an SSD sentence
+func (nc *NMEACodec) EncodeSSD(p SsdPacket) string {
+	dte := "0"
+	if p.Dte {
+		dte = "1"
+	}
+
+	return encodeRawSentence('$', talkerOrDefault(p.TalkerID), "SSD",
+		escapeText(p.CallSign),
+		escapeText(p.Name),
+		strconv.Itoa(int(p.Dimension.A)),
+		strconv.Itoa(int(p.Dimension.B)),
+		strconv.Itoa(int(p.Dimension.C)),
+		strconv.Itoa(int(p.Dimension.D)),
+		dte,
+		p.SourceID)
+}
+
+// ParseSSD decodes an SSD sentence
+func (nc *NMEACodec) ParseSSD(sentence string) (*SsdPacket, error) {
+	r, err := parseStatusSentence(sentence, "SSD", 8)
+	if err != nil {
+		return nil, err
+	}
+
+	var dim [4]int64
+	for i := range dim {
+		if dim[i], err = r.intField(2+i, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	dte, err := r.intField(6, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	callSign, err1 := r.textField(0)
+	name, err2 := r.textField(1)
+	if err1 != nil || err2 != nil {
+		return nil, fmt.Errorf("aisnmea: SSD sentence has invalid text fields")
+	}
+
+	return &SsdPacket{
+		TalkerID:  r.Talker,
+		CallSign:  callSign,
+		Name:      name,
+		Dimension: ais.FieldDimension{A: uint16(dim[0]), B: uint16(dim[1]), C: uint8(dim[2]), D: uint8(dim[3])},
+		Dte:       dte != 0,
+		SourceID:  r.field(7),
+	}, nil
+}
+
+// VsdPacketFromShipStaticData creates a VSD sentence containing the voyage data of a message 5.
+// The navigational status is set to not defined.
+func VsdPacketFromShipStaticData(s *ais.ShipStaticData) VsdPacket {
+	return VsdPacket{
+		Type:                 s.Type,
+		MaximumStaticDraught: s.MaximumStaticDraught,
+		Destination:          s.Destination,
+		Eta:                  s.Eta,
+		NavigationalStatus:   15,
+	}
+}
+
+// ApplyTo copies the fields of the sentence into a message 5
+func (p *VsdPacket) ApplyTo(s *ais.ShipStaticData) {
+	s.Type = p.Type
+	s.MaximumStaticDraught = p.MaximumStaticDraught
+	s.Destination = p.Destination
+	s.Eta = p.Eta
+}
+
+// EncodeVSD encodes a VSD sentence
+func (nc *NMEACodec) EncodeVSD(p VsdPacket) string {
+	/* Hour 24 and minute 60 mean that the time is not available */
+	eta := ""
+	if p.Eta.Hour < 24 && p.Eta.Minute < 60 {
+		eta = fmt.Sprintf("%02d%02d00", p.Eta.Hour, p.Eta.Minute)
+	}
+
+	return encodeRawSentence('$', talkerOrDefault(p.TalkerID), "VSD",
+		strconv.Itoa(int(p.Type)),
+		strconv.FormatFloat(float64(p.MaximumStaticDraught), 'f', 1, 64),
+		strconv.Itoa(int(p.PersonsOnBoard)),
+		escapeText(p.Destination),
+		eta,
+		strconv.Itoa(int(p.Eta.Day)),
+		strconv.Itoa(int(p.Eta.Month)),
+		strconv.Itoa(int(p.NavigationalStatus)),
+		strconv.Itoa(int(p.RegionalFlags)))
+}
+
+// ParseVSD decodes a VSD sentence
+func (nc *NMEACodec) ParseVSD(sentence string) (*VsdPacket, error) {
+	r, err := parseStatusSentence(sentence, "VSD", 9)
+	if err != nil {
+		return nil, err
+	}
+
+	draught, err := r.floatField(1, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var values [9]int64
+	for _, k := range []int{0, 2, 5, 6, 7, 8} {
+		def := int64(0)
+		if k == 7 {
+			def = 15
+		}
+		if values[k], err = r.intField(k, def); err != nil {
+			return nil, err
+		}
+	}
+
+	destination, err := r.textField(3)
+	if err != nil {
+		return nil, err
+	}
+
+	eta, err := r.timeField(4)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &VsdPacket{
+		TalkerID:             r.Talker,
+		Type:                 uint8(values[0]),
+		MaximumStaticDraught: ais.Field10(draught),
+		PersonsOnBoard:       uint16(values[2]),
+		Destination:          destination,
+		Eta:                  ais.FieldETA{Month: uint8(values[6]), Day: uint8(values[5]), Hour: 24, Minute: 60},
+		NavigationalStatus:   uint8(values[7]),
+		RegionalFlags:        uint8(values[8]),
+	}
+
+	if eta.Valid {
+		p.Eta.Hour = uint8(eta.Hour)
+		p.Eta.Minute = uint8(eta.Minute)
+	}
+
+	return p, nil
+}
+
+// EncodeVSI encodes a VSI sentence
+func (nc *NMEACodec) EncodeVSI(p VsiPacket) string {
+	seqID := ""
+	if p.SequenceID >= 0 {
+		seqID = strconv.Itoa(p.SequenceID)
+	}
+
+	formatLevel := func(v float64) string {
+		if v == 0 {
+			return ""
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	return encodeRawSentence('$', talkerOrDefault(p.TalkerID), "VSI",
+		escapeText(p.Description),
+		seqID,
+		formatTime(p.Time),
+		formatOptional(uint64(p.Slot), p.SlotValid),
+		formatLevel(p.SignalStrength),
+		formatLevel(p.SNR))
+}
+
+// ParseVSI decodes a VSI sentence. LastPacket is set if the sequential message identifier matches
+// the last packet returned by this codec. The packet itself is not modified, use Apply for that.
+func (nc *NMEACodec) ParseVSI(sentence string) (*VsiPacket, error) {
+	r, err := parseStatusSentence(sentence, "VSI", 6)
+	if err != nil {
+		return nil, err
+	}
+
+	description, err := r.textField(0)
+	if err != nil {
+		return nil, err
+	}
+
+	seqID, err1 := r.intField(1, -1)
+	slot, err2 := r.intField(3, -1)
+	signal, err3 := r.floatField(4, 0)
+	snr, err4 := r.floatField(5, 0)
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		return nil, fmt.Errorf("aisnmea: VSI sentence has invalid fields")
+	}
+
+	t, err := r.timeField(2)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &VsiPacket{
+		TalkerID:       r.Talker,
+		Description:    description,
+		SequenceID:     int(seqID),
+		Time:           t,
+		SlotValid:      slot >= 0,
+		SignalStrength: signal,
+		SNR:            snr,
+	}
+	if p.SlotValid {
+		p.Slot = uint16(slot)
+	}
+
+	p.LastPacket = nc.correlateVSI(p)
+
+	return p, nil
+}
+
+// correlateVSI returns true if the sequential message identifier matches the last packet. It is
+// not checked if either of them has no identifier.
+func (nc *NMEACodec) correlateVSI(p *VsiPacket) bool {
+	nc.lastPacketMutex.Lock()
+	defer nc.lastPacketMutex.Unlock()
+
+	return nc.lastPacketSeen && (p.SequenceID < 0 || nc.lastPacketSeqID < 0 || int64(p.SequenceID) == nc.lastPacketSeqID)
+}
+
+// EncodeTXT encodes a TXT sentence. An empty string is returned if encoding failed.
+func (nc *NMEACodec) EncodeTXT(p TxtPacket) string {
+	if p.Total < 1 || p.Total > 99 || p.Number < 1 || p.Number > p.Total || p.ID < 0 || p.ID > 99 {
+		return ""
+	}
+
+	return encodeRawSentence('$', talkerOrDefault(p.TalkerID), "TXT",
+		fmt.Sprintf("%02d", p.Total),
+		fmt.Sprintf("%02d", p.Number),
+		fmt.Sprintf("%02d", p.ID),
+		escapeText(p.Text))
+}
+
+// ParseTXT decodes a TXT sentence
+func (nc *NMEACodec) ParseTXT(sentence string) (*TxtPacket, error) {
+	r, err := parseStatusSentence(sentence, "TXT", 4)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err1 := r.intField(0, 1)
+	number, err2 := r.intField(1, 1)
+	id, err3 := r.intField(2, 0)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return nil, fmt.Errorf("aisnmea: TXT sentence has invalid fields")
+	}
+
+	text, err := r.textField(3)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TxtPacket{
+		TalkerID: r.Talker,
+		Total:    int(total),
+		Number:   int(number),
+		ID:       int(id),
+		Text:     text,
+	}, nil
+}
+
+// EncodeALR encodes an ALR sentence. An empty string is returned if encoding failed.
+func (nc *NMEACodec) EncodeALR(p AlrPacket) string {
+	if p.ID < 0 || p.ID > 999 {
+		return ""
+	}
+
+	return encodeRawSentence('$', talkerOrDefault(p.TalkerID), "ALR",
+		formatTime(p.Time),
+		fmt.Sprintf("%03d", p.ID),
+		formatFlag(p.Active),
+		formatFlag(p.Acknowledged),
+		escapeText(p.Text))
+}
+
+// ParseALR decodes an ALR sentence
+func (nc *NMEACodec) ParseALR(sentence string) (*AlrPacket, error) {
+	r, err := parseStatusSentence(sentence, "ALR", 5)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := r.timeField(0)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := r.intField(1, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := r.textField(4)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AlrPacket{
+		TalkerID:     r.Talker,
+		Time:         t,
+		ID:           int(id),
+		Active:       r.field(2) == "A",
+		Acknowledged: r.field(3) == "A",
+		Text:         text,
+	}, nil
+}
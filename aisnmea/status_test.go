@@ -0,0 +1,187 @@
+package aisnmea
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/BertoldVdb/go-ais"
+	nmea "github.com/adrianmo/go-nmea"
+)
+
+func TestABK(t *testing.T) {
+	nm := NMEACodecNew(ais.CodecNew(false, false))
+
+	p := AbkPacket{TalkerID: "AI", DestinationID: 244123456, Channel: 2, MessageID: 6, SequenceID: 3, Type: AckReceived}
+	encoded := nm.EncodeABK(p)
+
+	decoded, err := nm.ParseABK(encoded)
+	if err != nil || !reflect.DeepEqual(*decoded, p) {
+		t.Errorf("ABK not identical: %s %+v %v", encoded, decoded, err)
+	}
+
+	decoded, err = nm.ParseABK("$AIABK,,,8,1,3*56")
+	if err != nil || decoded.DestinationID != 0 || decoded.Channel != 0 || decoded.MessageID != 8 || decoded.Type != AckBroadcastCompleted {
+		t.Errorf("Broadcast ABK wrongly decoded: %+v %v", decoded, err)
+	}
+
+	if _, err := nm.ParseABK("$AIABK,,,8,1,7*52"); err == nil {
+		t.Error("Invalid acknowledgement type accepted")
+	}
+}
+
+func TestSSDVSD(t *testing.T) {
+	nm := NMEACodecNew(ais.CodecNew(false, false))
+
+	static := ais.ShipStaticData{
+		CallSign:             "PD1234",
+		Name:                 "MV TEST, SHIP",
+		Type:                 70,
+		Dimension:            ais.FieldDimension{A: 100, B: 20, C: 5, D: 10},
+		Eta:                  ais.FieldETA{Month: 6, Day: 11, Hour: 14, Minute: 30},
+		MaximumStaticDraught: 8.5,
+		Destination:          "ROTTERDAM",
+	}
+
+	ssd := SsdPacketFromShipStaticData(&static)
+	encoded := nm.EncodeSSD(ssd)
+	if encoded != "$AISSD,PD1234,MV TEST^2C SHIP,100,20,5,10,0,AI*43" {
+		t.Error("Unexpected SSD encoding", encoded)
+	}
+
+	decodedSSD, err := nm.ParseSSD(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vsd := VsdPacketFromShipStaticData(&static)
+	vsd.PersonsOnBoard = 12
+	decodedVSD, err := nm.ParseVSD(nm.EncodeVSD(vsd))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vsd.TalkerID = "AI"
+	if !reflect.DeepEqual(*decodedVSD, vsd) {
+		t.Errorf("VSD not identical: %+v %+v", *decodedVSD, vsd)
+	}
+
+	var result ais.ShipStaticData
+	decodedSSD.ApplyTo(&result)
+	decodedVSD.ApplyTo(&result)
+	if !reflect.DeepEqual(result, static) {
+		t.Errorf("Static data not identical: %+v %+v", result, static)
+	}
+
+	/* Missing ETA time is decoded as not available */
+	decodedVSD, err = nm.ParseVSD("$AIVSD,70,8.5,,ROTTERDAM,,,,,*03")
+	if err != nil || decodedVSD.Eta != (ais.FieldETA{Hour: 24, Minute: 60}) || decodedVSD.NavigationalStatus != 15 {
+		t.Errorf("VSD without ETA wrongly decoded: %+v %v", decodedVSD, err)
+	}
+}
+
+func TestVSICorrelation(t *testing.T) {
+	nm := NMEACodecNew(ais.CodecNew(false, false))
+
+	if vsi, err := nm.ParseVSI(nm.EncodeVSI(VsiPacket{SequenceID: -1})); err != nil || vsi.LastPacket {
+		t.Error("VSI matched without a packet", vsi, err)
+	}
+
+	p, err := nm.ParseSentence("!AIVDM,1,1,,A,13aEOK?P00PD2wVMdLDRhgvL289?,0*26")
+	if err != nil || p == nil {
+		t.Fatal("Could not decode VDM", err)
+	}
+
+	vsi, err := nm.ParseVSI(nm.EncodeVSI(VsiPacket{
+		Description:    "AI",
+		SequenceID:     -1,
+		Time:           nmea.Time{Valid: true, Hour: 12, Minute: 1, Second: 2, Millisecond: 500},
+		SlotValid:      true,
+		Slot:           1234,
+		SignalStrength: -87.5,
+		SNR:            12,
+	}))
+	if err != nil {
+		t.Fatal("Could not decode VSI", err)
+	}
+
+	if p.SlotValid {
+		t.Error("Packet modified by VSI")
+	}
+	vsi.Apply(p)
+	if !vsi.LastPacket || !p.SlotValid || p.Slot != 1234 || p.SignalStrength != -87.5 || p.SNR != 12 {
+		t.Errorf("VSI not attached to packet: %+v %+v", vsi, p)
+	}
+
+	if vsi.Time != (nmea.Time{Valid: true, Hour: 12, Minute: 1, Second: 2, Millisecond: 500}) {
+		t.Error("Wrong VSI time", vsi.Time)
+	}
+
+	/* A multi sentence packet only matches its own sequential message identifier */
+	for _, l := range []string{
+		"!AIVDM,2,1,7,A,8h3OwjQKP@5UUEPPP121IoCol54cd0Wws7wwjp:@`P1UUFD9e2B94oCPH54M`3kw,0*7A",
+		"!AIVDM,2,2,7,A,sUwwjt;HvP1,2*4F",
+	} {
+		if p, err = nm.ParseSentence(l); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if p == nil {
+		t.Fatal("Multi sentence packet not decoded")
+	}
+
+	if vsi, err = nm.ParseVSI(nm.EncodeVSI(VsiPacket{SequenceID: 4, SlotValid: true, Slot: 10})); err != nil || vsi.LastPacket {
+		t.Error("VSI with wrong identifier attached", vsi, err)
+	}
+
+	if vsi, err = nm.ParseVSI(nm.EncodeVSI(VsiPacket{SequenceID: 7, SlotValid: true, Slot: 10})); err != nil || !vsi.LastPacket {
+		t.Error("VSI with matching identifier not attached", vsi, err)
+	}
+}
+
+func TestVSIApplyKeepsSlot(t *testing.T) {
+	nm := NMEACodecNew(ais.CodecNew(false, false))
+
+	/* The slot is already known, for example from metadata */
+	p := &VdmPacket{SlotValid: true, Slot: 1234}
+
+	vsi, err := nm.ParseVSI(nm.EncodeVSI(VsiPacket{SequenceID: -1, SignalStrength: -90}))
+	if err != nil {
+		t.Fatal("Could not decode VSI", err)
+	}
+	if vsi.SlotValid {
+		t.Fatal("VSI without slot has a slot", vsi)
+	}
+
+	vsi.Apply(p)
+	if !p.SlotValid || p.Slot != 1234 || p.SignalStrength != -90 {
+		t.Errorf("Slot cleared by VSI: %+v", p)
+	}
+}
+
+func TestTXTALR(t *testing.T) {
+	nm := NMEACodecNew(ais.CodecNew(false, false))
+
+	txt := TxtPacket{TalkerID: "AI", Total: 1, Number: 1, ID: 21, Text: "EXTERNAL EPFS LOST"}
+	encoded := nm.EncodeTXT(txt)
+	if encoded != "$AITXT,01,01,21,EXTERNAL EPFS LOST*4A" {
+		t.Error("Unexpected TXT encoding", encoded)
+	}
+	if decoded, err := nm.ParseTXT(encoded); err != nil || !reflect.DeepEqual(*decoded, txt) {
+		t.Errorf("TXT not identical: %+v %v", decoded, err)
+	}
+
+	alr := AlrPacket{
+		TalkerID: "AI",
+		Time:     nmea.Time{Valid: true, Hour: 8, Minute: 15, Second: 30},
+		ID:       26,
+		Active:   true,
+		Text:     "AIS: loss of position sensor*",
+	}
+	encoded = nm.EncodeALR(alr)
+	if decoded, err := nm.ParseALR(encoded); err != nil || !reflect.DeepEqual(*decoded, alr) {
+		t.Errorf("ALR not identical: %s %+v %v", encoded, decoded, err)
+	}
+
+	if nm.EncodeTXT(TxtPacket{Total: 1, Number: 2}) != "" {
+		t.Error("Invalid TXT sentence number accepted")
+	}
+}
@@ -2,7 +2,9 @@ package aisnmea
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	nmea "github.com/adrianmo/go-nmea"
 )
@@ -10,6 +12,185 @@ import (
 // BlankTagBlock is a pseudo-constant for convenient comparisons to a blank value
 var BlankTagBlock nmea.TagBlock
 
+// TagBlockGroup is the content of the g: parameter, which links the sentences of a group together
+type TagBlockGroup struct {
+	Sentence int   // Number of this sentence in the group, starting at 1
+	Total    int   // Total number of sentences in the group
+	ID       int64 // Group identifier
+}
+
+// TagBlock contains the parameters of a NMEA 4.10 TAG Block
+type TagBlock struct {
+	Time             int64         // UNIX time (c:), unit depends on TimeMilliseconds
+	TimeMilliseconds bool          // Time is in milliseconds instead of seconds
+	RelativeTime     int64         // Relative time (r:)
+	Destination      string        // Destination identification (d:)
+	Group            TagBlockGroup // Sentence grouping (g:)
+	LineCount        int64         // Line count (n:)
+	Source           string        // Source identification (s:)
+	Text             string        // Text string (t:)
+
+	// Unknown contains all non-standard parameters in the original order, formatted as they
+	// appear in the TAG Block (key:value pairs separated by commas)
+	Unknown string
+}
+
+// Timestamp returns the time in the c: parameter, or the zero time if it is not set
+func (t *TagBlock) Timestamp() time.Time {
+	if t.Time == 0 {
+		return time.Time{}
+	}
+
+	if t.TimeMilliseconds {
+		return time.Unix(t.Time/1000, (t.Time%1000)*int64(time.Millisecond))
+	}
+
+	return time.Unix(t.Time, 0)
+}
+
+// SetTimestamp sets the c: parameter with a resolution of seconds or milliseconds
+func (t *TagBlock) SetTimestamp(ts time.Time, milliseconds bool) {
+	t.TimeMilliseconds = milliseconds
+	if milliseconds {
+		t.Time = ts.UnixNano() / int64(time.Millisecond)
+	} else {
+		t.Time = ts.Unix()
+	}
+}
+
+// String encodes the TAG Block, including the checksum and delimiters. An empty string is returned
+// for a blank TAG Block.
+func (t TagBlock) String() string {
+	return encodeTagBlock(&t, 1, 1, 0, false)
+}
+
+// tagBlockMillisecondThreshold is used to guess the unit of the c: parameter. Most sources send seconds
+// since the epoch, but some send milliseconds. Values that would be past the year 5000 in seconds are
+// assumed to be in milliseconds.
+const tagBlockMillisecondThreshold = 100000000000
+
+func parseTagBlockGroup(value string) (TagBlockGroup, error) {
+	parts := strings.Split(value, "-")
+	if len(parts) != 3 {
+		return TagBlockGroup{}, fmt.Errorf("aisnmea: invalid TAG Block group [%s]", value)
+	}
+
+	sentence, err1 := strconv.Atoi(parts[0])
+	total, err2 := strconv.Atoi(parts[1])
+	id, err3 := strconv.ParseInt(parts[2], 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return TagBlockGroup{}, fmt.Errorf("aisnmea: invalid TAG Block group [%s]", value)
+	}
+
+	return TagBlockGroup{Sentence: sentence, Total: total, ID: id}, nil
+}
+
+// ParseTagBlock decodes a NMEA 4.10 TAG Block. The input may include the surrounding backslashes. The
+// checksum is required and verified. Unknown parameters are preserved in the Unknown field.
+func ParseTagBlock(s string) (TagBlock, error) {
+	s = strings.TrimSuffix(strings.TrimPrefix(s, `\`), `\`)
+
+	sumSepIndex := strings.LastIndex(s, "*")
+	if sumSepIndex < 0 || len(s)-sumSepIndex != 3 {
+		return TagBlock{}, fmt.Errorf("aisnmea: TAG Block does not contain a checksum [%s]", s)
+	}
+
+	fields := s[:sumSepIndex]
+	if addTagBlockChecksum(fields) != fields+strings.ToUpper(s[sumSepIndex:]) {
+		return TagBlock{}, fmt.Errorf("aisnmea: TAG Block checksum mismatch [%s]", s)
+	}
+
+	var tagBlock TagBlock
+	var unknown []string
+	var err error
+
+	for _, item := range strings.Split(fields, ",") {
+		parts := strings.SplitN(item, ":", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 {
+			return TagBlock{}, fmt.Errorf("aisnmea: TAG Block parameter is malformed [%s]", item)
+		}
+
+		key, value := parts[0], parts[1]
+		switch key {
+		case "c":
+			tagBlock.Time, err = strconv.ParseInt(value, 10, 64)
+			tagBlock.TimeMilliseconds = tagBlock.Time >= tagBlockMillisecondThreshold
+		case "d":
+			tagBlock.Destination = value
+		case "g":
+			tagBlock.Group, err = parseTagBlockGroup(value)
+		case "n":
+			tagBlock.LineCount, err = strconv.ParseInt(value, 10, 64)
+		case "r":
+			tagBlock.RelativeTime, err = strconv.ParseInt(value, 10, 64)
+		case "s":
+			tagBlock.Source = value
+		case "t":
+			tagBlock.Text = value
+		default:
+			unknown = append(unknown, item)
+		}
+
+		if err != nil {
+			return TagBlock{}, fmt.Errorf("aisnmea: TAG Block parameter has invalid value [%s]", item)
+		}
+	}
+
+	tagBlock.Unknown = strings.Join(unknown, ",")
+	return tagBlock, nil
+}
+
+// splitTagBlock separates a leading TAG Block from a sentence. The TAG Block is returned without delimiters.
+func splitTagBlock(sentence string) (string, string) {
+	if len(sentence) == 0 || sentence[0] != '\\' {
+		return "", sentence
+	}
+
+	end := strings.IndexByte(sentence[1:], '\\')
+	if end < 0 {
+		return "", sentence
+	}
+
+	return sentence[1 : end+1], sentence[end+2:]
+}
+
+// tagBlockFromNMEA converts the TAG Block as parsed by go-nmea. This is lossy as go-nmea drops unknown parameters.
+func tagBlockFromNMEA(in nmea.TagBlock) TagBlock {
+	out := TagBlock{
+		Time:             in.Time,
+		TimeMilliseconds: in.Time >= tagBlockMillisecondThreshold,
+		RelativeTime:     in.RelativeTime,
+		Destination:      in.Destination,
+		LineCount:        in.LineCount,
+		Source:           in.Source,
+		Text:             in.Text,
+	}
+
+	if in.Grouping != "" {
+		out.Group, _ = parseTagBlockGroup(in.Grouping)
+	}
+
+	return out
+}
+
+// tagBlockToNMEA converts the TAG Block to the representation of go-nmea, unknown parameters are dropped
+func tagBlockToNMEA(in TagBlock) nmea.TagBlock {
+	out := nmea.TagBlock{
+		Time:         in.Time,
+		RelativeTime: in.RelativeTime,
+		Destination:  in.Destination,
+		LineCount:    in.LineCount,
+		Source:       in.Source,
+		Text:         in.Text,
+	}
+
+	if g := in.Group; g != (TagBlockGroup{}) {
+		out.Grouping = fmt.Sprintf("%d-%d-%d", g.Sentence, g.Total, g.ID)
+	}
+
+	return out
+}
+
 // addTagBlockChecksum performs the NMEA checksum from byte 0 (unlike addChecksum from nmea.go, which starts at 1)
 func addTagBlockChecksum(sentence string) string {
 	checksum := byte(0)
@@ -28,18 +209,20 @@ func addTagBlockChecksum(sentence string) string {
 	- Certain tags should be never have more than one value (time, source, etc), so we pick
       the first value, and ignore subsequent ones. If for some weird reason the sentences are
       timestamped instead of the message, we'd want to use the earliest timestamp.
-	- The grouping tag should be different for every sentence, so there is no sense in including it.
+	- The grouping tag is different for every sentence. The caller merges the sentences in order, so
+	  the group of the first sentence is kept. It still identifies the group and the number of sentences.
 	- Most examples in the wild seem to send the same tag block for every sentence of a message,
 	  in those cases this function does no useful work, but no harm either.
 	- If each sentence contributes a different subset of tags, we'll get a complete set at the end.
 */
-func mergeTagBlocks(dst *nmea.TagBlock, src *nmea.TagBlock) {
+func mergeTagBlocks(dst *TagBlock, src *TagBlock) {
 	if dst == nil || src == nil {
 		return
 	}
 
 	if src.Time != 0 && dst.Time == 0 {
 		dst.Time = src.Time
+		dst.TimeMilliseconds = src.TimeMilliseconds
 	}
 
 	if src.Text != "" && dst.Text == "" {
@@ -61,11 +244,19 @@ func mergeTagBlocks(dst *nmea.TagBlock, src *nmea.TagBlock) {
 	if src.LineCount != 0 && dst.LineCount == 0 {
 		dst.LineCount = src.LineCount
 	}
+
+	if src.Group != (TagBlockGroup{}) && dst.Group == (TagBlockGroup{}) {
+		dst.Group = src.Group
+	}
+
+	if src.Unknown != "" && dst.Unknown == "" {
+		dst.Unknown = src.Unknown
+	}
 }
 
 // encodeTagBlock encodes the fields of tagBlock into a NMEA 4.10 TAG Block string
-func encodeTagBlock(tagBlock *nmea.TagBlock, msgIndex, msgNum, seqNo int, addLineCount bool) string {
-	if tagBlock == nil || *tagBlock == BlankTagBlock {
+func encodeTagBlock(tagBlock *TagBlock, msgIndex, msgNum, seqNo int, addLineCount bool) string {
+	if tagBlock == nil || *tagBlock == (TagBlock{}) {
 		return ""
 	}
 
@@ -84,6 +275,13 @@ func encodeTagBlock(tagBlock *nmea.TagBlock, msgIndex, msgNum, seqNo int, addLin
 		if addLineCount {
 			tags = append(tags, fmt.Sprintf("n:%d", msgNum))
 		}
+	} else if tagBlock.Group != (TagBlockGroup{}) {
+		g := tagBlock.Group
+		tags = append(tags, fmt.Sprintf("g:%d-%d-%d", g.Sentence, g.Total, g.ID))
+	}
+
+	if msgNum <= 1 && tagBlock.LineCount != 0 {
+		tags = append(tags, fmt.Sprintf("n:%d", tagBlock.LineCount))
 	}
 
 	if tagBlock.Source != "" {
@@ -106,5 +304,9 @@ func encodeTagBlock(tagBlock *nmea.TagBlock, msgIndex, msgNum, seqNo int, addLin
 		tags = append(tags, fmt.Sprintf("t:%s", tagBlock.Text))
 	}
 
+	if tagBlock.Unknown != "" {
+		tags = append(tags, tagBlock.Unknown)
+	}
+
 	return fmt.Sprintf("\\%s\\", addTagBlockChecksum(strings.Join(tags, ",")))
 }
@@ -1,11 +1,11 @@
 package aisnmea
 
 import "testing"
-import nmea "github.com/adrianmo/go-nmea"
+import "time"
 
 func Test_encodeTagBlock(t *testing.T) {
 	type args struct {
-		tagBlock     *nmea.TagBlock
+		tagBlock     *TagBlock
 		msgIndex     int
 		msgNum       int
 		seqNo        int
@@ -19,10 +19,10 @@ func Test_encodeTagBlock(t *testing.T) {
 		{name: "nil input tagblock", args: args{
 			tagBlock: nil, msgIndex: 0, msgNum: 0, seqNo: 0, addLineCount: true}, want: ""},
 		{name: "blank input tagblock", args: args{
-			tagBlock: &nmea.TagBlock{}, msgIndex: 0, msgNum: 0, seqNo: 0, addLineCount: true}, want: ""},
+			tagBlock: &TagBlock{}, msgIndex: 0, msgNum: 0, seqNo: 0, addLineCount: true}, want: ""},
 		{name: "single sentence message",
 			args: args{
-				tagBlock: &nmea.TagBlock{
+				tagBlock: &TagBlock{
 					Time: 1,
 				},
 				msgIndex: 1, msgNum: 1, seqNo: 0, addLineCount: true,
@@ -31,7 +31,7 @@ func Test_encodeTagBlock(t *testing.T) {
 		},
 		{name: "multi sentence message, sentence 1",
 			args: args{
-				tagBlock: &nmea.TagBlock{
+				tagBlock: &TagBlock{
 					Time: 1,
 				},
 				msgIndex: 1, msgNum: 2, seqNo: 0, addLineCount: true,
@@ -40,7 +40,7 @@ func Test_encodeTagBlock(t *testing.T) {
 		},
 		{name: "multi sentence message, sentence 2",
 			args: args{
-				tagBlock: &nmea.TagBlock{
+				tagBlock: &TagBlock{
 					Time: 1,
 				},
 				msgIndex: 2, msgNum: 2, seqNo: 0, addLineCount: true,
@@ -49,10 +49,10 @@ func Test_encodeTagBlock(t *testing.T) {
 		},
 		{name: "correct checksum time source and grouping",
 			args: args{
-				tagBlock: &nmea.TagBlock{
-					Time:     1560234814,
-					Source:   "2251",
-					Grouping: "1-2-2449555",
+				tagBlock: &TagBlock{
+					Time:   1560234814,
+					Source: "2251",
+					Group:  TagBlockGroup{Sentence: 1, Total: 2, ID: 2449555},
 				},
 				msgIndex: 1, msgNum: 2, seqNo: 2449555, addLineCount: false,
 			},
@@ -71,38 +71,38 @@ func Test_encodeTagBlock(t *testing.T) {
 
 func Test_mergeTagBlocks(t *testing.T) {
 	type args struct {
-		dst *nmea.TagBlock
-		src *nmea.TagBlock
+		dst *TagBlock
+		src *TagBlock
 	}
 	tests := []struct {
 		name string
 		args args
 		ok   func(t *testing.T, a args)
 	}{
-		{name: "null src", args: args{dst: &nmea.TagBlock{Text: "foo"}, src: nil}, ok: func(t *testing.T, a args) {
-			same := nmea.TagBlock{Text: "foo"}
+		{name: "null src", args: args{dst: &TagBlock{Text: "foo"}, src: nil}, ok: func(t *testing.T, a args) {
+			same := TagBlock{Text: "foo"}
 			if *a.dst != same {
 				t.Error("null src is expected to leave dst unchanged")
 			}
 		}},
-		{name: "null dst", args: args{src: &nmea.TagBlock{Text: "foo"}, dst: nil}, ok: func(t *testing.T, a args) {
+		{name: "null dst", args: args{src: &TagBlock{Text: "foo"}, dst: nil}, ok: func(t *testing.T, a args) {
 			if a.dst != nil {
 				t.Error("null dst is expected to remain unchanged")
 			}
 		}},
-		{name: "zero src time", args: args{src: &nmea.TagBlock{Time: 0}, dst: &nmea.TagBlock{Time: 1}},
+		{name: "zero src time", args: args{src: &TagBlock{Time: 0}, dst: &TagBlock{Time: 1}},
 			ok: func(t *testing.T, a args) {
 				if a.dst.Time != 1 {
 					t.Error("zero src time should not overwrite dst time")
 				}
 			}},
-		{name: "non-zero dst time", args: args{src: &nmea.TagBlock{Time: 2}, dst: &nmea.TagBlock{Time: 1}},
+		{name: "non-zero dst time", args: args{src: &TagBlock{Time: 2}, dst: &TagBlock{Time: 1}},
 			ok: func(t *testing.T, a args) {
 				if a.dst.Time != 1 {
 					t.Error("non-zero dst time should remain unchanged")
 				}
 			}},
-		{name: "orthogonal tags", args: args{src: &nmea.TagBlock{Source: "outer_space"}, dst: &nmea.TagBlock{Time: 1}},
+		{name: "orthogonal tags", args: args{src: &TagBlock{Source: "outer_space"}, dst: &TagBlock{Time: 1}},
 			ok: func(t *testing.T, a args) {
 				if a.dst.Time != 1 {
 					t.Error("time not set")
@@ -111,13 +111,13 @@ func Test_mergeTagBlocks(t *testing.T) {
 					t.Error("source not set")
 				}
 			}},
-		{name: "ignore grouping tag", args: args{src: &nmea.TagBlock{Grouping: "1-2-99"}, dst: &nmea.TagBlock{Time: 1}},
+		{name: "first grouping tag", args: args{src: &TagBlock{Group: TagBlockGroup{2, 2, 99}}, dst: &TagBlock{Time: 1, Group: TagBlockGroup{1, 2, 99}}},
 			ok: func(t *testing.T, a args) {
 				if a.dst.Time != 1 {
 					t.Error("dst time should remain unchanged")
 				}
-				if a.dst.Grouping != "" {
-					t.Error("dst grouping should never be set")
+				if a.dst.Group.Sentence != 1 {
+					t.Error("dst grouping should remain unchanged")
 				}
 			}},
 	}
@@ -128,3 +128,65 @@ func Test_mergeTagBlocks(t *testing.T) {
 		})
 	}
 }
+
+func TestParseTagBlock(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  TagBlock
+	}{
+		{name: "seconds", input: "\\s:2156,c:1560234814*36\\",
+			want: TagBlock{Source: "2156", Time: 1560234814}},
+		{name: "milliseconds", input: "c:1560234814123*63",
+			want: TagBlock{Time: 1560234814123, TimeMilliseconds: true}},
+		{name: "group", input: "g:1-2-2449555,s:2251,c:1560234814*7E",
+			want: TagBlock{Group: TagBlockGroup{1, 2, 2449555}, Source: "2251", Time: 1560234814}},
+		{name: "all parameters", input: "c:1,d:DEST,g:2-3-4,n:5,r:6,s:SRC,t:Text,x:1,y:two*63",
+			want: TagBlock{Time: 1, Destination: "DEST", Group: TagBlockGroup{2, 3, 4}, LineCount: 5,
+				RelativeTime: 6, Source: "SRC", Text: "Text", Unknown: "x:1,y:two"}},
+		{name: "lowercase checksum", input: "s:2156,c:1560234814123,x:42*6e", want: TagBlock{Source: "2156", Time: 1560234814123, TimeMilliseconds: true, Unknown: "x:42"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTagBlock(tt.input)
+			if err != nil {
+				t.Fatal("Parsing failed", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseTagBlock() = %+v, want %+v", got, tt.want)
+			}
+
+			/* Re-encoding must result in the same parameters */
+			again, err := ParseTagBlock(got.String())
+			if err != nil || again != got {
+				t.Errorf("Round trip failed: %s, %+v, %v", got.String(), again, err)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"c:1", "c:1*69", "c:x*1F", "g:1-2*2D", "foo*66", ""} {
+		if _, err := ParseTagBlock(invalid); err == nil {
+			t.Error("Invalid TAG block accepted", invalid)
+		}
+	}
+}
+
+func TestTagBlockTimestamp(t *testing.T) {
+	var tb TagBlock
+	if !tb.Timestamp().IsZero() {
+		t.Error("Blank TAG block has timestamp")
+	}
+
+	ts := time.Unix(1560234814, 123000000)
+
+	tb.SetTimestamp(ts, true)
+	if tb.Time != 1560234814123 || !tb.Timestamp().Equal(ts) {
+		t.Error("Milliseconds not encoded", tb.Time, tb.Timestamp())
+	}
+
+	tb.SetTimestamp(ts, false)
+	if tb.Time != 1560234814 || !tb.Timestamp().Equal(ts.Truncate(time.Second)) {
+		t.Error("Seconds not encoded", tb.Time, tb.Timestamp())
+	}
+}
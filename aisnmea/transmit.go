@@ -0,0 +1,514 @@
+package aisnmea
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+// AbmPacket is an addressed binary and safety related message (ABM) sentence. It instructs a
+// transponder to transmit message 6, 12, 25 or 26 to another station.
+type AbmPacket struct {
+	TalkerID string
+
+	// SequenceID is the sequential message identifier (0-3). For message 6 and 12 it is used as
+	// the sequence number of the transmitted message.
+	SequenceID int
+
+	DestinationID uint32
+	Channel       byte  // 0: no preference, 1: A, 2: B, 3: both
+	MessageID     uint8 // ITU-R M.1371 message ID
+
+	// Payload contains the binary data (one bit per byte) that follows the addressing fields in
+	// the transmitted message
+	Payload []byte
+
+	// Packet is the message the transponder will transmit. The source MMSI and communication state
+	// are added by the transponder and are zero here. For message 25 and 26 the application
+	// identifier cannot be distinguished from the data, so it is always part of Payload on decode.
+	Packet ais.Packet
+}
+
+// BbmPacket is a broadcast binary message (BBM) sentence. It instructs a transponder to broadcast
+// message 8, 14, 25 or 26.
+type BbmPacket struct {
+	TalkerID   string
+	SequenceID int   // Sequential message identifier (0-9)
+	Channel    byte  // 0: no preference, 1: A, 2: B, 3: both
+	MessageID  uint8 // ITU-R M.1371 message ID
+
+	// Payload contains the binary data (one bit per byte) that follows the header in the transmitted message
+	Payload []byte
+
+	// Packet is the message the transponder will transmit, see AbmPacket.Packet
+	Packet ais.Packet
+}
+
+// AirPacket is an AIS interrogation request (AIR) sentence. It instructs a transponder to transmit
+// an interrogation (message 15). The message sub-section fields are not supported.
+type AirPacket struct {
+	TalkerID string
+	Channel  byte // 0: no preference, 1: A, 2: B
+
+	// Interrogation contains the stations and messages that are requested. The source MMSI is
+	// added by the transponder and is zero here.
+	Interrogation ais.Interrogation
+}
+
+type fragmentWork struct {
+	received uint32
+	data     [maxFragments]string
+	fillBits int
+}
+
+// fragmentAssembler combines the data of multi-sentence ABM and BBM sentences
+type fragmentAssembler struct {
+	mutex sync.Mutex
+	work  map[string]*fragmentWork
+}
+
+// process adds a sentence to its message. When the message is complete the combined data is
+// returned with the number of fill bits of the last sentence.
+func (f *fragmentAssembler) process(key string, total int, index int, data string, fillBits int) (string, int, bool) {
+	if total == 1 {
+		return data, fillBits, true
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.work == nil {
+		f.work = make(map[string]*fragmentWork)
+	}
+
+	key = fmt.Sprintf("%s,%d", key, total)
+
+	/* The first sentence always starts a new message */
+	w, ok := f.work[key]
+	if !ok || index == 1 {
+		w = &fragmentWork{}
+		f.work[key] = w
+	}
+
+	w.data[index-1] = data
+	w.received |= 1 << uint(index-1)
+	if index == total {
+		w.fillBits = fillBits
+	}
+
+	if w.received != (1<<uint(total))-1 {
+		return "", 0, false
+	}
+
+	delete(f.work, key)
+
+	result := ""
+	for _, d := range w.data[:total] {
+		result += d
+	}
+
+	return result, w.fillBits, true
+}
+
+func appendBits(dst []byte, value uint64, width int) []byte {
+	for i := width - 1; i >= 0; i-- {
+		dst = append(dst, byte(value>>uint(i))&1)
+	}
+	return dst
+}
+
+func appendApplicationID(dst []byte, a ais.FieldApplicationIdentifier) []byte {
+	dst = appendBits(dst, uint64(a.DesignatedAreaCode), 10)
+	return appendBits(dst, uint64(a.FunctionIdentifier), 6)
+}
+
+// textPayload returns the bits of the text at the end of a message 12 or 14
+func (nc *NMEACodec) textPayload(p ais.Packet, offset int, text string) []byte {
+	bits := nc.codec.EncodePacket(p)
+	if bits == nil || len(bits) < offset+6*len(text) {
+		return nil
+	}
+
+	return bits[offset : offset+6*len(text)]
+}
+
+// transmitPayload extracts the data of a message that is sent to the transponder
+func (nc *NMEACodec) transmitPayload(p ais.Packet, addressed bool) ([]byte, uint32, int, bool) {
+	switch m := p.(type) {
+	case ais.AddressedBinaryMessage:
+		if !addressed {
+			return nil, 0, 0, false
+		}
+		return append(appendApplicationID(nil, m.ApplicationID), m.BinaryData...), m.DestinationID, int(m.SequenceNumber), true
+	case ais.AddessedSafetyMessage:
+		if !addressed {
+			return nil, 0, 0, false
+		}
+		return nc.textPayload(m, 72, m.Text), m.DestinationID, int(m.SequenceNumber), true
+	case ais.BinaryBroadcastMessage:
+		if addressed {
+			return nil, 0, 0, false
+		}
+		return append(appendApplicationID(nil, m.ApplicationID), m.BinaryData...), 0, -1, true
+	case ais.SafetyBroadcastMessage:
+		if addressed {
+			return nil, 0, 0, false
+		}
+		return nc.textPayload(m, 40, m.Text), 0, -1, true
+	case ais.SingleSlotBinaryMessage:
+		if addressed != m.DestinationIDValid {
+			return nil, 0, 0, false
+		}
+		var payload []byte
+		if m.ApplicationIDValid {
+			payload = appendApplicationID(payload, m.ApplicationID)
+		}
+		return append(payload, m.Payload...), m.DestinationID, -1, true
+	case ais.MultiSlotBinaryMessage:
+		if addressed != m.DestinationIDValid {
+			return nil, 0, 0, false
+		}
+		var payload []byte
+		if m.ApplicationIDValid {
+			payload = appendApplicationID(payload, m.ApplicationID)
+		}
+		return append(payload, m.Payload...), m.DestinationID, -1, true
+	}
+
+	return nil, 0, 0, false
+}
+
+// transmitPacket reconstructs the message the transponder will send
+func (nc *NMEACodec) transmitPacket(messageID uint8, addressed bool, destinationID uint32, sequenceID int, payload []byte) ais.Packet {
+	bits := appendBits(nil, uint64(messageID), 6)
+	bits = appendBits(bits, 0, 32)
+
+	switch messageID {
+	case 6, 12:
+		bits = appendBits(bits, uint64(sequenceID), 2)
+		bits = appendBits(bits, uint64(destinationID), 30)
+		bits = appendBits(bits, 0, 2)
+	case 8, 14:
+		bits = appendBits(bits, 0, 2)
+	case 25, 26:
+		if addressed {
+			bits = appendBits(bits, 0x2, 2)
+			bits = appendBits(bits, uint64(destinationID), 30)
+			bits = appendBits(bits, 0, 2)
+		} else {
+			bits = appendBits(bits, 0, 2)
+		}
+	}
+
+	bits = append(bits, payload...)
+
+	/* The spare bits and communication state are added by the transponder */
+	if messageID == 26 {
+		bits = appendBits(bits, 0, 24)
+	}
+
+	return nc.codec.DecodePacket(bits)
+}
+
+func validTransmitMessage(messageID uint8, addressed bool) bool {
+	switch messageID {
+	case 6, 12:
+		return addressed
+	case 8, 14:
+		return !addressed
+	case 25, 26:
+		return true
+	}
+	return false
+}
+
+func talkerOrDefault(talker string) string {
+	if talker == "" {
+		return "AI"
+	}
+	return talker
+}
+
+// encodeTransmitSentences encodes the data of an ABM or BBM sentence. The fields before the data are
+// provided by the caller, the sentence counters are added.
+func (nc *NMEACodec) encodeTransmitSentences(talker string, typ string, seqID int, fields []string, payload []byte) []string {
+	asciiPayload, fillBits := armorPayload(payload)
+	if asciiPayload == nil {
+		return nil
+	}
+
+	prefix := ""
+	for _, f := range fields {
+		prefix += "," + f
+	}
+
+	layout, ok := layoutFragments(nc.MaxLineLength, len(asciiPayload), func(msgNum, msgIndex int) int {
+		return len(fmt.Sprintf("!%s%s,%d,%d,%d%s,,0*00\r\n", talker, typ, msgNum, msgIndex, seqID, prefix))
+	})
+	if !ok {
+		return nil
+	}
+
+	output := make([]string, 0, layout.msgNum)
+	dataIndex := 0
+
+	for msgIndex := 1; msgIndex <= layout.msgNum; msgIndex++ {
+		sub := asciiPayload[dataIndex : dataIndex+layout.dataSizes[msgIndex-1]]
+		dataIndex += len(sub)
+
+		suffix := 0
+		if msgIndex == layout.msgNum {
+			suffix = fillBits
+		}
+
+		output = append(output, addChecksum(fmt.Sprintf("!%s%s,%d,%d,%d%s,%s,%d", talker, typ, layout.msgNum, msgIndex, seqID, prefix, sub, suffix)))
+	}
+
+	return output
+}
+
+// EncodeABM encodes an ABM sentence, split over multiple sentences if needed. If Payload is nil,
+// the message ID, destination, payload and (for message 6 and 12) sequence ID are taken from Packet.
+// nil is returned if encoding failed.
+func (nc *NMEACodec) EncodeABM(p AbmPacket) []string {
+	if p.Payload == nil && p.Packet != nil {
+		payload, destinationID, seqID, ok := nc.transmitPayload(p.Packet, true)
+		if !ok || payload == nil {
+			return nil
+		}
+
+		p.Payload = payload
+		p.DestinationID = destinationID
+		p.MessageID = p.Packet.GetHeader().MessageID
+		if seqID >= 0 {
+			p.SequenceID = seqID
+		}
+	}
+
+	if p.Payload == nil || !validTransmitMessage(p.MessageID, true) || p.SequenceID < 0 || p.SequenceID > 3 || p.Channel > 3 {
+		return nil
+	}
+
+	fields := []string{
+		strconv.FormatUint(uint64(p.DestinationID), 10),
+		strconv.Itoa(int(p.Channel)),
+		strconv.Itoa(int(p.MessageID)),
+	}
+
+	return nc.encodeTransmitSentences(talkerOrDefault(p.TalkerID), "ABM", p.SequenceID, fields, p.Payload)
+}
+
+// EncodeBBM encodes a BBM sentence, split over multiple sentences if needed. If Payload is nil,
+// the message ID and payload are taken from Packet. nil is returned if encoding failed.
+func (nc *NMEACodec) EncodeBBM(p BbmPacket) []string {
+	if p.Payload == nil && p.Packet != nil {
+		payload, _, _, ok := nc.transmitPayload(p.Packet, false)
+		if !ok || payload == nil {
+			return nil
+		}
+
+		p.Payload = payload
+		p.MessageID = p.Packet.GetHeader().MessageID
+	}
+
+	if p.Payload == nil || !validTransmitMessage(p.MessageID, false) || p.SequenceID < 0 || p.SequenceID > 9 || p.Channel > 3 {
+		return nil
+	}
+
+	fields := []string{
+		strconv.Itoa(int(p.Channel)),
+		strconv.Itoa(int(p.MessageID)),
+	}
+
+	return nc.encodeTransmitSentences(talkerOrDefault(p.TalkerID), "BBM", p.SequenceID, fields, p.Payload)
+}
+
+// parseTransmitSentence parses the common part of ABM and BBM sentences. The payload is returned when
+// all sentences have been received.
+func (nc *NMEACodec) parseTransmitSentence(sentence string, typ string, numFields int) (*rawSentence, []byte, error) {
+	r, err := parseRawSentence(sentence)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if r.Type != typ || len(r.Fields) != numFields {
+		return nil, nil, fmt.Errorf("aisnmea: sentence is not a valid %s sentence", typ)
+	}
+
+	total, err1 := r.intField(0, -1)
+	index, err2 := r.intField(1, -1)
+	seqID, err3 := r.intField(2, -1)
+	if err1 != nil || err2 != nil || err3 != nil || total < 1 || total > maxFragments || index < 1 || index > total || seqID < 0 {
+		return nil, nil, fmt.Errorf("aisnmea: %s sentence has invalid sentence numbering", typ)
+	}
+
+	fillBits, err := r.intField(numFields-1, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	/* Only the last sentence of a message determines the number of fill bits */
+	data, fill, ok := nc.transmitAssembler.process(r.Talker+r.Type+r.Fields[2], int(total), int(index), r.Fields[numFields-2], int(fillBits))
+	if !ok {
+		return nil, nil, nil
+	}
+
+	payload, err := dearmorPayload(data, fill)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &r, payload, nil
+}
+
+// ParseABM decodes an ABM sentence. nil is returned without an error if more sentences are needed.
+func (nc *NMEACodec) ParseABM(sentence string) (*AbmPacket, error) {
+	r, payload, err := nc.parseTransmitSentence(sentence, "ABM", 8)
+	if r == nil {
+		return nil, err
+	}
+
+	seqID, _ := r.intField(2, 0)
+	destinationID, err1 := r.intField(3, 0)
+	channel, err2 := r.intField(4, 0)
+	messageID, err3 := r.intField(5, 0)
+	if err1 != nil || err2 != nil || err3 != nil || !validTransmitMessage(uint8(messageID), true) {
+		return nil, fmt.Errorf("aisnmea: ABM sentence has invalid fields")
+	}
+
+	return &AbmPacket{
+		TalkerID:      r.Talker,
+		SequenceID:    int(seqID),
+		DestinationID: uint32(destinationID),
+		Channel:       byte(channel),
+		MessageID:     uint8(messageID),
+		Payload:       payload,
+		Packet:        nc.transmitPacket(uint8(messageID), true, uint32(destinationID), int(seqID), payload),
+	}, nil
+}
+
+// ParseBBM decodes a BBM sentence. nil is returned without an error if more sentences are needed.
+func (nc *NMEACodec) ParseBBM(sentence string) (*BbmPacket, error) {
+	r, payload, err := nc.parseTransmitSentence(sentence, "BBM", 7)
+	if r == nil {
+		return nil, err
+	}
+
+	seqID, _ := r.intField(2, 0)
+	channel, err1 := r.intField(3, 0)
+	messageID, err2 := r.intField(4, 0)
+	if err1 != nil || err2 != nil || !validTransmitMessage(uint8(messageID), false) {
+		return nil, fmt.Errorf("aisnmea: BBM sentence has invalid fields")
+	}
+
+	return &BbmPacket{
+		TalkerID:   r.Talker,
+		SequenceID: int(seqID),
+		Channel:    byte(channel),
+		MessageID:  uint8(messageID),
+		Payload:    payload,
+		Packet:     nc.transmitPacket(uint8(messageID), false, 0, 0, payload),
+	}, nil
+}
+
+func formatOptional(value uint64, valid bool) string {
+	if !valid {
+		return ""
+	}
+	return strconv.FormatUint(value, 10)
+}
+
+// EncodeAIR encodes an AIR sentence
+func (nc *NMEACodec) EncodeAIR(p AirPacket) string {
+	i := p.Interrogation
+	s1m2 := i.Station1Msg2.Valid
+	s2 := i.Station2.Valid
+
+	channel := ""
+	if p.Channel == 1 || p.Channel == 2 {
+		channel = encodeChannel(p.Channel)
+	}
+
+	return encodeRawSentence('$', talkerOrDefault(p.TalkerID), "AIR",
+		strconv.FormatUint(uint64(i.Station1Msg1.StationID), 10),
+		strconv.Itoa(int(i.Station1Msg1.MessageID)),
+		"",
+		formatOptional(uint64(i.Station1Msg2.MessageID), s1m2),
+		"",
+		formatOptional(uint64(i.Station2.StationID), s2),
+		formatOptional(uint64(i.Station2.MessageID), s2),
+		"",
+		channel,
+		formatOptional(uint64(i.Station1Msg1.SlotOffset), i.Station1Msg1.SlotOffset > 0),
+		formatOptional(uint64(i.Station1Msg2.SlotOffset), s1m2 && i.Station1Msg2.SlotOffset > 0),
+		formatOptional(uint64(i.Station2.SlotOffset), s2 && i.Station2.SlotOffset > 0))
+}
+
+// ParseAIR decodes an AIR sentence
+func (nc *NMEACodec) ParseAIR(sentence string) (*AirPacket, error) {
+	r, err := parseRawSentence(sentence)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Type != "AIR" || len(r.Fields) < 8 {
+		return nil, fmt.Errorf("aisnmea: sentence is not a valid AIR sentence")
+	}
+
+	var values [12]int64
+	for _, k := range []int{0, 1, 3, 5, 6, 9, 10, 11} {
+		if values[k], err = r.intField(k, -1); err != nil {
+			return nil, err
+		}
+	}
+
+	if values[0] < 0 || values[1] < 0 {
+		return nil, fmt.Errorf("aisnmea: AIR sentence does not contain a station")
+	}
+
+	p := &AirPacket{TalkerID: r.Talker}
+	switch r.field(8) {
+	case "A":
+		p.Channel = 1
+	case "B":
+		p.Channel = 2
+	}
+
+	slot := func(v int64) uint16 {
+		if v < 0 {
+			return 0
+		}
+		return uint16(v)
+	}
+
+	i := &p.Interrogation
+	i.Header.MessageID = 15
+	i.Valid = true
+	i.Station1Msg1 = ais.InterrogationStation1Message1{
+		Valid:      true,
+		StationID:  uint32(values[0]),
+		MessageID:  uint8(values[1]),
+		SlotOffset: slot(values[9]),
+	}
+
+	if values[3] >= 0 {
+		i.Station1Msg2 = ais.InterrogationStation1Message2{
+			Valid:      true,
+			MessageID:  uint8(values[3]),
+			SlotOffset: slot(values[10]),
+		}
+	}
+
+	if values[5] >= 0 && values[6] >= 0 {
+		i.Station2 = ais.InterrogationStation2{
+			Valid:      true,
+			StationID:  uint32(values[5]),
+			MessageID:  uint8(values[6]),
+			SlotOffset: slot(values[11]),
+		}
+	}
+
+	return p, nil
+}
@@ -0,0 +1,215 @@
+package aisnmea
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+func TestABMRoundTrip(t *testing.T) {
+	packets := []ais.Packet{
+		ais.AddressedBinaryMessage{
+			Header:         ais.Header{MessageID: 6},
+			Valid:          true,
+			SequenceNumber: 2,
+			DestinationID:  211378120,
+			ApplicationID:  ais.FieldApplicationIdentifier{Valid: true, DesignatedAreaCode: 235, FunctionIdentifier: 10},
+			BinaryData:     []byte{1, 0, 1, 1, 0, 0, 1, 0, 1, 1, 1, 1, 0, 0, 0, 1},
+		},
+		ais.AddessedSafetyMessage{
+			Header:         ais.Header{MessageID: 12},
+			Valid:          true,
+			SequenceNumber: 1,
+			DestinationID:  244123456,
+			Text:           "PLEASE KEEP CLEAR OF THE FAIRWAY, DREDGING IN PROGRESS UNTIL 1800",
+		},
+		ais.SingleSlotBinaryMessage{
+			Header:             ais.Header{MessageID: 25},
+			Valid:              true,
+			DestinationIDValid: true,
+			DestinationID:      244123456,
+			Payload:            []byte{1, 1, 0, 0, 1, 0, 1, 0},
+		},
+		ais.MultiSlotBinaryMessage{
+			Header:             ais.Header{MessageID: 26},
+			Valid:              true,
+			DestinationIDValid: true,
+			DestinationID:      244123456,
+			Payload:            []byte{0, 1, 1, 1, 0, 0, 1, 0, 1, 1, 1, 1},
+		},
+	}
+
+	for _, lineLength := range []int{82, 50} {
+		nm := NMEACodecNew(ais.CodecNew(false, false))
+		nm.MaxLineLength = lineLength
+
+		for _, p := range packets {
+			encoded := nm.EncodeABM(AbmPacket{Channel: 3, Packet: p})
+			if encoded == nil {
+				t.Fatal("Could not encode", p)
+			}
+
+			var decoded *AbmPacket
+			for i, l := range encoded {
+				if len(l)+2 > lineLength {
+					t.Error("Line too long", l)
+				}
+
+				var err error
+				decoded, err = nm.ParseABM(l)
+				if err != nil {
+					t.Fatal("Could not decode sentence we just encoded", l, err)
+				}
+				if (decoded != nil) != (i == len(encoded)-1) {
+					t.Fatal("Message not completed with the last sentence", encoded)
+				}
+			}
+
+			if decoded.Channel != 3 || decoded.MessageID != p.GetHeader().MessageID || decoded.TalkerID != "AI" {
+				t.Error("Wrong ABM fields", decoded)
+			}
+
+			if !reflect.DeepEqual(decoded.Packet, p) {
+				t.Errorf("Packet not identical: %+v %+v", decoded.Packet, p)
+			}
+		}
+	}
+}
+
+func TestBBMRoundTrip(t *testing.T) {
+	packets := []ais.Packet{
+		ais.BinaryBroadcastMessage{
+			Header:        ais.Header{MessageID: 8},
+			Valid:         true,
+			ApplicationID: ais.FieldApplicationIdentifier{Valid: true, DesignatedAreaCode: 1, FunctionIdentifier: 31},
+			BinaryData:    make([]byte, 256),
+		},
+		ais.SafetyBroadcastMessage{
+			Header: ais.Header{MessageID: 14},
+			Valid:  true,
+			Text:   "SECURITE",
+		},
+		ais.SingleSlotBinaryMessage{
+			Header:  ais.Header{MessageID: 25},
+			Valid:   true,
+			Payload: []byte{1, 1, 0, 0, 1, 0, 1, 0},
+		},
+	}
+
+	nm := NMEACodecNew(ais.CodecNew(false, false))
+
+	for _, p := range packets {
+		encoded := nm.EncodeBBM(BbmPacket{SequenceID: 7, Packet: p})
+		if encoded == nil {
+			t.Fatal("Could not encode", p)
+		}
+
+		var decoded *BbmPacket
+		for _, l := range encoded {
+			var err error
+			if decoded, err = nm.ParseBBM(l); err != nil {
+				t.Fatal("Could not decode sentence we just encoded", l, err)
+			}
+		}
+
+		if decoded == nil || decoded.SequenceID != 7 || !reflect.DeepEqual(decoded.Packet, p) {
+			t.Errorf("Packet not identical: %+v %+v", decoded, p)
+		}
+	}
+}
+
+func TestBBMReordered(t *testing.T) {
+	nm := NMEACodecNew(ais.CodecNew(false, false))
+	nm.MaxLineLength = 40
+
+	/* The last sentence has fill bits, the others do not */
+	payload := make([]byte, 301)
+	for i := range payload {
+		payload[i] = byte(i % 3 % 2)
+	}
+
+	encoded := nm.EncodeBBM(BbmPacket{MessageID: 8, SequenceID: 3, Payload: payload})
+	if len(encoded) < 3 {
+		t.Fatal("Expected at least three sentences", encoded)
+	}
+
+	/* The last sentence arrives before the others, except the first that starts the message */
+	reordered := append([]string{encoded[0], encoded[len(encoded)-1]}, encoded[1:len(encoded)-1]...)
+
+	var decoded *BbmPacket
+	for _, l := range reordered {
+		var err error
+		if decoded, err = nm.ParseBBM(l); err != nil {
+			t.Fatal("Could not decode sentence we just encoded", l, err)
+		}
+	}
+
+	if decoded == nil || !reflect.DeepEqual(decoded.Payload, payload) {
+		t.Error("Wrong payload after reordering", decoded)
+	}
+}
+
+func TestTransmitInvalid(t *testing.T) {
+	nm := NMEACodecNew(ais.CodecNew(false, false))
+
+	if nm.EncodeABM(AbmPacket{Packet: ais.SafetyBroadcastMessage{Header: ais.Header{MessageID: 14}, Valid: true}}) != nil {
+		t.Error("Broadcast message encoded as ABM")
+	}
+
+	if nm.EncodeBBM(BbmPacket{Packet: ais.PositionReport{Header: ais.Header{MessageID: 1}, Valid: true}}) != nil {
+		t.Error("Position report encoded as BBM")
+	}
+
+	if nm.EncodeABM(AbmPacket{MessageID: 6, SequenceID: 4, Payload: []byte{0}}) != nil {
+		t.Error("Invalid sequence ID accepted")
+	}
+
+	if _, err := nm.ParseABM("!AIBBM,1,1,0,0,8,0,0*51"); err == nil {
+		t.Error("BBM accepted as ABM")
+	}
+
+	encoded := nm.EncodeABM(AbmPacket{MessageID: 6, DestinationID: 1, Payload: []byte{0, 1}})
+	if len(encoded) != 1 || encoded[0] != "!AIABM,1,1,0,1,0,6,@,4*35" {
+		t.Error("Unexpected encoding", encoded)
+	}
+}
+
+func TestAIRRoundTrip(t *testing.T) {
+	nm := NMEACodecNew(ais.CodecNew(false, false))
+
+	packets := []AirPacket{
+		{Channel: 1, Interrogation: ais.Interrogation{
+			Header:       ais.Header{MessageID: 15},
+			Valid:        true,
+			Station1Msg1: ais.InterrogationStation1Message1{Valid: true, StationID: 244123456, MessageID: 5},
+		}},
+		{Interrogation: ais.Interrogation{
+			Header:       ais.Header{MessageID: 15},
+			Valid:        true,
+			Station1Msg1: ais.InterrogationStation1Message1{Valid: true, StationID: 244123456, MessageID: 3, SlotOffset: 100},
+			Station1Msg2: ais.InterrogationStation1Message2{Valid: true, MessageID: 5, SlotOffset: 200},
+			Station2:     ais.InterrogationStation2{Valid: true, StationID: 211000000, MessageID: 3, SlotOffset: 300},
+		}},
+	}
+
+	for _, p := range packets {
+		encoded := nm.EncodeAIR(p)
+		p.TalkerID = "AI"
+
+		decoded, err := nm.ParseAIR(encoded)
+		if err != nil || !reflect.DeepEqual(*decoded, p) {
+			t.Errorf("AIR not identical: %s %+v %+v %v", encoded, decoded, p, err)
+		}
+
+		/* The transponder would send the interrogation like this */
+		bits := nm.codec.EncodePacket(p.Interrogation)
+		if bits == nil || !reflect.DeepEqual(nm.codec.DecodePacket(bits), p.Interrogation) {
+			t.Error("Interrogation cannot be transmitted", p.Interrogation)
+		}
+	}
+
+	if encoded := nm.EncodeAIR(packets[0]); encoded != "$AIAIR,244123456,5,,,,,,,A,,,*13" {
+		t.Error("Unexpected encoding", encoded)
+	}
+}
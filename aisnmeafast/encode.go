@@ -0,0 +1,242 @@
+package aisnmeafast
+
+import (
+	"github.com/BertoldVdb/go-ais"
+)
+
+// EncoderConfig contains the settings of an Encoder
+type EncoderConfig struct {
+	// AIS is used to encode packets in AppendPacket. It is not needed for Append.
+	AIS *ais.Codec
+
+	// MaxLineLength is the maximum length of a sentence, including the TAG block, checksum and line
+	// ending. Messages that do not fit are split over multiple sentences. The default is 82.
+	MaxLineLength int
+}
+
+// SentenceInfo contains the fields of the sentences that are not part of the AIS payload
+type SentenceInfo struct {
+	Talker  string // Defaults to AI
+	Channel byte   // 0 or 1: A, 2: B
+	OwnShip bool   // Produce VDO instead of VDM sentences
+
+	// TagBlock contains the TAG block parameters without delimiters and checksum, for example
+	// s:station,c:1560234814. Multi-sentence messages get a group parameter added. No TAG block is
+	// written if it is empty.
+	TagBlock []byte
+}
+
+// Encoder produces VDM/VDO sentences without allocating memory. Like the Decoder it must not be
+// used from multiple goroutines at the same time.
+type Encoder struct {
+	cfg   EncoderConfig
+	seqNo byte
+}
+
+// NewEncoder creates an Encoder
+func NewEncoder(cfg EncoderConfig) *Encoder {
+	if cfg.MaxLineLength <= 0 {
+		cfg.MaxLineLength = 82
+	}
+
+	return &Encoder{
+		cfg: cfg,
+	}
+}
+
+// sentenceOverhead is the length of !VDM,n,i,,c,,f*hh\r\n, the sentence without talker, sequential
+// message identifier and payload
+const sentenceOverhead = 19
+
+func tagBlockLength(tagBlock []byte, msgIndex int, msgNum int) int {
+	if len(tagBlock) == 0 {
+		return 0
+	}
+
+	/* \...*hh\ */
+	l := 5
+	if msgNum > 1 {
+		/* g:i-n-s */
+		l += 7
+		if msgIndex > 1 {
+			return l
+		}
+		/* ,n:n, */
+		l += 5
+	}
+
+	return l + len(tagBlock)
+}
+
+// layout returns the number of sentences needed for a payload of numChars characters and the
+// number of characters that fit in the first one and in the others
+func (e *Encoder) layout(info *SentenceInfo, numChars int) (int, int, int) {
+	for msgNum := 1; msgNum <= 9; msgNum++ {
+		overhead := sentenceOverhead + len(info.Talker)
+		if msgNum > 1 {
+			overhead++
+		}
+
+		first := e.cfg.MaxLineLength - overhead - tagBlockLength(info.TagBlock, 1, msgNum)
+		other := e.cfg.MaxLineLength - overhead - tagBlockLength(info.TagBlock, 2, msgNum)
+		if first < 1 || (msgNum > 1 && other < 1) {
+			return 0, 0, 0
+		}
+
+		if first+(msgNum-1)*other >= numChars {
+			return msgNum, first, other
+		}
+	}
+
+	return 0, 0, 0
+}
+
+const hexDigits = "0123456789ABCDEF"
+
+// appendChecksum adds the checksum of dst[start:], excluding the first character
+func appendChecksum(dst []byte, start int) []byte {
+	cs := byte(0)
+	for _, c := range dst[start+1:] {
+		cs ^= c
+	}
+	return append(dst, '*', hexDigits[cs>>4], hexDigits[cs&0xf])
+}
+
+// sixBits returns the six bits starting at bit offset k
+func sixBits(payload []uint64, k int) byte {
+	w := k >> 6
+	o := uint(k & 63)
+
+	v := payload[w] << o >> 58
+	if o > 58 && w+1 < len(payload) {
+		v |= payload[w+1] >> (122 - o)
+	}
+
+	return byte(v)
+}
+
+func valueToChar(v byte) byte {
+	v += 48
+	if v >= 88 {
+		v += 8
+	}
+	return v
+}
+
+// Append adds the sentences for a packed payload to dst, splitting it if needed. The payload uses
+// the same layout as Codec.DecodePacket64: the first bit is the most significant bit of payload[0].
+// The second return value is false if the payload could not be encoded, dst is not modified in
+// that case.
+func (e *Encoder) Append(dst []byte, info SentenceInfo, payload []uint64, numBits int) ([]byte, bool) {
+	if numBits <= 0 || numBits > 64*len(payload) {
+		return dst, false
+	}
+
+	if info.Talker == "" {
+		info.Talker = "AI"
+	}
+
+	sentenceType := byte('M')
+	if info.OwnShip {
+		sentenceType = 'O'
+	}
+
+	channel := byte('A')
+	if info.Channel == 2 {
+		channel = 'B'
+	}
+
+	numChars := (numBits + 5) / 6
+	fillBits := numChars*6 - numBits
+
+	msgNum, first, other := e.layout(&info, numChars)
+	if msgNum == 0 {
+		return dst, false
+	}
+
+	seqNo := byte(0)
+	if msgNum > 1 {
+		seqNo = '0' + e.seqNo
+		if e.seqNo++; e.seqNo == 10 {
+			e.seqNo = 0
+		}
+	}
+
+	char := 0
+	for msgIndex := 1; msgIndex <= msgNum; msgIndex++ {
+		if len(info.TagBlock) > 0 {
+			dst = append(dst, '\\')
+			start := len(dst) - 1
+
+			if msgNum > 1 {
+				dst = append(dst, 'g', ':', '0'+byte(msgIndex), '-', '0'+byte(msgNum), '-', seqNo)
+				if msgIndex == 1 {
+					dst = append(dst, ',', 'n', ':', '0'+byte(msgNum), ',')
+				}
+			}
+			if msgIndex == 1 {
+				dst = append(dst, info.TagBlock...)
+			}
+
+			dst = appendChecksum(dst, start)
+			dst = append(dst, '\\')
+		}
+
+		start := len(dst)
+		dst = append(dst, '!')
+		dst = append(dst, info.Talker...)
+		dst = append(dst, 'V', 'D', sentenceType, ',', '0'+byte(msgNum), ',', '0'+byte(msgIndex), ',')
+		if msgNum > 1 {
+			dst = append(dst, seqNo)
+		}
+		dst = append(dst, ',', channel, ',')
+
+		n := other
+		if msgIndex == 1 {
+			n = first
+		}
+		if n > numChars-char {
+			n = numChars - char
+		}
+
+		for ; n > 0; n-- {
+			v := sixBits(payload, char*6)
+			if char == numChars-1 {
+				v &^= 1<<uint(fillBits) - 1
+			}
+			dst = append(dst, valueToChar(v))
+			char++
+		}
+
+		fill := byte('0')
+		if msgIndex == msgNum {
+			fill += byte(fillBits)
+		}
+		dst = append(dst, ',', fill)
+		dst = appendChecksum(dst, start)
+		dst = append(dst, '\r', '\n')
+	}
+
+	return dst, true
+}
+
+// AppendPacket encodes a packet and adds the sentences to dst. Encoding the packet itself is
+// done by the AIS codec and is not free of allocations.
+func (e *Encoder) AppendPacket(dst []byte, info SentenceInfo, p ais.Packet) ([]byte, bool) {
+	if e.cfg.AIS == nil {
+		return dst, false
+	}
+
+	bits := e.cfg.AIS.EncodePacket(p)
+
+	var payload [32]uint64
+	if bits == nil || len(bits) > 64*len(payload) {
+		return dst, false
+	}
+
+	for i, b := range bits {
+		payload[i>>6] |= uint64(b&1) << (63 - uint(i&63))
+	}
+
+	return e.Append(dst, info, payload[:], len(bits))
+}
@@ -0,0 +1,160 @@
+package aisnmeafast
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/BertoldVdb/go-ais"
+	"github.com/BertoldVdb/go-ais/aisnmea"
+)
+
+func packPayload(bits []byte) ([]uint64, int) {
+	payload := make([]uint64, (len(bits)+63)/64)
+	for i, b := range bits {
+		payload[i>>6] |= uint64(b) << (63 - uint(i&63))
+	}
+	return payload, len(bits)
+}
+
+/* The output must be identical to that of the aisnmea encoder */
+func TestEncoderMatchesNMEACodec(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tagBlocks := []aisnmea.TagBlock{{}, {Source: "station123", Time: 1560234814}}
+
+	for i := 0; i < 2000; i++ {
+		lineLength := 50 + r.Intn(100)
+		tagBlock := tagBlocks[r.Intn(len(tagBlocks))]
+
+		nm := aisnmea.NMEACodecNew(ais.CodecNew(false, false))
+		nm.MaxLineLength = lineLength
+		e := NewEncoder(EncoderConfig{MaxLineLength: lineLength})
+
+		bits := make([]byte, 1+r.Intn(1100))
+		for j := range bits {
+			bits[j] = byte(r.Intn(2))
+		}
+
+		p := aisnmea.VdmPacket{
+			Channel: byte(1 + r.Intn(2)),
+			OwnShip: r.Intn(2) == 0,
+			Payload: bits,
+			Tags:    tagBlock,
+		}
+
+		info := SentenceInfo{Channel: p.Channel, OwnShip: p.OwnShip}
+		if tagBlock.Source != "" {
+			info.TagBlock = []byte("s:station123,c:1560234814")
+		}
+
+		/* Advance both sequence counters equally */
+		for j := r.Intn(3); j > 0; j-- {
+			nm.EncodeSentence(aisnmea.VdmPacket{Payload: make([]byte, 1000)})
+			e.Append(nil, SentenceInfo{}, make([]uint64, 16), 1000)
+		}
+
+		expected := nm.EncodeSentence(p)
+
+		payload, numBits := packPayload(bits)
+		out, ok := e.Append([]byte("prefix"), info, payload, numBits)
+
+		if expected == nil {
+			if ok || string(out) != "prefix" {
+				t.Fatal("Encoded message that does not fit", lineLength, len(bits))
+			}
+			continue
+		}
+
+		if !ok || string(out) != "prefix"+strings.Join(expected, "\r\n")+"\r\n" {
+			t.Fatalf("Output differs:\n%s\n%s", out, strings.Join(expected, "\r\n"))
+		}
+	}
+}
+
+func TestEncoderRoundTrip(t *testing.T) {
+	var decoded []AISParsed
+	d := New(DecoderConfig{
+		AIS: ais.CodecNew(false, false),
+		AISDecodedFunc: func(nmea NMEAParsed, ais AISParsed) error {
+			decoded = append(decoded, ais)
+			return nil
+		},
+	})
+
+	e := NewEncoder(EncoderConfig{AIS: ais.CodecNew(false, false), MaxLineLength: 60})
+
+	p := ais.ShipStaticData{
+		Header:      ais.Header{MessageID: 5, UserID: 244123456},
+		Valid:       true,
+		CallSign:    "PD1234",
+		Name:        "TEST",
+		Destination: "ROTTERDAM",
+	}
+
+	out, ok := e.AppendPacket(nil, SentenceInfo{Channel: 2, OwnShip: true, TagBlock: []byte("s:test")}, p)
+	if !ok || bytes.Count(out, []byte("\r\n")) < 2 {
+		t.Fatal("Expected multiple sentences", string(out))
+	}
+
+	d.Write(out)
+	if len(decoded) != 1 || !decoded[0].OwnShip || decoded[0].Channel != 2 {
+		t.Fatal("Could not decode encoded packet", string(out), decoded)
+	}
+
+	if got := decoded[0].Packet.(ais.ShipStaticData); got.UserID != p.UserID || got.Destination != p.Destination {
+		t.Error("Packet not identical", got)
+	}
+}
+
+func TestEncoderAllocations(t *testing.T) {
+	e := NewEncoder(EncoderConfig{})
+	payload, numBits := packPayload(make([]byte, 424))
+	info := SentenceInfo{TagBlock: []byte("s:test,c:1560234814")}
+	buf := make([]byte, 0, 1024)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		buf, _ = e.Append(buf[:0], info, payload, numBits)
+	})
+	if allocs != 0 {
+		t.Error("Encoder allocates", allocs)
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	p := ais.ShipStaticData{
+		Header:      ais.Header{MessageID: 5, UserID: 244123456},
+		Valid:       true,
+		CallSign:    "PD1234",
+		Name:        "TEST",
+		Destination: "ROTTERDAM",
+	}
+	codec := ais.CodecNew(false, false)
+	payload, numBits := packPayload(codec.EncodePacket(p))
+
+	b.Run("aisnmea EncodeSentence", func(b *testing.B) {
+		nm := aisnmea.NMEACodecNew(codec)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			nm.EncodeSentence(aisnmea.VdmPacket{Packet: p})
+		}
+	})
+
+	b.Run("aisnmeafast AppendPacket", func(b *testing.B) {
+		e := NewEncoder(EncoderConfig{AIS: codec})
+		var buf []byte
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf, _ = e.AppendPacket(buf[:0], SentenceInfo{}, p)
+		}
+	})
+
+	b.Run("aisnmeafast Append", func(b *testing.B) {
+		e := NewEncoder(EncoderConfig{})
+		var buf []byte
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf, _ = e.Append(buf[:0], SentenceInfo{}, payload, numBits)
+		}
+	})
+}
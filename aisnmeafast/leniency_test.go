@@ -0,0 +1,65 @@
+package aisnmeafast
+
+import (
+	"testing"
+
+	"github.com/BertoldVdb/go-ais"
+	"github.com/BertoldVdb/go-ais/aisnmea"
+)
+
+func TestLeniency(t *testing.T) {
+	tests := []struct {
+		input    string
+		leniency aisnmea.Leniency
+		ok       bool
+		relax    aisnmea.Relaxation
+	}{
+		{"!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3D\r\n", aisnmea.LeniencyStrict, true, 0},
+		{"!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3d\r\n", aisnmea.LeniencyStrict, false, 0},
+		{"!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3d\r\n", aisnmea.LeniencyAcceptLowercase, true, aisnmea.RelaxedLowercaseChecksum},
+		{"!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0\r\n", aisnmea.LeniencyAcceptLowercase, false, 0},
+		{"!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0\r\n", aisnmea.LeniencyAcceptMissingChecksum, true, aisnmea.RelaxedMissingChecksum},
+		{"!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0\n", aisnmea.LeniencyAcceptMissingChecksum, true, aisnmea.RelaxedMissingChecksum},
+		{"!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3C\r\n", aisnmea.LeniencyAcceptMissingChecksum, false, 0},
+		{"!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,2*3F\r\n", aisnmea.LeniencyAcceptMissingChecksum, false, 0},
+		{"!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,2*3F\r\n", aisnmea.LeniencyTryAlternateFillBits, true, aisnmea.RelaxedFillBits},
+		{"\\s:2156*49\\!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,2\r\n", aisnmea.LeniencyTryAlternateFillBits, true, aisnmea.RelaxedMissingChecksum | aisnmea.RelaxedFillBits},
+		{"!AIVDM,2,1,7,A,8h3OwjQKP@5UUEPPP121IoCol54cd0Wws7wwjp:@`P1UUFD9e2B94oCPH54M`3kw,0*7A\r\n" +
+			"!AIVDM,2,2,7,A,sUwwjt;HvP1,2\r\n", aisnmea.LeniencyAcceptMissingChecksum, true, aisnmea.RelaxedMissingChecksum},
+	}
+
+	for i, test := range tests {
+		var packets []AISParsed
+		var dropped []DropReason
+
+		d := New(DecoderConfig{
+			AIS:      ais.CodecNew(false, false),
+			Leniency: test.leniency,
+			AISDecodedFunc: func(nmea NMEAParsed, ais AISParsed) error {
+				packets = append(packets, ais)
+				return nil
+			},
+			ErrorFunc: func(reason DropReason, raw []byte) {
+				dropped = append(dropped, reason)
+			},
+		})
+		d.Write([]byte(test.input))
+
+		/* Without leniency, a sentence without checksum is not complete and is not reported */
+		if !test.ok {
+			if len(packets) > 0 {
+				t.Error("Test", i, "accepted invalid input", packets)
+			}
+			continue
+		}
+
+		if len(packets) != 1 || len(dropped) > 0 {
+			t.Error("Test", i, "did not decode input", dropped)
+			continue
+		}
+
+		if packets[0].Relaxations != test.relax {
+			t.Error("Test", i, "wrong relaxations:", packets[0].Relaxations)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package aisnmeafast
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BertoldVdb/go-ais"
+	"github.com/BertoldVdb/go-ais/aisnmea"
+)
+
+func TestMetadata(t *testing.T) {
+	var packets []AISParsed
+	var sentences []string
+
+	d := New(DecoderConfig{
+		AIS:             ais.CodecNew(false, false),
+		MetadataParsers: aisnmea.DefaultMetadataParsers,
+		NMEAFunc: func(nmea NMEAParsed) error {
+			sentences = append(sentences, string(nmea.Sentence))
+			return nil
+		},
+		AISDecodedFunc: func(nmea NMEAParsed, ais AISParsed) error {
+			packets = append(packets, ais)
+			return nil
+		},
+	})
+
+	d.Write([]byte("$PGHP,1,2008,5,9,0,0,0,10,338,2,,1,09*17\r\n" +
+		"$PSTT,10A,123456.50,1234,-78.5*52\r\n" +
+		"!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3D ( MSG: 2, signalpower: -47.1, ppm: 0.2)\r\n" +
+		"!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3D\r\n" +
+		"$GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*6A\r\n"))
+
+	if len(sentences) != 1 {
+		t.Error("Metadata sentences passed to NMEAFunc", sentences)
+	}
+
+	if len(packets) != 2 {
+		t.Fatal("Expected two packets", len(packets))
+	}
+
+	md := packets[0].Metadata
+	if md.TimeOfDay != 12*time.Hour+34*time.Minute+56500*time.Millisecond || !md.SlotValid || md.Slot != 1234 || md.SignalStrength != -47.1 || md.FrequencyOffset != 0.2 {
+		t.Error("Metadata not attached", md)
+	}
+
+	if packets[1].Metadata != (aisnmea.Metadata{}) {
+		t.Error("Metadata attached twice", packets[1].Metadata)
+	}
+
+	/* The sentences in AIS-catcher JSON are found, and the fields following them */
+	packets = nil
+	d.Write([]byte(`{"class":"AIS","device":"AIS-catcher","channel":"B",` +
+		`"nmea":["!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>` + "`" + `<,0*3D"],"signalpower":-40.5,"ppm":-1.2}` + "\n"))
+
+	if len(packets) != 1 || packets[0].Metadata.SignalStrength != -40.5 || packets[0].Metadata.FrequencyOffset != -1.2 {
+		t.Error("AIS-catcher JSON metadata not attached", packets)
+	}
+}
+
+func TestMetadataLineEnd(t *testing.T) {
+	var packets int
+
+	d := New(DecoderConfig{
+		AIS:             ais.CodecNew(false, false),
+		MetadataParsers: aisnmea.DefaultMetadataParsers,
+		AISDecodedFunc: func(nmea NMEAParsed, ais AISParsed) error {
+			packets++
+			return nil
+		},
+	})
+
+	/* The packet is only decoded once the line ends or the next sentence starts */
+	d.Write([]byte("!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3D"))
+	if packets != 0 {
+		t.Error("Packet decoded before end of line")
+	}
+
+	d.Write([]byte("!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3D\r\n"))
+	if packets != 2 {
+		t.Error("Packets not decoded", packets)
+	}
+}
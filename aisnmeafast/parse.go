@@ -1,9 +1,12 @@
 package aisnmeafast
 
 import (
+	"bytes"
 	"sync"
+	"time"
 
 	"github.com/BertoldVdb/go-ais"
+	"github.com/BertoldVdb/go-ais/aisnmea"
 )
 
 type stateType int
@@ -15,13 +18,13 @@ const (
 
 	stateChecksum0
 	stateChecksum1
-)
 
-type recombState struct {
-	MsgTotal int
-	TagBlock []byte
-	Data     [10][]byte
-}
+	/* The line ended without a checksum */
+	stateLineEnd
+
+	/* Collecting the text after the checksum for the metadata parsers */
+	stateTrailer
+)
 
 type Decoder struct {
 	cfg DecoderConfig
@@ -33,7 +36,23 @@ type Decoder struct {
 	tagBlock      []byte
 	tagBlockValid []byte
 
-	aisRecombine map[[8]byte]*recombState
+	aisRecombine      map[[8]byte]*recombState
+	aisRecombineQueue []recombQueueEntry
+	aisRecombineFree  []*recombState
+	aisRecombineID    uint64
+	now               func() time.Time
+
+	payload [32]uint64
+	relax   aisnmea.Relaxation
+
+	trailer      []byte
+	lineMetadata aisnmea.Metadata // Metadata following the checksum of the current sentence
+	metadata     aisnmea.Metadata // Metadata for the next packet
+
+	stats decoderStats
+
+	/* Used by the Pipeline to decode packets elsewhere */
+	dispatch func(nmea NMEAParsed, ais AISParsed) error
 }
 
 type NMEAParsed struct {
@@ -41,10 +60,38 @@ type NMEAParsed struct {
 	Sentence []byte
 }
 
+// TagBlock decodes the raw TAG Block. A blank TAG Block is returned if there is none.
+func (n NMEAParsed) TagBlock() (aisnmea.TagBlock, error) {
+	if len(n.Tagblock) == 0 {
+		return aisnmea.TagBlock{}, nil
+	}
+
+	return aisnmea.ParseTagBlock(string(n.Tagblock))
+}
+
 type AISParsed struct {
 	Talker  []byte
 	Channel byte
 	Packet  ais.Packet
+
+	// OwnShip is set if the packet was received in a VDO sentence, meaning it was sent by the
+	// receiving vessel itself
+	OwnShip bool
+
+	// MessageID is the AIS message ID taken from the payload
+	MessageID uint8
+
+	// Payload contains the reassembled payload as passed to Codec.DecodePacket64. It is only valid
+	// during the callback, copy it if it is needed later.
+	Payload []uint64
+	NumBits int
+
+	// Relaxations contains the deviations from the standard that were accepted to decode the
+	// packet, see DecoderConfig.Leniency
+	Relaxations aisnmea.Relaxation
+
+	// Metadata contains the receiver metadata recognized by DecoderConfig.MetadataParsers
+	Metadata aisnmea.Metadata
 }
 
 type DecoderConfig struct {
@@ -54,7 +101,42 @@ type DecoderConfig struct {
 	NMEAEncapsulatedFunc func(nmea NMEAParsed) error
 	AISDecodedFunc       func(nmea NMEAParsed, ais AISParsed) error
 
+	// OwnShipFunc receives the packets from VDO sentences. If it is nil they are passed to
+	// AISDecodedFunc instead.
+	OwnShipFunc func(nmea NMEAParsed, ais AISParsed) error
+
+	// ErrorFunc is called for every sentence that is dropped. The raw sentence is only valid
+	// during the call.
+	ErrorFunc func(reason DropReason, raw []byte)
+
+	// MaxPendingGroups limits the number of incomplete multi-sentence messages. When it is reached
+	// the oldest message is evicted. The default is 1024.
+	MaxPendingGroups int
+
+	// FragmentMaxSentences expires incomplete messages once this many further sentences have been
+	// received. Zero disables this.
+	FragmentMaxSentences uint64
+
+	// FragmentTimeout expires incomplete messages after this duration. Zero disables this.
+	FragmentTimeout time.Duration
+
+	// PassThrough passes payloads that cannot be decoded, for example because the message ID is
+	// unknown, to the callbacks as a RawPacket instead of dropping them
+	PassThrough bool
+
+	// IgnoreChecksum accepts all sentences, even with a wrong checksum. Sentences without a
+	// checksum are only accepted if allowed by Leniency.
 	IgnoreChecksum bool
+
+	// Leniency selects which deviations from the standard are accepted. The zero value is strict,
+	// use aisnmea.LeniencyAcceptLowercase to accept lowercase checksums.
+	Leniency aisnmea.Leniency
+
+	// MetadataParsers recognize receiver metadata in $ sentences and in the text following the
+	// checksum of VDM/VDO sentences, see aisnmea.DefaultMetadataParsers. The metadata is attached
+	// to the next packet and recognized sentences are not passed to NMEAFunc. If it is set, a
+	// packet is only decoded when the end of its line is received. Parsing metadata allocates memory.
+	MetadataParsers []aisnmea.MetadataParser
 }
 
 var fillDecodeTableOnce sync.Once
@@ -66,8 +148,14 @@ func New(cfg DecoderConfig) *Decoder {
 		cfg: cfg,
 
 		aisRecombine: make(map[[8]byte]*recombState),
+		now:          time.Now,
 	}
 
+	if a.cfg.MaxPendingGroups <= 0 {
+		a.cfg.MaxPendingGroups = 1024
+	}
+	a.stats.talkers = make(map[[2]byte]uint64)
+
 	return a
 }
 
@@ -80,6 +168,13 @@ func (c *Decoder) nmeaCopyMany(i int, in []byte) int {
 			c.state = stateChecksum0
 			break
 		}
+
+		if (in[k] == '\r' || in[k] == '\n') && c.cfg.Leniency.AcceptMissingChecksum() {
+			c.dataBlock = append(c.dataBlock, in[i:k]...)
+			i = k
+			c.state = stateLineEnd
+			break
+		}
 	}
 
 	/* Simply add all remaining bytes... */
@@ -94,12 +189,33 @@ func (c *Decoder) nmeaCopyMany(i int, in []byte) int {
 
 func (c *Decoder) Write(in []byte) (int, error) {
 	if len(c.dataBlock) >= 8192 || len(c.tagBlock) >= 8192 {
+		if c.state != stateIdle {
+			c.drop(DropMalformed, c.dataBlock)
+		}
 		c.state = stateIdle
+		c.dataBlock = c.dataBlock[:0]
+		c.tagBlock = c.tagBlock[:0]
 	}
 
 	for i := 0; i < len(in); i++ {
 		m := in[i]
 
+		if c.state == stateTrailer {
+			if m != '\r' && m != '\n' && m != '!' && m != '$' && m != '\\' {
+				if len(c.trailer) < maxTrailerLength {
+					c.trailer = append(c.trailer, m)
+				}
+				continue
+			}
+
+			if err := c.handleTrailer(); err != nil {
+				return i, err
+			}
+			if m == '\r' || m == '\n' {
+				continue
+			}
+		}
+
 		if m == '!' || m == '$' {
 			c.dataBlock = c.dataBlock[:0]
 
@@ -111,6 +227,9 @@ func (c *Decoder) Write(in []byte) (int, error) {
 			c.state = stateData
 
 			i = c.nmeaCopyMany(i, in)
+			if err := c.handleLineEnd(); err != nil {
+				return i, err
+			}
 
 		} else if c.state == stateIdle {
 			if m == '\\' {
@@ -123,6 +242,9 @@ func (c *Decoder) Write(in []byte) (int, error) {
 
 		} else if c.state == stateData {
 			i = c.nmeaCopyMany(i, in)
+			if err := c.handleLineEnd(); err != nil {
+				return i, err
+			}
 
 		} else if c.state == stateChecksum0 {
 			c.dataBlock = append(c.dataBlock, m)
@@ -130,6 +252,14 @@ func (c *Decoder) Write(in []byte) (int, error) {
 
 		} else if c.state == stateChecksum1 {
 			c.dataBlock = append(c.dataBlock, m)
+
+			/* Wait for the end of the line, it may contain metadata */
+			if len(c.cfg.MetadataParsers) > 0 && c.dataBlock[0] == '!' {
+				c.state = stateTrailer
+				c.trailer = c.trailer[:0]
+				continue
+			}
+
 			c.state = stateIdle
 
 			if err := c.handleMessage(); err != nil {
@@ -153,6 +283,10 @@ func nmeaReadNibble(nib byte) (uint8, bool) {
 	return 0, false
 }
 
+func isLowercaseHex(c byte) bool {
+	return c >= 'a' && c <= 'f'
+}
+
 func nmeaChecksumRead(cs []byte) (uint8, bool) {
 	out0, ok := nmeaReadNibble(cs[0])
 	if !ok {
@@ -226,19 +360,82 @@ func encapsulatedToUint64(in []byte, out []uint64) int {
 	return len(in) * 6
 }
 
+// handleLineEnd handles a sentence without checksum
+func (c *Decoder) handleLineEnd() error {
+	if c.state != stateLineEnd {
+		return nil
+	}
+
+	err := c.handleMessage()
+	c.state = stateIdle
+	return err
+}
+
+// maxTrailerLength limits the text after the checksum that is passed to the metadata parsers
+const maxTrailerLength = 1024
+
+// handleTrailer handles a sentence once the text following its checksum is received
+func (c *Decoder) handleTrailer() error {
+	if trailer := bytes.TrimSpace(c.trailer); len(trailer) > 0 {
+		c.lineMetadata, _ = c.parseMetadata(trailer)
+	}
+
+	err := c.handleMessage()
+	c.state = stateIdle
+	c.lineMetadata = aisnmea.Metadata{}
+	return err
+}
+
+// parseMetadata runs the metadata parsers on a line
+func (c *Decoder) parseMetadata(line []byte) (aisnmea.Metadata, bool) {
+	s := string(line)
+	for _, parser := range c.cfg.MetadataParsers {
+		if md, ok := parser(s); ok {
+			return md, true
+		}
+	}
+
+	return aisnmea.Metadata{}, false
+}
+
 func (c *Decoder) handleMessage() error {
+	c.stats.sentences++
+	c.relax = 0
+
+	if c.state == stateLineEnd {
+		/* Only reached if missing checksums are accepted, add it so the rest of the code can
+		   treat it like any other sentence */
+		if len(c.dataBlock) < 2 {
+			c.drop(DropMalformed, c.dataBlock)
+			return nil
+		}
+		c.dataBlock = appendChecksum(c.dataBlock, 0)
+		c.relax |= aisnmea.RelaxedMissingChecksum
+
+	} else if isLowercaseHex(c.dataBlock[len(c.dataBlock)-2]) || isLowercaseHex(c.dataBlock[len(c.dataBlock)-1]) {
+		if !c.cfg.Leniency.AcceptLowercase() && !c.cfg.IgnoreChecksum {
+			c.drop(DropBadChecksum, c.dataBlock)
+			return nil
+		}
+		c.relax |= aisnmea.RelaxedLowercaseChecksum
+	}
+
 	if !c.cfg.IgnoreChecksum {
 		if !nmeaChecksumVerify(c.dataBlock, true) {
+			c.drop(DropBadChecksum, c.dataBlock)
 			return nil
 		}
 
 		if c.tagBlockValid != nil {
 			if !nmeaChecksumVerify(c.tagBlockValid, false) {
+				c.drop(DropBadTagBlockChecksum, c.dataBlock)
 				return nil
 			}
 		}
 	}
 
+	c.countTalker(c.dataBlock)
+
 	nmeaParsed := NMEAParsed{
 		Tagblock: c.tagBlockValid,
 		Sentence: c.dataBlock,
@@ -246,6 +443,13 @@ func (c *Decoder) handleMessage() error {
 
 	/* Let other code handle normal NMEA messages */
 	if c.dataBlock[0] == '$' {
+		if len(c.cfg.MetadataParsers) > 0 {
+			if md, ok := c.parseMetadata(c.dataBlock); ok {
+				c.metadata.Merge(&md)
+				return nil
+			}
+		}
+
 		if c.cfg.NMEAFunc != nil {
 			if err := c.cfg.NMEAFunc(nmeaParsed); err != nil {
 				return err
@@ -264,25 +468,42 @@ func (c *Decoder) handleMessage() error {
 }
 
 func (c *Decoder) decodeAISSentence(nmeaParsed NMEAParsed) error {
-	if c.cfg.AIS == nil || c.cfg.AISDecodedFunc == nil || len(nmeaParsed.Sentence) < 14+1+3 {
+	if c.cfg.AIS == nil || (c.cfg.AISDecodedFunc == nil && c.cfg.OwnShipFunc == nil) {
 		return nil
 	}
 
-	msg := nmeaParsed.Sentence[1 : len(nmeaParsed.Sentence)-3]
+	raw := nmeaParsed.Sentence
+	if len(raw) < 14+1+3 {
+		c.drop(DropMalformed, raw)
+		return nil
+	}
+
+	msg := raw[1 : len(raw)-3]
 
 	//msgType, rest := splitComma(nmeaParsed.Sentence[1:])
-	if msg[5] != ',' || msg[2] != 'V' || msg[3] != 'D' || (msg[4] != 'M' && msg[4] != 'O') {
+	if msg[2] != 'V' || msg[3] != 'D' || (msg[4] != 'M' && msg[4] != 'O') {
+		return nil
+	}
+	if msg[5] != ',' || msg[7] != ',' || msg[9] != ',' {
+		c.drop(DropMalformed, raw)
 		return nil
 	}
 	talker := msg[:2]
+	sentenceType := msg[4]
+
+	if c.cfg.AISDecodedFunc == nil && (sentenceType != 'O' || c.cfg.OwnShipFunc == nil) {
+		return nil
+	}
 
 	msgTotal, ok := nmeaReadNibble(msg[6])
-	if !ok || msgTotal == 0 {
+	if !ok || msgTotal == 0 || msgTotal > 9 {
+		c.drop(DropInvalidFragment, raw)
 		return nil
 	}
 
 	msgIndex, ok := nmeaReadNibble(msg[8])
-	if !ok || msgIndex > msgTotal {
+	if !ok || msgIndex == 0 || msgIndex > msgTotal {
+		c.drop(DropInvalidFragment, raw)
 		return nil
 	}
 
@@ -290,6 +511,7 @@ func (c *Decoder) decodeAISSentence(nmeaParsed NMEAParsed) error {
 	msgIDValid := msg[10] != ','
 	msgID := msg[10]
 	if !msgIDValid && msgTotal != 1 {
+		c.drop(DropInvalidFragment, raw)
 		return nil
 	}
 
@@ -312,52 +534,110 @@ func (c *Decoder) decodeAISSentence(nmeaParsed NMEAParsed) error {
 	}
 
 	if len(msg) < 2 {
+		c.drop(DropMalformed, raw)
 		return nil
 	}
 
 	data := msg[:len(msg)-2]
 
+	padding, ok := nmeaReadNibble(msg[len(msg)-1])
+	if !ok || msg[len(msg)-2] != ',' {
+		c.drop(DropMalformed, raw)
+		return nil
+	}
+
+	c.metadata.Merge(&c.lineMetadata)
+
 	/* Need to combine messages? */
+	relax := c.relax
 	if msgTotal > 1 {
-		data, nmeaParsed.Tagblock = c.recombineMessages(msgID, int(msgTotal), int(msgIndex), nmeaParsed.Tagblock, data)
+		data, nmeaParsed.Tagblock, relax = c.recombineMessages(sentenceType, msgID, int(msgTotal), int(msgIndex), nmeaParsed.Tagblock, data, raw, relax)
 		if data == nil {
 			return nil
 		}
 	}
 
-	padding, ok := nmeaReadNibble(msg[len(msg)-1])
-	if !ok {
+	/* This is enough to fit any valid AIS message with some extra (20 should be enough) */
+	out := c.payload[:]
+	for i := range out {
+		out[i] = 0
+	}
+	numBits := encapsulatedToUint64(data, out)
+	if numBits == 0 && len(data) > 0 {
+		c.drop(DropInvalidArmoring, raw)
 		return nil
 	}
 
-	/* This is enough to fit any valid AIS message with some extra (20 should be enough) */
-	var out [32]uint64
-	numBits := encapsulatedToUint64(data, out[:])
 	numBits -= int(padding)
-
 	if numBits <= 0 {
+		c.drop(DropMalformed, raw)
 		return nil
 	}
 
 	aisParsed := AISParsed{
-		Talker:  talker,
-		Channel: channel,
-		Packet:  c.cfg.AIS.DecodePacket64(out[:], numBits),
+		Talker:    talker,
+		Channel:   channel,
+		OwnShip:   sentenceType == 'O',
+		MessageID: rawHeader(out, numBits).MessageID,
+		Payload:   out[:(numBits+63)/64],
+		NumBits:   numBits,
+
+		Relaxations: relax,
+		Metadata:    c.metadata,
 	}
+	c.metadata = aisnmea.Metadata{}
 
-	if aisParsed.Packet == nil {
-		return nil
+	if c.dispatch != nil {
+		return c.dispatch(nmeaParsed, aisParsed)
 	}
 
-	return c.cfg.AISDecodedFunc(nmeaParsed, aisParsed)
+	ok = decodePayload(&c.cfg, out, &aisParsed)
+	return emitPacket(&c.cfg, &c.stats, nmeaParsed, aisParsed, ok)
 }
 
-func (s *recombState) reset() {
-	s.MsgTotal = 0
-	s.TagBlock = s.TagBlock[:0]
-	for i := range s.Data {
-		s.Data[i] = s.Data[i][:0]
+// decodePayload decodes the payload of a packet. full is the complete payload buffer, of which
+// p.Payload is the used part.
+func decodePayload(cfg *DecoderConfig, full []uint64, p *AISParsed) bool {
+	p.Packet = cfg.AIS.DecodePacket64(full, p.NumBits)
+	if p.Packet == nil {
+		/* The bits after the payload are either fill bits or zero */
+		for _, delta := range cfg.Leniency.AlternateFillBits() {
+			numBits := p.NumBits + delta
+			if numBits <= 0 || numBits > 64*len(full) {
+				continue
+			}
+
+			if p.Packet = cfg.AIS.DecodePacket64(full, numBits); p.Packet != nil {
+				p.NumBits = numBits
+				p.Payload = full[:(numBits+63)/64]
+				p.Relaxations |= aisnmea.RelaxedFillBits
+				return true
+			}
+		}
+
+		if !cfg.PassThrough {
+			return false
+		}
+		p.Packet = newRawPacket(full, p.NumBits)
 	}
+
+	return true
+}
+
+// emitPacket passes a decoded packet to the callback, or reports it as dropped
+func emitPacket(cfg *DecoderConfig, stats *decoderStats, nmeaParsed NMEAParsed, p AISParsed, ok bool) error {
+	if !ok {
+		reportDrop(cfg, stats, DropDecodeFailed, nmeaParsed.Sentence)
+		return nil
+	}
+
+	stats.packets++
+	stats.messageTypes[p.MessageID]++
+
+	if p.OwnShip && cfg.OwnShipFunc != nil {
+		return cfg.OwnShipFunc(nmeaParsed, p)
+	}
+	return cfg.AISDecodedFunc(nmeaParsed, p)
 }
 
 func splitComma(in []byte) ([]byte, []byte) {
@@ -399,58 +679,3 @@ func tagblockGetBlockID(key []byte, tagblock []byte) int {
 
 	return -1
 }
-
-func (c *Decoder) recombineMessages(msgID byte, msgTotal int, msgIndex int, tagBlock []byte, data []byte) ([]byte, []byte) {
-	msgIndex--
-
-	var key [8]byte
-	key[0] = msgID
-	if tagblockGetBlockID(key[1:6], tagBlock) < 0 {
-		key[7] = 1
-	}
-
-	state, ok := c.aisRecombine[key]
-	if !ok {
-		state = &recombState{}
-		c.aisRecombine[key] = state
-	}
-
-	if msgTotal >= len(state.Data) || msgIndex >= len(state.Data) || msgIndex < 0 {
-		return nil, nil
-	}
-
-	if state.MsgTotal != msgTotal {
-		state.reset()
-	}
-
-	state.MsgTotal = msgTotal
-
-	if len(tagBlock) > len(state.TagBlock) {
-		state.TagBlock = append(state.TagBlock[:0], tagBlock...)
-	}
-
-	state.Data[msgIndex] = append(state.Data[msgIndex][:0], data...)
-
-	/* Do we have everything? */
-	totalLen := 0
-	for i := 0; i < msgTotal; i++ {
-		if l := len(state.Data[i]); l == 0 {
-			return nil, nil
-		} else {
-			totalLen += l
-		}
-	}
-
-	out := make([]byte, 0, totalLen)
-	for i := 0; i < msgTotal; i++ {
-		out = append(out, state.Data[i]...)
-	}
-
-	var tb []byte
-	if len(state.TagBlock) > 0 {
-		tb = append([]byte{}, state.TagBlock...)
-	}
-	state.reset()
-
-	return out, tb
-}
@@ -0,0 +1,81 @@
+package aisnmeafast
+
+import (
+	"testing"
+
+	"github.com/BertoldVdb/go-ais"
+	"github.com/BertoldVdb/go-ais/aisnmea"
+)
+
+func TestTagBlock(t *testing.T) {
+	var tagBlocks []aisnmea.TagBlock
+
+	d := New(DecoderConfig{
+		AIS: ais.CodecNew(false, false),
+		AISDecodedFunc: func(nmea NMEAParsed, ais AISParsed) error {
+			tb, err := nmea.TagBlock()
+			if err != nil {
+				t.Error("Failed to parse TAG block", err)
+			}
+			tagBlocks = append(tagBlocks, tb)
+			return nil
+		},
+	})
+
+	d.Write([]byte("\\g:1-2-2449555,s:2251,c:1560234814*7E\\!AIVDM,2,1,7,A," +
+		"8h3OwjQKP@5UUEPPP121IoCol54cd0Wws7wwjp:@`P1UUFD9e2B94oCPH54M`3kw,0*7A\r\n" +
+		"\\g:2-2-2449555*63\\!AIVDM,2,2,7,A,sUwwjt;HvP1,2*4F\r\n" +
+		"!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3D\r\n"))
+
+	if len(tagBlocks) != 2 {
+		t.Fatal("Expected two packets", len(tagBlocks))
+	}
+
+	want := aisnmea.TagBlock{Group: aisnmea.TagBlockGroup{Sentence: 1, Total: 2, ID: 2449555}, Source: "2251", Time: 1560234814}
+	if tagBlocks[0] != want {
+		t.Error("Wrong TAG block", tagBlocks[0])
+	}
+
+	if tagBlocks[1] != (aisnmea.TagBlock{}) {
+		t.Error("TAG block for sentence without one", tagBlocks[1])
+	}
+}
+
+func TestOwnShip(t *testing.T) {
+	var others, own []AISParsed
+
+	input := []byte("!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3D\r\n" +
+		"!AIVDO,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3F\r\n")
+
+	d := New(DecoderConfig{
+		AIS: ais.CodecNew(false, false),
+		AISDecodedFunc: func(nmea NMEAParsed, ais AISParsed) error {
+			others = append(others, ais)
+			return nil
+		},
+		OwnShipFunc: func(nmea NMEAParsed, ais AISParsed) error {
+			own = append(own, ais)
+			return nil
+		},
+	})
+	d.Write(input)
+
+	if len(others) != 1 || len(own) != 1 || others[0].OwnShip || !own[0].OwnShip {
+		t.Fatal("Own ship reports not separated", others, own)
+	}
+
+	/* Without a separate callback everything goes to AISDecodedFunc */
+	others = nil
+	d = New(DecoderConfig{
+		AIS: ais.CodecNew(false, false),
+		AISDecodedFunc: func(nmea NMEAParsed, ais AISParsed) error {
+			others = append(others, ais)
+			return nil
+		},
+	})
+	d.Write(input)
+
+	if len(others) != 2 || others[0].OwnShip || !others[1].OwnShip {
+		t.Error("Own ship flag not set", others)
+	}
+}
@@ -0,0 +1,302 @@
+package aisnmeafast
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+)
+
+// Order selects the ordering guarantee of the callbacks of a Pipeline
+type Order int
+
+const (
+	// OrderNone calls the callbacks from the workers in any order
+	OrderNone Order = iota
+	// OrderPerMMSI keeps the packets of every station in input order. The callbacks are called
+	// from the workers, so packets of different stations can be reordered.
+	OrderPerMMSI
+	// OrderGlobal keeps all packets in input order. The callbacks are called from a single goroutine.
+	OrderGlobal
+)
+
+// PipelineConfig contains the settings of a Pipeline
+type PipelineConfig struct {
+	DecoderConfig
+
+	Workers int // Number of goroutines decoding packets, defaults to the number of CPUs
+	Order   Order
+}
+
+// ErrPipelineClosed is returned when writing to a Pipeline that has been closed
+var ErrPipelineClosed = errors.New("aisnmeafast: pipeline is closed")
+
+const pipelineBatchSize = 64
+
+type pipelineJob struct {
+	tagBlock []byte
+	sentence []byte
+	payload  [32]uint64
+	parsed   AISParsed
+	ok       bool
+}
+
+func (j *pipelineJob) nmea() NMEAParsed {
+	n := NMEAParsed{Sentence: j.sentence}
+	if len(j.tagBlock) > 0 {
+		n.Tagblock = j.tagBlock
+	}
+	return n
+}
+
+type pipelineBatch struct {
+	jobs [pipelineBatchSize]pipelineJob
+	n    int
+}
+
+type pipelineWorker struct {
+	in  chan *pipelineBatch
+	out chan *pipelineBatch // Only used for OrderGlobal
+}
+
+// Pipeline is a Decoder that decodes the AIS payloads and calls the callbacks in multiple
+// goroutines. Sentence framing and reassembly are done in Write, in order. The NMEAFunc and
+// NMEAEncapsulatedFunc callbacks are also called from Write, the others from the workers or, for
+// OrderGlobal, from a single output goroutine. The callbacks, including ErrorFunc, must therefore
+// be safe for concurrent use.
+type Pipeline struct {
+	cfg PipelineConfig
+
+	writeMutex sync.Mutex
+	decoder    *Decoder
+	pending    []*pipelineBatch
+	next       int
+	closed     bool
+
+	workers   []pipelineWorker
+	batchPool sync.Pool
+	wg        sync.WaitGroup
+
+	statsMutex sync.Mutex
+	stats      decoderStats
+
+	errMutex sync.Mutex
+	err      error
+}
+
+// NewPipeline creates a Pipeline and starts its goroutines. Close must be called to stop them.
+func NewPipeline(cfg PipelineConfig) *Pipeline {
+	if cfg.Workers <= 0 {
+		cfg.Workers = runtime.NumCPU()
+	}
+
+	p := &Pipeline{
+		cfg:     cfg,
+		decoder: New(cfg.DecoderConfig),
+		pending: make([]*pipelineBatch, cfg.Workers),
+		workers: make([]pipelineWorker, cfg.Workers),
+	}
+
+	p.decoder.dispatch = p.dispatch
+	p.batchPool.New = func() interface{} {
+		return &pipelineBatch{}
+	}
+
+	for i := range p.workers {
+		w := &p.workers[i]
+		w.in = make(chan *pipelineBatch, 4)
+		if cfg.Order == OrderGlobal {
+			w.out = make(chan *pipelineBatch, 4)
+		}
+
+		p.wg.Add(1)
+		go p.work(w)
+	}
+
+	if cfg.Order == OrderGlobal {
+		p.wg.Add(1)
+		go p.emitOrdered()
+	}
+
+	return p
+}
+
+func (p *Pipeline) setError(err error) {
+	if err == nil {
+		return
+	}
+
+	p.errMutex.Lock()
+	if p.err == nil {
+		p.err = err
+	}
+	p.errMutex.Unlock()
+}
+
+func (p *Pipeline) getError() error {
+	p.errMutex.Lock()
+	defer p.errMutex.Unlock()
+
+	return p.err
+}
+
+func (p *Pipeline) addStats(s *decoderStats) {
+	p.statsMutex.Lock()
+	p.stats.add(s)
+	p.statsMutex.Unlock()
+
+	*s = decoderStats{}
+}
+
+// work decodes the batches of a worker
+func (p *Pipeline) work(w *pipelineWorker) {
+	defer p.wg.Done()
+
+	var stats decoderStats
+	for b := range w.in {
+		for i := 0; i < b.n; i++ {
+			j := &b.jobs[i]
+			j.ok = decodePayload(&p.cfg.DecoderConfig, j.payload[:], &j.parsed)
+
+			if w.out == nil {
+				p.setError(emitPacket(&p.cfg.DecoderConfig, &stats, j.nmea(), j.parsed, j.ok))
+			}
+		}
+
+		if w.out != nil {
+			w.out <- b
+		} else {
+			p.addStats(&stats)
+			p.batchPool.Put(b)
+		}
+	}
+
+	if w.out != nil {
+		close(w.out)
+	}
+}
+
+// emitOrdered calls the callbacks for OrderGlobal. The batches are distributed round-robin, so
+// reading the workers in the same order restores the input order.
+func (p *Pipeline) emitOrdered() {
+	defer p.wg.Done()
+
+	var stats decoderStats
+	for i := 0; ; i = (i + 1) % len(p.workers) {
+		b, ok := <-p.workers[i].out
+		if !ok {
+			return
+		}
+
+		for k := 0; k < b.n; k++ {
+			j := &b.jobs[k]
+			p.setError(emitPacket(&p.cfg.DecoderConfig, &stats, j.nmea(), j.parsed, j.ok))
+		}
+
+		p.addStats(&stats)
+		p.batchPool.Put(b)
+	}
+}
+
+// dispatch is called by the Decoder for every reassembled payload
+func (p *Pipeline) dispatch(nmeaParsed NMEAParsed, a AISParsed) error {
+	w := p.next
+	if p.cfg.Order == OrderPerMMSI {
+		w = int(rawHeader(a.Payload, a.NumBits).UserID % uint32(len(p.workers)))
+	}
+
+	b := p.pending[w]
+	if b == nil {
+		b = p.batchPool.Get().(*pipelineBatch)
+		b.n = 0
+		p.pending[w] = b
+	}
+
+	j := &b.jobs[b.n]
+	b.n++
+
+	j.tagBlock = append(j.tagBlock[:0], nmeaParsed.Tagblock...)
+	j.sentence = append(j.sentence[:0], nmeaParsed.Sentence...)
+	j.payload = p.decoder.payload
+
+	j.parsed = a
+	j.parsed.Talker = j.sentence[1:3]
+	j.parsed.Payload = j.payload[:len(a.Payload)]
+
+	if b.n == len(b.jobs) {
+		p.send(w)
+	}
+
+	return p.getError()
+}
+
+func (p *Pipeline) send(w int) {
+	p.workers[w].in <- p.pending[w]
+	p.pending[w] = nil
+
+	if p.cfg.Order != OrderPerMMSI {
+		p.next = (p.next + 1) % len(p.workers)
+	}
+}
+
+func (p *Pipeline) flush() {
+	for w, b := range p.pending {
+		if b != nil {
+			p.send(w)
+		}
+	}
+}
+
+// Write parses the input and hands the packets to the workers. Packets that are not yet passed to
+// a worker are sent at the end of every call. If a callback returned an error, it is returned by
+// one of the following calls.
+func (p *Pipeline) Write(in []byte) (int, error) {
+	p.writeMutex.Lock()
+	defer p.writeMutex.Unlock()
+
+	if p.closed {
+		return 0, ErrPipelineClosed
+	}
+
+	if err := p.getError(); err != nil {
+		return 0, err
+	}
+
+	n, err := p.decoder.Write(in)
+	p.flush()
+
+	return n, err
+}
+
+// Close waits until all packets are processed and stops the goroutines. It returns the first
+// error returned by a callback.
+func (p *Pipeline) Close() error {
+	p.writeMutex.Lock()
+	defer p.writeMutex.Unlock()
+
+	if !p.closed {
+		p.closed = true
+		p.flush()
+
+		for i := range p.workers {
+			close(p.workers[i].in)
+		}
+		p.wg.Wait()
+	}
+
+	return p.getError()
+}
+
+// Stats returns a copy of the counters. Packets that are still being decoded are not included.
+func (p *Pipeline) Stats() DecoderStats {
+	p.writeMutex.Lock()
+	defer p.writeMutex.Unlock()
+
+	p.statsMutex.Lock()
+	defer p.statsMutex.Unlock()
+
+	var s decoderStats
+	s.add(&p.decoder.stats)
+	s.add(&p.stats)
+
+	return s.export(len(p.decoder.aisRecombine))
+}
@@ -0,0 +1,140 @@
+package aisnmeafast
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+/* pipelineTestInput returns sentences of 50 stations, with every 10th packet split over two sentences */
+func pipelineTestInput(n int) []byte {
+	e := NewEncoder(EncoderConfig{AIS: ais.CodecNew(false, false)})
+
+	var out []byte
+	for i := 0; i < n; i++ {
+		header := ais.Header{MessageID: 1, UserID: uint32(244000000 + i%50)}
+
+		var p ais.Packet = ais.PositionReport{Header: header, Valid: true, TrueHeading: uint16(i % 360), Timestamp: uint8(i % 60)}
+		if i%10 == 0 {
+			header.MessageID = 5
+			p = ais.ShipStaticData{Header: header, Valid: true, ImoNumber: uint32(i)}
+		}
+
+		out, _ = e.AppendPacket(out, SentenceInfo{TagBlock: []byte("s:test")}, p)
+	}
+
+	return out
+}
+
+type pipelineResult struct {
+	mmsi     uint32
+	sentence string
+	tagBlock string
+}
+
+func TestPipeline(t *testing.T) {
+	input := pipelineTestInput(5000)
+
+	collect := func(results *[]pipelineResult, mutex *sync.Mutex) func(nmea NMEAParsed, ais AISParsed) error {
+		return func(nmea NMEAParsed, ais AISParsed) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			*results = append(*results, pipelineResult{ais.Packet.GetHeader().UserID, string(nmea.Sentence), string(nmea.Tagblock)})
+			return nil
+		}
+	}
+
+	var expected []pipelineResult
+	var mutex sync.Mutex
+	d := New(DecoderConfig{AIS: ais.CodecNew(false, false), AISDecodedFunc: collect(&expected, &mutex)})
+	d.Write(input)
+
+	if len(expected) != 5000 {
+		t.Fatal("Wrong number of packets", len(expected))
+	}
+
+	perMMSI := func(r []pipelineResult) map[uint32][]pipelineResult {
+		m := make(map[uint32][]pipelineResult)
+		for _, v := range r {
+			m[v.mmsi] = append(m[v.mmsi], v)
+		}
+		return m
+	}
+
+	for _, order := range []Order{OrderNone, OrderPerMMSI, OrderGlobal} {
+		var results []pipelineResult
+
+		p := NewPipeline(PipelineConfig{
+			DecoderConfig: DecoderConfig{AIS: ais.CodecNew(false, false), AISDecodedFunc: collect(&results, &mutex)},
+			Workers:       4,
+			Order:         order,
+		})
+
+		/* Write in chunks that do not align with the sentences */
+		for i := 0; i < len(input); i += 1000 {
+			end := i + 1000
+			if end > len(input) {
+				end = len(input)
+			}
+			if _, err := p.Write(input[i:end]); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := p.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if s := p.Stats(); s.Packets != 5000 || s.Sentences != 5500 || s.MessageTypes[5] != 500 {
+			t.Errorf("Wrong statistics for order %d: %+v", order, s)
+		}
+
+		switch order {
+		case OrderGlobal:
+			if !reflect.DeepEqual(results, expected) {
+				t.Error("Global order not preserved")
+			}
+		case OrderPerMMSI:
+			if !reflect.DeepEqual(perMMSI(results), perMMSI(expected)) {
+				t.Error("Per MMSI order not preserved")
+			}
+		default:
+			less := func(r []pipelineResult) func(i, j int) bool {
+				return func(i, j int) bool { return r[i].sentence < r[j].sentence }
+			}
+			sorted := append([]pipelineResult(nil), expected...)
+			sort.SliceStable(sorted, less(sorted))
+			sort.SliceStable(results, less(results))
+			if !reflect.DeepEqual(results, sorted) {
+				t.Error("Packets differ")
+			}
+		}
+
+		if _, err := p.Write(input); err != ErrPipelineClosed {
+			t.Error("Write after close accepted", err)
+		}
+	}
+}
+
+func TestPipelineError(t *testing.T) {
+	errStop := errors.New("stop")
+
+	p := NewPipeline(PipelineConfig{
+		DecoderConfig: DecoderConfig{
+			AIS:            ais.CodecNew(false, false),
+			AISDecodedFunc: func(nmea NMEAParsed, ais AISParsed) error { return errStop },
+		},
+		Workers: 2,
+		Order:   OrderGlobal,
+	})
+
+	input := pipelineTestInput(100)
+	p.Write(input)
+	if err := p.Close(); err != errStop {
+		t.Error("Callback error not returned", err)
+	}
+}
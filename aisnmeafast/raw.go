@@ -0,0 +1,40 @@
+package aisnmeafast
+
+import "github.com/BertoldVdb/go-ais"
+
+// RawPacket contains a payload that the AIS codec could not decode. It is passed to the callbacks
+// instead of a decoded packet if DecoderConfig.PassThrough is set.
+type RawPacket struct {
+	// Header is taken from the start of the payload. Fields that are not fully present are zero.
+	ais.Header
+
+	// Payload contains the bits using the same layout as Codec.DecodePacket64. It is a copy that
+	// remains valid after the callback returns.
+	Payload []uint64
+	NumBits int
+}
+
+// rawHeader extracts the header fields from a packed payload
+func rawHeader(payload []uint64, numBits int) ais.Header {
+	var h ais.Header
+
+	if numBits >= 6 {
+		h.MessageID = uint8(payload[0] >> 58)
+	}
+	if numBits >= 8 {
+		h.RepeatIndicator = uint8(payload[0]>>56) & 3
+	}
+	if numBits >= 38 {
+		h.UserID = uint32(payload[0]>>26) & (1<<30 - 1)
+	}
+
+	return h
+}
+
+func newRawPacket(payload []uint64, numBits int) RawPacket {
+	return RawPacket{
+		Header:  rawHeader(payload, numBits),
+		Payload: append([]uint64(nil), payload[:(numBits+63)/64]...),
+		NumBits: numBits,
+	}
+}
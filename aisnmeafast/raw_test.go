@@ -0,0 +1,69 @@
+package aisnmeafast
+
+import (
+	"testing"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+func TestPassThrough(t *testing.T) {
+	/* Message 28 with repeat indicator 1 and user ID 244123456, followed by some data */
+	bits := appendTestBits(nil, 28, 6)
+	bits = appendTestBits(bits, 1, 2)
+	bits = appendTestBits(bits, 244123456, 30)
+	bits = appendTestBits(bits, 0x5a5a, 16)
+	payload, numBits := packPayload(bits)
+
+	input, _ := NewEncoder(EncoderConfig{}).Append(nil, SentenceInfo{}, payload, numBits)
+
+	for _, passThrough := range []bool{false, true} {
+		var decoded []AISParsed
+		var dropped []DropReason
+
+		d := New(DecoderConfig{
+			AIS:         ais.CodecNew(false, false),
+			PassThrough: passThrough,
+			AISDecodedFunc: func(nmea NMEAParsed, ais AISParsed) error {
+				decoded = append(decoded, ais)
+				return nil
+			},
+			ErrorFunc: func(reason DropReason, raw []byte) { dropped = append(dropped, reason) },
+		})
+		d.Write(input)
+
+		if !passThrough {
+			if len(decoded) != 0 || len(dropped) != 1 || dropped[0] != DropDecodeFailed {
+				t.Error("Unknown message not dropped", decoded, dropped)
+			}
+			continue
+		}
+
+		if len(decoded) != 1 || len(dropped) != 0 {
+			t.Fatal("Unknown message not passed through", decoded, dropped)
+		}
+
+		p, ok := decoded[0].Packet.(RawPacket)
+		if !ok || decoded[0].MessageID != 28 || decoded[0].NumBits != numBits {
+			t.Fatal("Wrong pass through packet", decoded[0])
+		}
+
+		if p.Header != (ais.Header{MessageID: 28, RepeatIndicator: 1, UserID: 244123456}) || p.NumBits != numBits || p.Payload[0] != payload[0] {
+			t.Errorf("Wrong raw packet %+v", p)
+		}
+
+		if &p.Payload[0] == &decoded[0].Payload[0] {
+			t.Error("Raw packet payload is not a copy")
+		}
+
+		if d.Stats().MessageTypes[28] != 1 {
+			t.Error("Raw packet not counted")
+		}
+	}
+}
+
+func appendTestBits(dst []byte, value uint64, width int) []byte {
+	for i := width - 1; i >= 0; i-- {
+		dst = append(dst, byte(value>>uint(i))&1)
+	}
+	return dst
+}
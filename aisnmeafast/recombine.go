@@ -0,0 +1,180 @@
+package aisnmeafast
+
+import (
+	"time"
+
+	"github.com/BertoldVdb/go-ais/aisnmea"
+)
+
+type recombState struct {
+	MsgTotal int
+	TagBlock []byte
+	Data     [10][]byte
+	Raw      [10][]byte
+
+	relax aisnmea.Relaxation // Relaxations used by the fragments received so far
+
+	id        uint64    // Identifies the entry in the expiry queue
+	sentences uint64    // Sentence counter when the first fragment was received
+	created   time.Time // Time the first fragment was received, only set if FragmentTimeout is used
+}
+
+// recombQueueEntry refers to a pending message, in order of creation. Entries whose id no
+// longer matches the state in the map are stale and skipped.
+type recombQueueEntry struct {
+	key [8]byte
+	id  uint64
+}
+
+func (s *recombState) reset() {
+	s.MsgTotal = 0
+	s.TagBlock = s.TagBlock[:0]
+	s.relax = 0
+	for i := range s.Data {
+		s.Data[i] = s.Data[i][:0]
+		s.Raw[i] = s.Raw[i][:0]
+	}
+}
+
+// releaseRecombine removes a pending message. If reason is not negative, the fragments are
+// reported as dropped.
+func (c *Decoder) releaseRecombine(key [8]byte, state *recombState, reason DropReason) {
+	if reason >= 0 {
+		for i := range state.Raw {
+			if len(state.Raw[i]) > 0 {
+				c.drop(reason, state.Raw[i])
+			}
+		}
+	}
+
+	delete(c.aisRecombine, key)
+	state.reset()
+	c.aisRecombineFree = append(c.aisRecombineFree, state)
+}
+
+// expireRecombine removes the oldest pending messages while they are expired or there are too many
+func (c *Decoder) expireRecombine(room int) {
+	var now time.Time
+	if c.cfg.FragmentTimeout > 0 {
+		now = c.now()
+	}
+
+	for len(c.aisRecombineQueue) > 0 {
+		e := c.aisRecombineQueue[0]
+
+		state, ok := c.aisRecombine[e.key]
+		if ok && state.id == e.id {
+			var reason DropReason = -1
+
+			if len(c.aisRecombine)+room > c.cfg.MaxPendingGroups {
+				reason = DropEvicted
+			} else if c.cfg.FragmentMaxSentences > 0 && c.stats.sentences-state.sentences > c.cfg.FragmentMaxSentences {
+				reason = DropExpired
+			} else if c.cfg.FragmentTimeout > 0 && now.Sub(state.created) > c.cfg.FragmentTimeout {
+				reason = DropExpired
+			} else {
+				break
+			}
+
+			c.releaseRecombine(e.key, state, reason)
+		}
+
+		c.aisRecombineQueue = c.aisRecombineQueue[1:]
+	}
+
+	/* Completed messages leave stale entries behind, drop them once they dominate the queue */
+	if len(c.aisRecombineQueue) > 2*c.cfg.MaxPendingGroups+16 {
+		queue := c.aisRecombineQueue[:0]
+		for _, e := range c.aisRecombineQueue {
+			if state, ok := c.aisRecombine[e.key]; ok && state.id == e.id {
+				queue = append(queue, e)
+			}
+		}
+		c.aisRecombineQueue = queue
+	}
+}
+
+func (c *Decoder) recombineMessages(sentenceType byte, msgID byte, msgTotal int, msgIndex int, tagBlock []byte, data []byte, raw []byte, relax aisnmea.Relaxation) ([]byte, []byte, aisnmea.Relaxation) {
+	msgIndex--
+
+	var key [8]byte
+	key[0] = msgID
+	key[6] = sentenceType
+	if tagblockGetBlockID(key[1:6], tagBlock) < 0 {
+		key[7] = 1
+	}
+
+	if msgTotal >= len(recombState{}.Data) || msgIndex >= len(recombState{}.Data) || msgIndex < 0 {
+		return nil, nil, 0
+	}
+
+	state, ok := c.aisRecombine[key]
+	if ok && state.MsgTotal != msgTotal {
+		/* A different message is using the same identifier, the old one is lost */
+		c.releaseRecombine(key, state, DropEvicted)
+		ok = false
+	}
+
+	room := 0
+	if !ok {
+		room = 1
+	}
+	c.expireRecombine(room)
+
+	/* The message this fragment belongs to may just have expired */
+	if ok {
+		_, ok = c.aisRecombine[key]
+	}
+
+	if !ok {
+		if n := len(c.aisRecombineFree); n > 0 {
+			state = c.aisRecombineFree[n-1]
+			c.aisRecombineFree = c.aisRecombineFree[:n-1]
+		} else {
+			state = &recombState{}
+		}
+
+		c.aisRecombineID++
+		state.id = c.aisRecombineID
+		state.sentences = c.stats.sentences
+		if c.cfg.FragmentTimeout > 0 {
+			state.created = c.now()
+		}
+		state.MsgTotal = msgTotal
+
+		c.aisRecombine[key] = state
+		c.aisRecombineQueue = append(c.aisRecombineQueue, recombQueueEntry{key: key, id: state.id})
+	}
+
+	if len(tagBlock) > len(state.TagBlock) {
+		state.TagBlock = append(state.TagBlock[:0], tagBlock...)
+	}
+
+	state.Data[msgIndex] = append(state.Data[msgIndex][:0], data...)
+	state.Raw[msgIndex] = append(state.Raw[msgIndex][:0], raw...)
+	state.relax |= relax
+
+	/* Do we have everything? */
+	totalLen := 0
+	for i := 0; i < msgTotal; i++ {
+		if l := len(state.Data[i]); l == 0 {
+			return nil, nil, 0
+		} else {
+			totalLen += l
+		}
+	}
+
+	out := make([]byte, 0, totalLen)
+	for i := 0; i < msgTotal; i++ {
+		out = append(out, state.Data[i]...)
+	}
+
+	var tb []byte
+	if len(state.TagBlock) > 0 {
+		tb = append([]byte{}, state.TagBlock...)
+	}
+	relax = state.relax
+	c.releaseRecombine(key, state, -1)
+
+	return out, tb, relax
+}
@@ -0,0 +1,121 @@
+package aisnmeafast
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+func nmeaWithChecksum(s string) string {
+	cs := byte(0)
+	for i := 0; i < len(s); i++ {
+		cs ^= s[i]
+	}
+	return fmt.Sprintf("%s*%02X", s, cs)
+}
+
+/* First fragments of messages that never complete */
+func incompleteSentence(r *rand.Rand) []byte {
+	tagBlock := nmeaWithChecksum(fmt.Sprintf("g:1-2-%d", r.Intn(100000)))
+	sentence := nmeaWithChecksum(fmt.Sprintf("AIVDM,2,1,%d,A,8h3OwjQKP@5UUEPPP121IoCol54cd0Wws7wwjp:@`P1UUFD9e2B94oCPH54M`3kw,0", r.Intn(10)))
+	return []byte("\\" + tagBlock + "\\!" + sentence + "\r\n")
+}
+
+func heapInUse() uint64 {
+	var m runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&m)
+	return m.HeapInuse
+}
+
+func TestRecombineSoak(t *testing.T) {
+	/* The long run takes about a minute with the race detector, it is only done on request */
+	n := 200000
+	if os.Getenv("AIS_SOAK") != "" {
+		n = 2000000
+	}
+
+	evicted := 0
+	d := New(DecoderConfig{
+		AIS:              ais.CodecNew(false, false),
+		AISDecodedFunc:   func(nmea NMEAParsed, ais AISParsed) error { return nil },
+		ErrorFunc:        func(reason DropReason, raw []byte) { evicted++ },
+		MaxPendingGroups: 256,
+	})
+
+	r := rand.New(rand.NewSource(1))
+
+	var baseline uint64
+	for i := 0; i < n; i++ {
+		if i == n/10 {
+			baseline = heapInUse()
+		}
+		d.Write(incompleteSentence(r))
+
+		if len(d.aisRecombine) > 256 || len(d.aisRecombineQueue) > 2*256+16 || len(d.aisRecombineFree) > 257 {
+			t.Fatal("Recombination state not bounded", len(d.aisRecombine), len(d.aisRecombineQueue), len(d.aisRecombineFree))
+		}
+	}
+
+	if growth := int64(heapInUse()) - int64(baseline); growth > 1<<20 {
+		t.Error("Memory grew by", growth)
+	}
+
+	s := d.Stats()
+	if s.Pending != 256 || uint64(evicted) != s.Dropped[DropEvicted] || s.Dropped[DropEvicted] < uint64(n/2) {
+		t.Errorf("Evictions not reported: %d %+v", evicted, s)
+	}
+}
+
+func TestRecombineExpiry(t *testing.T) {
+	first := []byte("!AIVDM,2,1,7,A,8h3OwjQKP@5UUEPPP121IoCol54cd0Wws7wwjp:@`P1UUFD9e2B94oCPH54M`3kw,0*7A\r\n")
+	second := []byte("!AIVDM,2,2,7,A,sUwwjt;HvP1,2*4F\r\n")
+	single := []byte("!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3D\r\n")
+
+	var decoded int
+	var dropped []DropReason
+	cfg := DecoderConfig{
+		AIS:            ais.CodecNew(false, false),
+		AISDecodedFunc: func(nmea NMEAParsed, ais AISParsed) error { decoded++; return nil },
+		ErrorFunc:      func(reason DropReason, raw []byte) { dropped = append(dropped, reason) },
+	}
+
+	/* Count based */
+	cfg.FragmentMaxSentences = 2
+	d := New(cfg)
+	d.Write(first)
+	d.Write(single)
+	d.Write(second)
+	if decoded != 2 || len(dropped) != 0 {
+		t.Fatal("Message expired too early", decoded, dropped)
+	}
+
+	d.Write(first)
+	d.Write(single)
+	d.Write(single)
+	d.Write(second)
+	if decoded != 4 || len(dropped) != 1 || dropped[0] != DropExpired || d.Stats().Pending != 1 {
+		t.Fatal("Message did not expire", decoded, dropped)
+	}
+
+	/* Time based */
+	decoded, dropped = 0, nil
+	cfg.FragmentMaxSentences = 0
+	cfg.FragmentTimeout = time.Minute
+
+	now := time.Unix(1560234814, 0)
+	d = New(cfg)
+	d.now = func() time.Time { return now }
+
+	d.Write(first)
+	now = now.Add(2 * time.Minute)
+	d.Write(second)
+	if decoded != 0 || len(dropped) != 1 || dropped[0] != DropExpired {
+		t.Fatal("Message did not expire", decoded, dropped)
+	}
+}
@@ -0,0 +1,145 @@
+package aisnmeafast
+
+// DropReason describes why a sentence did not result in a packet
+type DropReason int
+
+const (
+	// DropMalformed is used for sentences that are too long or do not have the VDM/VDO structure
+	DropMalformed DropReason = iota
+	// DropBadChecksum is used for sentences with a missing or wrong checksum
+	DropBadChecksum
+	// DropBadTagBlockChecksum is used for sentences with a TAG Block that has a wrong checksum
+	DropBadTagBlockChecksum
+	// DropInvalidArmoring is used for payloads containing characters outside the six bit alphabet
+	DropInvalidArmoring
+	// DropInvalidFragment is used for sentences with out of range fragment numbers
+	DropInvalidFragment
+	// DropDecodeFailed is used if the AIS codec could not decode the payload
+	DropDecodeFailed
+	// DropEvicted is used for the fragments of an incomplete message that was removed to make
+	// room for a new one
+	DropEvicted
+	// DropExpired is used for the fragments of an incomplete message that was not completed in time
+	DropExpired
+
+	numDropReasons
+)
+
+func (r DropReason) String() string {
+	switch r {
+	case DropMalformed:
+		return "malformed"
+	case DropBadChecksum:
+		return "bad checksum"
+	case DropBadTagBlockChecksum:
+		return "bad TAG block checksum"
+	case DropInvalidArmoring:
+		return "invalid armoring"
+	case DropInvalidFragment:
+		return "invalid fragment"
+	case DropDecodeFailed:
+		return "decode failed"
+	case DropEvicted:
+		return "evicted"
+	case DropExpired:
+		return "expired"
+	}
+	return "unknown"
+}
+
+// DecoderStats contains counters about the decoded input
+type DecoderStats struct {
+	Sentences    uint64                // Number of complete sentences seen, including dropped ones
+	Packets      uint64                // Number of decoded AIS packets
+	Pending      int                   // Number of incomplete multi-sentence messages
+	Dropped      map[DropReason]uint64 // Number of dropped sentences per reason
+	MessageTypes map[uint8]uint64      // Number of decoded packets per AIS message ID
+	Talkers      map[string]uint64     // Number of sentences with a valid checksum per talker ID
+}
+
+type decoderStats struct {
+	sentences    uint64
+	packets      uint64
+	dropped      [numDropReasons]uint64
+	messageTypes [64]uint64
+	talkers      map[[2]byte]uint64
+}
+
+// Stats returns a copy of the counters. Like Write, it must not be called concurrently with
+// other methods of the Decoder.
+func (c *Decoder) Stats() DecoderStats {
+	return c.stats.export(len(c.aisRecombine))
+}
+
+func (s *decoderStats) export(pending int) DecoderStats {
+	r := DecoderStats{
+		Sentences:    s.sentences,
+		Packets:      s.packets,
+		Pending:      pending,
+		Dropped:      make(map[DropReason]uint64),
+		MessageTypes: make(map[uint8]uint64),
+		Talkers:      make(map[string]uint64, len(s.talkers)),
+	}
+
+	for i, v := range s.dropped {
+		if v > 0 {
+			r.Dropped[DropReason(i)] = v
+		}
+	}
+
+	for i, v := range s.messageTypes {
+		if v > 0 {
+			r.MessageTypes[uint8(i)] = v
+		}
+	}
+
+	for k, v := range s.talkers {
+		r.Talkers[string(k[:])] = v
+	}
+
+	return r
+}
+
+// add sums the counters of o into s
+func (s *decoderStats) add(o *decoderStats) {
+	s.sentences += o.sentences
+	s.packets += o.packets
+
+	for i, v := range o.dropped {
+		s.dropped[i] += v
+	}
+
+	for i, v := range o.messageTypes {
+		s.messageTypes[i] += v
+	}
+
+	for k, v := range o.talkers {
+		if s.talkers == nil {
+			s.talkers = make(map[[2]byte]uint64)
+		}
+		s.talkers[k] += v
+	}
+}
+
+// drop counts a dropped sentence and reports it to the ErrorFunc
+func (c *Decoder) drop(reason DropReason, raw []byte) {
+	reportDrop(&c.cfg, &c.stats, reason, raw)
+}
+
+func reportDrop(cfg *DecoderConfig, stats *decoderStats, reason DropReason, raw []byte) {
+	stats.dropped[reason]++
+
+	if cfg.ErrorFunc != nil {
+		cfg.ErrorFunc(reason, raw)
+	}
+}
+
+func (c *Decoder) countTalker(sentence []byte) {
+	if len(sentence) < 3 {
+		return
+	}
+
+	var key [2]byte
+	copy(key[:], sentence[1:3])
+	c.stats.talkers[key]++
+}
@@ -0,0 +1,44 @@
+package aisnmeafast
+
+import (
+	"testing"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+func TestStats(t *testing.T) {
+	var reasons []DropReason
+
+	d := New(DecoderConfig{
+		AIS:            ais.CodecNew(false, false),
+		AISDecodedFunc: func(nmea NMEAParsed, ais AISParsed) error { return nil },
+		ErrorFunc: func(reason DropReason, raw []byte) {
+			reasons = append(reasons, reason)
+		},
+	})
+
+	d.Write([]byte("!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3D\r\n" +
+		"!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3E\r\n" +
+		"\\s:2251*00\\!AIVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*3D\r\n" +
+		"!BSVDM,1,1,,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`~,0*66\r\n" +
+		"!BSVDM,3,4,1,B,23aDqDOP0S0:mk2Kv3Ip=wvpR>`<,0*12\r\n" +
+		"!BSVDM,1,1,,B,2,0*0E\r\n"))
+
+	want := []DropReason{DropBadChecksum, DropBadTagBlockChecksum, DropInvalidArmoring, DropInvalidFragment, DropDecodeFailed}
+	if len(reasons) != len(want) {
+		t.Fatal("Wrong drop reasons", reasons)
+	}
+	for i := range want {
+		if reasons[i] != want[i] {
+			t.Errorf("Wrong drop reason %d: %s instead of %s", i, reasons[i], want[i])
+		}
+	}
+
+	s := d.Stats()
+	if s.Sentences != 6 || s.Packets != 1 || s.MessageTypes[2] != 1 || s.Talkers["AI"] != 1 || s.Talkers["BS"] != 3 {
+		t.Errorf("Wrong counters: %+v", s)
+	}
+	if s.Dropped[DropBadChecksum] != 1 || s.Dropped[DropMalformed] != 0 {
+		t.Errorf("Wrong drop counters: %+v", s.Dropped)
+	}
+}
@@ -0,0 +1,3379 @@
+// Code generated by parser_generator/main.go. DO NOT EDIT.
+
+package aisproto
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+// Marshal encodes a packet as a Packet protobuf message
+func Marshal(p ais.Packet) ([]byte, error) {
+	switch x := p.(type) {
+	case ais.PositionReport:
+		return appendMessageField(nil, 1, marshalPositionReport(nil, &x)), nil
+	case ais.BaseStationReport:
+		return appendMessageField(nil, 4, marshalBaseStationReport(nil, &x)), nil
+	case ais.ShipStaticData:
+		return appendMessageField(nil, 5, marshalShipStaticData(nil, &x)), nil
+	case ais.AddressedBinaryMessage:
+		return appendMessageField(nil, 6, marshalAddressedBinaryMessage(nil, &x)), nil
+	case ais.BinaryAcknowledge:
+		return appendMessageField(nil, 7, marshalBinaryAcknowledge(nil, &x)), nil
+	case ais.BinaryBroadcastMessage:
+		return appendMessageField(nil, 8, marshalBinaryBroadcastMessage(nil, &x)), nil
+	case ais.StandardSearchAndRescueAircraftReport:
+		return appendMessageField(nil, 9, marshalStandardSearchAndRescueAircraftReport(nil, &x)), nil
+	case ais.CoordinatedUTCInquiry:
+		return appendMessageField(nil, 10, marshalCoordinatedUTCInquiry(nil, &x)), nil
+	case ais.AddessedSafetyMessage:
+		return appendMessageField(nil, 12, marshalAddessedSafetyMessage(nil, &x)), nil
+	case ais.SafetyBroadcastMessage:
+		return appendMessageField(nil, 14, marshalSafetyBroadcastMessage(nil, &x)), nil
+	case ais.Interrogation:
+		return appendMessageField(nil, 15, marshalInterrogation(nil, &x)), nil
+	case ais.AssignedModeCommand:
+		return appendMessageField(nil, 16, marshalAssignedModeCommand(nil, &x)), nil
+	case ais.GnssBroadcastBinaryMessage:
+		return appendMessageField(nil, 17, marshalGnssBroadcastBinaryMessage(nil, &x)), nil
+	case ais.StandardClassBPositionReport:
+		return appendMessageField(nil, 18, marshalStandardClassBPositionReport(nil, &x)), nil
+	case ais.ExtendedClassBPositionReport:
+		return appendMessageField(nil, 19, marshalExtendedClassBPositionReport(nil, &x)), nil
+	case ais.DataLinkManagementMessage:
+		return appendMessageField(nil, 20, marshalDataLinkManagementMessage(nil, &x)), nil
+	case ais.AidsToNavigationReport:
+		return appendMessageField(nil, 21, marshalAidsToNavigationReport(nil, &x)), nil
+	case ais.ChannelManagement:
+		return appendMessageField(nil, 22, marshalChannelManagement(nil, &x)), nil
+	case ais.GroupAssignmentCommand:
+		return appendMessageField(nil, 23, marshalGroupAssignmentCommand(nil, &x)), nil
+	case ais.StaticDataReport:
+		return appendMessageField(nil, 24, marshalStaticDataReport(nil, &x)), nil
+	case ais.SingleSlotBinaryMessage:
+		return appendMessageField(nil, 25, marshalSingleSlotBinaryMessage(nil, &x)), nil
+	case ais.MultiSlotBinaryMessage:
+		return appendMessageField(nil, 26, marshalMultiSlotBinaryMessage(nil, &x)), nil
+	case ais.LongRangeAisBroadcastMessage:
+		return appendMessageField(nil, 27, marshalLongRangeAisBroadcastMessage(nil, &x)), nil
+	}
+	return nil, fmt.Errorf("aisproto: unsupported packet type [%T]", p)
+}
+
+// Unmarshal decodes a Packet protobuf message
+func Unmarshal(data []byte) (ais.Packet, error) {
+	var p ais.Packet
+
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return nil, err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireBytes); err != nil {
+				return nil, err
+			}
+			var x ais.PositionReport
+			if err := unmarshalPositionReport(f.data, &x); err != nil {
+				return nil, err
+			}
+			p = x
+		case 4:
+			if err := f.expect(wireBytes); err != nil {
+				return nil, err
+			}
+			var x ais.BaseStationReport
+			if err := unmarshalBaseStationReport(f.data, &x); err != nil {
+				return nil, err
+			}
+			p = x
+		case 5:
+			if err := f.expect(wireBytes); err != nil {
+				return nil, err
+			}
+			var x ais.ShipStaticData
+			if err := unmarshalShipStaticData(f.data, &x); err != nil {
+				return nil, err
+			}
+			p = x
+		case 6:
+			if err := f.expect(wireBytes); err != nil {
+				return nil, err
+			}
+			var x ais.AddressedBinaryMessage
+			if err := unmarshalAddressedBinaryMessage(f.data, &x); err != nil {
+				return nil, err
+			}
+			p = x
+		case 7:
+			if err := f.expect(wireBytes); err != nil {
+				return nil, err
+			}
+			var x ais.BinaryAcknowledge
+			if err := unmarshalBinaryAcknowledge(f.data, &x); err != nil {
+				return nil, err
+			}
+			p = x
+		case 8:
+			if err := f.expect(wireBytes); err != nil {
+				return nil, err
+			}
+			var x ais.BinaryBroadcastMessage
+			if err := unmarshalBinaryBroadcastMessage(f.data, &x); err != nil {
+				return nil, err
+			}
+			p = x
+		case 9:
+			if err := f.expect(wireBytes); err != nil {
+				return nil, err
+			}
+			var x ais.StandardSearchAndRescueAircraftReport
+			if err := unmarshalStandardSearchAndRescueAircraftReport(f.data, &x); err != nil {
+				return nil, err
+			}
+			p = x
+		case 10:
+			if err := f.expect(wireBytes); err != nil {
+				return nil, err
+			}
+			var x ais.CoordinatedUTCInquiry
+			if err := unmarshalCoordinatedUTCInquiry(f.data, &x); err != nil {
+				return nil, err
+			}
+			p = x
+		case 12:
+			if err := f.expect(wireBytes); err != nil {
+				return nil, err
+			}
+			var x ais.AddessedSafetyMessage
+			if err := unmarshalAddessedSafetyMessage(f.data, &x); err != nil {
+				return nil, err
+			}
+			p = x
+		case 14:
+			if err := f.expect(wireBytes); err != nil {
+				return nil, err
+			}
+			var x ais.SafetyBroadcastMessage
+			if err := unmarshalSafetyBroadcastMessage(f.data, &x); err != nil {
+				return nil, err
+			}
+			p = x
+		case 15:
+			if err := f.expect(wireBytes); err != nil {
+				return nil, err
+			}
+			var x ais.Interrogation
+			if err := unmarshalInterrogation(f.data, &x); err != nil {
+				return nil, err
+			}
+			p = x
+		case 16:
+			if err := f.expect(wireBytes); err != nil {
+				return nil, err
+			}
+			var x ais.AssignedModeCommand
+			if err := unmarshalAssignedModeCommand(f.data, &x); err != nil {
+				return nil, err
+			}
+			p = x
+		case 17:
+			if err := f.expect(wireBytes); err != nil {
+				return nil, err
+			}
+			var x ais.GnssBroadcastBinaryMessage
+			if err := unmarshalGnssBroadcastBinaryMessage(f.data, &x); err != nil {
+				return nil, err
+			}
+			p = x
+		case 18:
+			if err := f.expect(wireBytes); err != nil {
+				return nil, err
+			}
+			var x ais.StandardClassBPositionReport
+			if err := unmarshalStandardClassBPositionReport(f.data, &x); err != nil {
+				return nil, err
+			}
+			p = x
+		case 19:
+			if err := f.expect(wireBytes); err != nil {
+				return nil, err
+			}
+			var x ais.ExtendedClassBPositionReport
+			if err := unmarshalExtendedClassBPositionReport(f.data, &x); err != nil {
+				return nil, err
+			}
+			p = x
+		case 20:
+			if err := f.expect(wireBytes); err != nil {
+				return nil, err
+			}
+			var x ais.DataLinkManagementMessage
+			if err := unmarshalDataLinkManagementMessage(f.data, &x); err != nil {
+				return nil, err
+			}
+			p = x
+		case 21:
+			if err := f.expect(wireBytes); err != nil {
+				return nil, err
+			}
+			var x ais.AidsToNavigationReport
+			if err := unmarshalAidsToNavigationReport(f.data, &x); err != nil {
+				return nil, err
+			}
+			p = x
+		case 22:
+			if err := f.expect(wireBytes); err != nil {
+				return nil, err
+			}
+			var x ais.ChannelManagement
+			if err := unmarshalChannelManagement(f.data, &x); err != nil {
+				return nil, err
+			}
+			p = x
+		case 23:
+			if err := f.expect(wireBytes); err != nil {
+				return nil, err
+			}
+			var x ais.GroupAssignmentCommand
+			if err := unmarshalGroupAssignmentCommand(f.data, &x); err != nil {
+				return nil, err
+			}
+			p = x
+		case 24:
+			if err := f.expect(wireBytes); err != nil {
+				return nil, err
+			}
+			var x ais.StaticDataReport
+			if err := unmarshalStaticDataReport(f.data, &x); err != nil {
+				return nil, err
+			}
+			p = x
+		case 25:
+			if err := f.expect(wireBytes); err != nil {
+				return nil, err
+			}
+			var x ais.SingleSlotBinaryMessage
+			if err := unmarshalSingleSlotBinaryMessage(f.data, &x); err != nil {
+				return nil, err
+			}
+			p = x
+		case 26:
+			if err := f.expect(wireBytes); err != nil {
+				return nil, err
+			}
+			var x ais.MultiSlotBinaryMessage
+			if err := unmarshalMultiSlotBinaryMessage(f.data, &x); err != nil {
+				return nil, err
+			}
+			p = x
+		case 27:
+			if err := f.expect(wireBytes); err != nil {
+				return nil, err
+			}
+			var x ais.LongRangeAisBroadcastMessage
+			if err := unmarshalLongRangeAisBroadcastMessage(f.data, &x); err != nil {
+				return nil, err
+			}
+			p = x
+		}
+	}
+
+	if p == nil {
+		return nil, errNoPacket
+	}
+	return p, nil
+}
+
+func marshalHeader(b []byte, v *ais.Header) []byte {
+	if v.MessageID != 0 {
+		b = appendVarintField(b, 1, uint64(v.MessageID))
+	}
+	if v.RepeatIndicator != 0 {
+		b = appendVarintField(b, 2, uint64(v.RepeatIndicator))
+	}
+	if v.UserID != 0 {
+		b = appendVarintField(b, 3, uint64(v.UserID))
+	}
+	return b
+}
+
+func unmarshalHeader(data []byte, v *ais.Header) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.MessageID = uint8(f.value)
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.RepeatIndicator = uint8(f.value)
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.UserID = uint32(f.value)
+		}
+	}
+	return nil
+}
+
+func marshalPositionReport(b []byte, v *ais.PositionReport) []byte {
+	b = appendMessageField(b, 1, marshalHeader(nil, &v.Header))
+	if v.Valid {
+		b = appendVarintField(b, 2, 1)
+	}
+	if v.NavigationalStatus != 0 {
+		b = appendVarintField(b, 3, uint64(v.NavigationalStatus))
+	}
+	if v.RateOfTurn != 0 {
+		b = appendVarintField(b, 4, zigzagEncode(int64(v.RateOfTurn)))
+	}
+	if v.Sog != 0 {
+		b = appendFixed64Field(b, 5, math.Float64bits(float64(v.Sog)))
+	}
+	if v.PositionAccuracy {
+		b = appendVarintField(b, 6, 1)
+	}
+	if v.Longitude != 0 {
+		b = appendFixed64Field(b, 7, math.Float64bits(float64(v.Longitude)))
+	}
+	if v.Latitude != 0 {
+		b = appendFixed64Field(b, 8, math.Float64bits(float64(v.Latitude)))
+	}
+	if v.Cog != 0 {
+		b = appendFixed64Field(b, 9, math.Float64bits(float64(v.Cog)))
+	}
+	if v.TrueHeading != 0 {
+		b = appendVarintField(b, 10, uint64(v.TrueHeading))
+	}
+	if v.Timestamp != 0 {
+		b = appendVarintField(b, 11, uint64(v.Timestamp))
+	}
+	if v.SpecialManoeuvreIndicator != 0 {
+		b = appendVarintField(b, 12, uint64(v.SpecialManoeuvreIndicator))
+	}
+	if v.Spare != 0 {
+		b = appendVarintField(b, 13, uint64(v.Spare))
+	}
+	if v.Raim {
+		b = appendVarintField(b, 14, 1)
+	}
+	b = appendMessageField(b, 15, marshalCommunicationStateNoItdma(nil, &v.CommunicationStateNoItdma))
+	return b
+}
+
+func unmarshalPositionReport(data []byte, v *ais.PositionReport) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalHeader(f.data, &v.Header); err != nil {
+				return err
+			}
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.NavigationalStatus = uint8(f.value)
+		case 4:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.RateOfTurn = int16(zigzagDecode(f.value))
+		case 5:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Sog = ais.Field10(math.Float64frombits(f.value))
+		case 6:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.PositionAccuracy = f.value != 0
+		case 7:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Longitude = ais.FieldLatLonFine(math.Float64frombits(f.value))
+		case 8:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Latitude = ais.FieldLatLonFine(math.Float64frombits(f.value))
+		case 9:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Cog = ais.Field10(math.Float64frombits(f.value))
+		case 10:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.TrueHeading = uint16(f.value)
+		case 11:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Timestamp = uint8(f.value)
+		case 12:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.SpecialManoeuvreIndicator = uint8(f.value)
+		case 13:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare = uint8(f.value)
+		case 14:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Raim = f.value != 0
+		case 15:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalCommunicationStateNoItdma(f.data, &v.CommunicationStateNoItdma); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func marshalBaseStationReport(b []byte, v *ais.BaseStationReport) []byte {
+	b = appendMessageField(b, 1, marshalHeader(nil, &v.Header))
+	if v.Valid {
+		b = appendVarintField(b, 2, 1)
+	}
+	if v.UtcYear != 0 {
+		b = appendVarintField(b, 3, uint64(v.UtcYear))
+	}
+	if v.UtcMonth != 0 {
+		b = appendVarintField(b, 4, uint64(v.UtcMonth))
+	}
+	if v.UtcDay != 0 {
+		b = appendVarintField(b, 5, uint64(v.UtcDay))
+	}
+	if v.UtcHour != 0 {
+		b = appendVarintField(b, 6, uint64(v.UtcHour))
+	}
+	if v.UtcMinute != 0 {
+		b = appendVarintField(b, 7, uint64(v.UtcMinute))
+	}
+	if v.UtcSecond != 0 {
+		b = appendVarintField(b, 8, uint64(v.UtcSecond))
+	}
+	if v.PositionAccuracy {
+		b = appendVarintField(b, 9, 1)
+	}
+	if v.Longitude != 0 {
+		b = appendFixed64Field(b, 10, math.Float64bits(float64(v.Longitude)))
+	}
+	if v.Latitude != 0 {
+		b = appendFixed64Field(b, 11, math.Float64bits(float64(v.Latitude)))
+	}
+	if v.FixType != 0 {
+		b = appendVarintField(b, 12, uint64(v.FixType))
+	}
+	if v.LongRangeEnable {
+		b = appendVarintField(b, 13, 1)
+	}
+	if v.Spare != 0 {
+		b = appendVarintField(b, 14, uint64(v.Spare))
+	}
+	if v.Raim {
+		b = appendVarintField(b, 15, 1)
+	}
+	b = appendMessageField(b, 16, marshalCommunicationStateNoItdma(nil, &v.CommunicationStateNoItdma))
+	return b
+}
+
+func unmarshalBaseStationReport(data []byte, v *ais.BaseStationReport) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalHeader(f.data, &v.Header); err != nil {
+				return err
+			}
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.UtcYear = uint16(f.value)
+		case 4:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.UtcMonth = uint8(f.value)
+		case 5:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.UtcDay = uint8(f.value)
+		case 6:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.UtcHour = uint8(f.value)
+		case 7:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.UtcMinute = uint8(f.value)
+		case 8:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.UtcSecond = uint8(f.value)
+		case 9:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.PositionAccuracy = f.value != 0
+		case 10:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Longitude = ais.FieldLatLonFine(math.Float64frombits(f.value))
+		case 11:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Latitude = ais.FieldLatLonFine(math.Float64frombits(f.value))
+		case 12:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.FixType = uint8(f.value)
+		case 13:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.LongRangeEnable = f.value != 0
+		case 14:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare = uint16(f.value)
+		case 15:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Raim = f.value != 0
+		case 16:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalCommunicationStateNoItdma(f.data, &v.CommunicationStateNoItdma); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func marshalShipStaticData(b []byte, v *ais.ShipStaticData) []byte {
+	b = appendMessageField(b, 1, marshalHeader(nil, &v.Header))
+	if v.Valid {
+		b = appendVarintField(b, 2, 1)
+	}
+	if v.AisVersion != 0 {
+		b = appendVarintField(b, 3, uint64(v.AisVersion))
+	}
+	if v.ImoNumber != 0 {
+		b = appendVarintField(b, 4, uint64(v.ImoNumber))
+	}
+	if len(v.CallSign) > 0 {
+		b = appendBytesField(b, 5, []byte(v.CallSign))
+	}
+	if len(v.Name) > 0 {
+		b = appendBytesField(b, 6, []byte(v.Name))
+	}
+	if v.Type != 0 {
+		b = appendVarintField(b, 7, uint64(v.Type))
+	}
+	b = appendMessageField(b, 8, marshalFieldDimension(nil, &v.Dimension))
+	if v.FixType != 0 {
+		b = appendVarintField(b, 9, uint64(v.FixType))
+	}
+	b = appendMessageField(b, 10, marshalFieldETA(nil, &v.Eta))
+	if v.MaximumStaticDraught != 0 {
+		b = appendFixed64Field(b, 11, math.Float64bits(float64(v.MaximumStaticDraught)))
+	}
+	if len(v.Destination) > 0 {
+		b = appendBytesField(b, 12, []byte(v.Destination))
+	}
+	if v.Dte {
+		b = appendVarintField(b, 13, 1)
+	}
+	if v.Spare {
+		b = appendVarintField(b, 14, 1)
+	}
+	return b
+}
+
+func unmarshalShipStaticData(data []byte, v *ais.ShipStaticData) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalHeader(f.data, &v.Header); err != nil {
+				return err
+			}
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.AisVersion = uint8(f.value)
+		case 4:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.ImoNumber = uint32(f.value)
+		case 5:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			v.CallSign = string(f.data)
+		case 6:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			v.Name = string(f.data)
+		case 7:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Type = uint8(f.value)
+		case 8:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalFieldDimension(f.data, &v.Dimension); err != nil {
+				return err
+			}
+		case 9:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.FixType = uint8(f.value)
+		case 10:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalFieldETA(f.data, &v.Eta); err != nil {
+				return err
+			}
+		case 11:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.MaximumStaticDraught = ais.Field10(math.Float64frombits(f.value))
+		case 12:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			v.Destination = string(f.data)
+		case 13:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Dte = f.value != 0
+		case 14:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare = f.value != 0
+		}
+	}
+	return nil
+}
+
+func marshalAddressedBinaryMessage(b []byte, v *ais.AddressedBinaryMessage) []byte {
+	b = appendMessageField(b, 1, marshalHeader(nil, &v.Header))
+	if v.Valid {
+		b = appendVarintField(b, 2, 1)
+	}
+	if v.SequenceNumber != 0 {
+		b = appendVarintField(b, 3, uint64(v.SequenceNumber))
+	}
+	if v.DestinationID != 0 {
+		b = appendVarintField(b, 4, uint64(v.DestinationID))
+	}
+	if v.Retransmission {
+		b = appendVarintField(b, 5, 1)
+	}
+	if v.Spare {
+		b = appendVarintField(b, 6, 1)
+	}
+	b = appendMessageField(b, 7, marshalFieldApplicationIdentifier(nil, &v.ApplicationID))
+	if len(v.BinaryData) > 0 {
+		b = appendBytesField(b, 8, []byte(v.BinaryData))
+	}
+	return b
+}
+
+func unmarshalAddressedBinaryMessage(data []byte, v *ais.AddressedBinaryMessage) error {
+	v.BinaryData = []byte{}
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalHeader(f.data, &v.Header); err != nil {
+				return err
+			}
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.SequenceNumber = uint8(f.value)
+		case 4:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.DestinationID = uint32(f.value)
+		case 5:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Retransmission = f.value != 0
+		case 6:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare = f.value != 0
+		case 7:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalFieldApplicationIdentifier(f.data, &v.ApplicationID); err != nil {
+				return err
+			}
+		case 8:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			v.BinaryData = append([]byte{}, f.data...)
+		}
+	}
+	return nil
+}
+
+func marshalBinaryBroadcastMessage(b []byte, v *ais.BinaryBroadcastMessage) []byte {
+	b = appendMessageField(b, 1, marshalHeader(nil, &v.Header))
+	if v.Valid {
+		b = appendVarintField(b, 2, 1)
+	}
+	if v.Spare != 0 {
+		b = appendVarintField(b, 3, uint64(v.Spare))
+	}
+	b = appendMessageField(b, 4, marshalFieldApplicationIdentifier(nil, &v.ApplicationID))
+	if len(v.BinaryData) > 0 {
+		b = appendBytesField(b, 5, []byte(v.BinaryData))
+	}
+	return b
+}
+
+func unmarshalBinaryBroadcastMessage(data []byte, v *ais.BinaryBroadcastMessage) error {
+	v.BinaryData = []byte{}
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalHeader(f.data, &v.Header); err != nil {
+				return err
+			}
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare = uint8(f.value)
+		case 4:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalFieldApplicationIdentifier(f.data, &v.ApplicationID); err != nil {
+				return err
+			}
+		case 5:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			v.BinaryData = append([]byte{}, f.data...)
+		}
+	}
+	return nil
+}
+
+func marshalStandardSearchAndRescueAircraftReport(b []byte, v *ais.StandardSearchAndRescueAircraftReport) []byte {
+	b = appendMessageField(b, 1, marshalHeader(nil, &v.Header))
+	if v.Valid {
+		b = appendVarintField(b, 2, 1)
+	}
+	if v.Altitude != 0 {
+		b = appendVarintField(b, 3, uint64(v.Altitude))
+	}
+	if v.Sog != 0 {
+		b = appendVarintField(b, 4, uint64(v.Sog))
+	}
+	if v.PositionAccuracy {
+		b = appendVarintField(b, 5, 1)
+	}
+	if v.Longitude != 0 {
+		b = appendFixed64Field(b, 6, math.Float64bits(float64(v.Longitude)))
+	}
+	if v.Latitude != 0 {
+		b = appendFixed64Field(b, 7, math.Float64bits(float64(v.Latitude)))
+	}
+	if v.Cog != 0 {
+		b = appendFixed64Field(b, 8, math.Float64bits(float64(v.Cog)))
+	}
+	if v.Timestamp != 0 {
+		b = appendVarintField(b, 9, uint64(v.Timestamp))
+	}
+	if v.AltFromBaro {
+		b = appendVarintField(b, 10, 1)
+	}
+	if v.Spare1 != 0 {
+		b = appendVarintField(b, 11, uint64(v.Spare1))
+	}
+	if v.Dte {
+		b = appendVarintField(b, 12, 1)
+	}
+	if v.Spare2 != 0 {
+		b = appendVarintField(b, 13, uint64(v.Spare2))
+	}
+	if v.AssignedMode {
+		b = appendVarintField(b, 14, 1)
+	}
+	if v.Raim {
+		b = appendVarintField(b, 15, 1)
+	}
+	b = appendMessageField(b, 16, marshalCommunicationStateItdma(nil, &v.CommunicationStateItdma))
+	return b
+}
+
+func unmarshalStandardSearchAndRescueAircraftReport(data []byte, v *ais.StandardSearchAndRescueAircraftReport) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalHeader(f.data, &v.Header); err != nil {
+				return err
+			}
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Altitude = uint16(f.value)
+		case 4:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Sog = uint16(f.value)
+		case 5:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.PositionAccuracy = f.value != 0
+		case 6:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Longitude = ais.FieldLatLonFine(math.Float64frombits(f.value))
+		case 7:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Latitude = ais.FieldLatLonFine(math.Float64frombits(f.value))
+		case 8:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Cog = ais.Field10(math.Float64frombits(f.value))
+		case 9:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Timestamp = uint8(f.value)
+		case 10:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.AltFromBaro = f.value != 0
+		case 11:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare1 = uint8(f.value)
+		case 12:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Dte = f.value != 0
+		case 13:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare2 = uint8(f.value)
+		case 14:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.AssignedMode = f.value != 0
+		case 15:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Raim = f.value != 0
+		case 16:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalCommunicationStateItdma(f.data, &v.CommunicationStateItdma); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func marshalCoordinatedUTCInquiry(b []byte, v *ais.CoordinatedUTCInquiry) []byte {
+	b = appendMessageField(b, 1, marshalHeader(nil, &v.Header))
+	if v.Valid {
+		b = appendVarintField(b, 2, 1)
+	}
+	if v.Spare1 != 0 {
+		b = appendVarintField(b, 3, uint64(v.Spare1))
+	}
+	if v.DestinationID != 0 {
+		b = appendVarintField(b, 4, uint64(v.DestinationID))
+	}
+	if v.Spare2 != 0 {
+		b = appendVarintField(b, 5, uint64(v.Spare2))
+	}
+	return b
+}
+
+func unmarshalCoordinatedUTCInquiry(data []byte, v *ais.CoordinatedUTCInquiry) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalHeader(f.data, &v.Header); err != nil {
+				return err
+			}
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare1 = uint8(f.value)
+		case 4:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.DestinationID = uint32(f.value)
+		case 5:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare2 = uint8(f.value)
+		}
+	}
+	return nil
+}
+
+func marshalAddessedSafetyMessage(b []byte, v *ais.AddessedSafetyMessage) []byte {
+	b = appendMessageField(b, 1, marshalHeader(nil, &v.Header))
+	if v.Valid {
+		b = appendVarintField(b, 2, 1)
+	}
+	if v.SequenceNumber != 0 {
+		b = appendVarintField(b, 3, uint64(v.SequenceNumber))
+	}
+	if v.DestinationID != 0 {
+		b = appendVarintField(b, 4, uint64(v.DestinationID))
+	}
+	if v.Retransmission {
+		b = appendVarintField(b, 5, 1)
+	}
+	if v.Spare {
+		b = appendVarintField(b, 6, 1)
+	}
+	if len(v.Text) > 0 {
+		b = appendBytesField(b, 7, []byte(v.Text))
+	}
+	return b
+}
+
+func unmarshalAddessedSafetyMessage(data []byte, v *ais.AddessedSafetyMessage) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalHeader(f.data, &v.Header); err != nil {
+				return err
+			}
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.SequenceNumber = uint8(f.value)
+		case 4:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.DestinationID = uint32(f.value)
+		case 5:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Retransmission = f.value != 0
+		case 6:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare = f.value != 0
+		case 7:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			v.Text = string(f.data)
+		}
+	}
+	return nil
+}
+
+func marshalSafetyBroadcastMessage(b []byte, v *ais.SafetyBroadcastMessage) []byte {
+	b = appendMessageField(b, 1, marshalHeader(nil, &v.Header))
+	if v.Valid {
+		b = appendVarintField(b, 2, 1)
+	}
+	if v.Spare != 0 {
+		b = appendVarintField(b, 3, uint64(v.Spare))
+	}
+	if len(v.Text) > 0 {
+		b = appendBytesField(b, 4, []byte(v.Text))
+	}
+	return b
+}
+
+func unmarshalSafetyBroadcastMessage(data []byte, v *ais.SafetyBroadcastMessage) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalHeader(f.data, &v.Header); err != nil {
+				return err
+			}
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare = uint8(f.value)
+		case 4:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			v.Text = string(f.data)
+		}
+	}
+	return nil
+}
+
+func marshalGnssBroadcastBinaryMessage(b []byte, v *ais.GnssBroadcastBinaryMessage) []byte {
+	b = appendMessageField(b, 1, marshalHeader(nil, &v.Header))
+	if v.Valid {
+		b = appendVarintField(b, 2, 1)
+	}
+	if v.Spare1 != 0 {
+		b = appendVarintField(b, 3, uint64(v.Spare1))
+	}
+	if v.Longitude != 0 {
+		b = appendFixed64Field(b, 4, math.Float64bits(float64(v.Longitude)))
+	}
+	if v.Latitude != 0 {
+		b = appendFixed64Field(b, 5, math.Float64bits(float64(v.Latitude)))
+	}
+	if v.Spare2 != 0 {
+		b = appendVarintField(b, 6, uint64(v.Spare2))
+	}
+	if len(v.Data) > 0 {
+		b = appendBytesField(b, 7, []byte(v.Data))
+	}
+	return b
+}
+
+func unmarshalGnssBroadcastBinaryMessage(data []byte, v *ais.GnssBroadcastBinaryMessage) error {
+	v.Data = []byte{}
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalHeader(f.data, &v.Header); err != nil {
+				return err
+			}
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare1 = uint8(f.value)
+		case 4:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Longitude = ais.FieldLatLonCoarse(math.Float64frombits(f.value))
+		case 5:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Latitude = ais.FieldLatLonCoarse(math.Float64frombits(f.value))
+		case 6:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare2 = uint8(f.value)
+		case 7:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			v.Data = append([]byte{}, f.data...)
+		}
+	}
+	return nil
+}
+
+func marshalStandardClassBPositionReport(b []byte, v *ais.StandardClassBPositionReport) []byte {
+	b = appendMessageField(b, 1, marshalHeader(nil, &v.Header))
+	if v.Valid {
+		b = appendVarintField(b, 2, 1)
+	}
+	if v.Spare1 != 0 {
+		b = appendVarintField(b, 3, uint64(v.Spare1))
+	}
+	if v.Sog != 0 {
+		b = appendFixed64Field(b, 4, math.Float64bits(float64(v.Sog)))
+	}
+	if v.PositionAccuracy {
+		b = appendVarintField(b, 5, 1)
+	}
+	if v.Longitude != 0 {
+		b = appendFixed64Field(b, 6, math.Float64bits(float64(v.Longitude)))
+	}
+	if v.Latitude != 0 {
+		b = appendFixed64Field(b, 7, math.Float64bits(float64(v.Latitude)))
+	}
+	if v.Cog != 0 {
+		b = appendFixed64Field(b, 8, math.Float64bits(float64(v.Cog)))
+	}
+	if v.TrueHeading != 0 {
+		b = appendVarintField(b, 9, uint64(v.TrueHeading))
+	}
+	if v.Timestamp != 0 {
+		b = appendVarintField(b, 10, uint64(v.Timestamp))
+	}
+	if v.Spare2 != 0 {
+		b = appendVarintField(b, 11, uint64(v.Spare2))
+	}
+	if v.ClassBUnit {
+		b = appendVarintField(b, 12, 1)
+	}
+	if v.ClassBDisplay {
+		b = appendVarintField(b, 13, 1)
+	}
+	if v.ClassBDsc {
+		b = appendVarintField(b, 14, 1)
+	}
+	if v.ClassBBand {
+		b = appendVarintField(b, 15, 1)
+	}
+	if v.ClassBMsg22 {
+		b = appendVarintField(b, 16, 1)
+	}
+	if v.AssignedMode {
+		b = appendVarintField(b, 17, 1)
+	}
+	if v.Raim {
+		b = appendVarintField(b, 18, 1)
+	}
+	b = appendMessageField(b, 19, marshalCommunicationStateItdma(nil, &v.CommunicationStateItdma))
+	return b
+}
+
+func unmarshalStandardClassBPositionReport(data []byte, v *ais.StandardClassBPositionReport) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalHeader(f.data, &v.Header); err != nil {
+				return err
+			}
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare1 = uint8(f.value)
+		case 4:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Sog = ais.Field10(math.Float64frombits(f.value))
+		case 5:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.PositionAccuracy = f.value != 0
+		case 6:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Longitude = ais.FieldLatLonFine(math.Float64frombits(f.value))
+		case 7:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Latitude = ais.FieldLatLonFine(math.Float64frombits(f.value))
+		case 8:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Cog = ais.Field10(math.Float64frombits(f.value))
+		case 9:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.TrueHeading = uint16(f.value)
+		case 10:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Timestamp = uint8(f.value)
+		case 11:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare2 = uint8(f.value)
+		case 12:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.ClassBUnit = f.value != 0
+		case 13:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.ClassBDisplay = f.value != 0
+		case 14:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.ClassBDsc = f.value != 0
+		case 15:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.ClassBBand = f.value != 0
+		case 16:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.ClassBMsg22 = f.value != 0
+		case 17:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.AssignedMode = f.value != 0
+		case 18:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Raim = f.value != 0
+		case 19:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalCommunicationStateItdma(f.data, &v.CommunicationStateItdma); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func marshalExtendedClassBPositionReport(b []byte, v *ais.ExtendedClassBPositionReport) []byte {
+	b = appendMessageField(b, 1, marshalHeader(nil, &v.Header))
+	if v.Valid {
+		b = appendVarintField(b, 2, 1)
+	}
+	if v.Spare1 != 0 {
+		b = appendVarintField(b, 3, uint64(v.Spare1))
+	}
+	if v.Sog != 0 {
+		b = appendFixed64Field(b, 4, math.Float64bits(float64(v.Sog)))
+	}
+	if v.PositionAccuracy {
+		b = appendVarintField(b, 5, 1)
+	}
+	if v.Longitude != 0 {
+		b = appendFixed64Field(b, 6, math.Float64bits(float64(v.Longitude)))
+	}
+	if v.Latitude != 0 {
+		b = appendFixed64Field(b, 7, math.Float64bits(float64(v.Latitude)))
+	}
+	if v.Cog != 0 {
+		b = appendFixed64Field(b, 8, math.Float64bits(float64(v.Cog)))
+	}
+	if v.TrueHeading != 0 {
+		b = appendVarintField(b, 9, uint64(v.TrueHeading))
+	}
+	if v.Timestamp != 0 {
+		b = appendVarintField(b, 10, uint64(v.Timestamp))
+	}
+	if v.Spare2 != 0 {
+		b = appendVarintField(b, 11, uint64(v.Spare2))
+	}
+	if len(v.Name) > 0 {
+		b = appendBytesField(b, 12, []byte(v.Name))
+	}
+	if v.Type != 0 {
+		b = appendVarintField(b, 13, uint64(v.Type))
+	}
+	b = appendMessageField(b, 14, marshalFieldDimension(nil, &v.Dimension))
+	if v.FixType != 0 {
+		b = appendVarintField(b, 15, uint64(v.FixType))
+	}
+	if v.Raim {
+		b = appendVarintField(b, 16, 1)
+	}
+	if v.Dte {
+		b = appendVarintField(b, 17, 1)
+	}
+	if v.AssignedMode {
+		b = appendVarintField(b, 18, 1)
+	}
+	if v.Spare3 != 0 {
+		b = appendVarintField(b, 19, uint64(v.Spare3))
+	}
+	return b
+}
+
+func unmarshalExtendedClassBPositionReport(data []byte, v *ais.ExtendedClassBPositionReport) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalHeader(f.data, &v.Header); err != nil {
+				return err
+			}
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare1 = uint8(f.value)
+		case 4:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Sog = ais.Field10(math.Float64frombits(f.value))
+		case 5:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.PositionAccuracy = f.value != 0
+		case 6:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Longitude = ais.FieldLatLonFine(math.Float64frombits(f.value))
+		case 7:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Latitude = ais.FieldLatLonFine(math.Float64frombits(f.value))
+		case 8:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Cog = ais.Field10(math.Float64frombits(f.value))
+		case 9:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.TrueHeading = uint16(f.value)
+		case 10:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Timestamp = uint8(f.value)
+		case 11:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare2 = uint8(f.value)
+		case 12:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			v.Name = string(f.data)
+		case 13:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Type = uint8(f.value)
+		case 14:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalFieldDimension(f.data, &v.Dimension); err != nil {
+				return err
+			}
+		case 15:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.FixType = uint8(f.value)
+		case 16:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Raim = f.value != 0
+		case 17:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Dte = f.value != 0
+		case 18:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.AssignedMode = f.value != 0
+		case 19:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare3 = uint8(f.value)
+		}
+	}
+	return nil
+}
+
+func marshalAidsToNavigationReport(b []byte, v *ais.AidsToNavigationReport) []byte {
+	b = appendMessageField(b, 1, marshalHeader(nil, &v.Header))
+	if v.Valid {
+		b = appendVarintField(b, 2, 1)
+	}
+	if v.Type != 0 {
+		b = appendVarintField(b, 3, uint64(v.Type))
+	}
+	if len(v.Name) > 0 {
+		b = appendBytesField(b, 4, []byte(v.Name))
+	}
+	if v.PositionAccuracy {
+		b = appendVarintField(b, 5, 1)
+	}
+	if v.Longitude != 0 {
+		b = appendFixed64Field(b, 6, math.Float64bits(float64(v.Longitude)))
+	}
+	if v.Latitude != 0 {
+		b = appendFixed64Field(b, 7, math.Float64bits(float64(v.Latitude)))
+	}
+	b = appendMessageField(b, 8, marshalFieldDimension(nil, &v.Dimension))
+	if v.Fixtype != 0 {
+		b = appendVarintField(b, 9, uint64(v.Fixtype))
+	}
+	if v.Timestamp != 0 {
+		b = appendVarintField(b, 10, uint64(v.Timestamp))
+	}
+	if v.OffPosition {
+		b = appendVarintField(b, 11, 1)
+	}
+	if v.AtoN != 0 {
+		b = appendVarintField(b, 12, uint64(v.AtoN))
+	}
+	if v.Raim {
+		b = appendVarintField(b, 13, 1)
+	}
+	if v.VirtualAtoN {
+		b = appendVarintField(b, 14, 1)
+	}
+	if v.AssignedMode {
+		b = appendVarintField(b, 15, 1)
+	}
+	if v.Spare {
+		b = appendVarintField(b, 16, 1)
+	}
+	if len(v.NameExtension) > 0 {
+		b = appendBytesField(b, 17, []byte(v.NameExtension))
+	}
+	return b
+}
+
+func unmarshalAidsToNavigationReport(data []byte, v *ais.AidsToNavigationReport) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalHeader(f.data, &v.Header); err != nil {
+				return err
+			}
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Type = uint8(f.value)
+		case 4:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			v.Name = string(f.data)
+		case 5:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.PositionAccuracy = f.value != 0
+		case 6:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Longitude = ais.FieldLatLonFine(math.Float64frombits(f.value))
+		case 7:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Latitude = ais.FieldLatLonFine(math.Float64frombits(f.value))
+		case 8:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalFieldDimension(f.data, &v.Dimension); err != nil {
+				return err
+			}
+		case 9:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Fixtype = uint8(f.value)
+		case 10:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Timestamp = uint8(f.value)
+		case 11:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.OffPosition = f.value != 0
+		case 12:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.AtoN = uint8(f.value)
+		case 13:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Raim = f.value != 0
+		case 14:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.VirtualAtoN = f.value != 0
+		case 15:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.AssignedMode = f.value != 0
+		case 16:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare = f.value != 0
+		case 17:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			v.NameExtension = string(f.data)
+		}
+	}
+	return nil
+}
+
+func marshalGroupAssignmentCommand(b []byte, v *ais.GroupAssignmentCommand) []byte {
+	b = appendMessageField(b, 1, marshalHeader(nil, &v.Header))
+	if v.Valid {
+		b = appendVarintField(b, 2, 1)
+	}
+	if v.Spare1 != 0 {
+		b = appendVarintField(b, 3, uint64(v.Spare1))
+	}
+	if v.Longitude1 != 0 {
+		b = appendFixed64Field(b, 4, math.Float64bits(float64(v.Longitude1)))
+	}
+	if v.Latitude1 != 0 {
+		b = appendFixed64Field(b, 5, math.Float64bits(float64(v.Latitude1)))
+	}
+	if v.Longitude2 != 0 {
+		b = appendFixed64Field(b, 6, math.Float64bits(float64(v.Longitude2)))
+	}
+	if v.Latitude2 != 0 {
+		b = appendFixed64Field(b, 7, math.Float64bits(float64(v.Latitude2)))
+	}
+	if v.StationType != 0 {
+		b = appendVarintField(b, 8, uint64(v.StationType))
+	}
+	if v.ShipType != 0 {
+		b = appendVarintField(b, 9, uint64(v.ShipType))
+	}
+	if v.Spare2 != 0 {
+		b = appendVarintField(b, 10, uint64(v.Spare2))
+	}
+	if v.TxRxMode != 0 {
+		b = appendVarintField(b, 11, uint64(v.TxRxMode))
+	}
+	if v.ReportingInterval != 0 {
+		b = appendVarintField(b, 12, uint64(v.ReportingInterval))
+	}
+	if v.QuietTime != 0 {
+		b = appendVarintField(b, 13, uint64(v.QuietTime))
+	}
+	if v.Spare3 != 0 {
+		b = appendVarintField(b, 14, uint64(v.Spare3))
+	}
+	return b
+}
+
+func unmarshalGroupAssignmentCommand(data []byte, v *ais.GroupAssignmentCommand) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalHeader(f.data, &v.Header); err != nil {
+				return err
+			}
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare1 = uint8(f.value)
+		case 4:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Longitude1 = ais.FieldLatLonCoarse(math.Float64frombits(f.value))
+		case 5:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Latitude1 = ais.FieldLatLonCoarse(math.Float64frombits(f.value))
+		case 6:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Longitude2 = ais.FieldLatLonCoarse(math.Float64frombits(f.value))
+		case 7:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Latitude2 = ais.FieldLatLonCoarse(math.Float64frombits(f.value))
+		case 8:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.StationType = uint8(f.value)
+		case 9:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.ShipType = uint8(f.value)
+		case 10:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare2 = uint32(f.value)
+		case 11:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.TxRxMode = uint8(f.value)
+		case 12:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.ReportingInterval = uint8(f.value)
+		case 13:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.QuietTime = uint8(f.value)
+		case 14:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare3 = uint8(f.value)
+		}
+	}
+	return nil
+}
+
+func marshalStaticDataReportA(b []byte, v *ais.StaticDataReportA) []byte {
+	if v.Valid {
+		b = appendVarintField(b, 1, 1)
+	}
+	if len(v.Name) > 0 {
+		b = appendBytesField(b, 2, []byte(v.Name))
+	}
+	return b
+}
+
+func unmarshalStaticDataReportA(data []byte, v *ais.StaticDataReportA) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 2:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			v.Name = string(f.data)
+		}
+	}
+	return nil
+}
+
+func marshalStaticDataReportB(b []byte, v *ais.StaticDataReportB) []byte {
+	if v.Valid {
+		b = appendVarintField(b, 1, 1)
+	}
+	if v.ShipType != 0 {
+		b = appendVarintField(b, 2, uint64(v.ShipType))
+	}
+	if len(v.VendorIDName) > 0 {
+		b = appendBytesField(b, 3, []byte(v.VendorIDName))
+	}
+	if v.VenderIDModel != 0 {
+		b = appendVarintField(b, 4, uint64(v.VenderIDModel))
+	}
+	if v.VenderIDSerial != 0 {
+		b = appendVarintField(b, 5, uint64(v.VenderIDSerial))
+	}
+	if len(v.CallSign) > 0 {
+		b = appendBytesField(b, 6, []byte(v.CallSign))
+	}
+	b = appendMessageField(b, 7, marshalFieldDimension(nil, &v.Dimension))
+	if v.FixType != 0 {
+		b = appendVarintField(b, 8, uint64(v.FixType))
+	}
+	if v.Spare != 0 {
+		b = appendVarintField(b, 9, uint64(v.Spare))
+	}
+	return b
+}
+
+func unmarshalStaticDataReportB(data []byte, v *ais.StaticDataReportB) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.ShipType = uint8(f.value)
+		case 3:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			v.VendorIDName = string(f.data)
+		case 4:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.VenderIDModel = uint8(f.value)
+		case 5:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.VenderIDSerial = uint32(f.value)
+		case 6:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			v.CallSign = string(f.data)
+		case 7:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalFieldDimension(f.data, &v.Dimension); err != nil {
+				return err
+			}
+		case 8:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.FixType = uint8(f.value)
+		case 9:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare = uint8(f.value)
+		}
+	}
+	return nil
+}
+
+func marshalStaticDataReport(b []byte, v *ais.StaticDataReport) []byte {
+	b = appendMessageField(b, 1, marshalHeader(nil, &v.Header))
+	if v.Valid {
+		b = appendVarintField(b, 2, 1)
+	}
+	if v.Reserved != 0 {
+		b = appendVarintField(b, 3, uint64(v.Reserved))
+	}
+	if v.PartNumber {
+		b = appendVarintField(b, 4, 1)
+	}
+	b = appendMessageField(b, 5, marshalStaticDataReportA(nil, &v.ReportA))
+	b = appendMessageField(b, 6, marshalStaticDataReportB(nil, &v.ReportB))
+	return b
+}
+
+func unmarshalStaticDataReport(data []byte, v *ais.StaticDataReport) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalHeader(f.data, &v.Header); err != nil {
+				return err
+			}
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Reserved = uint8(f.value)
+		case 4:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.PartNumber = f.value != 0
+		case 5:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalStaticDataReportA(f.data, &v.ReportA); err != nil {
+				return err
+			}
+		case 6:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalStaticDataReportB(f.data, &v.ReportB); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func marshalLongRangeAisBroadcastMessage(b []byte, v *ais.LongRangeAisBroadcastMessage) []byte {
+	b = appendMessageField(b, 1, marshalHeader(nil, &v.Header))
+	if v.Valid {
+		b = appendVarintField(b, 2, 1)
+	}
+	if v.PositionAccuracy {
+		b = appendVarintField(b, 3, 1)
+	}
+	if v.Raim {
+		b = appendVarintField(b, 4, 1)
+	}
+	if v.NavigationalStatus != 0 {
+		b = appendVarintField(b, 5, uint64(v.NavigationalStatus))
+	}
+	if v.Longitude != 0 {
+		b = appendFixed64Field(b, 6, math.Float64bits(float64(v.Longitude)))
+	}
+	if v.Latitude != 0 {
+		b = appendFixed64Field(b, 7, math.Float64bits(float64(v.Latitude)))
+	}
+	if v.Sog != 0 {
+		b = appendVarintField(b, 8, uint64(v.Sog))
+	}
+	if v.Cog != 0 {
+		b = appendVarintField(b, 9, uint64(v.Cog))
+	}
+	if v.PositionLatency {
+		b = appendVarintField(b, 10, 1)
+	}
+	if v.Spare {
+		b = appendVarintField(b, 11, 1)
+	}
+	return b
+}
+
+func unmarshalLongRangeAisBroadcastMessage(data []byte, v *ais.LongRangeAisBroadcastMessage) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalHeader(f.data, &v.Header); err != nil {
+				return err
+			}
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.PositionAccuracy = f.value != 0
+		case 4:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Raim = f.value != 0
+		case 5:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.NavigationalStatus = uint8(f.value)
+		case 6:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Longitude = ais.FieldLatLonCoarse(math.Float64frombits(f.value))
+		case 7:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Latitude = ais.FieldLatLonCoarse(math.Float64frombits(f.value))
+		case 8:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Sog = uint8(f.value)
+		case 9:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Cog = uint16(f.value)
+		case 10:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.PositionLatency = f.value != 0
+		case 11:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare = f.value != 0
+		}
+	}
+	return nil
+}
+
+func marshalBinaryAcknowledgeData(b []byte, v *ais.BinaryAcknowledgeData) []byte {
+	if v.Valid {
+		b = appendVarintField(b, 1, 1)
+	}
+	if v.DestinationID != 0 {
+		b = appendVarintField(b, 2, uint64(v.DestinationID))
+	}
+	if v.SequenceNumber != 0 {
+		b = appendVarintField(b, 3, uint64(v.SequenceNumber))
+	}
+	return b
+}
+
+func unmarshalBinaryAcknowledgeData(data []byte, v *ais.BinaryAcknowledgeData) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.DestinationID = uint32(f.value)
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.SequenceNumber = uint8(f.value)
+		}
+	}
+	return nil
+}
+
+func marshalBinaryAcknowledge(b []byte, v *ais.BinaryAcknowledge) []byte {
+	b = appendMessageField(b, 1, marshalHeader(nil, &v.Header))
+	if v.Valid {
+		b = appendVarintField(b, 2, 1)
+	}
+	if v.Spare != 0 {
+		b = appendVarintField(b, 3, uint64(v.Spare))
+	}
+	for i := range v.Destinations {
+		b = appendMessageField(b, 4, marshalBinaryAcknowledgeData(nil, &v.Destinations[i]))
+	}
+	return b
+}
+
+func unmarshalBinaryAcknowledge(data []byte, v *ais.BinaryAcknowledge) error {
+	nDestinations := 0
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalHeader(f.data, &v.Header); err != nil {
+				return err
+			}
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare = uint8(f.value)
+		case 4:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if nDestinations >= len(v.Destinations) {
+				return errTooManyElements
+			}
+			if err := unmarshalBinaryAcknowledgeData(f.data, &v.Destinations[nDestinations]); err != nil {
+				return err
+			}
+			nDestinations++
+		}
+	}
+	return nil
+}
+
+func marshalInterrogationStation1Message1(b []byte, v *ais.InterrogationStation1Message1) []byte {
+	if v.Valid {
+		b = appendVarintField(b, 1, 1)
+	}
+	if v.StationID != 0 {
+		b = appendVarintField(b, 2, uint64(v.StationID))
+	}
+	if v.MessageID != 0 {
+		b = appendVarintField(b, 3, uint64(v.MessageID))
+	}
+	if v.SlotOffset != 0 {
+		b = appendVarintField(b, 4, uint64(v.SlotOffset))
+	}
+	return b
+}
+
+func unmarshalInterrogationStation1Message1(data []byte, v *ais.InterrogationStation1Message1) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.StationID = uint32(f.value)
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.MessageID = uint8(f.value)
+		case 4:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.SlotOffset = uint16(f.value)
+		}
+	}
+	return nil
+}
+
+func marshalInterrogationStation1Message2(b []byte, v *ais.InterrogationStation1Message2) []byte {
+	if v.Valid {
+		b = appendVarintField(b, 1, 1)
+	}
+	if v.Spare != 0 {
+		b = appendVarintField(b, 2, uint64(v.Spare))
+	}
+	if v.MessageID != 0 {
+		b = appendVarintField(b, 3, uint64(v.MessageID))
+	}
+	if v.SlotOffset != 0 {
+		b = appendVarintField(b, 4, uint64(v.SlotOffset))
+	}
+	return b
+}
+
+func unmarshalInterrogationStation1Message2(data []byte, v *ais.InterrogationStation1Message2) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare = uint8(f.value)
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.MessageID = uint8(f.value)
+		case 4:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.SlotOffset = uint16(f.value)
+		}
+	}
+	return nil
+}
+
+func marshalInterrogationStation2(b []byte, v *ais.InterrogationStation2) []byte {
+	if v.Valid {
+		b = appendVarintField(b, 1, 1)
+	}
+	if v.Spare1 != 0 {
+		b = appendVarintField(b, 2, uint64(v.Spare1))
+	}
+	if v.StationID != 0 {
+		b = appendVarintField(b, 3, uint64(v.StationID))
+	}
+	if v.MessageID != 0 {
+		b = appendVarintField(b, 4, uint64(v.MessageID))
+	}
+	if v.SlotOffset != 0 {
+		b = appendVarintField(b, 5, uint64(v.SlotOffset))
+	}
+	if v.Spare2 != 0 {
+		b = appendVarintField(b, 6, uint64(v.Spare2))
+	}
+	return b
+}
+
+func unmarshalInterrogationStation2(data []byte, v *ais.InterrogationStation2) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare1 = uint8(f.value)
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.StationID = uint32(f.value)
+		case 4:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.MessageID = uint8(f.value)
+		case 5:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.SlotOffset = uint16(f.value)
+		case 6:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare2 = uint8(f.value)
+		}
+	}
+	return nil
+}
+
+func marshalInterrogation(b []byte, v *ais.Interrogation) []byte {
+	b = appendMessageField(b, 1, marshalHeader(nil, &v.Header))
+	if v.Valid {
+		b = appendVarintField(b, 2, 1)
+	}
+	if v.Spare != 0 {
+		b = appendVarintField(b, 3, uint64(v.Spare))
+	}
+	b = appendMessageField(b, 4, marshalInterrogationStation1Message1(nil, &v.Station1Msg1))
+	b = appendMessageField(b, 5, marshalInterrogationStation1Message2(nil, &v.Station1Msg2))
+	b = appendMessageField(b, 6, marshalInterrogationStation2(nil, &v.Station2))
+	return b
+}
+
+func unmarshalInterrogation(data []byte, v *ais.Interrogation) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalHeader(f.data, &v.Header); err != nil {
+				return err
+			}
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare = uint8(f.value)
+		case 4:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalInterrogationStation1Message1(f.data, &v.Station1Msg1); err != nil {
+				return err
+			}
+		case 5:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalInterrogationStation1Message2(f.data, &v.Station1Msg2); err != nil {
+				return err
+			}
+		case 6:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalInterrogationStation2(f.data, &v.Station2); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func marshalAssignedModeCommandData(b []byte, v *ais.AssignedModeCommandData) []byte {
+	if v.Valid {
+		b = appendVarintField(b, 1, 1)
+	}
+	if v.DestinationID != 0 {
+		b = appendVarintField(b, 2, uint64(v.DestinationID))
+	}
+	if v.Offset != 0 {
+		b = appendVarintField(b, 3, uint64(v.Offset))
+	}
+	if v.Increment != 0 {
+		b = appendVarintField(b, 4, uint64(v.Increment))
+	}
+	return b
+}
+
+func unmarshalAssignedModeCommandData(data []byte, v *ais.AssignedModeCommandData) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.DestinationID = uint32(f.value)
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Offset = uint16(f.value)
+		case 4:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Increment = uint16(f.value)
+		}
+	}
+	return nil
+}
+
+func marshalAssignedModeCommand(b []byte, v *ais.AssignedModeCommand) []byte {
+	b = appendMessageField(b, 1, marshalHeader(nil, &v.Header))
+	if v.Valid {
+		b = appendVarintField(b, 2, 1)
+	}
+	if v.Spare != 0 {
+		b = appendVarintField(b, 3, uint64(v.Spare))
+	}
+	for i := range v.Commands {
+		b = appendMessageField(b, 4, marshalAssignedModeCommandData(nil, &v.Commands[i]))
+	}
+	return b
+}
+
+func unmarshalAssignedModeCommand(data []byte, v *ais.AssignedModeCommand) error {
+	nCommands := 0
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalHeader(f.data, &v.Header); err != nil {
+				return err
+			}
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare = uint8(f.value)
+		case 4:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if nCommands >= len(v.Commands) {
+				return errTooManyElements
+			}
+			if err := unmarshalAssignedModeCommandData(f.data, &v.Commands[nCommands]); err != nil {
+				return err
+			}
+			nCommands++
+		}
+	}
+	return nil
+}
+
+func marshalDataLinkManagementMessageData(b []byte, v *ais.DataLinkManagementMessageData) []byte {
+	if v.Valid {
+		b = appendVarintField(b, 1, 1)
+	}
+	if v.Offset != 0 {
+		b = appendVarintField(b, 2, uint64(v.Offset))
+	}
+	if v.NumberOfSlots != 0 {
+		b = appendVarintField(b, 3, uint64(v.NumberOfSlots))
+	}
+	if v.TimeOut != 0 {
+		b = appendVarintField(b, 4, uint64(v.TimeOut))
+	}
+	if v.Increment != 0 {
+		b = appendVarintField(b, 5, uint64(v.Increment))
+	}
+	return b
+}
+
+func unmarshalDataLinkManagementMessageData(data []byte, v *ais.DataLinkManagementMessageData) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Offset = uint16(f.value)
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.NumberOfSlots = uint8(f.value)
+		case 4:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.TimeOut = uint8(f.value)
+		case 5:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Increment = uint16(f.value)
+		}
+	}
+	return nil
+}
+
+func marshalDataLinkManagementMessage(b []byte, v *ais.DataLinkManagementMessage) []byte {
+	b = appendMessageField(b, 1, marshalHeader(nil, &v.Header))
+	if v.Valid {
+		b = appendVarintField(b, 2, 1)
+	}
+	if v.Spare != 0 {
+		b = appendVarintField(b, 3, uint64(v.Spare))
+	}
+	for i := range v.Data {
+		b = appendMessageField(b, 4, marshalDataLinkManagementMessageData(nil, &v.Data[i]))
+	}
+	return b
+}
+
+func unmarshalDataLinkManagementMessage(data []byte, v *ais.DataLinkManagementMessage) error {
+	nData := 0
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalHeader(f.data, &v.Header); err != nil {
+				return err
+			}
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare = uint8(f.value)
+		case 4:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if nData >= len(v.Data) {
+				return errTooManyElements
+			}
+			if err := unmarshalDataLinkManagementMessageData(f.data, &v.Data[nData]); err != nil {
+				return err
+			}
+			nData++
+		}
+	}
+	return nil
+}
+
+func marshalChannelManagementBroadcastData(b []byte, v *ais.ChannelManagementBroadcastData) []byte {
+	if v.Longitude1 != 0 {
+		b = appendFixed64Field(b, 1, math.Float64bits(float64(v.Longitude1)))
+	}
+	if v.Latitude1 != 0 {
+		b = appendFixed64Field(b, 2, math.Float64bits(float64(v.Latitude1)))
+	}
+	if v.Longitude2 != 0 {
+		b = appendFixed64Field(b, 3, math.Float64bits(float64(v.Longitude2)))
+	}
+	if v.Latitude2 != 0 {
+		b = appendFixed64Field(b, 4, math.Float64bits(float64(v.Latitude2)))
+	}
+	return b
+}
+
+func unmarshalChannelManagementBroadcastData(data []byte, v *ais.ChannelManagementBroadcastData) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Longitude1 = ais.FieldLatLonCoarse(math.Float64frombits(f.value))
+		case 2:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Latitude1 = ais.FieldLatLonCoarse(math.Float64frombits(f.value))
+		case 3:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Longitude2 = ais.FieldLatLonCoarse(math.Float64frombits(f.value))
+		case 4:
+			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			v.Latitude2 = ais.FieldLatLonCoarse(math.Float64frombits(f.value))
+		}
+	}
+	return nil
+}
+
+func marshalChannelManagementUnicastData(b []byte, v *ais.ChannelManagementUnicastData) []byte {
+	if v.AddressStation1 != 0 {
+		b = appendVarintField(b, 1, uint64(v.AddressStation1))
+	}
+	if v.Spare2 != 0 {
+		b = appendVarintField(b, 2, uint64(v.Spare2))
+	}
+	if v.AddressStation2 != 0 {
+		b = appendVarintField(b, 3, uint64(v.AddressStation2))
+	}
+	if v.Spare3 != 0 {
+		b = appendVarintField(b, 4, uint64(v.Spare3))
+	}
+	return b
+}
+
+func unmarshalChannelManagementUnicastData(data []byte, v *ais.ChannelManagementUnicastData) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.AddressStation1 = uint32(f.value)
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare2 = uint8(f.value)
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.AddressStation2 = uint32(f.value)
+		case 4:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare3 = uint8(f.value)
+		}
+	}
+	return nil
+}
+
+func marshalChannelManagement(b []byte, v *ais.ChannelManagement) []byte {
+	b = appendMessageField(b, 1, marshalHeader(nil, &v.Header))
+	if v.Valid {
+		b = appendVarintField(b, 2, 1)
+	}
+	if v.Spare1 != 0 {
+		b = appendVarintField(b, 3, uint64(v.Spare1))
+	}
+	if v.ChannelA != 0 {
+		b = appendVarintField(b, 4, uint64(v.ChannelA))
+	}
+	if v.ChannelB != 0 {
+		b = appendVarintField(b, 5, uint64(v.ChannelB))
+	}
+	if v.TxRxMode != 0 {
+		b = appendVarintField(b, 6, uint64(v.TxRxMode))
+	}
+	if v.LowPower {
+		b = appendVarintField(b, 7, 1)
+	}
+	b = appendMessageField(b, 8, marshalChannelManagementBroadcastData(nil, &v.Area))
+	b = appendMessageField(b, 9, marshalChannelManagementUnicastData(nil, &v.Unicast))
+	if v.IsAddressed {
+		b = appendVarintField(b, 10, 1)
+	}
+	if v.BwA {
+		b = appendVarintField(b, 11, 1)
+	}
+	if v.BwB {
+		b = appendVarintField(b, 12, 1)
+	}
+	if v.TransitionalZoneSize != 0 {
+		b = appendVarintField(b, 13, uint64(v.TransitionalZoneSize))
+	}
+	if v.Spare4 != 0 {
+		b = appendVarintField(b, 14, uint64(v.Spare4))
+	}
+	return b
+}
+
+func unmarshalChannelManagement(data []byte, v *ais.ChannelManagement) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalHeader(f.data, &v.Header); err != nil {
+				return err
+			}
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare1 = uint8(f.value)
+		case 4:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.ChannelA = uint16(f.value)
+		case 5:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.ChannelB = uint16(f.value)
+		case 6:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.TxRxMode = uint8(f.value)
+		case 7:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.LowPower = f.value != 0
+		case 8:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalChannelManagementBroadcastData(f.data, &v.Area); err != nil {
+				return err
+			}
+		case 9:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalChannelManagementUnicastData(f.data, &v.Unicast); err != nil {
+				return err
+			}
+		case 10:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.IsAddressed = f.value != 0
+		case 11:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.BwA = f.value != 0
+		case 12:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.BwB = f.value != 0
+		case 13:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.TransitionalZoneSize = uint8(f.value)
+		case 14:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare4 = uint32(f.value)
+		}
+	}
+	return nil
+}
+
+func marshalSingleSlotBinaryMessage(b []byte, v *ais.SingleSlotBinaryMessage) []byte {
+	b = appendMessageField(b, 1, marshalHeader(nil, &v.Header))
+	if v.Valid {
+		b = appendVarintField(b, 2, 1)
+	}
+	if v.DestinationIDValid {
+		b = appendVarintField(b, 3, 1)
+	}
+	if v.ApplicationIDValid {
+		b = appendVarintField(b, 4, 1)
+	}
+	if v.DestinationID != 0 {
+		b = appendVarintField(b, 5, uint64(v.DestinationID))
+	}
+	if v.Spare != 0 {
+		b = appendVarintField(b, 6, uint64(v.Spare))
+	}
+	b = appendMessageField(b, 7, marshalFieldApplicationIdentifier(nil, &v.ApplicationID))
+	if len(v.Payload) > 0 {
+		b = appendBytesField(b, 8, []byte(v.Payload))
+	}
+	return b
+}
+
+func unmarshalSingleSlotBinaryMessage(data []byte, v *ais.SingleSlotBinaryMessage) error {
+	v.Payload = []byte{}
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalHeader(f.data, &v.Header); err != nil {
+				return err
+			}
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.DestinationIDValid = f.value != 0
+		case 4:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.ApplicationIDValid = f.value != 0
+		case 5:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.DestinationID = uint32(f.value)
+		case 6:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare = uint8(f.value)
+		case 7:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalFieldApplicationIdentifier(f.data, &v.ApplicationID); err != nil {
+				return err
+			}
+		case 8:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			v.Payload = append([]byte{}, f.data...)
+		}
+	}
+	return nil
+}
+
+func marshalMultiSlotBinaryMessage(b []byte, v *ais.MultiSlotBinaryMessage) []byte {
+	b = appendMessageField(b, 1, marshalHeader(nil, &v.Header))
+	if v.Valid {
+		b = appendVarintField(b, 2, 1)
+	}
+	if v.DestinationIDValid {
+		b = appendVarintField(b, 3, 1)
+	}
+	if v.ApplicationIDValid {
+		b = appendVarintField(b, 4, 1)
+	}
+	if v.DestinationID != 0 {
+		b = appendVarintField(b, 5, uint64(v.DestinationID))
+	}
+	if v.Spare1 != 0 {
+		b = appendVarintField(b, 6, uint64(v.Spare1))
+	}
+	b = appendMessageField(b, 7, marshalFieldApplicationIdentifier(nil, &v.ApplicationID))
+	if len(v.Payload) > 0 {
+		b = appendBytesField(b, 8, []byte(v.Payload))
+	}
+	if v.Spare2 != 0 {
+		b = appendVarintField(b, 9, uint64(v.Spare2))
+	}
+	b = appendMessageField(b, 10, marshalCommunicationStateItdma(nil, &v.CommunicationStateItdma))
+	return b
+}
+
+func unmarshalMultiSlotBinaryMessage(data []byte, v *ais.MultiSlotBinaryMessage) error {
+	v.Payload = []byte{}
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalHeader(f.data, &v.Header); err != nil {
+				return err
+			}
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.DestinationIDValid = f.value != 0
+		case 4:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.ApplicationIDValid = f.value != 0
+		case 5:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.DestinationID = uint32(f.value)
+		case 6:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare1 = uint8(f.value)
+		case 7:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalFieldApplicationIdentifier(f.data, &v.ApplicationID); err != nil {
+				return err
+			}
+		case 8:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			v.Payload = append([]byte{}, f.data...)
+		case 9:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Spare2 = uint8(f.value)
+		case 10:
+			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			if err := unmarshalCommunicationStateItdma(f.data, &v.CommunicationStateItdma); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func marshalFieldETA(b []byte, v *ais.FieldETA) []byte {
+	if v.Month != 0 {
+		b = appendVarintField(b, 1, uint64(v.Month))
+	}
+	if v.Day != 0 {
+		b = appendVarintField(b, 2, uint64(v.Day))
+	}
+	if v.Hour != 0 {
+		b = appendVarintField(b, 3, uint64(v.Hour))
+	}
+	if v.Minute != 0 {
+		b = appendVarintField(b, 4, uint64(v.Minute))
+	}
+	return b
+}
+
+func unmarshalFieldETA(data []byte, v *ais.FieldETA) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Month = uint8(f.value)
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Day = uint8(f.value)
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Hour = uint8(f.value)
+		case 4:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Minute = uint8(f.value)
+		}
+	}
+	return nil
+}
+
+func marshalFieldDimension(b []byte, v *ais.FieldDimension) []byte {
+	if v.A != 0 {
+		b = appendVarintField(b, 1, uint64(v.A))
+	}
+	if v.B != 0 {
+		b = appendVarintField(b, 2, uint64(v.B))
+	}
+	if v.C != 0 {
+		b = appendVarintField(b, 3, uint64(v.C))
+	}
+	if v.D != 0 {
+		b = appendVarintField(b, 4, uint64(v.D))
+	}
+	return b
+}
+
+func unmarshalFieldDimension(data []byte, v *ais.FieldDimension) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.A = uint16(f.value)
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.B = uint16(f.value)
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.C = uint8(f.value)
+		case 4:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.D = uint8(f.value)
+		}
+	}
+	return nil
+}
+
+func marshalFieldApplicationIdentifier(b []byte, v *ais.FieldApplicationIdentifier) []byte {
+	if v.Valid {
+		b = appendVarintField(b, 1, 1)
+	}
+	if v.DesignatedAreaCode != 0 {
+		b = appendVarintField(b, 2, uint64(v.DesignatedAreaCode))
+	}
+	if v.FunctionIdentifier != 0 {
+		b = appendVarintField(b, 3, uint64(v.FunctionIdentifier))
+	}
+	return b
+}
+
+func unmarshalFieldApplicationIdentifier(data []byte, v *ais.FieldApplicationIdentifier) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.Valid = f.value != 0
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.DesignatedAreaCode = uint16(f.value)
+		case 3:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.FunctionIdentifier = uint8(f.value)
+		}
+	}
+	return nil
+}
+
+func marshalCommunicationStateItdma(b []byte, v *ais.CommunicationStateItdma) []byte {
+	if v.CommunicationStateIsItdma {
+		b = appendVarintField(b, 1, 1)
+	}
+	if v.CommunicationState != 0 {
+		b = appendVarintField(b, 2, uint64(v.CommunicationState))
+	}
+	return b
+}
+
+func unmarshalCommunicationStateItdma(data []byte, v *ais.CommunicationStateItdma) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.CommunicationStateIsItdma = f.value != 0
+		case 2:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.CommunicationState = uint32(f.value)
+		}
+	}
+	return nil
+}
+
+func marshalCommunicationStateNoItdma(b []byte, v *ais.CommunicationStateNoItdma) []byte {
+	if v.CommunicationState != 0 {
+		b = appendVarintField(b, 1, uint64(v.CommunicationState))
+	}
+	return b
+}
+
+func unmarshalCommunicationStateNoItdma(data []byte, v *ais.CommunicationStateNoItdma) error {
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+		case 1:
+			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			v.CommunicationState = uint32(f.value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package aisproto
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+func TestRoundTrip(t *testing.T) {
+	for _, raw := range []bool{false, true} {
+		c := ais.CodecNew(false, false)
+		c.FloatWithoutConversion = raw
+
+		count := 0
+		for msgID := 1; msgID <= 27; msgID++ {
+			f, err := os.Open(fmt.Sprintf("../testmsg/%d.msg", msgID))
+			if err != nil {
+				continue
+			}
+
+			scanner := bufio.NewScanner(f)
+			for index := 0; scanner.Scan(); index++ {
+				bits := []byte(strings.TrimSpace(scanner.Text()))
+				for i := range bits {
+					bits[i] -= '0'
+				}
+
+				p := c.DecodePacket(bits)
+				if p == nil {
+					continue
+				}
+
+				data, err := Marshal(p)
+				if err != nil {
+					t.Fatal("Marshal failed", msgID, index, err)
+				}
+
+				p2, err := Unmarshal(data)
+				if err != nil {
+					t.Fatal("Unmarshal failed", msgID, index, err)
+				}
+
+				if !reflect.DeepEqual(p, p2) {
+					t.Fatalf("Round trip changed packet %d/%d:\n%+v\n%+v", msgID, index, p, p2)
+				}
+				if raw && !bytes.Equal(c.EncodePacket(p), c.EncodePacket(p2)) {
+					t.Error("Round trip changed encoding", msgID, index)
+				}
+				count++
+			}
+			f.Close()
+		}
+
+		if count == 0 {
+			t.Fatal("No test packets")
+		}
+	}
+}
+
+func TestWireFormat(t *testing.T) {
+	data, err := Marshal(ais.PositionReport{
+		Header:      ais.Header{MessageID: 1, UserID: 300},
+		Valid:       true,
+		RateOfTurn:  -2,
+		Sog:         1,
+		TrueHeading: 511,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []byte{
+		0x0a, 0x19, /* Packet.position_report, 25 bytes */
+		0x0a, 0x05, 0x08, 0x01, 0x18, 0xac, 0x02, /* header: message_id 1, user_id 300 */
+		0x10, 0x01, /* valid */
+		0x20, 0x03, /* rate_of_turn, zigzag encoded */
+		0x29, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f, /* sog as double */
+		0x50, 0xff, 0x03, /* true_heading */
+		0x7a, 0x00, /* communication_state_no_itdma, empty */
+	}
+	if !bytes.Equal(data, want) {
+		t.Errorf("Wrong encoding\n% x\n% x", data, want)
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	valid, _ := Marshal(ais.PositionReport{Header: ais.Header{MessageID: 1}, Valid: true})
+
+	for _, data := range [][]byte{
+		nil,
+		valid[:len(valid)-1],
+		{0x0a, 0x02, 0x09, 0x00},       /* header with fixed64 wire type, truncated */
+		{0x0a, 0x02, 0x0d, 0x00},       /* header with wire type 5, truncated */
+		{0x0a, 0x03, 0x0a, 0x01, 0x00}, /* header as a length delimited field, invalid */
+		{0x3a, 0x05, 0x1a, 0x00, 0x1a, 0x00, 0x1a}, /* truncated acknowledge */
+	} {
+		if _, err := Unmarshal(data); err == nil {
+			t.Errorf("Invalid message accepted % x", data)
+		}
+	}
+
+	/* More destinations than the array can hold */
+	var many []byte
+	for i := 0; i < 5; i++ {
+		many = appendMessageField(many, 4, nil)
+	}
+	if _, err := Unmarshal(appendMessageField(nil, 7, many)); err != errTooManyElements {
+		t.Error("Too many elements accepted", err)
+	}
+
+	/* Unknown fields are skipped */
+	data := appendVarintField(append([]byte(nil), valid...), 100, 1)
+	if _, err := Unmarshal(data); err != nil {
+		t.Error("Unknown field rejected", err)
+	}
+
+	if _, err := Marshal(nil); err == nil {
+		t.Error("Nil packet accepted")
+	}
+}
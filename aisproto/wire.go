@@ -0,0 +1,146 @@
+// Package aisproto converts AIS packets to and from Protocol Buffers messages. The schema is in
+// ais.proto; it and the conversion functions are generated by parser_generator from the structs in
+// the ais package, so every field of every packet type is preserved.
+//
+// The wire encoding is implemented directly, so no protobuf runtime is needed. Other languages can
+// use ais.proto with their usual protobuf tooling. Binary data fields use one byte per bit, like
+// the ais package does.
+package aisproto
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+/* Protobuf wire types */
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var (
+	errTruncated       = errors.New("aisproto: message truncated")
+	errTooManyElements = errors.New("aisproto: too many elements in repeated field")
+	errNoPacket        = errors.New("aisproto: message contains no packet")
+)
+
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func appendTag(b []byte, number int, wireType int) []byte {
+	return appendVarint(b, uint64(number)<<3|uint64(wireType))
+}
+
+func appendVarintField(b []byte, number int, v uint64) []byte {
+	return appendVarint(appendTag(b, number, wireVarint), v)
+}
+
+func appendFixed64Field(b []byte, number int, v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(appendTag(b, number, wireFixed64), buf[:]...)
+}
+
+func appendBytesField(b []byte, number int, data []byte) []byte {
+	b = appendVarint(appendTag(b, number, wireBytes), uint64(len(data)))
+	return append(b, data...)
+}
+
+func appendMessageField(b []byte, number int, msg []byte) []byte {
+	return appendBytesField(b, number, msg)
+}
+
+func zigzagEncode(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+func zigzagDecode(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+// field is a decoded field of a message
+type field struct {
+	number   int
+	wireType int
+	value    uint64 /* Varint and fixed values */
+	data     []byte /* Length delimited values */
+}
+
+func (f *field) expect(wireType int) error {
+	if f.wireType != wireType {
+		return fmt.Errorf("aisproto: unexpected wire type for field [%d: %d]", f.number, f.wireType)
+	}
+	return nil
+}
+
+func readVarint(data []byte) (uint64, []byte, error) {
+	var v uint64
+	for i := 0; i < len(data) && i < 10; i++ {
+		v |= uint64(data[i]&0x7F) << uint(7*i)
+		if data[i] < 0x80 {
+			return v, data[i+1:], nil
+		}
+	}
+	return 0, nil, errTruncated
+}
+
+// readField decodes the first field in data and returns the remaining data. Unknown wire types
+// result in an error, unknown field numbers are left to the caller.
+func readField(data []byte) (field, []byte, error) {
+	tag, data, err := readVarint(data)
+	if err != nil {
+		return field{}, nil, err
+	}
+
+	f := field{number: int(tag >> 3), wireType: int(tag & 7)}
+	if f.number == 0 {
+		return field{}, nil, errors.New("aisproto: invalid field number 0")
+	}
+
+	switch f.wireType {
+	case wireVarint:
+		f.value, data, err = readVarint(data)
+		if err != nil {
+			return field{}, nil, err
+		}
+
+	case wireFixed64:
+		if len(data) < 8 {
+			return field{}, nil, errTruncated
+		}
+		f.value = binary.LittleEndian.Uint64(data)
+		data = data[8:]
+
+	case wireFixed32:
+		if len(data) < 4 {
+			return field{}, nil, errTruncated
+		}
+		f.value = uint64(binary.LittleEndian.Uint32(data))
+		data = data[4:]
+
+	case wireBytes:
+		var n uint64
+		n, data, err = readVarint(data)
+		if err != nil {
+			return field{}, nil, err
+		}
+		if n > uint64(len(data)) {
+			return field{}, nil, errTruncated
+		}
+		f.data = data[:n]
+		data = data[n:]
+
+	default:
+		return field{}, nil, fmt.Errorf("aisproto: unsupported wire type [%d]", f.wireType)
+	}
+
+	return f, data, nil
+}
@@ -0,0 +1,160 @@
+// Package aisreplay plays back captured NMEA feeds with the timing of the original reception.
+//
+// A capture is a text file with one sentence per line. The receive time is taken from a UNIX
+// timestamp in front of the sentence (seconds, milliseconds, microseconds or nanoseconds, as in
+// aisnmea/testdata/aistest.nmea) or from the c: parameter of a TAG Block. Lines without a time
+// inherit the time of the line before them.
+//
+// The sentences of a multi-sentence message are kept together in one Message, so a Player never
+// splits them, even when the capture is played as fast as possible or a seek lands in the middle.
+//
+// The Outputs send sentences to a file, TCP clients or a UDP destination. They are also used by
+// cmd/aissim.
+package aisreplay
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BertoldVdb/go-ais/aisnmea"
+)
+
+// Message contains the lines that are sent together
+type Message struct {
+	Time  time.Time // Receive time, zero if no time is known
+	Lines []string  // Lines without the time prefix, a TAG Block is kept
+}
+
+// SplitTimePrefix removes a UNIX timestamp from the start of the line. The unit is derived from
+// the magnitude of the number, fractional seconds are accepted as well.
+func SplitTimePrefix(line string) (time.Time, string) {
+	i := 0
+	for i < len(line) && (line[i] >= '0' && line[i] <= '9' || line[i] == '.') {
+		i++
+	}
+	if i == 0 || i == len(line) || (line[i] != ' ' && line[i] != '\t') {
+		return time.Time{}, line
+	}
+
+	number := line[:i]
+	rest := line[i+1:]
+
+	if v, err := strconv.ParseInt(number, 10, 64); err == nil {
+		switch {
+		case v >= 1e17:
+			return time.Unix(0, v).UTC(), rest
+		case v >= 1e14:
+			return time.Unix(0, v*1e3).UTC(), rest
+		case v >= 1e11:
+			return time.Unix(0, v*1e6).UTC(), rest
+		}
+		return time.Unix(v, 0).UTC(), rest
+	}
+
+	if v, err := strconv.ParseFloat(number, 64); err == nil {
+		sec := int64(v)
+		return time.Unix(sec, int64((v-float64(sec))*1e9)).Round(time.Microsecond).UTC(), rest
+	}
+	return time.Time{}, line
+}
+
+// fragment is the position of a line in a multi-sentence message
+type fragment struct {
+	key   string // Identifies the message the line belongs to
+	num   int    // Number of the line, starting at 1
+	total int    // Number of lines in the message
+}
+
+// splitTagBlock returns the TAG Block of a line, if it has one
+func splitTagBlock(line string) (aisnmea.TagBlock, string, bool) {
+	if len(line) == 0 || line[0] != '\\' {
+		return aisnmea.TagBlock{}, line, false
+	}
+
+	end := strings.IndexByte(line[1:], '\\')
+	if end < 0 {
+		return aisnmea.TagBlock{}, line, false
+	}
+
+	tagBlock, err := aisnmea.ParseTagBlock(line[1 : end+1])
+	return tagBlock, line[end+2:], err == nil
+}
+
+// fragmentOf finds out if a line is part of a multi-sentence message. A TAG Block group (g:) takes
+// precedence over the fragment fields of a VDM or VDO sentence.
+func fragmentOf(tagBlock aisnmea.TagBlock, sentence string) (fragment, bool) {
+	if g := tagBlock.Group; g.Total > 1 {
+		return fragment{key: "g" + strconv.FormatInt(g.ID, 10), num: g.Sentence, total: g.Total}, true
+	}
+
+	/* !AIVDM,2,1,3,A,... */
+	fields := strings.SplitN(sentence, ",", 6)
+	if len(fields) < 6 || len(fields[0]) != 6 || fields[0][0] != '!' {
+		return fragment{}, false
+	}
+	if typ := fields[0][3:]; typ != "VDM" && typ != "VDO" {
+		return fragment{}, false
+	}
+
+	total, err1 := strconv.Atoi(fields[1])
+	num, err2 := strconv.Atoi(fields[2])
+	if err1 != nil || err2 != nil || total < 2 || num < 1 || num > total {
+		return fragment{}, false
+	}
+	return fragment{key: fields[0] + "," + fields[3] + "," + fields[4], num: num, total: total}, true
+}
+
+// ReadCapture reads all messages of a capture. Empty lines are skipped. Messages are returned in
+// the order of their first line, incomplete multi-sentence messages are kept as they are.
+func ReadCapture(r io.Reader) ([]Message, error) {
+	var result []Message
+	var last time.Time
+
+	/* Index in result of the messages that are waiting for more lines */
+	pending := make(map[string]int)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		t, line := SplitTimePrefix(strings.TrimSpace(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		tagBlock, sentence, ok := splitTagBlock(line)
+		if t.IsZero() && ok {
+			t = tagBlock.Timestamp().UTC()
+		}
+		if t.IsZero() {
+			t = last
+		}
+		last = t
+
+		frag, ok := fragmentOf(tagBlock, sentence)
+		if ok {
+			if i, found := pending[frag.key]; found && frag.num == len(result[i].Lines)+1 {
+				result[i].Lines = append(result[i].Lines, line)
+				if result[i].Time.IsZero() {
+					result[i].Time = t
+				}
+				if frag.num == frag.total {
+					delete(pending, frag.key)
+				}
+				continue
+			}
+
+			/* The previous message with this key is incomplete, a new one only starts at line 1 */
+			delete(pending, frag.key)
+			if frag.num == 1 {
+				pending[frag.key] = len(result)
+			}
+		}
+
+		result = append(result, Message{Time: t, Lines: []string{line}})
+	}
+
+	return result, scanner.Err()
+}
@@ -0,0 +1,101 @@
+package aisreplay
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BertoldVdb/go-ais/aisnmea"
+)
+
+func TestSplitTimePrefix(t *testing.T) {
+	want := time.Date(2019, 2, 16, 7, 52, 46, 0, time.UTC)
+	for _, prefix := range []string{"1550303566", "1550303566000", "1550303566000000", "1550303566000000000", "1550303566.0"} {
+		ts, rest := SplitTimePrefix(prefix + " !AIVDM")
+		if !ts.Equal(want) || rest != "!AIVDM" {
+			t.Error("Wrong time", prefix, ts, rest)
+		}
+	}
+
+	for _, line := range []string{"!AIVDM", "123", "123!AIVDM", "\\c:1550303566*5D\\!AIVDM"} {
+		if ts, rest := SplitTimePrefix(line); !ts.IsZero() || rest != line {
+			t.Error("Prefix found in", line)
+		}
+	}
+}
+
+func TestReadCapture(t *testing.T) {
+	capture := `1550303566167726840 !AIVDM,1,1,,B,10bb7q@P0lPGHlVMhbl0Qgw>2>` + "`" + `<,0*7F
+1550303566267766932 !ABVDM,2,1,9,A,602=WITp2uLn01mVIj<04CH>NB0000PCEnUdK6UQKG=4HGIaI21:KnqQ,0*7E
+1550303566300000000 !AIVDM,1,1,,A,13u08p0000QDeLNO=PvHU3M>0>` + "`" + `<,0*00
+
+1550303566367776369 !ABVDM,2,2,9,A,M6QQKR1@JG9aI@0,2*69
+!AIVDM,2,2,9,B,M6QQKR1@JG9aI@0,2*61
+\g:1-2-73,c:1550303570*2C\!AIVDM,1,1,,A,13u08p0000QDeLNO=PvHU3M>0>` + "`" + `<,0*00
+\g:2-2-73*59\$AIALR,000000.00,001,A,V,Test*45
+`
+
+	messages, err := ReadCapture(strings.NewReader(capture))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantLines := []int{1, 2, 1, 1, 2}
+	if len(messages) != len(wantLines) {
+		t.Fatal("Wrong number of messages", len(messages), messages)
+	}
+	for i, m := range messages {
+		if len(m.Lines) != wantLines[i] {
+			t.Error("Wrong number of lines", i, m.Lines)
+		}
+	}
+
+	if !strings.HasPrefix(messages[1].Lines[0], "!ABVDM,2,1") || !strings.HasPrefix(messages[1].Lines[1], "!ABVDM,2,2") {
+		t.Error("Fragments not kept together", messages[1].Lines)
+	}
+	if messages[1].Time != time.Unix(0, 1550303566267766932).UTC() {
+		t.Error("Message does not have the time of its first line", messages[1].Time)
+	}
+
+	/* The fragment on channel B does not belong to the message on channel A */
+	if messages[3].Time != time.Unix(0, 1550303566367776369).UTC() {
+		t.Error("Line without time does not inherit the previous time", messages[3].Time)
+	}
+
+	if !messages[4].Time.Equal(time.Unix(1550303570, 0)) || !strings.HasPrefix(messages[4].Lines[0], `\g:1-2-73`) {
+		t.Error("TAG Block group not read", messages[4])
+	}
+}
+
+func TestReadTestData(t *testing.T) {
+	f, err := os.Open("../aisnmea/testdata/aistest.nmea")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	messages, err := ReadCapture(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) == 0 {
+		t.Fatal("No messages")
+	}
+
+	last := messages[0].Time
+	for _, m := range messages {
+		if m.Time.IsZero() || m.Time.Before(last) {
+			t.Fatal("Wrong time", m.Time, m.Lines)
+		}
+		last = m.Time
+
+		/* Single lines can be orphaned fragments */
+		for i, line := range m.Lines {
+			frag, ok := fragmentOf(aisnmea.TagBlock{}, line)
+			if ok && len(m.Lines) > 1 && (frag.total != len(m.Lines) || frag.num != i+1) {
+				t.Fatal("Fragments not kept together", m.Lines)
+			}
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package aisreplay
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Output receives the lines of every message
+type Output interface {
+	Send(lines []string) error
+	Close() error
+}
+
+func joinLines(lines []string) []byte {
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
+
+/* Files and stdout */
+
+type writerOutput struct {
+	w *bufio.Writer
+	c io.Closer
+
+	// flush after every message, for output that is followed live
+	flush bool
+}
+
+// NewWriterOutput creates an Output that writes to w. If flush is set the lines are written
+// immediately instead of being buffered. Close does not close w.
+func NewWriterOutput(w io.Writer, flush bool) Output {
+	return &writerOutput{w: bufio.NewWriter(w), flush: flush}
+}
+
+// NewFileOutput creates an Output that writes to a new file, or to stdout if the name is "-"
+func NewFileOutput(name string, flush bool) (Output, error) {
+	if name == "-" {
+		return NewWriterOutput(os.Stdout, flush), nil
+	}
+
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	return &writerOutput{w: bufio.NewWriter(f), c: f, flush: flush}, nil
+}
+
+func (o *writerOutput) Send(lines []string) error {
+	if _, err := o.w.Write(joinLines(lines)); err != nil {
+		return err
+	}
+	if o.flush {
+		return o.w.Flush()
+	}
+	return nil
+}
+
+func (o *writerOutput) Close() error {
+	err := o.w.Flush()
+	if o.c != nil {
+		if cerr := o.c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+/* UDP, one datagram per message */
+
+type udpOutput struct {
+	conn net.Conn
+}
+
+// NewUDPOutput creates an Output that sends every message as one datagram to addr
+func NewUDPOutput(addr string) (Output, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &udpOutput{conn: conn}, nil
+}
+
+func (o *udpOutput) Send(lines []string) error {
+	_, err := o.conn.Write(joinLines(lines))
+	return err
+}
+
+func (o *udpOutput) Close() error {
+	return o.conn.Close()
+}
+
+/* TCP server, every connected client receives all messages */
+
+// tcpClientBuffer is the number of messages queued for a client before it is disconnected
+const tcpClientBuffer = 4096
+
+// TCPOutput is a TCP server that sends every message to all connected clients. Clients that do
+// not keep up are disconnected.
+type TCPOutput struct {
+	ln net.Listener
+
+	mutex   sync.Mutex
+	clients map[net.Conn]chan []byte
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+// NewTCPOutput starts a TCP server listening on addr
+func NewTCPOutput(addr string) (*TCPOutput, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &TCPOutput{ln: ln, clients: make(map[net.Conn]chan []byte)}
+	o.wg.Add(1)
+	go o.accept()
+	return o, nil
+}
+
+// Addr returns the address the server listens on
+func (o *TCPOutput) Addr() net.Addr {
+	return o.ln.Addr()
+}
+
+func (o *TCPOutput) accept() {
+	defer o.wg.Done()
+
+	for {
+		conn, err := o.ln.Accept()
+		if err != nil {
+			return
+		}
+
+		ch := make(chan []byte, tcpClientBuffer)
+		o.mutex.Lock()
+		if o.closed {
+			o.mutex.Unlock()
+			conn.Close()
+			return
+		}
+		o.clients[conn] = ch
+		o.mutex.Unlock()
+
+		o.wg.Add(1)
+		go o.serve(conn, ch)
+	}
+}
+
+func (o *TCPOutput) serve(conn net.Conn, ch chan []byte) {
+	defer o.wg.Done()
+	defer conn.Close()
+
+	for data := range ch {
+		if _, err := conn.Write(data); err != nil {
+			o.remove(conn)
+			for range ch {
+			}
+			return
+		}
+	}
+}
+
+func (o *TCPOutput) remove(conn net.Conn) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if ch, ok := o.clients[conn]; ok {
+		delete(o.clients, conn)
+		close(ch)
+	}
+}
+
+// NumClients returns the number of connected clients
+func (o *TCPOutput) NumClients() int {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return len(o.clients)
+}
+
+// Send queues the lines for all clients, it does not block
+func (o *TCPOutput) Send(lines []string) error {
+	data := joinLines(lines)
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	for conn, ch := range o.clients {
+		select {
+		case ch <- data:
+		default:
+			/* The client does not keep up */
+			delete(o.clients, conn)
+			close(ch)
+		}
+	}
+	return nil
+}
+
+// Close stops the server and disconnects the clients after the queued messages were sent
+func (o *TCPOutput) Close() error {
+	err := o.ln.Close()
+
+	o.mutex.Lock()
+	o.closed = true
+	for conn, ch := range o.clients {
+		delete(o.clients, conn)
+		close(ch)
+	}
+	o.mutex.Unlock()
+
+	o.wg.Wait()
+	return err
+}
@@ -0,0 +1,92 @@
+package aisreplay
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+)
+
+var testLines = []string{"!AIVDM,2,1", "!AIVDM,2,2"}
+
+const testData = "!AIVDM,2,1\r\n!AIVDM,2,2\r\n"
+
+func TestWriterOutput(t *testing.T) {
+	var buf bytes.Buffer
+	o := NewWriterOutput(&buf, false)
+	if err := o.Send(testLines); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Error("Output was not buffered")
+	}
+	if err := o.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != testData {
+		t.Error("Wrong data", buf.String())
+	}
+}
+
+func TestTCPOutput(t *testing.T) {
+	o, err := NewTCPOutput("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := net.Dial("tcp", o.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	for i := 0; o.NumClients() == 0; i++ {
+		if i == 100 {
+			t.Fatal("Client not accepted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	o.Send(testLines)
+	if err := o.Close(); err != nil {
+		t.Error(err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	data, err := ioutil.ReadAll(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != testData {
+		t.Error("Wrong data", string(data))
+	}
+}
+
+func TestUDPOutput(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	o, err := NewUDPOutput(pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer o.Close()
+
+	if err := o.Send(testLines); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 1024)
+	pc.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf[:n]) != testData {
+		t.Error("Wrong datagram", string(buf[:n]))
+	}
+}
@@ -0,0 +1,210 @@
+package aisreplay
+
+import (
+	"sync"
+	"time"
+)
+
+// Config contains the settings of a Player
+type Config struct {
+	// Speed is the playback speed relative to the capture, 0 plays as fast as possible
+	Speed float64
+
+	// Loop restarts the playback at the first message after the last one was sent
+	Loop bool
+}
+
+// Player sends messages at the times they were received, relative to the start of the playback.
+// The methods that control the playback can be called while Run is active.
+type Player struct {
+	messages []Message
+	loop     bool
+
+	mutex  sync.Mutex
+	speed  float64
+	next   int       // Index of the next message to send
+	last   time.Time // Time of the last message that was sent
+	paused bool
+
+	/* The capture time base is played at the wall clock time wallBase */
+	base     time.Time
+	wallBase time.Time
+
+	wake chan struct{}
+	now  func() time.Time
+}
+
+// NewPlayer creates a Player for the messages, which are normally returned by ReadCapture
+func NewPlayer(messages []Message, cfg Config) *Player {
+	p := &Player{
+		messages: messages,
+		loop:     cfg.Loop,
+		speed:    cfg.Speed,
+		wake:     make(chan struct{}, 1),
+		now:      time.Now,
+	}
+	p.resync()
+	return p
+}
+
+// resync plays the next message now. Later messages are timed relative to it.
+func (p *Player) resync() {
+	p.base = time.Time{}
+	for i := p.next; i < len(p.messages); i++ {
+		if t := p.messages[i].Time; !t.IsZero() {
+			p.base = t
+			break
+		}
+	}
+	p.wallBase = p.now()
+}
+
+// notify wakes up Run after the state was changed
+func (p *Player) notify() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Pause stops the playback until Resume is called
+func (p *Player) Pause() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.paused = true
+	p.notify()
+}
+
+// Resume continues a paused playback with the next message
+func (p *Player) Resume() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.paused {
+		p.paused = false
+		p.resync()
+		p.notify()
+	}
+}
+
+// Paused returns true if the playback is paused
+func (p *Player) Paused() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.paused
+}
+
+// Seek continues the playback with the first message received at or after t. If there is no such
+// message the playback ends, or restarts if it loops.
+func (p *Player) Seek(t time.Time) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.next = len(p.messages)
+	for i, m := range p.messages {
+		if !m.Time.IsZero() && !m.Time.Before(t) {
+			p.next = i
+			break
+		}
+	}
+	p.resync()
+	p.notify()
+}
+
+// SetSpeed changes the playback speed, 0 plays as fast as possible
+func (p *Player) SetSpeed(speed float64) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.speed = speed
+	p.resync()
+	p.notify()
+}
+
+// Position returns the receive time of the last message that was sent
+func (p *Player) Position() time.Time {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.last
+}
+
+// Start returns the receive time of the first message with a time, or the zero time if there is none
+func (p *Player) Start() time.Time {
+	for _, m := range p.messages {
+		if !m.Time.IsZero() {
+			return m.Time
+		}
+	}
+	return time.Time{}
+}
+
+// wait returns how long to wait before the message can be sent
+func (p *Player) wait(m Message) time.Duration {
+	if p.speed <= 0 || m.Time.IsZero() || p.base.IsZero() {
+		return 0
+	}
+
+	offset := time.Duration(float64(m.Time.Sub(p.base)) / p.speed)
+	return p.wallBase.Add(offset).Sub(p.now())
+}
+
+// Run calls fn for every message at the right time. It returns when all messages were sent and
+// the player does not loop, when stop is closed or when fn returns an error.
+func (p *Player) Run(stop <-chan struct{}, fn func(m Message) error) error {
+	for {
+		p.mutex.Lock()
+
+		if p.paused {
+			p.mutex.Unlock()
+			select {
+			case <-p.wake:
+				continue
+			case <-stop:
+				return nil
+			}
+		}
+
+		if p.next >= len(p.messages) {
+			if !p.loop || len(p.messages) == 0 {
+				p.mutex.Unlock()
+				return nil
+			}
+			p.next = 0
+			p.resync()
+		}
+
+		m := p.messages[p.next]
+		if wait := p.wait(m); wait > 0 {
+			p.mutex.Unlock()
+
+			/* The state may have changed when waking up, so it is checked again */
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-p.wake:
+				timer.Stop()
+			case <-stop:
+				timer.Stop()
+				return nil
+			}
+			continue
+		}
+
+		p.next++
+		if !m.Time.IsZero() {
+			p.last = m.Time
+		}
+		p.mutex.Unlock()
+
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package aisreplay
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+var testStart = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// testMessages returns n messages that are one second apart
+func testMessages(n int) []Message {
+	messages := make([]Message, n)
+	for i := range messages {
+		messages[i] = Message{Time: testStart.Add(time.Duration(i) * time.Second), Lines: []string{fmt.Sprint(i)}}
+	}
+	return messages
+}
+
+// play runs the player and returns the first line of every message
+func play(t *testing.T, p *Player, fn func(m Message) error) []string {
+	var result []string
+	err := p.Run(nil, func(m Message) error {
+		result = append(result, m.Lines[0])
+		if fn != nil {
+			return fn(m)
+		}
+		return nil
+	})
+	if err != nil && err != errDone {
+		t.Fatal(err)
+	}
+	return result
+}
+
+var errDone = errors.New("done")
+
+func TestPlayFast(t *testing.T) {
+	messages := testMessages(100)
+	messages[50].Lines = append(messages[50].Lines, "second")
+
+	var lines int
+	start := time.Now()
+	result := play(t, NewPlayer(messages, Config{}), func(m Message) error {
+		lines += len(m.Lines)
+		return nil
+	})
+	if time.Since(start) > time.Second {
+		t.Error("Playback was not as fast as possible")
+	}
+	if len(result) != 100 || lines != 101 || result[99] != "99" {
+		t.Error("Wrong messages", len(result), lines)
+	}
+}
+
+func TestPlayTiming(t *testing.T) {
+	p := NewPlayer(testMessages(5), Config{Speed: 20})
+
+	var times []time.Time
+	play(t, p, func(m Message) error {
+		times = append(times, time.Now())
+		return nil
+	})
+
+	/* Four seconds at 20 times real time */
+	if d := times[4].Sub(times[0]); d < 190*time.Millisecond || d > time.Second {
+		t.Error("Wrong playback time", d)
+	}
+	if !p.Position().Equal(testStart.Add(4 * time.Second)) {
+		t.Error("Wrong position", p.Position())
+	}
+}
+
+func TestLoop(t *testing.T) {
+	p := NewPlayer(testMessages(3), Config{Loop: true})
+
+	n := 0
+	result := play(t, p, func(m Message) error {
+		if n++; n == 7 {
+			return errDone
+		}
+		return nil
+	})
+	if fmt.Sprint(result) != "[0 1 2 0 1 2 0]" {
+		t.Error("Wrong loop", result)
+	}
+}
+
+func TestSeek(t *testing.T) {
+	p := NewPlayer(testMessages(10), Config{Speed: 1})
+	if !p.Start().Equal(testStart) {
+		t.Error("Wrong start", p.Start())
+	}
+
+	/* A seek restarts the timing at the new position, so the playback does not wait 7 seconds */
+	p.Seek(testStart.Add(6500 * time.Millisecond))
+	result := play(t, p, func(m Message) error {
+		if m.Lines[0] == "7" {
+			p.SetSpeed(0)
+		}
+		if m.Lines[0] == "8" {
+			p.Seek(testStart.Add(2 * time.Second))
+		}
+		if m.Lines[0] == "4" {
+			p.Seek(testStart.Add(time.Hour))
+		}
+		return nil
+	})
+	if fmt.Sprint(result) != "[7 8 2 3 4]" {
+		t.Error("Wrong seek", result)
+	}
+}
+
+func TestPause(t *testing.T) {
+	p := NewPlayer(testMessages(2), Config{})
+
+	var times []time.Time
+	play(t, p, func(m Message) error {
+		times = append(times, time.Now())
+		if len(times) == 1 {
+			p.Pause()
+			if !p.Paused() {
+				t.Error("Player is not paused")
+			}
+			time.AfterFunc(100*time.Millisecond, p.Resume)
+		}
+		return nil
+	})
+
+	if d := times[1].Sub(times[0]); d < 100*time.Millisecond {
+		t.Error("Pause was too short", d)
+	}
+}
+
+func TestStop(t *testing.T) {
+	p := NewPlayer(testMessages(2), Config{Speed: 1})
+	p.Pause()
+
+	stop := make(chan struct{})
+	time.AfterFunc(50*time.Millisecond, func() { close(stop) })
+	if err := p.Run(stop, func(m Message) error {
+		t.Error("Paused player sent a message")
+		return nil
+	}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,182 @@
+package aissim
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+// Area is a rectangle in which random stations are placed
+type Area struct {
+	MinLatitude  float64
+	MinLongitude float64
+	MaxLatitude  float64
+	MaxLongitude float64
+}
+
+// Fleet contains the number of random stations of every kind
+type Fleet struct {
+	ClassA       int
+	ClassB       int
+	Aircraft     int
+	AtoN         int
+	BaseStations int
+}
+
+/* Maritime identification digits used for the random stations */
+var randomMIDs = []uint32{205, 211, 219, 227, 232, 235, 244, 245, 246, 257, 265, 303, 366, 431, 563}
+
+var classATypes = []uint8{30, 31, 52, 60, 70, 71, 79, 80, 89}
+var classBTypes = []uint8{36, 37, 30}
+
+func (s *Simulator) randomPoint(area Area) Waypoint {
+	return Waypoint{
+		Latitude:  area.MinLatitude + s.rng.Float64()*(area.MaxLatitude-area.MinLatitude),
+		Longitude: area.MinLongitude + s.rng.Float64()*(area.MaxLongitude-area.MinLongitude),
+	}
+}
+
+func (s *Simulator) randomRoute(area Area) []Waypoint {
+	route := make([]Waypoint, 2+s.rng.Intn(4))
+	for i := range route {
+		route[i] = s.randomPoint(area)
+	}
+	return route
+}
+
+func (s *Simulator) randomMID() uint32 {
+	return randomMIDs[s.rng.Intn(len(randomMIDs))]
+}
+
+func (s *Simulator) randomDimension(length int, beam int) ais.FieldDimension {
+	a := length/4 + s.rng.Intn(length/2+1)
+	c := beam/4 + s.rng.Intn(beam/2+1)
+	return ais.FieldDimension{
+		A: uint16(a),
+		B: uint16(length - a),
+		C: uint8(c),
+		D: uint8(beam - c),
+	}
+}
+
+func (s *Simulator) randomCallSign() string {
+	const letters = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	cs := make([]byte, 4)
+	for i := range cs {
+		cs[i] = letters[s.rng.Intn(len(letters))]
+	}
+	return string(cs) + fmt.Sprint(s.rng.Intn(10))
+}
+
+// AddRandomStations adds stations with random static data and routes inside the area. Vessels and
+// aircraft loop over their route, some Class A vessels are at anchor or moored.
+func (s *Simulator) AddRandomStations(f Fleet, area Area) error {
+	used := make(map[uint32]bool)
+	for _, st := range s.stations {
+		used[st.MMSI] = true
+	}
+
+	/* MMSI with the given prefix followed by random digits, like 111MIDxxx for aircraft */
+	mmsi := func(prefix uint32, digits int) uint32 {
+		scale := uint32(math.Pow10(digits))
+		for {
+			m := prefix*scale + uint32(s.rng.Intn(int(scale)))
+			if !used[m] {
+				used[m] = true
+				return m
+			}
+		}
+	}
+
+	add := func(st Station) error {
+		st.Loop = true
+		return s.AddStation(st)
+	}
+
+	for i := 0; i < f.ClassA; i++ {
+		length := 50 + s.rng.Intn(300)
+		st := Station{
+			Kind:        KindClassA,
+			MMSI:        mmsi(s.randomMID(), 6),
+			Name:        fmt.Sprintf("SIM VESSEL %d", i+1),
+			CallSign:    s.randomCallSign(),
+			ImoNumber:   uint32(9000000 + s.rng.Intn(999999)),
+			ShipType:    classATypes[s.rng.Intn(len(classATypes))],
+			Dimension:   s.randomDimension(length, length/7+2),
+			Destination: fmt.Sprintf("PORT %d", 1+s.rng.Intn(50)),
+			Draught:     float64(length)/25 + s.rng.Float64()*2,
+			Speed:       math.Round(5 + s.rng.Float64()*20),
+			Route:       s.randomRoute(area),
+		}
+		switch r := s.rng.Intn(10); {
+		case r == 0:
+			st.NavigationalStatus = StatusAnchored
+		case r == 1:
+			st.NavigationalStatus = StatusMoored
+		}
+		if err := add(st); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < f.ClassB; i++ {
+		length := 6 + s.rng.Intn(20)
+		err := add(Station{
+			Kind:      KindClassB,
+			MMSI:      mmsi(s.randomMID(), 6),
+			Name:      fmt.Sprintf("SIM YACHT %d", i+1),
+			CallSign:  s.randomCallSign(),
+			ShipType:  classBTypes[s.rng.Intn(len(classBTypes))],
+			Dimension: s.randomDimension(length, length/3+1),
+			Speed:     math.Round(s.rng.Float64() * 25),
+			Route:     s.randomRoute(area),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < f.Aircraft; i++ {
+		err := add(Station{
+			Kind:     KindAircraft,
+			MMSI:     mmsi(111000+s.randomMID(), 3),
+			Name:     fmt.Sprintf("SIM RESCUE %d", i+1),
+			Speed:    float64(90 + s.rng.Intn(150)),
+			Altitude: uint16(150 + s.rng.Intn(1500)),
+			Route:    s.randomRoute(area),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < f.AtoN; i++ {
+		err := add(Station{
+			Kind:      KindAtoN,
+			MMSI:      mmsi(99000+s.randomMID(), 4),
+			Name:      fmt.Sprintf("SIM BUOY %d", i+1),
+			ShipType:  uint8(1 + s.rng.Intn(31)),
+			Dimension: ais.FieldDimension{A: 1, B: 1, C: 1, D: 1},
+			Virtual:   s.rng.Intn(5) == 0,
+			Route:     []Waypoint{s.randomPoint(area)},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < f.BaseStations; i++ {
+		err := add(Station{
+			Kind:  KindBaseStation,
+			MMSI:  mmsi(s.randomMID(), 4),
+			Name:  fmt.Sprintf("SIM BASE %d", i+1),
+			Route: []Waypoint{s.randomPoint(area)},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,515 @@
+package aissim
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+// Transmission is a packet sent by a simulated station
+type Transmission struct {
+	Time    time.Time
+	Channel byte // 1 for A, 2 for B
+	Packet  ais.Packet
+}
+
+// Config contains the parameters of a Simulator
+type Config struct {
+	// Start is the time at which the simulation starts
+	Start time.Time
+	// Seed initializes the random generator, the same seed and start time give the same output
+	Seed int64
+}
+
+type eventKind int
+
+const (
+	eventPosition eventKind = iota
+	eventStatic
+	eventStaticB
+)
+
+type event struct {
+	t       time.Time
+	seq     uint64
+	kind    eventKind
+	station *stationState
+}
+
+type eventQueue []*event
+
+func (q eventQueue) Len() int { return len(q) }
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].t.Equal(q[j].t) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].t.Before(q[j].t)
+}
+func (q eventQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *eventQueue) Push(x interface{}) { *q = append(*q, x.(*event)) }
+func (q *eventQueue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+type stationState struct {
+	Station
+	added time.Time
+
+	channel          byte
+	positionAccuracy bool
+	heading          float64
+
+	/* The previous position report, used to detect changes of course and reporting interval */
+	lastCog      float64
+	lastInterval time.Duration
+	reported     bool
+
+	/* SOTDMA slot timeout and the frame (minute) it belongs to */
+	slotTimeout int
+	frame       int64
+}
+
+// Simulator produces the transmissions of a set of stations in chronological order. It is not
+// safe for concurrent use.
+type Simulator struct {
+	rng      *rand.Rand
+	now      time.Time
+	stations []*stationState
+	queue    eventQueue
+	seq      uint64
+}
+
+// NewSimulator creates a Simulator without stations
+func NewSimulator(cfg Config) *Simulator {
+	return &Simulator{
+		rng: rand.New(rand.NewSource(cfg.Seed)),
+		now: cfg.Start,
+	}
+}
+
+// Now returns the time of the last transmission, or the start time
+func (s *Simulator) Now() time.Time {
+	return s.now
+}
+
+// NumStations returns the number of stations that were added
+func (s *Simulator) NumStations() int {
+	return len(s.stations)
+}
+
+func (s *Simulator) schedule(st *stationState, kind eventKind, t time.Time) {
+	s.seq++
+	heap.Push(&s.queue, &event{t: t, seq: s.seq, kind: kind, station: st})
+}
+
+// randomDuration returns a random duration in [0, d)
+func (s *Simulator) randomDuration(d time.Duration) time.Duration {
+	return time.Duration(s.rng.Int63n(int64(d)))
+}
+
+// AddStation adds a station that starts at the current time of the simulation. The first
+// transmissions are spread randomly over the reporting interval.
+func (s *Simulator) AddStation(station Station) error {
+	if err := station.Validate(); err != nil {
+		return err
+	}
+
+	st := &stationState{
+		Station:          station,
+		added:            s.now,
+		channel:          byte(1 + s.rng.Intn(2)),
+		positionAccuracy: s.rng.Intn(2) == 0,
+		slotTimeout:      -1,
+	}
+	st.Route = append([]Waypoint{}, station.Route...)
+	s.stations = append(s.stations, st)
+
+	pos := st.PositionAt(0)
+	st.heading = pos.Cog
+	if !st.moving() {
+		st.heading = float64(s.rng.Intn(360))
+	}
+	interval := st.reportingInterval(pos, false)
+	s.schedule(st, eventPosition, s.now.Add(s.randomDuration(interval)))
+
+	/* Static data is sent soon after start-up, then every 6 minutes */
+	switch st.Kind {
+	case KindClassA, KindClassB, KindAircraft:
+		s.schedule(st, eventStatic, s.now.Add(s.randomDuration(time.Minute)))
+	}
+
+	return nil
+}
+
+// Next returns the next transmission. It returns false if there are no stations.
+func (s *Simulator) Next() (Transmission, bool) {
+	if len(s.queue) == 0 {
+		return Transmission{}, false
+	}
+
+	e := heap.Pop(&s.queue).(*event)
+	st := e.station
+	if e.t.After(s.now) {
+		s.now = e.t
+	}
+
+	var p ais.Packet
+	switch e.kind {
+	case eventPosition:
+		var next time.Duration
+		p, next = s.positionReport(st)
+		s.schedule(st, eventPosition, s.now.Add(next))
+
+	case eventStatic:
+		p = s.staticReport(st)
+		if st.Kind == KindClassB {
+			/* Part B follows part A within a minute */
+			s.schedule(st, eventStaticB, s.now.Add(time.Second+s.randomDuration(30*time.Second)))
+		}
+		s.schedule(st, eventStatic, s.now.Add(6*time.Minute))
+
+	case eventStaticB:
+		p = s.staticReportB(st)
+	}
+
+	/* Stations alternate between both channels */
+	t := Transmission{Time: s.now, Channel: st.channel, Packet: p}
+	st.channel = 3 - st.channel
+	return t, true
+}
+
+// Run calls fn for every transmission before the end time
+func (s *Simulator) Run(end time.Time, fn func(t Transmission) error) error {
+	for len(s.queue) > 0 && s.queue[0].t.Before(end) {
+		t, _ := s.Next()
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reportingInterval returns the interval of the position reports (ITU-R M.1371 Annex 1, table 1
+// and 2). Class B stations use the "CS" intervals.
+func (st *stationState) reportingInterval(pos Position, turning bool) time.Duration {
+	switch st.Kind {
+	case KindClassA:
+		status := st.navigationalStatus(pos)
+		switch {
+		case (status == StatusAnchored || status == StatusMoored) && pos.Sog <= 3:
+			return 3 * time.Minute
+		case pos.Sog <= 14 && turning:
+			return 3333 * time.Millisecond
+		case pos.Sog <= 14:
+			return 10 * time.Second
+		case pos.Sog <= 23 && turning:
+			return 2 * time.Second
+		case pos.Sog <= 23:
+			return 6 * time.Second
+		}
+		return 2 * time.Second
+
+	case KindClassB:
+		switch {
+		case pos.Sog <= 2:
+			return 3 * time.Minute
+		case pos.Sog <= 14:
+			return 30 * time.Second
+		case pos.Sog <= 23:
+			return 15 * time.Second
+		}
+		return 5 * time.Second
+
+	case KindAtoN:
+		return 3 * time.Minute
+	}
+
+	/* Aircraft and base stations */
+	return 10 * time.Second
+}
+
+func (st *stationState) navigationalStatus(pos Position) uint8 {
+	if pos.Stopped && st.NavigationalStatus == StatusUnderWay && st.Speed > 0 {
+		return StatusMoored
+	}
+	return st.NavigationalStatus
+}
+
+/* Slots of the TDMA frame, which is one minute long */
+const slotsPerMinute = 2250
+
+func slotNumber(t time.Time) int {
+	ns := int64(t.Second())*int64(time.Second) + int64(t.Nanosecond())
+	return int(ns * slotsPerMinute / int64(time.Minute))
+}
+
+func slotCount(d time.Duration, max int) uint32 {
+	n := int64(d) * slotsPerMinute / int64(time.Minute)
+	if n > int64(max) {
+		n = int64(max)
+	}
+	return uint32(n)
+}
+
+// sotdmaState returns the SOTDMA communication state (ITU-R M.1371 Annex 2, 3.3.7.2.2). The slot
+// timeout counts down every frame, the sub message depends on it.
+func (s *Simulator) sotdmaState(st *stationState, next time.Duration) uint32 {
+	frame := s.now.Unix() / 60
+	if st.slotTimeout < 0 || frame-st.frame > 7 {
+		st.slotTimeout = 3 + s.rng.Intn(5)
+	} else {
+		for f := st.frame; f < frame; f++ {
+			st.slotTimeout--
+			if st.slotTimeout < 0 {
+				st.slotTimeout = 3 + s.rng.Intn(5)
+			}
+		}
+	}
+	st.frame = frame
+
+	var sub uint32
+	switch st.slotTimeout {
+	case 3, 5, 7:
+		/* Number of received stations */
+		sub = uint32(len(s.stations) - 1)
+		if sub > 16383 {
+			sub = 16383
+		}
+	case 2, 4, 6:
+		sub = uint32(slotNumber(s.now))
+	case 1:
+		utc := s.now.UTC()
+		sub = uint32(utc.Hour())<<9 | uint32(utc.Minute())<<2
+	case 0:
+		sub = slotCount(next, 16383)
+	}
+
+	/* Sync state 0: UTC direct */
+	return uint32(st.slotTimeout)<<14 | sub
+}
+
+// itdmaState returns the ITDMA communication state (ITU-R M.1371 Annex 2, 3.3.7.3.2)
+func itdmaState(next time.Duration) uint32 {
+	increment := slotCount(next, 8191)
+	/* One slot, keep flag set */
+	return increment<<4 | 1
+}
+
+/* Communication state of Class B "CS" stations, which do not use TDMA */
+const carrierSenseState = 393222
+
+// jitter returns a random value in [-max, max]
+func (s *Simulator) jitter(max float64) float64 {
+	return (2*s.rng.Float64() - 1) * max
+}
+
+func round(v float64, step float64) float64 {
+	return math.Round(v/step) * step
+}
+
+func (s *Simulator) positionReport(st *stationState) (ais.Packet, time.Duration) {
+	pos := st.PositionAt(s.now.Sub(st.added))
+
+	turning := st.reported && math.Abs(math.Mod(pos.Cog-st.lastCog+540, 360)-180) >= 5
+	interval := st.reportingInterval(pos, turning)
+	intervalChanged := st.reported && interval != st.lastInterval
+	st.lastCog, st.lastInterval, st.reported = pos.Cog, interval, true
+
+	sog, cog := pos.Sog, pos.Cog
+	if sog > 0 {
+		sog = math.Max(0, round(sog+s.jitter(0.2), 0.1))
+		cog = math.Mod(round(cog+s.jitter(1)+360, 0.1), 360)
+		st.heading = math.Mod(math.Round(pos.Cog+s.jitter(2))+360, 360)
+	}
+
+	header := ais.Header{UserID: st.MMSI}
+	lat := ais.FieldLatLonFine(pos.Latitude)
+	lon := ais.FieldLatLonFine(pos.Longitude)
+	second := uint8(s.now.UTC().Second())
+
+	switch st.Kind {
+	case KindClassA:
+		/* Message 3 is used when the reporting interval changes, as the slots are then
+		 * allocated with ITDMA */
+		header.MessageID = 1
+		state := uint32(0)
+		if st.Assigned {
+			header.MessageID = 2
+		}
+		if intervalChanged {
+			header.MessageID = 3
+			state = itdmaState(interval)
+		} else {
+			state = s.sotdmaState(st, interval)
+		}
+
+		return ais.PositionReport{
+			Header:                    header,
+			Valid:                     true,
+			NavigationalStatus:        st.navigationalStatus(pos),
+			Sog:                       ais.Field10(sog),
+			PositionAccuracy:          st.positionAccuracy,
+			Longitude:                 lon,
+			Latitude:                  lat,
+			Cog:                       ais.Field10(cog),
+			TrueHeading:               uint16(st.heading),
+			Timestamp:                 second,
+			CommunicationStateNoItdma: ais.CommunicationStateNoItdma{CommunicationState: state},
+		}, interval
+
+	case KindClassB:
+		header.MessageID = 18
+		return ais.StandardClassBPositionReport{
+			Header:           header,
+			Valid:            true,
+			Sog:              ais.Field10(sog),
+			PositionAccuracy: st.positionAccuracy,
+			Longitude:        lon,
+			Latitude:         lat,
+			Cog:              ais.Field10(cog),
+			TrueHeading:      uint16(st.heading),
+			Timestamp:        second,
+			ClassBUnit:       true,
+			ClassBDsc:        true,
+			ClassBBand:       true,
+			ClassBMsg22:      true,
+			CommunicationStateItdma: ais.CommunicationStateItdma{
+				CommunicationStateIsItdma: true,
+				CommunicationState:        carrierSenseState,
+			},
+		}, interval
+
+	case KindAircraft:
+		header.MessageID = 9
+		return ais.StandardSearchAndRescueAircraftReport{
+			Header:           header,
+			Valid:            true,
+			Altitude:         st.Altitude,
+			Sog:              uint16(math.Round(sog)),
+			PositionAccuracy: st.positionAccuracy,
+			Longitude:        lon,
+			Latitude:         lat,
+			Cog:              ais.Field10(cog),
+			Timestamp:        second,
+			CommunicationStateItdma: ais.CommunicationStateItdma{
+				CommunicationState: s.sotdmaState(st, interval),
+			},
+		}, interval
+
+	case KindAtoN:
+		header.MessageID = 21
+		name, extension := st.Name, ""
+		if len(name) > 20 {
+			name, extension = name[:20], name[20:]
+			if len(extension) > 14 {
+				extension = extension[:14]
+			}
+		}
+		return ais.AidsToNavigationReport{
+			Header:           header,
+			Valid:            true,
+			Type:             st.ShipType,
+			Name:             name,
+			PositionAccuracy: true,
+			Longitude:        lon,
+			Latitude:         lat,
+			Dimension:        st.Dimension,
+			Fixtype:          7,
+			Timestamp:        second,
+			VirtualAtoN:      st.Virtual,
+			NameExtension:    extension,
+		}, interval
+	}
+
+	header.MessageID = 4
+	utc := s.now.UTC()
+	return ais.BaseStationReport{
+		Header:                    header,
+		Valid:                     true,
+		UtcYear:                   uint16(utc.Year()),
+		UtcMonth:                  uint8(utc.Month()),
+		UtcDay:                    uint8(utc.Day()),
+		UtcHour:                   uint8(utc.Hour()),
+		UtcMinute:                 uint8(utc.Minute()),
+		UtcSecond:                 uint8(utc.Second()),
+		PositionAccuracy:          true,
+		Longitude:                 lon,
+		Latitude:                  lat,
+		FixType:                   7,
+		CommunicationStateNoItdma: ais.CommunicationStateNoItdma{CommunicationState: s.sotdmaState(st, interval)},
+	}, interval
+}
+
+// eta returns the time the end of the route is reached, or "not available"
+func (s *Simulator) eta(st *stationState) ais.FieldETA {
+	na := ais.FieldETA{Hour: 24, Minute: 60}
+	if !st.moving() || st.Loop {
+		return na
+	}
+
+	total := 0.0
+	for i := 0; i+1 < len(st.Route); i++ {
+		l, _ := legLength(st.Route[i], st.Route[i+1])
+		total += l
+	}
+
+	arrival := st.added.Add(time.Duration(total / st.Speed * float64(time.Hour))).UTC()
+	if arrival.Before(s.now) {
+		return na
+	}
+	return ais.FieldETA{
+		Month:  uint8(arrival.Month()),
+		Day:    uint8(arrival.Day()),
+		Hour:   uint8(arrival.Hour()),
+		Minute: uint8(arrival.Minute()),
+	}
+}
+
+func (s *Simulator) staticReport(st *stationState) ais.Packet {
+	if st.Kind == KindClassB {
+		return ais.StaticDataReport{
+			Header:  ais.Header{MessageID: 24, UserID: st.MMSI},
+			Valid:   true,
+			ReportA: ais.StaticDataReportA{Valid: true, Name: st.Name},
+		}
+	}
+
+	return ais.ShipStaticData{
+		Header:               ais.Header{MessageID: 5, UserID: st.MMSI},
+		Valid:                true,
+		AisVersion:           2,
+		ImoNumber:            st.ImoNumber,
+		CallSign:             st.CallSign,
+		Name:                 st.Name,
+		Type:                 st.ShipType,
+		Dimension:            st.Dimension,
+		FixType:              1,
+		Eta:                  s.eta(st),
+		MaximumStaticDraught: ais.Field10(math.Min(25.5, round(st.Draught, 0.1))),
+		Destination:          st.Destination,
+	}
+}
+
+func (s *Simulator) staticReportB(st *stationState) ais.Packet {
+	return ais.StaticDataReport{
+		Header:     ais.Header{MessageID: 24, UserID: st.MMSI},
+		Valid:      true,
+		PartNumber: true,
+		ReportB: ais.StaticDataReportB{
+			Valid:          true,
+			ShipType:       st.ShipType,
+			VendorIDName:   "SIM",
+			VenderIDModel:  1,
+			VenderIDSerial: st.MMSI % (1 << 20),
+			CallSign:       st.CallSign,
+			Dimension:      st.Dimension,
+			FixType:        1,
+		},
+	}
+}
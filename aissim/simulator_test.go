@@ -0,0 +1,328 @@
+package aissim
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+var testStart = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var testArea = Area{MinLatitude: 51, MinLongitude: 3, MaxLatitude: 51.8, MaxLongitude: 4.5}
+
+func runSimulator(t *testing.T, sim *Simulator, d time.Duration) []Transmission {
+	var result []Transmission
+	if err := sim.Run(testStart.Add(d), func(tr Transmission) error {
+		result = append(result, tr)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestDeterministic(t *testing.T) {
+	fleet := Fleet{ClassA: 5, ClassB: 5, Aircraft: 1, AtoN: 2, BaseStations: 1}
+
+	var runs [3][]Transmission
+	for i := range runs {
+		sim := NewSimulator(Config{Start: testStart, Seed: int64(1 + i/2)})
+		if err := sim.AddRandomStations(fleet, testArea); err != nil {
+			t.Fatal(err)
+		}
+		runs[i] = runSimulator(t, sim, 10*time.Minute)
+	}
+
+	if !reflect.DeepEqual(runs[0], runs[1]) {
+		t.Error("Same seed gives different output")
+	}
+	if reflect.DeepEqual(runs[0], runs[2]) {
+		t.Error("Different seed gives the same output")
+	}
+}
+
+func TestTransmissions(t *testing.T) {
+	sim := NewSimulator(Config{Start: testStart, Seed: 5})
+	if err := sim.AddRandomStations(Fleet{ClassA: 10, ClassB: 10, Aircraft: 2, AtoN: 3, BaseStations: 2}, testArea); err != nil {
+		t.Fatal(err)
+	}
+
+	codec := ais.CodecNew(false, false)
+	expected := map[StationKind][]uint8{
+		KindClassA:      {1, 2, 3, 5},
+		KindClassB:      {18, 24},
+		KindAircraft:    {9, 5},
+		KindAtoN:        {21},
+		KindBaseStation: {4},
+	}
+	kinds := make(map[uint32]StationKind)
+	for _, st := range sim.stations {
+		kinds[st.MMSI] = st.Kind
+	}
+
+	last := testStart
+	seen := make(map[uint8]bool)
+	for _, tr := range runSimulator(t, sim, 30*time.Minute) {
+		if tr.Time.Before(last) {
+			t.Fatal("Transmissions not in order")
+		}
+		last = tr.Time
+
+		if tr.Channel != 1 && tr.Channel != 2 {
+			t.Error("Invalid channel", tr.Channel)
+		}
+
+		h := tr.Packet.GetHeader()
+		ok := false
+		for _, id := range expected[kinds[h.UserID]] {
+			ok = ok || id == h.MessageID
+		}
+		if !ok {
+			t.Error("Unexpected message", kinds[h.UserID], h.MessageID)
+		}
+		seen[h.MessageID] = true
+
+		bits := codec.EncodePacket(tr.Packet)
+		if bits == nil {
+			t.Fatal("Packet cannot be encoded", tr.Packet)
+		}
+		if p := codec.DecodePacket(bits); p == nil || p.GetHeader().UserID != h.UserID {
+			t.Error("Packet cannot be decoded", tr.Packet)
+		}
+	}
+
+	for _, id := range []uint8{1, 4, 5, 9, 18, 21, 24} {
+		if !seen[id] {
+			t.Error("Message not sent", id)
+		}
+	}
+}
+
+// reportTimes returns the times of the packets of a station with the given message IDs
+func reportTimes(trs []Transmission, mmsi uint32, ids ...uint8) []time.Time {
+	var result []time.Time
+	for _, tr := range trs {
+		h := tr.Packet.GetHeader()
+		for _, id := range ids {
+			if h.UserID == mmsi && h.MessageID == id {
+				result = append(result, tr.Time)
+			}
+		}
+	}
+	return result
+}
+
+func checkIntervals(t *testing.T, name string, times []time.Time, want time.Duration) {
+	if len(times) < 2 {
+		t.Error("Not enough reports", name)
+		return
+	}
+	for i := 1; i < len(times); i++ {
+		if d := times[i].Sub(times[i-1]); d != want {
+			t.Error("Wrong interval", name, d, want)
+			return
+		}
+	}
+}
+
+func TestReportingIntervals(t *testing.T) {
+	route := []Waypoint{{51, 3}, {51.5, 3}, {51.5, 4}}
+
+	tests := []struct {
+		station Station
+		ids     []uint8
+		want    time.Duration
+	}{
+		{Station{Kind: KindClassA, Speed: 10}, []uint8{1}, 10 * time.Second},
+		{Station{Kind: KindClassA, Speed: 20}, []uint8{1}, 6 * time.Second},
+		{Station{Kind: KindClassA, Speed: 30}, []uint8{1}, 2 * time.Second},
+		{Station{Kind: KindClassA, Speed: 10, NavigationalStatus: StatusAnchored}, []uint8{1}, 3 * time.Minute},
+		{Station{Kind: KindClassA, Speed: 10}, []uint8{5}, 6 * time.Minute},
+		{Station{Kind: KindClassA, Speed: 10, Assigned: true}, []uint8{2}, 10 * time.Second},
+		{Station{Kind: KindClassB, Speed: 1}, []uint8{18}, 3 * time.Minute},
+		{Station{Kind: KindClassB, Speed: 10}, []uint8{18}, 30 * time.Second},
+		{Station{Kind: KindClassB, Speed: 20}, []uint8{18}, 15 * time.Second},
+		{Station{Kind: KindClassB, Speed: 30}, []uint8{18}, 5 * time.Second},
+		{Station{Kind: KindAircraft, Speed: 150}, []uint8{9}, 10 * time.Second},
+		{Station{Kind: KindAtoN}, []uint8{21}, 3 * time.Minute},
+		{Station{Kind: KindBaseStation}, []uint8{4}, 10 * time.Second},
+	}
+
+	for i, test := range tests {
+		sim := NewSimulator(Config{Start: testStart, Seed: int64(i)})
+		st := test.station
+		st.MMSI = uint32(244000000 + i)
+		st.Route = route
+		st.Loop = true
+		if err := sim.AddStation(st); err != nil {
+			t.Fatal(err)
+		}
+
+		/* Stay on the first leg, a change of course shortens the interval */
+		times := reportTimes(runSimulator(t, sim, 20*time.Minute), st.MMSI, test.ids...)
+		checkIntervals(t, st.Kind.String(), times, test.want)
+	}
+}
+
+func TestClassBStaticData(t *testing.T) {
+	sim := NewSimulator(Config{Start: testStart, Seed: 3})
+	st := Station{Kind: KindClassB, MMSI: 244000001, Name: "YACHT", CallSign: "PD1234", Speed: 5, Route: []Waypoint{{51, 3}}}
+	if err := sim.AddStation(st); err != nil {
+		t.Fatal(err)
+	}
+
+	trs := runSimulator(t, sim, 20*time.Minute)
+
+	var partA, partB []time.Time
+	for _, tr := range trs {
+		if p, ok := tr.Packet.(ais.StaticDataReport); ok {
+			if p.PartNumber {
+				partB = append(partB, tr.Time)
+				if p.ReportB.CallSign != "PD1234" {
+					t.Error("Wrong call sign", p.ReportB.CallSign)
+				}
+			} else {
+				partA = append(partA, tr.Time)
+				if p.ReportA.Name != "YACHT" {
+					t.Error("Wrong name", p.ReportA.Name)
+				}
+			}
+		}
+	}
+
+	checkIntervals(t, "24A", partA, 6*time.Minute)
+	if len(partA) != len(partB) {
+		t.Fatal("Part B missing", len(partA), len(partB))
+	}
+	for i := range partA {
+		if d := partB[i].Sub(partA[i]); d <= 0 || d > time.Minute {
+			t.Error("Part B not sent within a minute", d)
+		}
+	}
+}
+
+func TestCommunicationState(t *testing.T) {
+	sim := NewSimulator(Config{Start: testStart, Seed: 7})
+	if err := sim.AddRandomStations(Fleet{ClassA: 5, BaseStations: 1}, testArea); err != nil {
+		t.Fatal(err)
+	}
+
+	type slotTimeout struct {
+		frame   int64
+		timeout int
+	}
+	timeouts := make(map[uint32]slotTimeout)
+	for _, tr := range runSimulator(t, sim, 20*time.Minute) {
+		var state uint32
+		switch p := tr.Packet.(type) {
+		case ais.PositionReport:
+			if p.MessageID == 3 {
+				continue
+			}
+			state = p.CommunicationState
+		case ais.BaseStationReport:
+			state = p.CommunicationState
+		default:
+			continue
+		}
+
+		if state>>17 != 0 {
+			t.Error("Sync state is not UTC direct", state)
+		}
+		timeout := int(state>>14) & 7
+		sub := state & 0x3FFF
+
+		switch timeout {
+		case 1:
+			utc := tr.Time.UTC()
+			if sub != uint32(utc.Hour())<<9|uint32(utc.Minute())<<2 {
+				t.Error("Wrong UTC sub message", sub, utc)
+			}
+		case 2, 4, 6:
+			if int(sub) != slotNumber(tr.Time) || sub >= slotsPerMinute {
+				t.Error("Wrong slot number", sub)
+			}
+		case 3, 5, 7:
+			if sub != 5 {
+				t.Error("Wrong number of received stations", sub)
+			}
+		}
+
+		/* The timeout decreases every frame and restarts after zero */
+		mmsi := tr.Packet.GetHeader().UserID
+		frame := tr.Time.Unix() / 60
+		if prev, ok := timeouts[mmsi]; ok {
+			switch frame - prev.frame {
+			case 0:
+				if timeout != prev.timeout {
+					t.Error("Slot timeout changed within a frame", prev.timeout, timeout)
+				}
+			case 1:
+				if (prev.timeout > 0 && timeout != prev.timeout-1) || (prev.timeout == 0 && timeout < 3) {
+					t.Error("Wrong slot timeout in next frame", prev.timeout, timeout)
+				}
+			}
+		}
+		timeouts[mmsi] = slotTimeout{frame, timeout}
+	}
+}
+
+func TestPositionAt(t *testing.T) {
+	st := Station{Kind: KindClassA, MMSI: 1, Speed: 6, Route: []Waypoint{{0, 0}, {0.1, 0}, {0.1, 0.1}}}
+
+	/* 6 knots is about 0.1 degree of latitude per hour */
+	pos := st.PositionAt(30 * time.Minute)
+	if math.Abs(pos.Latitude-0.05) > 1e-4 || pos.Longitude != 0 || math.Abs(pos.Cog) > 1e-6 || pos.Sog != 6 || pos.Stopped {
+		t.Error("Wrong position on first leg", pos)
+	}
+
+	pos = st.PositionAt(90 * time.Minute)
+	if math.Abs(pos.Latitude-0.1) > 1e-6 || math.Abs(pos.Longitude-0.05) > 1e-3 || math.Abs(pos.Cog-90) > 0.01 {
+		t.Error("Wrong position on second leg", pos)
+	}
+
+	pos = st.PositionAt(3 * time.Hour)
+	if !pos.Stopped || pos.Latitude != 0.1 || pos.Longitude != 0.1 {
+		t.Error("Station did not stop at the end", pos)
+	}
+	if status := (&stationState{Station: st}).navigationalStatus(pos); status != StatusMoored {
+		t.Error("Stopped station is not moored", status)
+	}
+
+	st.Loop = true
+	total := 0.0
+	for i, w := range st.Route {
+		l, _ := legLength(w, st.Route[(i+1)%len(st.Route)])
+		total += l
+	}
+	lap := time.Duration(total / st.Speed * float64(time.Hour))
+	pos = st.PositionAt(lap + 30*time.Minute)
+	if math.Abs(pos.Latitude-0.05) > 1e-4 || pos.Stopped {
+		t.Error("Looping station did not return to the start", pos)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := Station{Kind: KindClassA, MMSI: 244000000, Route: []Waypoint{{51, 3}}}
+	if err := valid.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	for _, modify := range []func(s *Station){
+		func(s *Station) { s.Kind = 10 },
+		func(s *Station) { s.MMSI = 0 },
+		func(s *Station) { s.Route = nil },
+		func(s *Station) { s.Route = []Waypoint{{91, 0}} },
+		func(s *Station) { s.Speed = 200 },
+		func(s *Station) { s.Name = "lower case" },
+	} {
+		st := valid
+		modify(&st)
+		if st.Validate() == nil {
+			t.Error("Invalid station accepted", st)
+		}
+	}
+}
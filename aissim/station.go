@@ -0,0 +1,246 @@
+// Package aissim generates synthetic AIS traffic for load testing and demonstrations. A Simulator
+// moves stations along their routes and produces the packets they would transmit, with the message
+// types and reporting intervals of ITU-R M.1371. The output is deterministic for a given seed and
+// start time.
+package aissim
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+// StationKind selects the kind of equipment that is simulated
+type StationKind int
+
+const (
+	// KindClassA is a Class A shipborne station sending messages 1, 2 or 3 and 5
+	KindClassA StationKind = iota
+	// KindClassB is a Class B "CS" shipborne station sending messages 18 and 24
+	KindClassB
+	// KindAircraft is a search and rescue aircraft sending message 9
+	KindAircraft
+	// KindAtoN is an aid to navigation sending message 21
+	KindAtoN
+	// KindBaseStation is a base station sending message 4
+	KindBaseStation
+)
+
+func (k StationKind) String() string {
+	switch k {
+	case KindClassA:
+		return "classa"
+	case KindClassB:
+		return "classb"
+	case KindAircraft:
+		return "aircraft"
+	case KindAtoN:
+		return "aton"
+	case KindBaseStation:
+		return "basestation"
+	}
+	return "unknown"
+}
+
+// Navigational status values used by the simulator
+const (
+	StatusUnderWay = 0
+	StatusAnchored = 1
+	StatusMoored   = 5
+)
+
+// Waypoint is a point of a route
+type Waypoint struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Station describes a simulated station. Fixed stations (AtoN and base stations) stay at the first
+// waypoint of their route.
+type Station struct {
+	Kind StationKind
+	MMSI uint32
+
+	// Static data, not all fields are sent by all kinds of stations
+	Name        string
+	CallSign    string
+	ImoNumber   uint32
+	ShipType    uint8 // Ship type, or the type of aid to navigation for KindAtoN
+	Dimension   ais.FieldDimension
+	Destination string
+	Draught     float64 // Meters
+
+	// Speed is the speed over ground in knots while moving along the route
+	Speed float64
+	// Altitude is the altitude of aircraft in meters
+	Altitude uint16
+	// NavigationalStatus is reported by Class A stations. Stations at anchor or moored do not move.
+	NavigationalStatus uint8
+	// Assigned makes a Class A station report with message 2, as if it was assigned a schedule by
+	// a base station
+	Assigned bool
+	// Virtual marks an AtoN as a virtual aid to navigation
+	Virtual bool
+
+	// Route is followed at Speed. When the last waypoint is reached the station continues with the
+	// first one if Loop is set, otherwise it stops there. A Class A station that stops reports
+	// StatusMoored.
+	Route []Waypoint
+	Loop  bool
+}
+
+// Validate checks that the station can be simulated
+func (s *Station) Validate() error {
+	if s.Kind < KindClassA || s.Kind > KindBaseStation {
+		return fmt.Errorf("aissim: unknown station kind [%d]", s.Kind)
+	}
+	if s.MMSI == 0 || s.MMSI >= 1<<30 {
+		return fmt.Errorf("aissim: invalid MMSI [%d]", s.MMSI)
+	}
+	if len(s.Route) == 0 {
+		return fmt.Errorf("aissim: station has no route [%09d]", s.MMSI)
+	}
+	for _, w := range s.Route {
+		if w.Latitude < -90 || w.Latitude > 90 || w.Longitude < -180 || w.Longitude > 180 {
+			return fmt.Errorf("aissim: invalid waypoint [%v,%v]", w.Latitude, w.Longitude)
+		}
+	}
+
+	/* Aircraft report the speed in knots, the others in 1/10 knots */
+	maxSpeed := 102.2
+	if s.Kind == KindAircraft {
+		maxSpeed = 1022
+	}
+	if s.Speed < 0 || s.Speed > maxSpeed {
+		return fmt.Errorf("aissim: invalid speed [%v]", s.Speed)
+	}
+
+	for _, str := range []string{s.Name, s.CallSign, s.Destination} {
+		for i := 0; i < len(str); i++ {
+			if str[i] < 32 || str[i] > 95 {
+				return fmt.Errorf("aissim: text cannot be encoded [%s]", str)
+			}
+		}
+	}
+	return nil
+}
+
+// fixed returns true for stations that do not move
+func (s *Station) fixed() bool {
+	return s.Kind == KindAtoN || s.Kind == KindBaseStation
+}
+
+// moving returns true if the station follows its route
+func (s *Station) moving() bool {
+	if s.fixed() || s.Speed <= 0 || len(s.Route) < 2 {
+		return false
+	}
+	if s.Kind == KindClassA && (s.NavigationalStatus == StatusAnchored || s.NavigationalStatus == StatusMoored) {
+		return false
+	}
+	return true
+}
+
+// Position is the state of a station at a moment in time
+type Position struct {
+	Latitude  float64
+	Longitude float64
+	Sog       float64 // Knots
+	Cog       float64 // Degrees
+	Stopped   bool    // Set if the end of a route was reached
+}
+
+const earthRadiusNM = 3440.065
+
+// legLength returns the length of the leg between two waypoints in nautical miles and its course.
+// The legs are rhumb lines, which is accurate enough for the short distances that are simulated.
+func legLength(a Waypoint, b Waypoint) (float64, float64) {
+	lat1, lat2 := a.Latitude*math.Pi/180, b.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+	if dLon > math.Pi {
+		dLon -= 2 * math.Pi
+	} else if dLon < -math.Pi {
+		dLon += 2 * math.Pi
+	}
+
+	/* Stretched latitude difference of the Mercator projection */
+	dPsi := math.Log(math.Tan(math.Pi/4+lat2/2) / math.Tan(math.Pi/4+lat1/2))
+	q := math.Cos(lat1)
+	if math.Abs(dPsi) > 1e-12 {
+		q = dLat / dPsi
+	}
+
+	distance := math.Sqrt(dLat*dLat+q*q*dLon*dLon) * earthRadiusNM
+	course := math.Mod(math.Atan2(dLon, dPsi)*180/math.Pi+360, 360)
+	return distance, course
+}
+
+// interpolate returns the point at fraction f of the leg from a to b
+func interpolate(a Waypoint, b Waypoint, f float64) Waypoint {
+	dLon := b.Longitude - a.Longitude
+	if dLon > 180 {
+		dLon -= 360
+	} else if dLon < -180 {
+		dLon += 360
+	}
+
+	lon := a.Longitude + f*dLon
+	if lon > 180 {
+		lon -= 360
+	} else if lon < -180 {
+		lon += 360
+	}
+
+	return Waypoint{
+		Latitude:  a.Latitude + f*(b.Latitude-a.Latitude),
+		Longitude: lon,
+	}
+}
+
+// PositionAt returns where the station is after travelling for the given time
+func (s *Station) PositionAt(elapsed time.Duration) Position {
+	start := s.Route[0]
+	if !s.moving() {
+		return Position{Latitude: start.Latitude, Longitude: start.Longitude, Stopped: true}
+	}
+
+	/* Build the legs, a looping route returns to the start */
+	points := s.Route
+	if s.Loop {
+		points = append(append([]Waypoint{}, s.Route...), s.Route[0])
+	}
+
+	total := 0.0
+	lengths := make([]float64, len(points)-1)
+	courses := make([]float64, len(points)-1)
+	for i := range lengths {
+		lengths[i], courses[i] = legLength(points[i], points[i+1])
+		total += lengths[i]
+	}
+
+	distance := s.Speed * elapsed.Hours()
+	if total <= 0 {
+		return Position{Latitude: start.Latitude, Longitude: start.Longitude, Stopped: true}
+	}
+	if s.Loop {
+		distance = math.Mod(distance, total)
+	} else if distance >= total {
+		end := points[len(points)-1]
+		return Position{Latitude: end.Latitude, Longitude: end.Longitude, Cog: courses[len(courses)-1], Stopped: true}
+	}
+
+	for i, l := range lengths {
+		if distance <= l && l > 0 {
+			p := interpolate(points[i], points[i+1], distance/l)
+			return Position{Latitude: p.Latitude, Longitude: p.Longitude, Sog: s.Speed, Cog: courses[i]}
+		}
+		distance -= l
+	}
+
+	end := points[len(points)-1]
+	return Position{Latitude: end.Latitude, Longitude: end.Longitude, Sog: s.Speed, Cog: courses[len(courses)-1]}
+}
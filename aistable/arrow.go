@@ -0,0 +1,289 @@
+package aistable
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+// DefaultBatchSize is the number of rows in a record batch if ArrowWriter.BatchSize is not set
+const DefaultBatchSize = 65536
+
+var arrowMagic = []byte("ARROW1\x00\x00")
+
+/* Constants of the Arrow flatbuffer schemas (Schema.fbs, Message.fbs) */
+const (
+	arrowMetadataV5 = 4
+
+	arrowHeaderSchema      = 1
+	arrowHeaderRecordBatch = 3
+
+	arrowTypeInt           = 2
+	arrowTypeFloatingPoint = 3
+	arrowTypeUtf8          = 5
+	arrowTypeBool          = 6
+
+	arrowPrecisionDouble = 2
+)
+
+// arrowBlock is the location of a record batch in the file
+type arrowBlock struct {
+	offset         int64
+	metaDataLength int64
+	bodyLength     int64
+}
+
+// ArrowWriter writes packets as an Apache Arrow IPC file (the random access format, also known as
+// Feather version 2). Booleans are stored as Bool, signed and unsigned integers as 64 bit Int,
+// floats as double precision FloatingPoint and strings and binary data as Utf8 columns. Close must
+// be called to write the last record batch and the file footer.
+type ArrowWriter struct {
+	// BatchSize is the maximum number of rows in a record batch
+	BatchSize int
+
+	schema *Schema
+	w      io.Writer
+	offset int64
+	err    error
+
+	rows   [][]interface{}
+	blocks []arrowBlock
+}
+
+// NewArrowWriter creates an ArrowWriter that writes rows of schema s to w
+func NewArrowWriter(w io.Writer, s *Schema) *ArrowWriter {
+	return &ArrowWriter{
+		schema: s,
+		w:      w,
+	}
+}
+
+func (a *ArrowWriter) write(data []byte) {
+	if a.err != nil {
+		return
+	}
+
+	n, err := a.w.Write(data)
+	a.offset += int64(n)
+	a.err = err
+}
+
+func (a *ArrowWriter) arrowSchema() *fbTable {
+	var fields fbVector
+	for _, c := range a.schema.Columns {
+		var typeID uint8
+		typ := &fbTable{}
+
+		switch c.Type {
+		case TypeBool:
+			typeID = arrowTypeBool
+		case TypeInt:
+			typeID = arrowTypeInt
+			typ.setInt32(0, 64).setBool(1, true)
+		case TypeUint:
+			typeID = arrowTypeInt
+			typ.setInt32(0, 64).setBool(1, false)
+		case TypeFloat:
+			typeID = arrowTypeFloatingPoint
+			typ.setInt16(0, arrowPrecisionDouble)
+		default:
+			typeID = arrowTypeUtf8
+		}
+
+		f := &fbTable{}
+		f.setRef(0, fbString(c.Name))
+		f.setBool(1, c.Nullable)
+		f.setUint8(2, typeID)
+		f.setRef(3, typ)
+		f.setRef(5, fbVector{})
+		fields = append(fields, f)
+	}
+
+	s := &fbTable{}
+	s.setInt16(0, 0) /* Little endian */
+	s.setRef(1, fields)
+	return s
+}
+
+// writeMessage writes an encapsulated IPC message and returns its location
+func (a *ArrowWriter) writeMessage(headerType uint8, header *fbTable, body []byte) arrowBlock {
+	msg := &fbTable{}
+	msg.setInt16(0, arrowMetadataV5)
+	msg.setUint8(1, headerType)
+	msg.setRef(2, header)
+	msg.setInt64(3, int64(len(body)))
+	meta := fbFinish(msg)
+
+	block := arrowBlock{
+		offset:         a.offset,
+		metaDataLength: int64(8 + len(meta)),
+		bodyLength:     int64(len(body)),
+	}
+
+	prefix := make([]byte, 8)
+	binary.LittleEndian.PutUint32(prefix, 0xFFFFFFFF)
+	binary.LittleEndian.PutUint32(prefix[4:], uint32(len(meta)))
+	a.write(prefix)
+	a.write(meta)
+	a.write(body)
+
+	return block
+}
+
+func (a *ArrowWriter) start() {
+	if a.offset > 0 || a.err != nil {
+		return
+	}
+
+	a.write(arrowMagic)
+	a.writeMessage(arrowHeaderSchema, a.arrowSchema(), nil)
+}
+
+// arrowBody collects the buffers of a record batch
+type arrowBody struct {
+	data    []byte
+	buffers fbStructVector
+}
+
+func (b *arrowBody) add(buf []byte) {
+	b.buffers.appendInt64s(int64(len(b.data)), int64(len(buf)))
+	b.data = append(b.data, buf...)
+	for len(b.data)%8 != 0 {
+		b.data = append(b.data, 0)
+	}
+}
+
+func setBit(bitmap []byte, i int) {
+	bitmap[i/8] |= 1 << uint(i%8)
+}
+
+func (a *ArrowWriter) flushBatch() {
+	if len(a.rows) == 0 {
+		return
+	}
+	a.start()
+
+	n := len(a.rows)
+	body := &arrowBody{}
+	nodes := &fbStructVector{}
+
+	for col, c := range a.schema.Columns {
+		validity := make([]byte, (n+7)/8)
+		nulls := 0
+		for i, row := range a.rows {
+			if row[col] == nil {
+				nulls++
+			} else {
+				setBit(validity, i)
+			}
+		}
+
+		nodes.appendInt64s(int64(n), int64(nulls))
+		if nulls == 0 {
+			body.add(nil)
+		} else {
+			body.add(validity)
+		}
+
+		switch c.Type {
+		case TypeBool:
+			values := make([]byte, (n+7)/8)
+			for i, row := range a.rows {
+				if v, _ := row[col].(bool); v {
+					setBit(values, i)
+				}
+			}
+			body.add(values)
+
+		case TypeInt, TypeUint, TypeFloat:
+			values := make([]byte, 8*n)
+			for i, row := range a.rows {
+				var v uint64
+				switch x := row[col].(type) {
+				case int64:
+					v = uint64(x)
+				case uint64:
+					v = x
+				case float64:
+					v = math.Float64bits(x)
+				}
+				binary.LittleEndian.PutUint64(values[8*i:], v)
+			}
+			body.add(values)
+
+		default:
+			offsets := make([]byte, 4*(n+1))
+			var data []byte
+			for i, row := range a.rows {
+				s, _ := row[col].(string)
+				data = append(data, s...)
+				binary.LittleEndian.PutUint32(offsets[4*(i+1):], uint32(len(data)))
+			}
+			body.add(offsets)
+			body.add(data)
+		}
+	}
+
+	batch := &fbTable{}
+	batch.setInt64(0, int64(n))
+	batch.setRef(1, nodes)
+	batch.setRef(2, &body.buffers)
+
+	a.blocks = append(a.blocks, a.writeMessage(arrowHeaderRecordBatch, batch, body.data))
+	a.rows = a.rows[:0]
+}
+
+// Write adds a packet to the output
+func (a *ArrowWriter) Write(p ais.Packet) error {
+	if a.err != nil {
+		return a.err
+	}
+
+	row, err := a.schema.Row(p)
+	if err != nil {
+		return err
+	}
+	a.rows = append(a.rows, row)
+
+	batchSize := a.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	if len(a.rows) >= batchSize {
+		a.flushBatch()
+	}
+
+	return a.err
+}
+
+// Close writes the remaining rows and the file footer. It does not close the underlying writer.
+func (a *ArrowWriter) Close() error {
+	a.start()
+	a.flushBatch()
+
+	/* End of stream marker */
+	a.write([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0, 0, 0, 0})
+
+	blocks := &fbStructVector{align: 8}
+	for _, b := range a.blocks {
+		/* The 32 bit metaDataLength is followed by padding, so it can be written as 64 bit */
+		blocks.appendInt64s(b.offset, b.metaDataLength, b.bodyLength)
+	}
+
+	footer := &fbTable{}
+	footer.setInt16(0, arrowMetadataV5)
+	footer.setRef(1, a.arrowSchema())
+	footer.setRef(2, &fbStructVector{align: 8})
+	footer.setRef(3, blocks)
+	data := fbFinish(footer)
+
+	length := make([]byte, 4)
+	binary.LittleEndian.PutUint32(length, uint32(len(data)))
+	a.write(data)
+	a.write(length)
+	a.write(arrowMagic[:6])
+
+	return a.err
+}
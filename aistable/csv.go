@@ -0,0 +1,86 @@
+package aistable
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+// CSVWriter writes packets as CSV records. The first record contains the column names. Null values
+// are written as empty fields.
+type CSVWriter struct {
+	schema *Schema
+	w      *csv.Writer
+	record []string
+
+	headerWritten bool
+}
+
+// NewCSVWriter creates a CSVWriter that writes rows of schema s to w
+func NewCSVWriter(w io.Writer, s *Schema) *CSVWriter {
+	return &CSVWriter{
+		schema: s,
+		w:      csv.NewWriter(w),
+		record: make([]string, len(s.Columns)),
+	}
+}
+
+// FormatValue converts a value returned by Schema.Row to text
+func FormatValue(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case bool:
+		return strconv.FormatBool(x)
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case uint64:
+		return strconv.FormatUint(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case string:
+		return x
+	}
+	panic("aistable: unexpected value type")
+}
+
+func (c *CSVWriter) writeHeader() error {
+	if c.headerWritten {
+		return nil
+	}
+	c.headerWritten = true
+
+	for i, col := range c.schema.Columns {
+		c.record[i] = col.Name
+	}
+	return c.w.Write(c.record)
+}
+
+// Write adds a packet to the output
+func (c *CSVWriter) Write(p ais.Packet) error {
+	row, err := c.schema.Row(p)
+	if err != nil {
+		return err
+	}
+
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+
+	for i, v := range row {
+		c.record[i] = FormatValue(v)
+	}
+	return c.w.Write(c.record)
+}
+
+// Flush writes any buffered data, and the header if no packets were written
+func (c *CSVWriter) Flush() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,204 @@
+package aistable
+
+import (
+	"encoding/binary"
+)
+
+/* A minimal FlatBuffers serializer, just enough to write the Arrow IPC metadata. Objects are written
+ * front to back: every table is followed by the objects it refers to, so all offsets point forward
+ * as the format requires. */
+
+// fbTable is a table, the index in fields is the field ID
+type fbTable struct {
+	fields []fbField
+}
+
+// fbField is either an inline scalar or a reference to another object
+type fbField struct {
+	inline []byte
+	ref    interface{}
+}
+
+// fbString is a string object
+type fbString string
+
+// fbVector is a vector of references to tables
+type fbVector []*fbTable
+
+// fbStructVector is a vector of inline structs
+type fbStructVector struct {
+	align int
+	data  []byte
+	n     int
+}
+
+func (t *fbTable) set(id int, f fbField) *fbTable {
+	for len(t.fields) <= id {
+		t.fields = append(t.fields, fbField{})
+	}
+	t.fields[id] = f
+	return t
+}
+
+func (t *fbTable) setUint8(id int, v uint8) *fbTable {
+	return t.set(id, fbField{inline: []byte{v}})
+}
+
+func (t *fbTable) setBool(id int, v bool) *fbTable {
+	if v {
+		return t.setUint8(id, 1)
+	}
+	return t.setUint8(id, 0)
+}
+
+func (t *fbTable) setInt16(id int, v int16) *fbTable {
+	b := make([]byte, 2)
+	binary.LittleEndian.PutUint16(b, uint16(v))
+	return t.set(id, fbField{inline: b})
+}
+
+func (t *fbTable) setInt32(id int, v int32) *fbTable {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, uint32(v))
+	return t.set(id, fbField{inline: b})
+}
+
+func (t *fbTable) setInt64(id int, v int64) *fbTable {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, uint64(v))
+	return t.set(id, fbField{inline: b})
+}
+
+func (t *fbTable) setRef(id int, ref interface{}) *fbTable {
+	return t.set(id, fbField{ref: ref})
+}
+
+// appendInt64s adds a struct consisting of int64 fields to the vector
+func (v *fbStructVector) appendInt64s(values ...int64) {
+	for _, x := range values {
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], uint64(x))
+		v.data = append(v.data, b[:]...)
+	}
+	v.align = 8
+	v.n++
+}
+
+type fbBuilder struct {
+	buf []byte
+}
+
+func (b *fbBuilder) pad(align int) {
+	for len(b.buf)%align != 0 {
+		b.buf = append(b.buf, 0)
+	}
+}
+
+func (b *fbBuilder) appendUint16(v uint16) {
+	b.buf = append(b.buf, byte(v), byte(v>>8))
+}
+
+func (b *fbBuilder) appendUint32(v uint32) {
+	b.buf = append(b.buf, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+// patchOffset stores the offset from pos to target at pos
+func (b *fbBuilder) patchOffset(pos int, target int) {
+	binary.LittleEndian.PutUint32(b.buf[pos:], uint32(target-pos))
+}
+
+func (b *fbBuilder) write(o interface{}) int {
+	switch x := o.(type) {
+	case *fbTable:
+		return b.writeTable(x)
+
+	case fbString:
+		b.pad(4)
+		pos := len(b.buf)
+		b.appendUint32(uint32(len(x)))
+		b.buf = append(b.buf, x...)
+		b.buf = append(b.buf, 0)
+		return pos
+
+	case fbVector:
+		b.pad(4)
+		pos := len(b.buf)
+		b.appendUint32(uint32(len(x)))
+		b.buf = append(b.buf, make([]byte, 4*len(x))...)
+		for i, t := range x {
+			b.patchOffset(pos+4+4*i, b.writeTable(t))
+		}
+		return pos
+
+	case *fbStructVector:
+		align := x.align
+		if align < 4 {
+			align = 4
+		}
+		/* The elements following the length must be aligned */
+		for (len(b.buf)+4)%align != 0 {
+			b.buf = append(b.buf, 0)
+		}
+		pos := len(b.buf)
+		b.appendUint32(uint32(x.n))
+		b.buf = append(b.buf, x.data...)
+		return pos
+	}
+
+	panic("BUG: unknown flatbuffer object")
+}
+
+func (b *fbBuilder) writeTable(t *fbTable) int {
+	/* Lay out the inline part, every field aligned to its size */
+	offsets := make([]int, len(t.fields))
+	size := 4
+	for i, f := range t.fields {
+		n := len(f.inline)
+		if f.ref != nil {
+			n = 4
+		}
+		if n == 0 {
+			continue
+		}
+		for size%n != 0 {
+			size++
+		}
+		offsets[i] = size
+		size += n
+	}
+
+	b.pad(2)
+	vtable := len(b.buf)
+	b.appendUint16(uint16(4 + 2*len(t.fields)))
+	b.appendUint16(uint16(size))
+	for _, o := range offsets {
+		b.appendUint16(uint16(o))
+	}
+
+	/* The table start is 8 byte aligned, so the fields are aligned as well */
+	b.pad(8)
+	pos := len(b.buf)
+	b.buf = append(b.buf, make([]byte, size)...)
+	binary.LittleEndian.PutUint32(b.buf[pos:], uint32(int32(pos-vtable)))
+
+	for i, f := range t.fields {
+		if f.inline != nil {
+			copy(b.buf[pos+offsets[i]:], f.inline)
+		}
+	}
+	for i, f := range t.fields {
+		if f.ref != nil {
+			b.patchOffset(pos+offsets[i], b.write(f.ref))
+		}
+	}
+
+	return pos
+}
+
+// fbFinish serializes a flatbuffer with root table t, padded to a multiple of 8 bytes
+func fbFinish(t *fbTable) []byte {
+	b := &fbBuilder{buf: make([]byte, 4, 256)}
+	b.patchOffset(0, b.writeTable(t))
+	b.pad(8)
+	return b.buf
+}
@@ -0,0 +1,396 @@
+// Package aistable flattens AIS packets into rows of a table with well-defined columns, and writes
+// them as CSV or as an Apache Arrow IPC file.
+//
+// The columns are derived from the struct tags of the ais package, the same ones parser_generator
+// uses. Nested structures are flattened by joining the snake case field names, for example
+// dimension_a, eta_month or report_b_call_sign, and array elements get their index in the name.
+// Spare fields that are always encoded as zero are left out. Fields that are not present in a packet,
+// because a nested structure is not valid or because a field depends on a flag that is not set, are
+// null.
+package aistable
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+// ColumnType is the type of the values in a column
+type ColumnType int
+
+const (
+	// TypeBool columns contain bool values
+	TypeBool ColumnType = iota
+	// TypeInt columns contain int64 values
+	TypeInt
+	// TypeUint columns contain uint64 values
+	TypeUint
+	// TypeFloat columns contain float64 values
+	TypeFloat
+	// TypeString columns contain string values
+	TypeString
+	// TypeBits columns contain binary data as a string of '0' and '1' characters
+	TypeBits
+)
+
+func (c ColumnType) String() string {
+	switch c {
+	case TypeBool:
+		return "bool"
+	case TypeInt:
+		return "int"
+	case TypeUint:
+		return "uint"
+	case TypeFloat:
+		return "float"
+	case TypeString:
+		return "string"
+	case TypeBits:
+		return "bits"
+	}
+	return "unknown"
+}
+
+// Column describes a column of a Schema
+type Column struct {
+	Name     string
+	Type     ColumnType
+	Width    int  // Number of bits in the message, 0 for variable length fields
+	Nullable bool // True if some rows may not have a value
+}
+
+// step selects a field of a struct and optionally an element of the array in that field
+type step struct {
+	field int
+	elem  int
+}
+
+// condition is a bool field that must have the value want for a leaf to be present
+type condition struct {
+	path []step
+	want bool
+}
+
+// leaf is a field of a packet type that results in a column
+type leaf struct {
+	column     Column
+	path       []step
+	conditions []condition
+}
+
+// binding links a leaf of a packet type to a column of the schema
+type binding struct {
+	column int
+	leaf   leaf
+}
+
+// Schema describes the columns of a table and how packets are converted to rows
+type Schema struct {
+	Columns []Column
+
+	bindings map[reflect.Type][]binding
+}
+
+/* Names that the generic conversion would split in an unexpected place */
+var nameReplacer = strings.NewReplacer("AtoN", "Aton")
+
+// columnName converts a Go field name to snake case
+func columnName(name string) string {
+	runes := []rune(nameReplacer.Replace(name))
+
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				sb.WriteByte('_')
+			}
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+
+	return sb.String()
+}
+
+func appendPath(path []step, s step) []step {
+	return append(append([]step(nil), path...), s)
+}
+
+func fieldWidth(f reflect.StructField) int {
+	width, _ := strconv.Atoi(f.Tag.Get("aisWidth"))
+	if width < 0 {
+		return 0
+	}
+	return width
+}
+
+// walkStruct adds a leaf for every field of t, which is found at path in the packet
+func walkStruct(t reflect.Type, prefix string, path []step, conditions []condition, leaves []leaf) []leaf {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		fieldPath := appendPath(path, step{field: i, elem: -1})
+
+		if _, spare := f.Tag.Lookup("aisEncodeAs"); spare {
+			continue
+		}
+
+		if f.Anonymous {
+			leaves = walkStruct(f.Type, prefix, fieldPath, conditions, leaves)
+			continue
+		}
+
+		if f.Name == "Valid" {
+			/* Added by walkValue, the Valid field of the packet itself is always true */
+			continue
+		}
+
+		fieldConditions := conditions
+		if depends, ok := f.Tag.Lookup("aisDependsField"); ok {
+			want := true
+			if strings.HasPrefix(depends, "~") {
+				want = false
+				depends = depends[1:]
+			}
+
+			df, ok := t.FieldByName(depends)
+			if !ok {
+				panic("aistable: unknown aisDependsField " + depends)
+			}
+			fieldConditions = append(append([]condition(nil), conditions...), condition{
+				path: appendPath(path, step{field: df.Index[0], elem: -1}),
+				want: want,
+			})
+		}
+
+		name := prefix + columnName(f.Name)
+
+		if f.Type.Kind() == reflect.Array {
+			for j := 0; j < f.Type.Len(); j++ {
+				elemPath := appendPath(path, step{field: i, elem: j})
+				leaves = walkValue(f.Type.Elem(), name+"_"+strconv.Itoa(j), fieldWidth(f), elemPath, fieldConditions, leaves)
+			}
+			continue
+		}
+
+		leaves = walkValue(f.Type, name, fieldWidth(f), fieldPath, fieldConditions, leaves)
+	}
+
+	return leaves
+}
+
+// walkValue adds the leaves for a value of type t
+func walkValue(t reflect.Type, name string, width int, path []step, conditions []condition, leaves []leaf) []leaf {
+	var ct ColumnType
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if vf, ok := t.FieldByName("Valid"); ok {
+			validPath := appendPath(path, step{field: vf.Index[0], elem: -1})
+			leaves = append(leaves, leaf{
+				column:     Column{Name: name + "_valid", Type: TypeBool, Nullable: len(conditions) > 0},
+				path:       validPath,
+				conditions: conditions,
+			})
+
+			/* All other fields are only present if the structure is valid */
+			conditions = append(append([]condition(nil), conditions...), condition{path: validPath, want: true})
+		}
+		return walkStruct(t, name+"_", path, conditions, leaves)
+	case reflect.Bool:
+		ct = TypeBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		ct = TypeInt
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		ct = TypeUint
+	case reflect.Float32, reflect.Float64:
+		ct = TypeFloat
+	case reflect.String:
+		ct = TypeString
+	case reflect.Slice:
+		ct = TypeBits
+	default:
+		panic("aistable: unsupported field type " + t.String())
+	}
+
+	return append(leaves, leaf{
+		column:     Column{Name: name, Type: ct, Width: width, Nullable: len(conditions) > 0},
+		path:       path,
+		conditions: conditions,
+	})
+}
+
+// packetLeaves returns the leaves of a packet type
+func packetLeaves(t reflect.Type) []leaf {
+	return walkStruct(t, "", nil, nil, nil)
+}
+
+func (s *Schema) bind(t reflect.Type, leaves []leaf, index map[string]int) {
+	if _, ok := s.bindings[t]; ok {
+		return
+	}
+
+	var bindings []binding
+	for _, l := range leaves {
+		col, ok := index[l.column.Name]
+		if !ok {
+			col = len(s.Columns)
+			index[l.column.Name] = col
+			s.Columns = append(s.Columns, l.column)
+		} else {
+			s.Columns[col] = mergeColumns(s.Columns[col], l.column)
+		}
+		bindings = append(bindings, binding{column: col, leaf: l})
+	}
+	s.bindings[t] = bindings
+}
+
+// mergeColumns combines two definitions of a column with the same name. Numbers of different types
+// become floats, anything else becomes a string.
+func mergeColumns(a Column, b Column) Column {
+	a.Nullable = a.Nullable || b.Nullable
+	if b.Width > a.Width {
+		a.Width = b.Width
+	}
+
+	if a.Type != b.Type {
+		numeric := func(t ColumnType) bool {
+			return t == TypeInt || t == TypeUint || t == TypeFloat
+		}
+		if numeric(a.Type) && numeric(b.Type) {
+			a.Type = TypeFloat
+		} else {
+			a.Type = TypeString
+		}
+	}
+
+	return a
+}
+
+// TypeSchema returns the schema for the packets of one message ID
+func TypeSchema(msgID uint8) (*Schema, error) {
+	t := ais.MessageType(msgID)
+	if t == nil {
+		return nil, fmt.Errorf("aistable: unknown message ID [%d]", msgID)
+	}
+
+	s := &Schema{bindings: make(map[reflect.Type][]binding)}
+	s.bind(t, packetLeaves(t), make(map[string]int))
+	return s, nil
+}
+
+// UnifiedSchema returns one wide schema that can hold all message types. Columns that are not used
+// by every message type are nullable. A column name used with different types in different messages
+// has a type that can hold both.
+func UnifiedSchema() *Schema {
+	s := &Schema{bindings: make(map[reflect.Type][]binding)}
+	index := make(map[string]int)
+
+	for msgID := uint8(1); msgID <= 27; msgID++ {
+		t := ais.MessageType(msgID)
+		s.bind(t, packetLeaves(t), index)
+	}
+
+	/* Mark the columns that do not appear in every message type */
+	for i := range s.Columns {
+		for _, bindings := range s.bindings {
+			found := false
+			for _, b := range bindings {
+				if b.column == i {
+					found = true
+					break
+				}
+			}
+			if !found {
+				s.Columns[i].Nullable = true
+				break
+			}
+		}
+	}
+
+	return s
+}
+
+func resolve(v reflect.Value, path []step) reflect.Value {
+	for _, s := range path {
+		v = v.Field(s.field)
+		if s.elem >= 0 {
+			v = v.Index(s.elem)
+		}
+	}
+	return v
+}
+
+// value returns the value of the leaf in packet v, converted to the type of column c
+func (l *leaf) value(v reflect.Value, c *Column) (interface{}, bool) {
+	for _, cond := range l.conditions {
+		if resolve(v, cond.path).Bool() != cond.want {
+			return nil, false
+		}
+	}
+
+	f := resolve(v, l.path)
+
+	var result interface{}
+	switch l.column.Type {
+	case TypeBool:
+		result = f.Bool()
+	case TypeInt:
+		result = f.Int()
+	case TypeUint:
+		result = f.Uint()
+	case TypeFloat:
+		result = f.Float()
+	case TypeString:
+		result = f.String()
+	case TypeBits:
+		bits := make([]byte, f.Len())
+		for i := range bits {
+			bits[i] = '0' + byte(f.Index(i).Uint()&1)
+		}
+		result = string(bits)
+	}
+
+	if c.Type == l.column.Type {
+		return result, true
+	}
+
+	switch c.Type {
+	case TypeFloat:
+		switch x := result.(type) {
+		case int64:
+			return float64(x), true
+		case uint64:
+			return float64(x), true
+		}
+	case TypeString:
+		return fmt.Sprint(result), true
+	}
+
+	return result, true
+}
+
+// Row converts a packet to the values of the columns of the schema. Null values are nil, the others
+// have the Go type documented for the column type.
+func (s *Schema) Row(p ais.Packet) ([]interface{}, error) {
+	v := reflect.ValueOf(p)
+
+	bindings, ok := s.bindings[v.Type()]
+	if !ok {
+		return nil, fmt.Errorf("aistable: packet type not in schema [%s]", v.Type().Name())
+	}
+
+	row := make([]interface{}, len(s.Columns))
+	for i := range bindings {
+		b := &bindings[i]
+		if value, ok := b.leaf.value(v, &s.Columns[b.column]); ok {
+			row[b.column] = value
+		}
+	}
+
+	return row, nil
+}
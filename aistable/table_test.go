@@ -0,0 +1,383 @@
+package aistable
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+func TestColumnName(t *testing.T) {
+	for in, out := range map[string]string{
+		"MessageID":                 "message_id",
+		"UserID":                    "user_id",
+		"Station1Msg1":              "station1_msg1",
+		"CommunicationStateIsItdma": "communication_state_is_itdma",
+		"VirtualAtoN":               "virtual_aton",
+		"Sog":                       "sog",
+	} {
+		if got := columnName(in); got != out {
+			t.Error("Wrong name for", in, got)
+		}
+	}
+}
+
+func columnIndex(t *testing.T, s *Schema, name string) int {
+	for i, c := range s.Columns {
+		if c.Name == name {
+			return i
+		}
+	}
+	t.Fatal("Column not found", name)
+	return -1
+}
+
+func TestTypeSchema(t *testing.T) {
+	s, err := TypeSchema(24)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	callSign := columnIndex(t, s, "report_b_call_sign")
+	if c := s.Columns[callSign]; c.Type != TypeString || c.Width != 42 || !c.Nullable {
+		t.Error("Wrong column definition", c)
+	}
+
+	row, err := s.Row(ais.StaticDataReport{
+		Header:  ais.Header{MessageID: 24, UserID: 1234},
+		Valid:   true,
+		ReportA: ais.StaticDataReportA{Valid: true, Name: "TEST"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if row[columnIndex(t, s, "user_id")] != uint64(1234) || row[columnIndex(t, s, "report_a_name")] != "TEST" ||
+		row[columnIndex(t, s, "report_a_valid")] != true {
+		t.Error("Wrong values", row)
+	}
+	if row[callSign] != nil || row[columnIndex(t, s, "report_b_valid")] != nil {
+		t.Error("Part B must be null", row)
+	}
+
+	if _, err := s.Row(ais.PositionReport{}); err == nil {
+		t.Error("Packet of other type accepted")
+	}
+	if _, err := TypeSchema(0); err == nil {
+		t.Error("Message ID 0 accepted")
+	}
+}
+
+func readTestPackets(t *testing.T) []ais.Packet {
+	c := ais.CodecNew(false, false)
+
+	var packets []ais.Packet
+	for msgID := 1; msgID <= 27; msgID++ {
+		f, err := os.Open(fmt.Sprintf("../testmsg/%d.msg", msgID))
+		if err != nil {
+			continue
+		}
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			bits := []byte(strings.TrimSpace(scanner.Text()))
+			for i := range bits {
+				bits[i] -= '0'
+			}
+
+			if p := c.DecodePacket(bits); p != nil {
+				packets = append(packets, p)
+			}
+		}
+		f.Close()
+	}
+
+	if len(packets) == 0 {
+		t.Fatal("No test packets")
+	}
+	return packets
+}
+
+func checkType(c Column, v interface{}) bool {
+	switch v.(type) {
+	case nil:
+		return c.Nullable
+	case bool:
+		return c.Type == TypeBool
+	case int64:
+		return c.Type == TypeInt
+	case uint64:
+		return c.Type == TypeUint
+	case float64:
+		return c.Type == TypeFloat
+	case string:
+		return c.Type == TypeString || c.Type == TypeBits
+	}
+	return false
+}
+
+func TestUnifiedSchema(t *testing.T) {
+	s := UnifiedSchema()
+
+	names := make(map[string]bool)
+	for _, c := range s.Columns {
+		if names[c.Name] {
+			t.Error("Duplicate column", c.Name)
+		}
+		names[c.Name] = true
+	}
+
+	if c := s.Columns[columnIndex(t, s, "message_id")]; c.Nullable {
+		t.Error("Header columns must not be nullable")
+	}
+	/* Sog is a Field10 in most messages, but an integer in messages 9 and 27 */
+	if c := s.Columns[columnIndex(t, s, "sog")]; c.Type != TypeFloat || !c.Nullable {
+		t.Error("Wrong merged column", c)
+	}
+
+	for _, p := range readTestPackets(t) {
+		row, err := s.Row(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i, v := range row {
+			if !checkType(s.Columns[i], v) {
+				t.Fatal("Value does not match column", s.Columns[i], v, p)
+			}
+		}
+	}
+}
+
+func TestCSV(t *testing.T) {
+	s, _ := TypeSchema(5)
+
+	var buf bytes.Buffer
+	w := NewCSVWriter(&buf, s)
+	if err := w.Write(ais.ShipStaticData{
+		Header:    ais.Header{MessageID: 5, UserID: 244660561},
+		Valid:     true,
+		Name:      "NAME, WITH COMMA",
+		Dimension: ais.FieldDimension{A: 10, B: 20, C: 3, D: 4},
+		Eta:       ais.FieldETA{Month: 5},
+
+		MaximumStaticDraught: 4.5,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatal("Wrong number of records", len(records))
+	}
+
+	values := make(map[string]string)
+	for i, name := range records[0] {
+		values[name] = records[1][i]
+	}
+	for name, want := range map[string]string{
+		"user_id":                "244660561",
+		"name":                   "NAME, WITH COMMA",
+		"dimension_b":            "20",
+		"eta_month":              "5",
+		"maximum_static_draught": "4.5",
+		"dte":                    "false",
+	} {
+		if values[name] != want {
+			t.Error("Wrong value for", name, values[name])
+		}
+	}
+}
+
+/* A minimal flatbuffer reader to check the Arrow output */
+
+type fbReader []byte
+
+func (r fbReader) u32(pos int) int {
+	return int(binary.LittleEndian.Uint32(r[pos:]))
+}
+
+func (r fbReader) root() int {
+	return r.u32(0)
+}
+
+// field returns the position of a field of the table at pos, or -1 if absent
+func (r fbReader) field(table int, id int) int {
+	vtable := table - int(int32(r.u32(table)))
+	if 4+2*id >= int(binary.LittleEndian.Uint16(r[vtable:])) {
+		return -1
+	}
+	o := int(binary.LittleEndian.Uint16(r[vtable+4+2*id:]))
+	if o == 0 {
+		return -1
+	}
+	return table + o
+}
+
+func (r fbReader) ref(table int, id int) int {
+	pos := r.field(table, id)
+	return pos + r.u32(pos)
+}
+
+func (r fbReader) vector(table int, id int) (n int, elems int) {
+	pos := r.ref(table, id)
+	return r.u32(pos), pos + 4
+}
+
+func (r fbReader) str(table int, id int) string {
+	pos := r.ref(table, id)
+	return string(r[pos+4 : pos+4+r.u32(pos)])
+}
+
+func (r fbReader) i64(pos int) int64 {
+	return int64(binary.LittleEndian.Uint64(r[pos:]))
+}
+
+// readArrow returns the values of column col, in the representation used by Schema.Row
+func readArrow(t *testing.T, data []byte, col string) []interface{} {
+	if !bytes.HasPrefix(data, []byte("ARROW1\x00\x00")) || !bytes.HasSuffix(data, []byte("ARROW1")) {
+		t.Fatal("Missing magic")
+	}
+
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-10:]))
+	footer := fbReader(data[len(data)-10-footerLen : len(data)-10])
+	ft := footer.root()
+
+	schema := footer.ref(ft, 1)
+	nFields, fields := footer.vector(schema, 1)
+	index := -1
+	types := make([]byte, nFields)
+	for i := range types {
+		f := fields + 4*i
+		f += footer.u32(f)
+		types[i] = footer[footer.field(f, 2)]
+		if footer.str(f, 0) == col {
+			index = i
+		}
+	}
+	if index < 0 {
+		t.Fatal("Column not in footer", col)
+	}
+
+	var result []interface{}
+	nBlocks, blocks := footer.vector(ft, 3)
+	for b := 0; b < nBlocks; b++ {
+		offset := int(footer.i64(blocks + 24*b))
+		metaLen := int(footer.i64(blocks+24*b+8) & 0xFFFFFFFF)
+
+		if binary.LittleEndian.Uint32(data[offset:]) != 0xFFFFFFFF {
+			t.Fatal("Missing continuation marker")
+		}
+		msg := fbReader(data[offset+8 : offset+metaLen])
+		mt := msg.root()
+		if msg[msg.field(mt, 1)] != arrowHeaderRecordBatch {
+			t.Fatal("Block is not a record batch")
+		}
+		body := data[offset+metaLen:]
+
+		batch := msg.ref(mt, 2)
+		length := int(msg.i64(msg.field(batch, 0)))
+
+		/* Find the first buffer of the column */
+		_, buffers := msg.vector(batch, 2)
+		_, nodes := msg.vector(batch, 1)
+		buf := 0
+		for i := 0; i < index; i++ {
+			buf += 2
+			if types[i] == arrowTypeUtf8 {
+				buf++
+			}
+		}
+		buffer := func(i int) []byte {
+			pos := buffers + 16*(buf+i)
+			o := msg.i64(pos)
+			return body[o : o+msg.i64(pos+8)]
+		}
+
+		nulls := msg.i64(nodes + 16*index + 8)
+		validity := buffer(0)
+		for i := 0; i < length; i++ {
+			if nulls > 0 && validity[i/8]&(1<<uint(i%8)) == 0 {
+				result = append(result, nil)
+				continue
+			}
+
+			switch types[index] {
+			case arrowTypeBool:
+				result = append(result, buffer(1)[i/8]&(1<<uint(i%8)) != 0)
+			case arrowTypeUtf8:
+				offsets := buffer(1)
+				start := binary.LittleEndian.Uint32(offsets[4*i:])
+				end := binary.LittleEndian.Uint32(offsets[4*i+4:])
+				result = append(result, string(buffer(2)[start:end]))
+			default:
+				result = append(result, binary.LittleEndian.Uint64(buffer(1)[8*i:]))
+			}
+		}
+	}
+
+	return result
+}
+
+func TestArrow(t *testing.T) {
+	s := UnifiedSchema()
+	packets := readTestPackets(t)
+
+	var buf bytes.Buffer
+	w := NewArrowWriter(&buf, s)
+	w.BatchSize = 7
+	for _, p := range packets {
+		if err := w.Write(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, col := range []string{"user_id", "name", "sog", "report_b_valid", "binary_data", "rate_of_turn"} {
+		values := readArrow(t, buf.Bytes(), col)
+		if len(values) != len(packets) {
+			t.Fatal("Wrong number of rows", col, len(values), len(packets))
+		}
+
+		index := columnIndex(t, s, col)
+		for i, p := range packets {
+			row, _ := s.Row(p)
+			want := row[index]
+			switch x := want.(type) {
+			case int64:
+				want = uint64(x)
+			case float64:
+				want = math.Float64bits(x)
+			}
+
+			if values[i] != want {
+				t.Fatal("Wrong value", col, i, values[i], want)
+			}
+		}
+	}
+}
+
+func TestArrowEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewArrowWriter(&buf, UnifiedSchema()).Close(); err != nil {
+		t.Fatal(err)
+	}
+	if values := readArrow(t, buf.Bytes(), "user_id"); len(values) != 0 {
+		t.Error("Rows in empty file", values)
+	}
+}
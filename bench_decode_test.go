@@ -5,7 +5,9 @@ import (
 	"compress/gzip"
 	"github.com/BertoldVdb/go-ais"
 	"github.com/BertoldVdb/go-ais/aisnmea"
+	"github.com/BertoldVdb/go-ais/aisnmeafast"
 	"io"
+	"io/ioutil"
 	"os"
 	"strings"
 	"testing"
@@ -79,3 +81,70 @@ func BenchmarkDecodeFile(b *testing.B) {
 		readFileTest(b, testFile, true)
 	})
 }
+
+// fastBenchInput returns the contents of NMEA_BENCH_FILE, or generated sentences if it is not set
+func fastBenchInput(b *testing.B) []byte {
+	if benchFile := os.Getenv("NMEA_BENCH_FILE"); benchFile != "" {
+		fp, err := os.Open(benchFile)
+		if err != nil {
+			b.Fatal("could not open ", benchFile, "\nerr:\n", err)
+		}
+		defer fp.Close()
+
+		var reader io.Reader = fp
+		if strings.Contains(benchFile, ".gz") {
+			if reader, err = gzip.NewReader(fp); err != nil {
+				b.Fatal("could not create gzip reader", err)
+			}
+		}
+
+		data, err := ioutil.ReadAll(reader)
+		if err != nil {
+			b.Fatal("error reading file", err)
+		}
+		return data
+	}
+
+	e := aisnmeafast.NewEncoder(aisnmeafast.EncoderConfig{AIS: ais.CodecNew(false, false)})
+	var data []byte
+	for i := 0; i < 20000; i++ {
+		header := ais.Header{MessageID: 1, UserID: uint32(244000000 + i%500)}
+		var p ais.Packet = ais.PositionReport{Header: header, Valid: true, TrueHeading: uint16(i % 360)}
+		if i%10 == 0 {
+			header.MessageID = 5
+			p = ais.ShipStaticData{Header: header, Valid: true, Name: "BENCHMARK", Destination: "ROTTERDAM"}
+		}
+		data, _ = e.AppendPacket(data, aisnmeafast.SentenceInfo{}, p)
+	}
+	return data
+}
+
+func BenchmarkDecodeFast(b *testing.B) {
+	input := fastBenchInput(b)
+	callback := func(nmea aisnmeafast.NMEAParsed, ais aisnmeafast.AISParsed) error { return nil }
+
+	b.Run("decoder", func(b *testing.B) {
+		b.SetBytes(int64(len(input)))
+		for i := 0; i < b.N; i++ {
+			d := aisnmeafast.New(aisnmeafast.DecoderConfig{AIS: ais.CodecNew(false, false), AISDecodedFunc: callback})
+			d.Write(input)
+		}
+	})
+
+	for _, order := range []struct {
+		name  string
+		order aisnmeafast.Order
+	}{{"pipeline no order", aisnmeafast.OrderNone}, {"pipeline per MMSI order", aisnmeafast.OrderPerMMSI}, {"pipeline global order", aisnmeafast.OrderGlobal}} {
+		b.Run(order.name, func(b *testing.B) {
+			b.SetBytes(int64(len(input)))
+			for i := 0; i < b.N; i++ {
+				p := aisnmeafast.NewPipeline(aisnmeafast.PipelineConfig{
+					DecoderConfig: aisnmeafast.DecoderConfig{AIS: ais.CodecNew(false, false), AISDecodedFunc: callback},
+					Order:         order.order,
+				})
+				p.Write(input)
+				p.Close()
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+// filter selects the packets that are shown. Empty criteria match everything.
+type filter struct {
+	types   map[uint8]bool
+	mmsi    map[uint32]bool
+	channel byte
+
+	from time.Time
+	to   time.Time
+
+	bboxValid bool
+	minLon    float64
+	minLat    float64
+	maxLon    float64
+	maxLat    float64
+}
+
+func parseList(list string, bits int, what string) ([]uint64, error) {
+	var result []uint64
+	for _, s := range strings.Split(list, ",") {
+		v, err := strconv.ParseUint(strings.TrimSpace(s), 10, bits)
+		if err != nil {
+			return nil, fmt.Errorf("aisdecode: invalid %s [%s]", what, s)
+		}
+		result = append(result, v)
+	}
+	return result, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("aisdecode: invalid time [%s]", s)
+	}
+	return t, nil
+}
+
+func parseFilter(types string, mmsi string, channel string, from string, to string, bbox string) (filter, error) {
+	var f filter
+	var err error
+
+	if types != "" {
+		list, err := parseList(types, 6, "message type")
+		if err != nil {
+			return f, err
+		}
+		f.types = make(map[uint8]bool)
+		for _, v := range list {
+			f.types[uint8(v)] = true
+		}
+	}
+
+	if mmsi != "" {
+		list, err := parseList(mmsi, 30, "MMSI")
+		if err != nil {
+			return f, err
+		}
+		f.mmsi = make(map[uint32]bool)
+		for _, v := range list {
+			f.mmsi[uint32(v)] = true
+		}
+	}
+
+	switch strings.ToUpper(channel) {
+	case "":
+	case "A", "1":
+		f.channel = 1
+	case "B", "2":
+		f.channel = 2
+	default:
+		return f, fmt.Errorf("aisdecode: invalid channel [%s]", channel)
+	}
+
+	if f.from, err = parseTime(from); err != nil {
+		return f, err
+	}
+	if f.to, err = parseTime(to); err != nil {
+		return f, err
+	}
+
+	if bbox != "" {
+		var v [4]float64
+		parts := strings.Split(bbox, ",")
+		if len(parts) != 4 {
+			return f, fmt.Errorf("aisdecode: bounding box needs four values [%s]", bbox)
+		}
+		for i, s := range parts {
+			if v[i], err = strconv.ParseFloat(strings.TrimSpace(s), 64); err != nil {
+				return f, fmt.Errorf("aisdecode: invalid bounding box [%s]", bbox)
+			}
+		}
+		f.bboxValid = true
+		f.minLon, f.minLat, f.maxLon, f.maxLat = v[0], v[1], v[2], v[3]
+	}
+
+	return f, nil
+}
+
+// header returns the header of the packet, taken from the payload if it could not be decoded
+func (r *record) header() ais.Header {
+	if r.Packet != nil {
+		return *r.Packet.GetHeader()
+	}
+
+	var h ais.Header
+	value := func(start int, width int) uint64 {
+		var v uint64
+		for i := start; i < start+width && i < len(r.Bits); i++ {
+			v = v<<1 | uint64(r.Bits[i])
+		}
+		return v
+	}
+	if len(r.Bits) >= 38 {
+		h.MessageID = uint8(value(0, 6))
+		h.RepeatIndicator = uint8(value(6, 2))
+		h.UserID = uint32(value(8, 30))
+	}
+	return h
+}
+
+// position returns the position reported in the packet. Packets without position, or with the
+// "not available" values, return false.
+func (r *record) position() (lat float64, lon float64, ok bool) {
+	if r.Packet == nil {
+		return 0, 0, false
+	}
+
+	v := reflect.ValueOf(r.Packet)
+	if v.Kind() != reflect.Struct {
+		return 0, 0, false
+	}
+	latField := v.FieldByName("Latitude")
+	lonField := v.FieldByName("Longitude")
+	if latField.Kind() != reflect.Float64 || lonField.Kind() != reflect.Float64 {
+		return 0, 0, false
+	}
+
+	lat, lon = latField.Float(), lonField.Float()
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return 0, 0, false
+	}
+	return lat, lon, true
+}
+
+func (f *filter) match(r *record) bool {
+	h := r.header()
+
+	if f.types != nil && !f.types[h.MessageID] {
+		return false
+	}
+	if f.mmsi != nil && !f.mmsi[h.UserID] {
+		return false
+	}
+	if f.channel != 0 && r.Channel != f.channel {
+		return false
+	}
+
+	if !f.from.IsZero() || !f.to.IsZero() {
+		if r.Time.IsZero() || (!f.from.IsZero() && r.Time.Before(f.from)) || (!f.to.IsZero() && !r.Time.Before(f.to)) {
+			return false
+		}
+	}
+
+	if f.bboxValid {
+		lat, lon, ok := r.position()
+		if !ok || lat < f.minLat || lat > f.maxLat || lon < f.minLon || lon > f.maxLon {
+			return false
+		}
+	}
+
+	return true
+}
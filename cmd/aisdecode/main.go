@@ -0,0 +1,266 @@
+// Command aisdecode decodes AIS NMEA sentences and prints the packets as JSON lines, as a table or
+// as a bit-level dissection. Input is read from the files given as arguments, or from stdin if
+// there are none. Gzip compressed files are detected automatically.
+//
+// Lines may start with a receive time as UNIX timestamp (seconds, milliseconds, microseconds or
+// nanoseconds, like aisnmea/testdata/aistest.nmea), otherwise the time is taken from the TAG block.
+// The default decoder is aisnmeafast, -slow selects aisnmea. For messages split over multiple
+// sentences aisnmea reports the time of the first sentence, aisnmeafast that of the last.
+//
+// Usage:
+//
+//	aisdecode [flags] [file ...]
+//
+// For example, to show the class B reports inside a bounding box:
+//
+//	aisdecode -format table -types 18,19,24 -bbox 3.0,51.0,4.5,51.5 capture.nmea.gz
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/BertoldVdb/go-ais"
+	"github.com/BertoldVdb/go-ais/aisnmea"
+	"github.com/BertoldVdb/go-ais/aisnmeafast"
+)
+
+// record is a decoded packet with the information used by the filters and output formats
+type record struct {
+	Time    time.Time
+	Channel byte
+	Packet  ais.Packet
+
+	// Bits is the payload, one byte per bit
+	Bits []byte
+}
+
+// lineDecoder decodes a line and calls emit for every completed packet. t is the time from the
+// line prefix, or zero.
+type lineDecoder interface {
+	decode(line []byte, t time.Time) error
+}
+
+type fastDecoder struct {
+	dec *aisnmeafast.Decoder
+	t   time.Time
+}
+
+func newFastDecoder(passThrough bool, emit func(r *record) error) *fastDecoder {
+	d := &fastDecoder{}
+	d.dec = aisnmeafast.New(aisnmeafast.DecoderConfig{
+		AIS:         ais.CodecNew(false, false),
+		PassThrough: passThrough,
+		AISDecodedFunc: func(n aisnmeafast.NMEAParsed, a aisnmeafast.AISParsed) error {
+			r := &record{
+				Time:    d.t,
+				Channel: a.Channel,
+				Packet:  a.Packet,
+				Bits:    make([]byte, a.NumBits),
+			}
+			for i := range r.Bits {
+				r.Bits[i] = byte(a.Payload[i/64]>>uint(63-i%64)) & 1
+			}
+
+			if tb, err := n.TagBlock(); err == nil && tb.Time != 0 {
+				r.Time = tb.Timestamp()
+			}
+			return emit(r)
+		},
+	})
+	return d
+}
+
+func (d *fastDecoder) decode(line []byte, t time.Time) error {
+	/* The decoder calls the callback from Write, so the time is known there */
+	d.t = t
+	_, err := d.dec.Write(append(line, '\n'))
+	return err
+}
+
+type slowDecoder struct {
+	nc          *aisnmea.NMEACodec
+	passThrough bool
+	emit        func(r *record) error
+}
+
+func (d *slowDecoder) decode(line []byte, t time.Time) error {
+	p, err := d.nc.ParseSentenceWithInfo(string(line), aisnmea.ReceiveInfo{ReceivedAt: t})
+	if err != nil || p == nil || (p.Packet == nil && !d.passThrough) {
+		/* Lines that are not AIS sentences are skipped */
+		return nil
+	}
+
+	return d.emit(&record{
+		Time:    p.ReceivedAt,
+		Channel: p.Channel,
+		Packet:  p.Packet,
+		Bits:    p.Payload,
+	})
+}
+
+// splitTimePrefix removes a UNIX timestamp from the start of the line. The unit is derived from
+// the magnitude of the number, fractional seconds are accepted as well.
+func splitTimePrefix(line []byte) (time.Time, []byte) {
+	i := 0
+	for i < len(line) && (line[i] >= '0' && line[i] <= '9' || line[i] == '.') {
+		i++
+	}
+	if i == 0 || i == len(line) || (line[i] != ' ' && line[i] != '\t') {
+		return time.Time{}, line
+	}
+
+	number := string(line[:i])
+	rest := line[i+1:]
+
+	if v, err := strconv.ParseInt(number, 10, 64); err == nil {
+		switch {
+		case v >= 1e17:
+			return time.Unix(0, v).UTC(), rest
+		case v >= 1e14:
+			return time.Unix(0, v*1e3).UTC(), rest
+		case v >= 1e11:
+			return time.Unix(0, v*1e6).UTC(), rest
+		}
+		return time.Unix(v, 0).UTC(), rest
+	}
+
+	if v, err := strconv.ParseFloat(number, 64); err == nil {
+		sec := int64(v)
+		return time.Unix(sec, int64((v-float64(sec))*1e9)).Round(time.Microsecond).UTC(), rest
+	}
+	return time.Time{}, line
+}
+
+// openInput returns a reader for a file, "-" being stdin. Gzip compressed input is decompressed.
+func openInput(name string) (io.ReadCloser, error) {
+	var f io.ReadCloser = os.Stdin
+	if name != "-" {
+		var err error
+		if f, err = os.Open(name); err != nil {
+			return nil, err
+		}
+	}
+
+	br := bufio.NewReader(f)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1F && magic[1] == 0x8B {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("aisdecode: invalid gzip input [%s]: %v", name, err)
+		}
+		return struct {
+			io.Reader
+			io.Closer
+		}{gz, f}, nil
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{br, f}, nil
+}
+
+func decodeInput(r io.Reader, dec lineDecoder) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		t, line := splitTimePrefix(scanner.Bytes())
+		if err := dec.decode(line, t); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// config contains the parsed command line
+type config struct {
+	format string
+	slow   bool
+	filter filter
+}
+
+func run(cfg config, inputs []string, w io.Writer) error {
+	out, err := newOutput(cfg.format, w)
+	if err != nil {
+		return err
+	}
+
+	/* Packets that cannot be decoded are only useful in the dissector view */
+	passThrough := cfg.format == "dissect"
+
+	emit := func(r *record) error {
+		if !cfg.filter.match(r) {
+			return nil
+		}
+		return out.write(r)
+	}
+
+	var dec lineDecoder
+	if cfg.slow {
+		dec = &slowDecoder{
+			nc:          aisnmea.NMEACodecNew(ais.CodecNew(false, false)),
+			passThrough: passThrough,
+			emit:        emit,
+		}
+	} else {
+		dec = newFastDecoder(passThrough, emit)
+	}
+
+	if len(inputs) == 0 {
+		inputs = []string{"-"}
+	}
+
+	for _, name := range inputs {
+		r, err := openInput(name)
+		if err != nil {
+			return err
+		}
+		err = decodeInput(r, dec)
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return out.flush()
+}
+
+func main() {
+	var cfg config
+	var types, mmsi, channel, from, to, bbox string
+
+	flag.StringVar(&cfg.format, "format", "json", "Output format: json, table or dissect")
+	flag.BoolVar(&cfg.slow, "slow", false, "Use the aisnmea decoder instead of aisnmeafast")
+	flag.StringVar(&types, "types", "", "Comma separated message IDs to show")
+	flag.StringVar(&mmsi, "mmsi", "", "Comma separated MMSIs to show")
+	flag.StringVar(&channel, "channel", "", "Only show packets received on channel A or B")
+	flag.StringVar(&from, "from", "", "Only show packets received at or after this time (RFC 3339)")
+	flag.StringVar(&to, "to", "", "Only show packets received before this time (RFC 3339)")
+	flag.StringVar(&bbox, "bbox", "", "Only show positions inside min_lon,min_lat,max_lon,max_lat")
+	flag.Parse()
+
+	var err error
+	cfg.filter, err = parseFilter(types, mmsi, channel, from, to, bbox)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	err = run(cfg, flag.Args(), w)
+	if ferr := w.Flush(); err == nil {
+		err = ferr
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
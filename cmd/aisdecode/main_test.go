@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testFile = "../../aisnmea/testdata/aistest.nmea"
+
+func TestSplitTimePrefix(t *testing.T) {
+	want := time.Date(2019, 2, 16, 7, 52, 46, 167000000, time.UTC)
+	for _, prefix := range []string{"1550303566167000000", "1550303566167000", "1550303566167", "1550303566.167"} {
+		tm, rest := splitTimePrefix([]byte(prefix + " !AIVDM"))
+		if !tm.Equal(want) || string(rest) != "!AIVDM" {
+			t.Error("Wrong prefix", prefix, tm, string(rest))
+		}
+	}
+
+	if tm, rest := splitTimePrefix([]byte("!AIVDM,1,1,,A,1,0*00")); !tm.IsZero() || string(rest) != "!AIVDM,1,1,,A,1,0*00" {
+		t.Error("Prefix found in plain sentence")
+	}
+	if tm, _ := splitTimePrefix([]byte("1550303566 !AIVDM")); tm.Unix() != 1550303566 {
+		t.Error("Wrong seconds prefix", tm)
+	}
+}
+
+func runTest(t *testing.T, cfg config, inputs ...string) string {
+	var buf bytes.Buffer
+	if err := run(cfg, inputs, &buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestRun(t *testing.T) {
+	fast := strings.Split(strings.TrimSpace(runTest(t, config{format: "json"}, testFile)), "\n")
+	slow := strings.Split(strings.TrimSpace(runTest(t, config{format: "json", slow: true}, testFile)), "\n")
+	if len(fast) < 900 || len(slow) < 900 {
+		t.Fatal("Too few packets", len(fast), len(slow))
+	}
+
+	var r struct {
+		Time    time.Time
+		Channel string
+		Type    string
+		Packet  map[string]interface{}
+	}
+	if err := json.Unmarshal([]byte(fast[0]), &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Type != "PositionReport" || r.Channel != "B" || r.Packet["UserID"] != float64(44730341) ||
+		!r.Time.Equal(time.Unix(0, 1550303566167726840)) {
+		t.Error("Wrong first packet", fast[0])
+	}
+
+	/* Gzip input gives the same result */
+	data, _ := ioutil.ReadFile(testFile)
+	dir, err := ioutil.TempDir("", "aisdecode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	gzFile := path.Join(dir, "test.nmea.gz")
+	var gzBuf bytes.Buffer
+	gz := gzip.NewWriter(&gzBuf)
+	gz.Write(data)
+	gz.Close()
+	ioutil.WriteFile(gzFile, gzBuf.Bytes(), 0644)
+
+	if out := runTest(t, config{format: "json"}, gzFile); out != strings.Join(fast, "\n")+"\n" {
+		t.Error("Gzip input differs")
+	}
+
+	if err := run(config{format: "xml"}, []string{testFile}, &bytes.Buffer{}); err == nil {
+		t.Error("Unknown format accepted")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	f, err := parseFilter("5, 24", "", "b", "2019-02-16T07:52:46.2Z", "2019-02-16T07:52:47Z", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(runTest(t, config{format: "table", filter: f}, testFile)), "\n")
+	if len(lines) < 3 || !strings.HasPrefix(lines[0], "TIME") {
+		t.Fatal("Too few packets", lines)
+	}
+	for _, l := range lines[1:] {
+		fields := strings.Fields(l)
+		if fields[1] != "B" || (fields[2] != "5" && fields[2] != "24") || fields[0] < "2019-02-16T07:52:46.2" {
+			t.Error("Packet not filtered", l)
+		}
+	}
+
+	f, _ = parseFilter("", "244750786", "", "", "", "4.2,51.3,4.3,51.4")
+	lines = strings.Split(strings.TrimSpace(runTest(t, config{format: "table", filter: f}, testFile)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], "51.33908,4.27489") {
+		t.Error("Wrong bounding box result", lines)
+	}
+
+	for _, args := range [][]string{
+		{"64", "", "", "", "", ""},
+		{"", "x", "", "", "", ""},
+		{"", "", "C", "", "", ""},
+		{"", "", "", "yesterday", "", ""},
+		{"", "", "", "", "", "1,2,3"},
+	} {
+		if _, err := parseFilter(args[0], args[1], args[2], args[3], args[4], args[5]); err == nil {
+			t.Error("Invalid filter accepted", args)
+		}
+	}
+}
+
+func checksum(s string) string {
+	var cs byte
+	for i := 0; i < len(s); i++ {
+		cs ^= s[i]
+	}
+	return fmt.Sprintf("%02X", cs)
+}
+
+func TestDissectOutput(t *testing.T) {
+	/* A part A static data report and a message with an unknown ID */
+	input := "!AIVDM,1,1,,B,H6:iQG@p4q@tpO?K;P000000000,2*4D\n"
+	input += "1550303566000 !AIVDM,1,1,,B,w0000000,0*" + checksum("AIVDM,1,1,,B,w0000000,0") + "\n"
+
+	dir, err := ioutil.TempDir("", "aisdecode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := path.Join(dir, "test.nmea")
+	ioutil.WriteFile(name, []byte(input), 0644)
+
+	for _, slow := range []bool{false, true} {
+		out := runTest(t, config{format: "dissect", slow: slow}, name)
+		if strings.Count(out, "=== ") != 2 || !strings.Contains(out, "not decodable") ||
+			!strings.Contains(out, "ReportA.Name") || !strings.Contains(out, "2019-02-16T07:52:46Z channel B") {
+			t.Error("Wrong dissection", slow, out)
+		}
+	}
+}
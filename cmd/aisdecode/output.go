@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/BertoldVdb/go-ais"
+	"github.com/BertoldVdb/go-ais/aisdissect"
+)
+
+type output interface {
+	write(r *record) error
+	flush() error
+}
+
+func newOutput(format string, w io.Writer) (output, error) {
+	switch format {
+	case "json":
+		return &jsonOutput{enc: json.NewEncoder(w)}, nil
+	case "table":
+		return &tableOutput{w: w}, nil
+	case "dissect":
+		return &dissectOutput{w: w}, nil
+	}
+	return nil, fmt.Errorf("aisdecode: unknown output format [%s]", format)
+}
+
+func channelName(channel byte) string {
+	switch channel {
+	case 1:
+		return "A"
+	case 2:
+		return "B"
+	}
+	return "-"
+}
+
+func typeName(p ais.Packet) string {
+	if p == nil {
+		return "Unknown"
+	}
+	return reflect.TypeOf(p).Name()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+/* JSON lines */
+
+type jsonRecord struct {
+	Time    *time.Time `json:",omitempty"`
+	Channel string
+	Type    string
+	Packet  ais.Packet
+}
+
+type jsonOutput struct {
+	enc *json.Encoder
+}
+
+func (o *jsonOutput) write(r *record) error {
+	if r.Packet == nil {
+		return nil
+	}
+
+	jr := jsonRecord{
+		Channel: channelName(r.Channel),
+		Type:    typeName(r.Packet),
+		Packet:  r.Packet,
+	}
+	if !r.Time.IsZero() {
+		t := r.Time.UTC()
+		jr.Time = &t
+	}
+	return o.enc.Encode(jr)
+}
+
+func (o *jsonOutput) flush() error {
+	return nil
+}
+
+/* Table */
+
+type tableOutput struct {
+	w      io.Writer
+	header bool
+}
+
+// summary returns the most interesting fields of a packet: name and position
+func summary(r *record) string {
+	var parts []string
+
+	if r.Packet != nil {
+		v := reflect.ValueOf(r.Packet)
+		if v.Kind() == reflect.Struct {
+			if name := v.FieldByName("Name"); name.Kind() == reflect.String {
+				if s := strings.TrimRight(name.String(), " @"); s != "" {
+					parts = append(parts, fmt.Sprintf("%q", s))
+				}
+			}
+		}
+	}
+
+	if lat, lon, ok := r.position(); ok {
+		parts = append(parts, fmt.Sprintf("%.5f,%.5f", lat, lon))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func (o *tableOutput) write(r *record) error {
+	if !o.header {
+		o.header = true
+		if _, err := fmt.Fprintf(o.w, "%-30s %-2s %-4s %-9s %-36s %s\n", "TIME", "CH", "ID", "MMSI", "TYPE", "SUMMARY"); err != nil {
+			return err
+		}
+	}
+
+	h := r.header()
+	_, err := fmt.Fprintf(o.w, "%-30s %-2s %-4d %09d %-36s %s\n",
+		formatTime(r.Time), channelName(r.Channel), h.MessageID, h.UserID, typeName(r.Packet), summary(r))
+	return err
+}
+
+func (o *tableOutput) flush() error {
+	return nil
+}
+
+/* Dissector view */
+
+type dissectOutput struct {
+	w io.Writer
+}
+
+func (o *dissectOutput) write(r *record) error {
+	h := r.header()
+	status := typeName(r.Packet)
+	if r.Packet == nil || status == "RawPacket" {
+		status = "not decodable"
+	}
+
+	if _, err := fmt.Fprintf(o.w, "=== %s channel %s, message %d from %09d, %d bits, %s\n",
+		formatTime(r.Time), channelName(r.Channel), h.MessageID, h.UserID, len(r.Bits), status); err != nil {
+		return err
+	}
+	if err := aisdissect.WriteText(o.w, ais.Dissect(r.Bits)); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(o.w)
+	return err
+}
+
+func (o *dissectOutput) flush() error {
+	return nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+// checkPacket finds the fields that prevent a packet from being encoded. The rules follow
+// Codec.EncodePacket, which only reports that encoding failed.
+func checkPacket(p ais.Packet) []error {
+	id := p.GetHeader().MessageID
+	desc := ais.Describe(id)
+	if !desc.Valid {
+		return []error{fmt.Errorf("aisencode: unknown message ID [%d]", id)}
+	}
+
+	v := reflect.ValueOf(p)
+
+	var errs []error
+	if !v.FieldByName("Valid").Bool() {
+		errs = append(errs, fmt.Errorf("aisencode: packet is not Valid"))
+	}
+
+	length := checkStruct(v, desc.Fields, "", &errs)
+
+	/* The codec rounds the maximum length like this */
+	maxLength := desc.MaxLength
+	if maxLength%8 != 0 {
+		maxLength += 7 - maxLength%8
+	}
+	if len(errs) == 0 && maxLength > 0 && length > maxLength {
+		errs = append(errs, fmt.Errorf("aisencode: packet needs %d bits, more than the maximum of %d [%s]", length, maxLength, desc.Name))
+	}
+
+	return errs
+}
+
+// checkStruct checks the fields of v and returns the number of bits they occupy
+func checkStruct(v reflect.Value, fields []ais.FieldDescriptor, prefix string, errs *[]error) int {
+	length := 0
+
+	for _, fd := range fields {
+		/* The encoder only looks at the field, the bit position is for the decoder */
+		if c := fd.Condition; c != nil && c.Field != "" && v.FieldByName(c.Field).Bool() != c.Value {
+			continue
+		}
+
+		fv := v.FieldByName(fd.Name)
+		name := prefix + fd.Name
+
+		switch fd.Type {
+		case ais.FieldTypeStruct:
+			if valid := fv.FieldByName("Valid"); valid.IsValid() && !valid.Bool() {
+				if !fd.Optional {
+					*errs = append(*errs, fmt.Errorf("aisencode: required field is not Valid [%s]", name))
+				}
+				continue
+			}
+			length += checkStruct(fv, fd.Fields, name+".", errs)
+
+		case ais.FieldTypeArray:
+			/* Elements are encoded until the first one that is not valid, the first is required */
+			for k := 0; k < fv.Len(); k++ {
+				ev := fv.Index(k)
+				en := fmt.Sprintf("%s[%d]", name, k)
+				if valid := ev.FieldByName("Valid"); valid.IsValid() && !valid.Bool() {
+					if k == 0 {
+						*errs = append(*errs, fmt.Errorf("aisencode: first element is not Valid [%s]", en))
+					}
+					break
+				}
+				length += checkStruct(ev, fd.Fields, en+".", errs)
+			}
+
+		case ais.FieldTypeString:
+			s := fv.String()
+			for i := 0; i < len(s); i++ {
+				if s[i] < 32 || s[i] > 95 {
+					*errs = append(*errs, fmt.Errorf("aisencode: %s contains a character that cannot be encoded [%q]", name, s[i]))
+					break
+				}
+			}
+			if fd.Width < 0 {
+				length += 6 * len(s)
+			} else {
+				length += fd.Width
+			}
+
+		case ais.FieldTypeBinary:
+			b := fv.Bytes()
+			for _, bit := range b {
+				if bit > 1 {
+					*errs = append(*errs, fmt.Errorf("aisencode: %s must contain one bit per byte [%d]", name, bit))
+					break
+				}
+			}
+			length += len(b)
+
+		default:
+			length += fd.Width
+			if fd.HasEncodeAs {
+				/* The value is ignored */
+				continue
+			}
+			if raw, ok := rawValue(fv, fd); !ok || !fitsWidth(raw, fd.Width, fd.Signed) {
+				*errs = append(*errs, fmt.Errorf("aisencode: %s does not fit in %d bits [%v]", name, fd.Width, fv.Interface()))
+			}
+		}
+	}
+
+	return length
+}
+
+// rawValue returns the integer the codec encodes for a number or boolean
+func rawValue(fv reflect.Value, fd ais.FieldDescriptor) (int64, bool) {
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(fv.Uint()), fv.Uint() <= 1<<62
+	case reflect.Bool:
+		return 0, true
+	case reflect.Float32, reflect.Float64:
+		return int64(fv.Float() * fd.Scale.Divisor()), true
+	}
+	return 0, false
+}
+
+func fitsWidth(raw int64, width int, signed bool) bool {
+	if width >= 63 {
+		return true
+	}
+	if signed {
+		return raw >= -(int64(1)<<uint(width))/2 && raw <= (int64(1)<<uint(width))/2-1
+	}
+	return raw >= 0 && raw <= (int64(1)<<uint(width))-1
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+// newPacket returns a pointer to an empty packet of the given message ID
+func newPacket(msgID uint64) (reflect.Value, error) {
+	var t reflect.Type
+	if msgID <= 255 {
+		t = ais.MessageType(uint8(msgID))
+	}
+	if t == nil {
+		return reflect.Value{}, fmt.Errorf("aisencode: unknown message ID [%d]", msgID)
+	}
+
+	v := reflect.New(t)
+	v.Elem().FieldByName("MessageID").SetUint(msgID)
+	return v, nil
+}
+
+// input is a packet read from the input, with the optional channel and time of aisdecode records
+type input struct {
+	Packet  ais.Packet
+	Channel byte
+	Time    time.Time
+
+	// Where is used in error messages to identify the input
+	Where string
+}
+
+type inputReader interface {
+	next() (*input, error)
+}
+
+// newInputReader selects the format by looking at the first character: JSON starts with '{'
+func newInputReader(r io.Reader) (inputReader, error) {
+	br := bufio.NewReader(r)
+	for {
+		c, err := br.ReadByte()
+		if err == io.EOF {
+			return &kvReader{}, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+			continue
+		}
+
+		br.UnreadByte()
+		if c == '{' {
+			return &jsonReader{dec: json.NewDecoder(br)}, nil
+		}
+		return &kvReader{scanner: bufio.NewScanner(br)}, nil
+	}
+}
+
+func parseChannel(s string) (byte, error) {
+	switch strings.ToUpper(s) {
+	case "", "-":
+		return 0, nil
+	case "A", "1":
+		return 1, nil
+	case "B", "2":
+		return 2, nil
+	}
+	return 0, fmt.Errorf("aisencode: invalid channel [%s]", s)
+}
+
+/* JSON objects, either packets or the records written by aisdecode */
+
+type jsonReader struct {
+	dec   *json.Decoder
+	count int
+}
+
+func (j *jsonReader) next() (*input, error) {
+	var raw json.RawMessage
+	if err := j.dec.Decode(&raw); err == io.EOF {
+		return nil, io.EOF
+	} else if err != nil {
+		return nil, fmt.Errorf("aisencode: invalid JSON [%v]", err)
+	}
+	j.count++
+
+	in := &input{Where: fmt.Sprintf("object %d", j.count)}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, fmt.Errorf("aisencode: %s is not a JSON object [%v]", in.Where, err)
+	}
+
+	if packet, ok := fields["Packet"]; ok {
+		var record struct {
+			Time    *time.Time
+			Channel string
+		}
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return nil, fmt.Errorf("aisencode: %s is not a valid record [%v]", in.Where, err)
+		}
+		if record.Time != nil {
+			in.Time = *record.Time
+		}
+		var err error
+		if in.Channel, err = parseChannel(record.Channel); err != nil {
+			return nil, err
+		}
+
+		raw = packet
+		fields = nil
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return nil, fmt.Errorf("aisencode: %s has an invalid packet [%v]", in.Where, err)
+		}
+	}
+
+	var msgID uint64
+	if err := json.Unmarshal(fields["MessageID"], &msgID); err != nil {
+		return nil, fmt.Errorf("aisencode: %s has no valid MessageID [%s]", in.Where, fields["MessageID"])
+	}
+
+	v, err := newPacket(msgID)
+	if err != nil {
+		return nil, err
+	}
+
+	/* Unknown fields are most likely typing errors, so they are not ignored */
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v.Interface()); err != nil {
+		return nil, fmt.Errorf("aisencode: %s cannot be converted to %s [%v]", in.Where, v.Elem().Type().Name(), err)
+	}
+
+	if _, ok := fields["Valid"]; !ok {
+		v.Elem().FieldByName("Valid").SetBool(true)
+	}
+
+	in.Packet = v.Elem().Interface().(ais.Packet)
+	return in, nil
+}
+
+/* Blocks of key=value lines, separated by empty lines or --- */
+
+type kvReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+type kvPair struct {
+	key   string
+	value string
+	line  int
+}
+
+func (k *kvReader) next() (*input, error) {
+	if k.scanner == nil {
+		return nil, io.EOF
+	}
+
+	var pairs []kvPair
+	start := 0
+	for k.scanner.Scan() {
+		k.line++
+		line := strings.TrimSpace(k.scanner.Text())
+
+		if line == "" || line == "---" {
+			if len(pairs) > 0 {
+				break
+			}
+			continue
+		}
+		if line[0] == '#' {
+			continue
+		}
+		if start == 0 {
+			start = k.line
+		}
+
+		sep := strings.IndexAny(line, "=:")
+		if sep <= 0 {
+			return nil, fmt.Errorf("aisencode: line %d is not key=value [%s]", k.line, line)
+		}
+		pairs = append(pairs, kvPair{
+			key:   strings.TrimSpace(line[:sep]),
+			value: strings.TrimSpace(line[sep+1:]),
+			line:  k.line,
+		})
+	}
+	if err := k.scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(pairs) == 0 {
+		return nil, io.EOF
+	}
+
+	in := &input{Where: fmt.Sprintf("packet at line %d", start)}
+
+	msgID := uint64(0)
+	for _, p := range pairs {
+		if p.key == "MessageID" {
+			var err error
+			if msgID, err = strconv.ParseUint(p.value, 10, 6); err != nil {
+				return nil, fmt.Errorf("aisencode: line %d has an invalid MessageID [%s]", p.line, p.value)
+			}
+		}
+	}
+	if msgID == 0 {
+		return nil, fmt.Errorf("aisencode: %s has no MessageID", in.Where)
+	}
+
+	v, err := newPacket(msgID)
+	if err != nil {
+		return nil, err
+	}
+	setRequiredValid(v.Elem())
+
+	for _, p := range pairs {
+		if err := setField(v.Elem(), p.key, p.value); err != nil {
+			return nil, fmt.Errorf("aisencode: line %d: %v", p.line, err)
+		}
+	}
+
+	in.Packet = v.Elem().Interface().(ais.Packet)
+	return in, nil
+}
+
+// setRequiredValid marks the packet and the nested structs that are not optional as Valid
+func setRequiredValid(v reflect.Value) {
+	if valid := v.FieldByName("Valid"); valid.IsValid() {
+		if vf, _ := v.Type().FieldByName("Valid"); isOptional(vf) {
+			return
+		}
+		valid.SetBool(true)
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		if f := v.Field(i); f.Kind() == reflect.Struct {
+			setRequiredValid(f)
+		}
+	}
+}
+
+func isOptional(sf reflect.StructField) bool {
+	_, ok := sf.Tag.Lookup("aisOptional")
+	return ok
+}
+
+// setField sets the field at path, like "Dimension.A" or "Destinations[1].DestinationID". Nested
+// structs that are touched become Valid.
+func setField(v reflect.Value, path string, value string) error {
+	for _, part := range strings.Split(path, ".") {
+		index := -1
+		if i := strings.IndexByte(part, '['); i > 0 && strings.HasSuffix(part, "]") {
+			n, err := strconv.Atoi(part[i+1 : len(part)-1])
+			if err != nil {
+				return fmt.Errorf("invalid index [%s]", path)
+			}
+			part, index = part[:i], n
+		}
+
+		if v.Kind() != reflect.Struct {
+			return fmt.Errorf("unknown field [%s]", path)
+		}
+		if valid := v.FieldByName("Valid"); valid.IsValid() && valid.Kind() == reflect.Bool {
+			valid.SetBool(true)
+		}
+
+		v = v.FieldByName(part)
+		if !v.IsValid() {
+			return fmt.Errorf("unknown field [%s]", path)
+		}
+
+		if index >= 0 {
+			if v.Kind() != reflect.Array || index >= v.Len() {
+				return fmt.Errorf("invalid index [%s]", path)
+			}
+			v = v.Index(index)
+		}
+	}
+
+	if len(value) >= 2 && value[0] == '"' {
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return fmt.Errorf("invalid quoted string [%s]", value)
+		}
+		value = unquoted
+	}
+
+	var err error
+	switch v.Kind() {
+	case reflect.Bool:
+		var b bool
+		b, err = strconv.ParseBool(value)
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		n, err = strconv.ParseInt(value, 0, v.Type().Bits())
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n uint64
+		n, err = strconv.ParseUint(value, 0, v.Type().Bits())
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		f, err = strconv.ParseFloat(value, 64)
+		v.SetFloat(f)
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Slice:
+		/* Binary data is written as bits */
+		bits := make([]byte, 0, len(value))
+		for _, c := range value {
+			switch c {
+			case '0', '1':
+				bits = append(bits, byte(c-'0'))
+			case ' ', '_':
+			default:
+				return fmt.Errorf("binary data must be written as bits [%s]", path)
+			}
+		}
+		v.SetBytes(bits)
+	default:
+		return fmt.Errorf("field cannot be set directly [%s]", path)
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid value for %s [%s]", path, value)
+	}
+	return nil
+}
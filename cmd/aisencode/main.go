@@ -0,0 +1,247 @@
+// Command aisencode encodes AIS packets into VDM or VDO sentences. It is intended for crafting test
+// messages by hand. Input is read from the files given as arguments, or from stdin if there are none.
+//
+// Two input formats are accepted. JSON objects have the shape encoding/json produces for the
+// packets of the ais package, the records written by aisdecode -format json are accepted as well.
+// Otherwise the input consists of key=value (or key: value) lines, one packet per block. Blocks
+// are separated by an empty line or ---, lines starting with # are ignored:
+//
+//	MessageID=1
+//	UserID=244123456
+//	NavigationalStatus=0
+//	Longitude=4.4
+//	Latitude=51.2
+//	Sog=12.3
+//	Cog=45
+//	TrueHeading=44
+//	Timestamp=60
+//
+// Fields of nested structs and arrays are written like Dimension.A or Commands[1].DestinationID,
+// binary data as a string of bits. The packet, the required nested structs and the nested structs
+// that are set become Valid.
+// Fixed width strings that are too long are truncated like ais.Codec does.
+//
+// When a packet cannot be encoded, the fields that are responsible are reported.
+//
+// Usage:
+//
+//	aisencode [flags] [file ...]
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/BertoldVdb/go-ais"
+	"github.com/BertoldVdb/go-ais/aisnmea"
+)
+
+// config contains the parsed command line
+type config struct {
+	talker     string
+	ownShip    bool
+	channel    byte
+	lineLength int
+	seqID      int
+
+	tagBlock aisnmea.TagBlock
+	// tagTime selects the c: parameter: "" for the value in tagBlock, "now" or "input"
+	tagTime string
+	// now is used for tagTime "now", it can be replaced in tests
+	now func() time.Time
+}
+
+// encodeError lists the problems of a packet that could not be encoded
+type encodeError struct {
+	where string
+	errs  []error
+}
+
+func (e *encodeError) Error() string {
+	s := fmt.Sprintf("aisencode: %s cannot be encoded", e.where)
+	for _, err := range e.errs {
+		s += "\n\t" + err.Error()
+	}
+	return s
+}
+
+// tagBlockChecksum returns the checksum ParseTagBlock expects
+func tagBlockChecksum(s string) string {
+	checksum := byte(0)
+	for i := 0; i < len(s); i++ {
+		checksum ^= s[i]
+	}
+	return fmt.Sprintf("%s*%02X", s, checksum)
+}
+
+// parseTagBlock parses the TAG Block parameters given on the command line, like "s:station1,c:now".
+// The c: parameter may be "now" or "input" instead of a UNIX time.
+func parseTagBlock(s string) (aisnmea.TagBlock, string, error) {
+	if s == "" {
+		return aisnmea.TagBlock{}, "", nil
+	}
+
+	var params []string
+	tagTime := ""
+	for _, p := range strings.Split(s, ",") {
+		switch p {
+		case "c:now":
+			tagTime = "now"
+		case "c:input":
+			tagTime = "input"
+		default:
+			params = append(params, p)
+		}
+	}
+
+	if len(params) == 0 {
+		return aisnmea.TagBlock{}, tagTime, nil
+	}
+
+	tb, err := aisnmea.ParseTagBlock(tagBlockChecksum(strings.Join(params, ",")))
+	if err != nil {
+		return aisnmea.TagBlock{}, "", fmt.Errorf("aisencode: invalid TAG Block [%s]", s)
+	}
+	return tb, tagTime, nil
+}
+
+func encode(cfg config, codec *ais.Codec, nc *aisnmea.NMEACodec, in *input) ([]string, error) {
+	p := aisnmea.VdmPacket{
+		TalkerID: cfg.talker,
+		OwnShip:  cfg.ownShip,
+		Channel:  cfg.channel,
+		Packet:   in.Packet,
+		Tags:     cfg.tagBlock,
+	}
+	if p.Channel == 0 {
+		p.Channel = in.Channel
+	}
+
+	switch cfg.tagTime {
+	case "now":
+		p.Tags.SetTimestamp(cfg.now(), false)
+	case "input":
+		if !in.Time.IsZero() {
+			p.Tags.SetTimestamp(in.Time, true)
+		}
+	}
+
+	p.Payload = codec.EncodePacket(in.Packet)
+	if p.Payload == nil {
+		errs := checkPacket(in.Packet)
+		if len(errs) == 0 {
+			errs = append(errs, fmt.Errorf("aisencode: the codec rejected the packet"))
+		}
+		return nil, &encodeError{where: in.Where, errs: errs}
+	}
+
+	var sentences []string
+	if cfg.seqID >= 0 {
+		sentences = nc.EncodeSentenceWithSequenceID(p, cfg.seqID)
+	} else {
+		sentences = nc.EncodeSentence(p)
+	}
+	if sentences == nil {
+		return nil, fmt.Errorf("aisencode: %s does not fit in 9 sentences of %d characters", in.Where, cfg.lineLength)
+	}
+
+	return sentences, nil
+}
+
+func run(cfg config, inputs []string, w io.Writer) error {
+	if cfg.now == nil {
+		cfg.now = time.Now
+	}
+
+	codec := ais.CodecNew(false, false)
+	nc := aisnmea.NMEACodecNew(codec)
+	nc.MaxLineLength = cfg.lineLength
+
+	if len(inputs) == 0 {
+		inputs = []string{"-"}
+	}
+
+	for _, name := range inputs {
+		var f io.ReadCloser = os.Stdin
+		if name != "-" {
+			var err error
+			if f, err = os.Open(name); err != nil {
+				return err
+			}
+		}
+
+		err := encodeInput(cfg, codec, nc, f, w)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func encodeInput(cfg config, codec *ais.Codec, nc *aisnmea.NMEACodec, r io.Reader, w io.Writer) error {
+	ir, err := newInputReader(r)
+	if err != nil {
+		return err
+	}
+
+	for {
+		in, err := ir.next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		sentences, err := encode(cfg, codec, nc, in)
+		if err != nil {
+			return err
+		}
+		for _, s := range sentences {
+			if _, err := fmt.Fprintln(w, s); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func main() {
+	var cfg config
+	var channel, tagBlock string
+
+	flag.StringVar(&cfg.talker, "talker", "AI", "Talker ID")
+	flag.BoolVar(&cfg.ownShip, "vdo", false, "Encode own ship reports (VDO) instead of VDM")
+	flag.StringVar(&channel, "channel", "", "Channel A or B, the default is the channel of the input or A")
+	flag.IntVar(&cfg.lineLength, "linelength", 82, "Maximum sentence length including TAG Block and line ending, 0 for no limit")
+	flag.IntVar(&cfg.seqID, "seqid", -1, "Sequential message identifier (0-9), automatic if negative")
+	flag.StringVar(&tagBlock, "tagblock", "", "TAG Block parameters, like s:station1,c:now. c:input uses the time of aisdecode records")
+	flag.Parse()
+
+	var err error
+	if cfg.channel, err = parseChannel(channel); err == nil {
+		cfg.tagBlock, cfg.tagTime, err = parseTagBlock(tagBlock)
+	}
+	if err == nil && cfg.seqID > 9 {
+		err = fmt.Errorf("aisencode: invalid sequential message identifier [%d]", cfg.seqID)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	err = run(cfg, flag.Args(), w)
+	if ferr := w.Flush(); err == nil {
+		err = ferr
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
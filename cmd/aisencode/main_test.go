@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BertoldVdb/go-ais"
+	"github.com/BertoldVdb/go-ais/aisnmea"
+)
+
+func defaultConfig() config {
+	return config{talker: "AI", lineLength: 82, seqID: -1}
+}
+
+func encodeString(cfg config, input string) (string, error) {
+	var buf bytes.Buffer
+	codec := ais.CodecNew(false, false)
+	nc := aisnmea.NMEACodecNew(codec)
+	nc.MaxLineLength = cfg.lineLength
+	err := encodeInput(cfg, codec, nc, strings.NewReader(input), &buf)
+	return buf.String(), err
+}
+
+func decodeSentences(t *testing.T, sentences string) []*aisnmea.VdmPacket {
+	nc := aisnmea.NMEACodecNew(ais.CodecNew(false, false))
+
+	var result []*aisnmea.VdmPacket
+	for _, line := range strings.Split(strings.TrimSpace(sentences), "\n") {
+		p, err := nc.ParseSentence(line)
+		if err != nil {
+			t.Fatal(err, line)
+		}
+		if p != nil {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+const keyValueInput = `# Position report
+MessageID=1
+UserID=244123456
+Longitude=4.4
+Latitude=51.2
+Sog=12.3
+Cog: 45
+TrueHeading=44
+Timestamp=60
+
+---
+MessageID=5
+UserID=244123456
+Name="TEST SHIP"
+Dimension.A=10
+Destination=ROTTERDAM
+`
+
+func TestKeyValue(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.channel = 2
+	cfg.tagBlock, cfg.tagTime, _ = parseTagBlock("s:test,c:now")
+	cfg.now = func() time.Time { return time.Unix(1550303566, 0) }
+
+	out, err := encodeString(cfg, keyValueInput)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	packets := decodeSentences(t, out)
+	if len(packets) != 2 {
+		t.Fatal("Wrong number of packets", out)
+	}
+
+	pr, ok := packets[0].Packet.(ais.PositionReport)
+	if !ok || pr.UserID != 244123456 || pr.Sog != 12.3 || pr.Cog != 45 || pr.Latitude != 51.2 || pr.Longitude != 4.4 {
+		t.Error("Wrong position report", packets[0].Packet)
+	}
+	if packets[0].Channel != 2 || packets[0].TagBlock.Source != "test" || packets[0].TagBlock.Time != 1550303566 {
+		t.Error("Wrong sentence parameters", out)
+	}
+
+	sd, ok := packets[1].Packet.(ais.ShipStaticData)
+	if !ok || sd.Name != "TEST SHIP" || sd.Dimension.A != 10 || sd.Destination != "ROTTERDAM" {
+		t.Error("Wrong static data", packets[1].Packet)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	f, err := os.Open("../../aisnmea/testdata/aistest.nmea")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	/* Encode the decoded packets as JSON, both plain and as aisdecode record */
+	nc := aisnmea.NMEACodecNew(ais.CodecNew(false, false))
+	var input bytes.Buffer
+	var packets []ais.Packet
+	codec := ais.CodecNew(false, false)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), " ", 2)
+		p, err := nc.ParseSentence(fields[len(fields)-1])
+		if err != nil || p == nil || p.Packet == nil {
+			continue
+		}
+
+		var data []byte
+		if len(packets)%2 == 0 {
+			data, _ = json.Marshal(p.Packet)
+		} else {
+			data, _ = json.Marshal(map[string]interface{}{"Channel": "B", "Packet": p.Packet})
+		}
+		input.Write(append(data, '\n'))
+		packets = append(packets, p.Packet)
+	}
+
+	out, err := encodeString(defaultConfig(), input.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := decodeSentences(t, out)
+	if len(decoded) != len(packets) {
+		t.Fatal("Wrong number of packets", len(decoded), len(packets))
+	}
+	for i, p := range decoded {
+		/* Floats are not always converted back to the same value, so compare the payloads */
+		if !bytes.Equal(p.Payload, codec.EncodePacket(packets[i])) {
+			t.Error("Packet changed", i, p.Packet, packets[i])
+		}
+		if wantChannel := byte(1 + i%2); p.Channel != wantChannel {
+			t.Error("Wrong channel", i, p.Channel)
+		}
+	}
+}
+
+func TestSequenceAndLineLength(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.seqID = 7
+	cfg.talker = "BS"
+	cfg.ownShip = true
+	cfg.lineLength = 50
+
+	out, err := encodeString(cfg, keyValueInput)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	for _, l := range lines {
+		if !strings.HasPrefix(l, "!BSVDO,") || len(l)+2 > 50 || strings.Split(l, ",")[3] != "7" {
+			t.Error("Wrong sentence", l)
+		}
+	}
+	if len(decodeSentences(t, out)) != 2 {
+		t.Error("Packets not decodable", out)
+	}
+}
+
+func TestFieldErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		errors []string
+	}{
+		{"MessageID=1\nSog=200\nLatitude=-100\nTrueHeading=600", []string{"Sog does not fit in 10 bits", "TrueHeading does not fit in 9 bits"}},
+		{"MessageID=5\nName=lower case", []string{"Name contains a character that cannot be encoded"}},
+		{`{"MessageID":1,"Valid":false}`, []string{"packet is not Valid"}},
+		{"MessageID=16\nCommands[1].DestinationID=5", []string{"first element is not Valid [Commands[0]]"}},
+		{"MessageID=8\nBinaryData=" + strings.Repeat("01", 600), []string{"packet needs 1256 bits, more than the maximum of 1008"}},
+	}
+
+	for _, test := range tests {
+		_, err := encodeString(defaultConfig(), test.input)
+		if err == nil {
+			t.Error("Encoding did not fail", test.input)
+			continue
+		}
+
+		msg := err.Error()
+		if _, ok := err.(*encodeError); !ok {
+			t.Error("Not an encodeError", msg)
+		}
+		for _, e := range test.errors {
+			if !strings.Contains(msg, e) {
+				t.Errorf("Error %q does not contain %q", msg, e)
+			}
+		}
+	}
+}
+
+func TestInputErrors(t *testing.T) {
+	for _, input := range []string{
+		"MessageID=1\nSpeed=12",
+		"MessageID=1\nSog=fast",
+		"MessageID=28",
+		"UserID=1",
+		"MessageID=1\nno separator",
+		`{"MessageID":1,"Speed":12}`,
+		`{"UserID":1}`,
+		`{"Channel":"C","Packet":{"MessageID":1}}`,
+	} {
+		if _, err := encodeString(defaultConfig(), input); err == nil {
+			t.Error("Invalid input accepted", input)
+		}
+	}
+}
+
+func TestCheckPacket(t *testing.T) {
+	/* Packets that the codec can encode must not have problems */
+	codec := ais.CodecNew(false, false)
+	for id := 1; id <= 27; id++ {
+		f, err := os.Open(fmt.Sprintf("../../testmsg/%d.msg", id))
+		if err != nil {
+			continue
+		}
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			bits := []byte(strings.TrimSpace(scanner.Text()))
+			for i := range bits {
+				bits[i] -= '0'
+			}
+
+			p := codec.DecodePacket(bits)
+			if p == nil || codec.EncodePacket(p) == nil {
+				continue
+			}
+			if errs := checkPacket(p); len(errs) > 0 {
+				t.Error("Problems found in valid packet", id, errs)
+			}
+		}
+		f.Close()
+	}
+}
@@ -0,0 +1,267 @@
+// Command aisreplay plays back a captured NMEA feed with its original timing and sends the
+// sentences to a file, stdout, the clients of a TCP server or a UDP destination. Captures are read
+// from the files given as arguments, or from stdin if there are none. Gzip compressed files are
+// detected automatically.
+//
+// The receive time of a line is a UNIX timestamp in front of the sentence (like
+// aisnmea/testdata/aistest.nmea) or the c: parameter of its TAG Block. The timestamp prefix is not
+// repeated in the output. The sentences of a multi-sentence message are always sent together.
+//
+// With -control the playback is controlled by commands on stdin:
+//
+//	pause           pause the playback
+//	resume          continue the playback
+//	seek <time>     continue at a time, which is RFC 3339, an offset from the start of the
+//	                capture like 15m, or relative to the current position like +30s or -1m
+//	speed <factor>  change the speed, 0 plays as fast as possible
+//	status          print the state of the playback
+//
+// Usage:
+//
+//	aisreplay [flags] [file ...]
+//
+// For example, to serve a capture at ten times the original speed on port 10110 forever:
+//
+//	aisreplay -speed 10 -loop -tcp :10110 -output "" capture.nmea.gz
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BertoldVdb/go-ais/aisreplay"
+)
+
+func openInput(name string) (io.ReadCloser, error) {
+	var f io.ReadCloser = os.Stdin
+	if name != "-" {
+		var err error
+		if f, err = os.Open(name); err != nil {
+			return nil, err
+		}
+	}
+
+	br := bufio.NewReader(f)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1F && magic[1] == 0x8B {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("aisreplay: invalid gzip input [%s]: %v", name, err)
+		}
+		return struct {
+			io.Reader
+			io.Closer
+		}{gz, f}, nil
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{br, f}, nil
+}
+
+// readCaptures reads the captures one after the other
+func readCaptures(names []string) ([]aisreplay.Message, error) {
+	var result []aisreplay.Message
+	for _, name := range names {
+		r, err := openInput(name)
+		if err != nil {
+			return nil, err
+		}
+
+		messages, err := aisreplay.ReadCapture(r)
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("aisreplay: cannot read capture [%s]: %v", name, err)
+		}
+		result = append(result, messages...)
+	}
+	return result, nil
+}
+
+// parseSeek converts the argument of -start or the seek command to a time in the capture
+func parseSeek(s string, p *aisreplay.Player) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+
+	if len(s) > 0 && (s[0] == '+' || s[0] == '-') {
+		if d, err := time.ParseDuration(s); err == nil {
+			pos := p.Position()
+			if pos.IsZero() {
+				pos = p.Start()
+			}
+			return pos.Add(d), nil
+		}
+	} else if d, err := time.ParseDuration(s); err == nil {
+		return p.Start().Add(d), nil
+	}
+
+	return time.Time{}, fmt.Errorf("aisreplay: invalid time [%s]", s)
+}
+
+// control executes the commands read from r and writes the responses to w
+func control(r io.Reader, w io.Writer, p *aisreplay.Player) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		var err error
+		switch {
+		case fields[0] == "pause" && len(fields) == 1:
+			p.Pause()
+		case fields[0] == "resume" && len(fields) == 1:
+			p.Resume()
+		case fields[0] == "seek" && len(fields) == 2:
+			var t time.Time
+			if t, err = parseSeek(fields[1], p); err == nil {
+				p.Seek(t)
+			}
+		case fields[0] == "speed" && len(fields) == 2:
+			var speed float64
+			if speed, err = strconv.ParseFloat(fields[1], 64); err == nil && speed >= 0 {
+				p.SetSpeed(speed)
+			} else {
+				err = fmt.Errorf("aisreplay: invalid speed [%s]", fields[1])
+			}
+		case fields[0] == "status" && len(fields) == 1:
+			state := "playing"
+			if p.Paused() {
+				state = "paused"
+			}
+			fmt.Fprintf(w, "%s at %s\n", state, p.Position().Format(time.RFC3339Nano))
+		default:
+			err = fmt.Errorf("aisreplay: unknown command [%s]", scanner.Text())
+		}
+
+		if err != nil {
+			fmt.Fprintln(w, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// config contains the parsed command line
+type config struct {
+	player aisreplay.Config
+
+	// start is the position where the playback starts, see parseSeek
+	start string
+}
+
+func run(cfg config, messages []aisreplay.Message, outputs []aisreplay.Output, stop <-chan struct{}, controls io.Reader) error {
+	p := aisreplay.NewPlayer(messages, cfg.player)
+	if cfg.start != "" {
+		t, err := parseSeek(cfg.start, p)
+		if err != nil {
+			return err
+		}
+		p.Seek(t)
+	}
+
+	if controls != nil {
+		go control(controls, os.Stderr, p)
+	}
+
+	return p.Run(stop, func(m aisreplay.Message) error {
+		for _, o := range outputs {
+			if err := o.Send(m.Lines); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func main() {
+	var cfg config
+	var output, tcpAddr, udpAddr string
+	var controlStdin bool
+
+	flag.Float64Var(&cfg.player.Speed, "speed", 1, "Speed relative to the capture, 0 plays as fast as possible")
+	flag.BoolVar(&cfg.player.Loop, "loop", false, "Restart at the beginning after the end of the capture")
+	flag.StringVar(&cfg.start, "start", "", "Start at this time (RFC 3339) or offset from the start of the capture")
+	flag.BoolVar(&controlStdin, "control", false, "Read playback commands from stdin")
+	flag.StringVar(&output, "output", "-", "Output file, - for stdout, empty for none")
+	flag.StringVar(&tcpAddr, "tcp", "", "Serve the sentences to TCP clients on this address")
+	flag.StringVar(&udpAddr, "udp", "", "Send the sentences to this UDP address")
+	flag.Parse()
+
+	fail := func(err error, code int) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(code)
+	}
+
+	inputs := flag.Args()
+	if len(inputs) == 0 {
+		inputs = []string{"-"}
+	}
+
+	var controls io.Reader
+	if controlStdin {
+		for _, name := range inputs {
+			if name == "-" {
+				fail(fmt.Errorf("aisreplay: stdin cannot be used for the capture and the commands"), 2)
+			}
+		}
+		controls = os.Stdin
+	}
+
+	messages, err := readCaptures(inputs)
+	if err != nil {
+		fail(err, 1)
+	}
+
+	var outputs []aisreplay.Output
+	if output != "" {
+		o, err := aisreplay.NewFileOutput(output, cfg.player.Speed > 0 || controlStdin)
+		if err != nil {
+			fail(err, 1)
+		}
+		outputs = append(outputs, o)
+	}
+	if tcpAddr != "" {
+		o, err := aisreplay.NewTCPOutput(tcpAddr)
+		if err != nil {
+			fail(err, 1)
+		}
+		outputs = append(outputs, o)
+	}
+	if udpAddr != "" {
+		o, err := aisreplay.NewUDPOutput(udpAddr)
+		if err != nil {
+			fail(err, 1)
+		}
+		outputs = append(outputs, o)
+	}
+
+	/* Interrupting the playback still flushes and closes the outputs */
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+
+	err = run(cfg, messages, outputs, stop, controls)
+	for _, o := range outputs {
+		if cerr := o.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		fail(err, 1)
+	}
+}
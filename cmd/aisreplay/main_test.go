@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BertoldVdb/go-ais/aisreplay"
+)
+
+var testStart = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func testMessages() []aisreplay.Message {
+	var capture strings.Builder
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(&capture, "%d !AIVDM,%d\n", testStart.Add(time.Duration(i)*time.Minute).Unix(), i)
+	}
+
+	messages, err := aisreplay.ReadCapture(strings.NewReader(capture.String()))
+	if err != nil {
+		panic(err)
+	}
+	return messages
+}
+
+func TestRun(t *testing.T) {
+	var buf bytes.Buffer
+	o := aisreplay.NewWriterOutput(&buf, false)
+
+	cfg := config{start: "7m"}
+	if err := run(cfg, testMessages(), []aisreplay.Output{o}, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	o.Close()
+
+	if buf.String() != "!AIVDM,7\r\n!AIVDM,8\r\n!AIVDM,9\r\n" {
+		t.Error("Wrong output", buf.String())
+	}
+
+	cfg.start = "tomorrow"
+	if err := run(cfg, testMessages(), nil, nil, nil); err == nil {
+		t.Error("Invalid start accepted")
+	}
+}
+
+func TestParseSeek(t *testing.T) {
+	p := aisreplay.NewPlayer(testMessages(), aisreplay.Config{})
+
+	tests := []struct {
+		s    string
+		want time.Time
+	}{
+		{"2020-01-01T00:05:00Z", testStart.Add(5 * time.Minute)},
+		{"90s", testStart.Add(90 * time.Second)},
+		{"+1m", testStart.Add(time.Minute)},
+		{"-1m", testStart.Add(-time.Minute)},
+	}
+	for _, test := range tests {
+		if got, err := parseSeek(test.s, p); err != nil || !got.Equal(test.want) {
+			t.Error("Wrong time", test.s, got, err)
+		}
+	}
+
+	for _, s := range []string{"", "+", "now", "2020-01-01"} {
+		if _, err := parseSeek(s, p); err == nil {
+			t.Error("Invalid time accepted", s)
+		}
+	}
+}
+
+func TestControl(t *testing.T) {
+	p := aisreplay.NewPlayer(testMessages(), aisreplay.Config{Speed: 1})
+
+	var out bytes.Buffer
+	commands := "pause\nstatus\n\nseek 5m\nspeed 0\nspeed -1\njump\nresume\n"
+	if err := control(strings.NewReader(commands), &out, p); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "paused") ||
+		!strings.Contains(lines[1], "invalid speed") || !strings.Contains(lines[2], "unknown command") {
+		t.Error("Wrong responses", lines)
+	}
+
+	var result []string
+	p.Run(nil, func(m aisreplay.Message) error {
+		result = append(result, m.Lines[0])
+		return nil
+	})
+	if len(result) != 5 || result[0] != "!AIVDM,5" {
+		t.Error("Commands not applied", result)
+	}
+}
@@ -0,0 +1,42 @@
+// Command aisschema writes a JSON Schema document for every AIS message type. The documents
+// describe the encoding/json representation of the packets of the ais package.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+var outputDir = flag.String("output", ".", "Output directory")
+var messageID = flag.Int("id", 0, "Only write the schema of this message ID to stdout")
+
+func main() {
+	flag.Parse()
+
+	if *messageID != 0 {
+		schema, err := ais.JSONSchema(uint8(*messageID))
+		if err != nil {
+			log.Fatal(err)
+		}
+		os.Stdout.Write(append(schema, '\n'))
+		return
+	}
+
+	for id := uint8(1); id <= 27; id++ {
+		schema, err := ais.JSONSchema(id)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		name := path.Join(*outputDir, fmt.Sprintf("message%d.schema.json", id))
+		if err := os.WriteFile(name, append(schema, '\n'), 0644); err != nil {
+			log.Fatal(err)
+		}
+		log.Println("generated: ", name)
+	}
+}
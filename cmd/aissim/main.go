@@ -0,0 +1,238 @@
+// Command aissim generates synthetic AIS traffic with the aissim package and writes it as VDM
+// sentences to a file, stdout, the clients of a TCP server or a UDP destination.
+//
+// Random stations are placed in the area given by -bbox, stations with a fixed route can be loaded
+// from a JSON file containing a list of aissim.Station objects. The output only depends on the
+// seed and the start time, so use -start to produce the same traffic again.
+//
+// Usage:
+//
+//	aissim [flags]
+//
+// For example, to serve one hour of traffic for 50 vessels at ten times real time on port 10110:
+//
+//	aissim -classa 50 -duration 1h -rate 10 -tcp :10110 -output ""
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BertoldVdb/go-ais"
+	"github.com/BertoldVdb/go-ais/aisnmea"
+	"github.com/BertoldVdb/go-ais/aisreplay"
+	"github.com/BertoldVdb/go-ais/aissim"
+)
+
+// config contains the parsed command line
+type config struct {
+	seed     int64
+	start    time.Time
+	duration time.Duration
+
+	// rate is the speed of the simulation relative to real time, 0 runs as fast as possible
+	rate float64
+
+	fleet    aissim.Fleet
+	area     aissim.Area
+	scenario string
+
+	// tagBlock adds a TAG Block with the time (c:) and the source (s:) if it is not empty
+	tagBlock bool
+	source   string
+}
+
+func parseArea(s string) (aissim.Area, error) {
+	var v [4]float64
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return aissim.Area{}, fmt.Errorf("aissim: bounding box needs four values [%s]", s)
+	}
+	for i, p := range parts {
+		var err error
+		if v[i], err = strconv.ParseFloat(strings.TrimSpace(p), 64); err != nil {
+			return aissim.Area{}, fmt.Errorf("aissim: invalid bounding box [%s]", s)
+		}
+	}
+	if v[0] >= v[2] || v[1] >= v[3] || v[1] < -90 || v[3] > 90 || v[0] < -180 || v[2] > 180 {
+		return aissim.Area{}, fmt.Errorf("aissim: invalid bounding box [%s]", s)
+	}
+	return aissim.Area{MinLongitude: v[0], MinLatitude: v[1], MaxLongitude: v[2], MaxLatitude: v[3]}, nil
+}
+
+func loadScenario(sim *aissim.Simulator, name string) error {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	var stations []aissim.Station
+	if err := json.Unmarshal(data, &stations); err != nil {
+		return fmt.Errorf("aissim: invalid scenario [%s]: %v", name, err)
+	}
+	for _, st := range stations {
+		if err := sim.AddStation(st); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newSimulator(cfg config) (*aissim.Simulator, error) {
+	sim := aissim.NewSimulator(aissim.Config{Start: cfg.start, Seed: cfg.seed})
+
+	if cfg.scenario != "" {
+		if err := loadScenario(sim, cfg.scenario); err != nil {
+			return nil, err
+		}
+	}
+	if err := sim.AddRandomStations(cfg.fleet, cfg.area); err != nil {
+		return nil, err
+	}
+	if sim.NumStations() == 0 {
+		return nil, fmt.Errorf("aissim: no stations to simulate")
+	}
+	return sim, nil
+}
+
+// run simulates until the duration has passed, or forever if it is zero. The stop channel ends the
+// simulation early.
+func run(cfg config, outputs []aisreplay.Output, stop <-chan struct{}) error {
+	sim, err := newSimulator(cfg)
+	if err != nil {
+		return err
+	}
+
+	nc := aisnmea.NMEACodecNew(ais.CodecNew(false, false))
+	wallStart := time.Now()
+
+	for {
+		t, _ := sim.Next()
+		if cfg.duration > 0 && t.Time.Sub(cfg.start) >= cfg.duration {
+			return nil
+		}
+
+		if cfg.rate > 0 {
+			wait := wallStart.Add(time.Duration(float64(t.Time.Sub(cfg.start)) / cfg.rate)).Sub(time.Now())
+			if wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-timer.C:
+				case <-stop:
+					timer.Stop()
+					return nil
+				}
+			}
+		}
+
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+
+		p := aisnmea.VdmPacket{Channel: t.Channel, Packet: t.Packet}
+		if cfg.tagBlock {
+			p.Tags.SetTimestamp(t.Time, true)
+			p.Tags.Source = cfg.source
+		}
+
+		sentences := nc.EncodeSentence(p)
+		if sentences == nil {
+			return fmt.Errorf("aissim: packet cannot be encoded [%v]", t.Packet)
+		}
+		for _, o := range outputs {
+			if err := o.Send(sentences); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func main() {
+	var cfg config
+	var start, bbox, output, tcpAddr, udpAddr string
+
+	flag.Int64Var(&cfg.seed, "seed", 1, "Seed of the random generator")
+	flag.StringVar(&start, "start", "", "Start time of the simulation (RFC 3339), the default is the current time")
+	flag.DurationVar(&cfg.duration, "duration", 0, "Simulated time, 0 runs forever")
+	flag.Float64Var(&cfg.rate, "rate", 1, "Speed relative to real time, 0 runs as fast as possible")
+	flag.IntVar(&cfg.fleet.ClassA, "classa", 20, "Number of random Class A vessels")
+	flag.IntVar(&cfg.fleet.ClassB, "classb", 10, "Number of random Class B vessels")
+	flag.IntVar(&cfg.fleet.Aircraft, "aircraft", 1, "Number of random SAR aircraft")
+	flag.IntVar(&cfg.fleet.AtoN, "aton", 5, "Number of random aids to navigation")
+	flag.IntVar(&cfg.fleet.BaseStations, "base", 1, "Number of random base stations")
+	flag.StringVar(&bbox, "bbox", "3.0,51.0,4.5,51.8", "Area of the random stations: min_lon,min_lat,max_lon,max_lat")
+	flag.StringVar(&cfg.scenario, "scenario", "", "JSON file with a list of stations to simulate")
+	flag.BoolVar(&cfg.tagBlock, "tagblock", false, "Add a TAG Block with the simulated time")
+	flag.StringVar(&cfg.source, "source", "", "Source (s:) in the TAG Block")
+	flag.StringVar(&output, "output", "-", "Output file, - for stdout, empty for none")
+	flag.StringVar(&tcpAddr, "tcp", "", "Serve the sentences to TCP clients on this address")
+	flag.StringVar(&udpAddr, "udp", "", "Send the sentences to this UDP address")
+	flag.Parse()
+
+	fail := func(err error, code int) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(code)
+	}
+
+	var err error
+	cfg.start = time.Now().UTC().Truncate(time.Second)
+	if start != "" {
+		if cfg.start, err = time.Parse(time.RFC3339Nano, start); err != nil {
+			fail(fmt.Errorf("aissim: invalid time [%s]", start), 2)
+		}
+	}
+	if cfg.area, err = parseArea(bbox); err != nil {
+		fail(err, 2)
+	}
+
+	var outputs []aisreplay.Output
+	if output != "" {
+		o, err := aisreplay.NewFileOutput(output, cfg.rate > 0)
+		if err != nil {
+			fail(err, 1)
+		}
+		outputs = append(outputs, o)
+	}
+	if tcpAddr != "" {
+		o, err := aisreplay.NewTCPOutput(tcpAddr)
+		if err != nil {
+			fail(err, 1)
+		}
+		outputs = append(outputs, o)
+	}
+	if udpAddr != "" {
+		o, err := aisreplay.NewUDPOutput(udpAddr)
+		if err != nil {
+			fail(err, 1)
+		}
+		outputs = append(outputs, o)
+	}
+
+	/* Interrupting the simulation still flushes and closes the outputs */
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+
+	err = run(cfg, outputs, stop)
+	for _, o := range outputs {
+		if cerr := o.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		fail(err, 1)
+	}
+}
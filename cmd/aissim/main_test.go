@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BertoldVdb/go-ais"
+	"github.com/BertoldVdb/go-ais/aisnmea"
+	"github.com/BertoldVdb/go-ais/aisreplay"
+	"github.com/BertoldVdb/go-ais/aissim"
+)
+
+func testConfig() config {
+	return config{
+		seed:     1,
+		start:    time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		duration: 5 * time.Minute,
+		fleet:    aissim.Fleet{ClassA: 3, ClassB: 2, AtoN: 1, BaseStations: 1},
+		area:     aissim.Area{MinLatitude: 51, MinLongitude: 3, MaxLatitude: 51.8, MaxLongitude: 4.5},
+		tagBlock: true,
+		source:   "sim",
+	}
+}
+
+func runToBuffer(t *testing.T, cfg config) string {
+	var buf bytes.Buffer
+	o := aisreplay.NewWriterOutput(&buf, false)
+	if err := run(cfg, []aisreplay.Output{o}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestRun(t *testing.T) {
+	cfg := testConfig()
+	out := runToBuffer(t, cfg)
+	if out != runToBuffer(t, cfg) {
+		t.Error("Output is not deterministic")
+	}
+
+	nc := aisnmea.NMEACodecNew(ais.CodecNew(false, false))
+	packets := 0
+	for _, line := range strings.Split(strings.TrimSpace(out), "\r\n") {
+		p, err := nc.ParseSentence(line)
+		if err != nil {
+			t.Fatal(err, line)
+		}
+		if p == nil {
+			continue
+		}
+		packets++
+
+		ts := p.Tags.Timestamp()
+		if ts.Before(cfg.start) || ts.Sub(cfg.start) >= cfg.duration {
+			t.Error("Wrong timestamp", line)
+		}
+		if p.Tags.Source != "sim" {
+			t.Error("Wrong source", line)
+		}
+	}
+	if packets < 100 {
+		t.Error("Too few packets", packets)
+	}
+}
+
+func TestRunScenario(t *testing.T) {
+	f, err := ioutil.TempFile("", "aissim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.WriteString(`[{"Kind": 0, "MMSI": 244000001, "Name": "TEST", "Speed": 12, "Route": [{"Latitude": 51, "Longitude": 3}, {"Latitude": 51.1, "Longitude": 3}]}]`)
+
+	cfg := testConfig()
+	cfg.fleet = aissim.Fleet{}
+	cfg.scenario = f.Name()
+	if !strings.Contains(runToBuffer(t, cfg), "!AIVDM") {
+		t.Error("Scenario produced no output")
+	}
+
+	cfg.scenario = ""
+	if err := run(cfg, nil, nil); err == nil {
+		t.Error("Simulation without stations accepted")
+	}
+}
+
+func TestParseArea(t *testing.T) {
+	area, err := parseArea("3.0, 51.0,4.5,51.8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if area != (aissim.Area{MinLatitude: 51, MinLongitude: 3, MaxLatitude: 51.8, MaxLongitude: 4.5}) {
+		t.Error("Wrong area", area)
+	}
+
+	for _, s := range []string{"", "1,2,3", "a,51,4,52", "4,51,3,52", "3,-91,4,52", "3,51,181,52"} {
+		if _, err := parseArea(s); err == nil {
+			t.Error("Invalid area accepted", s)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package ais
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// FieldType describes how a field is represented on the air
+type FieldType int
+
+const (
+	// FieldTypeUnsigned is an unsigned integer
+	FieldTypeUnsigned FieldType = iota
+	// FieldTypeSigned is a two's complement signed integer
+	FieldTypeSigned
+	// FieldTypeBool is a single bit
+	FieldTypeBool
+	// FieldTypeString is a string of 6 bit characters
+	FieldTypeString
+	// FieldTypeBinary is binary data, stored as one byte per bit
+	FieldTypeBinary
+	// FieldTypeStruct is a group of fields, described in Fields
+	FieldTypeStruct
+	// FieldTypeArray is a repeated group of fields, the element is described in Fields
+	FieldTypeArray
+)
+
+func (t FieldType) String() string {
+	switch t {
+	case FieldTypeUnsigned:
+		return "unsigned"
+	case FieldTypeSigned:
+		return "signed"
+	case FieldTypeBool:
+		return "bool"
+	case FieldTypeString:
+		return "string"
+	case FieldTypeBinary:
+		return "binary"
+	case FieldTypeStruct:
+		return "struct"
+	case FieldTypeArray:
+		return "array"
+	}
+	return "unknown"
+}
+
+// FieldScale describes the conversion between the raw value and the value stored in the struct
+type FieldScale int
+
+const (
+	// ScaleNone means the raw value is stored
+	ScaleNone FieldScale = iota
+	// ScaleField10 is used by Field10: the raw value is divided by 10
+	ScaleField10
+	// ScaleLatLonFine is used by FieldLatLonFine: the raw value is in 1/10000 minutes
+	ScaleLatLonFine
+	// ScaleLatLonCoarse is used by FieldLatLonCoarse: the raw value is in 1/10 minutes
+	ScaleLatLonCoarse
+)
+
+// Divisor returns the number the raw value is divided by
+func (s FieldScale) Divisor() float64 {
+	switch s {
+	case ScaleField10:
+		return 10
+	case ScaleLatLonFine:
+		return 10000 * 60
+	case ScaleLatLonCoarse:
+		return 10 * 60
+	}
+	return 1
+}
+
+// FieldCondition describes when a conditional field is present
+type FieldCondition struct {
+	// Bit is the position of the bit in the message that selects the field
+	Bit int
+	// Field is the name of the field containing that bit
+	Field string
+	// Value is the value the bit must have for the field to be present
+	Value bool
+}
+
+// FieldDescriptor describes a single field of a message
+type FieldDescriptor struct {
+	// Name is the name of the field in the Go struct. Fields of embedded structs like Header are
+	// listed directly.
+	Name string
+	// GoType is the name of the Go type of the field
+	GoType string
+	Type   FieldType
+
+	// Offset is the position of the first bit of the field in the message, or -1 if it depends on
+	// the content of the message
+	Offset int
+	// Width is the number of bits, or -1 if the field takes up the remainder of the message. For
+	// arrays it is the width of a single element, element i starts at Offset+i*Width.
+	Width  int
+	Signed bool
+	Scale  FieldScale
+
+	// Condition is set if the field is only present depending on another bit in the message.
+	// The fields of a struct inherit the condition of the struct.
+	Condition *FieldCondition
+	// Optional is set if the field may be left out at the end of a short message
+	Optional bool
+
+	// HasEncodeAs is set if the field is always encoded as EncodeAs, usually because it is spare
+	HasEncodeAs bool
+	EncodeAs    int64
+
+	// Count is the number of elements of an array. The first element is required, the others are
+	// only present if the message is long enough.
+	Count int
+	// Fields describes the content of structs and array elements
+	Fields []FieldDescriptor
+}
+
+// MessageDescriptor describes the layout of a message type
+type MessageDescriptor struct {
+	// Valid is false if the message ID is unknown
+	Valid     bool
+	MessageID uint8
+	// Name is the name of the Go type
+	Name string
+	// MaxLength is the maximum number of bits of the encoded message
+	MaxLength int
+	Fields    []FieldDescriptor
+}
+
+// MessageType returns the Go type of the packets with the given message ID, or nil if the ID is
+// unknown
+func MessageType(messageID uint8) reflect.Type {
+	if messageID < 1 || messageID > 27 {
+		return nil
+	}
+	return msgMap[messageID].rType
+}
+
+// Describe returns the layout of the message with the given ID, based on the struct definitions
+// used by the codec. Float fields are described as they are after conversion, see
+// Codec.FloatWithoutConversion.
+func Describe(messageID uint8) MessageDescriptor {
+	st := MessageType(messageID)
+	if st == nil {
+		return MessageDescriptor{MessageID: messageID}
+	}
+
+	vf, _ := st.FieldByName("Valid")
+	maxLength, _ := strconv.Atoi(vf.Tag.Get("aisEncodeMaxLen"))
+	fields, _ := describeStruct(st, 0)
+
+	return MessageDescriptor{
+		Valid:     true,
+		MessageID: messageID,
+		Name:      st.Name(),
+		MaxLength: maxLength,
+		Fields:    fields,
+	}
+}
+
+func addOffset(a int, b int) int {
+	if a < 0 || b < 0 {
+		return -1
+	}
+	return a + b
+}
+
+func describeCondition(sf reflect.StructField) *FieldCondition {
+	bit, ok := sf.Tag.Lookup("aisDependsBit")
+	if !ok {
+		return nil
+	}
+
+	c := &FieldCondition{Value: true, Field: sf.Tag.Get("aisDependsField")}
+	if bit[0] == '~' {
+		c.Value = false
+		bit = bit[1:]
+	}
+	if len(c.Field) > 0 && c.Field[0] == '~' {
+		c.Field = c.Field[1:]
+	}
+	c.Bit, _ = strconv.Atoi(bit)
+	return c
+}
+
+/* conditionalRun is a sequence of fields that share the same condition */
+type conditionalRun struct {
+	start  int
+	length int
+	cond   FieldCondition
+}
+
+// describeStruct returns the fields of st, starting at bit offset. It also returns the total
+// width, or -1 if it is variable.
+func describeStruct(st reflect.Type, offset int) ([]FieldDescriptor, int) {
+	var fields []FieldDescriptor
+	start := offset
+
+	/* Conditional fields that are each other's complement and have the same length
+	 * (like ReportA and ReportB in message 24) occupy the same bits, so the offset
+	 * of the following fields is still known. */
+	var run, prev *conditionalRun
+
+	for i := 0; i < st.NumField(); i++ {
+		sf := st.Field(i)
+		if sf.Name == "Valid" {
+			continue
+		}
+
+		cond := describeCondition(sf)
+		if cond == nil && run != nil {
+			offset = endOfRuns(run, prev)
+			run, prev = nil, nil
+		}
+
+		fieldOffset := offset
+		if cond != nil {
+			switch {
+			case run != nil && run.cond == *cond:
+				fieldOffset = addOffset(run.start, run.length)
+			case run != nil && prev == nil && run.cond.Bit == cond.Bit && run.cond.Value != cond.Value:
+				prev, run = run, &conditionalRun{start: run.start, cond: *cond}
+				fieldOffset = run.start
+			case run != nil:
+				prev, run = run, &conditionalRun{start: -1, cond: *cond}
+				fieldOffset = -1
+			default:
+				run = &conditionalRun{start: offset, cond: *cond}
+			}
+		}
+
+		fd := describeField(sf, fieldOffset)
+		fd.Condition = cond
+
+		if sf.Anonymous {
+			/* Embedded structs are flattened, like encoding/json does */
+			fields = append(fields, fd.Fields...)
+		} else {
+			fields = append(fields, fd)
+		}
+
+		width := fd.Width
+		if fd.Type == FieldTypeArray {
+			/* Only the first element is required */
+			width = -1
+		}
+
+		if run != nil {
+			run.length = addOffset(run.length, fd.Width)
+		} else {
+			offset = addOffset(offset, width)
+		}
+	}
+
+	if run != nil {
+		offset = endOfRuns(run, prev)
+	}
+	if start < 0 || offset < 0 {
+		return fields, variableWidth(fields)
+	}
+	return fields, offset - start
+}
+
+/* endOfRuns returns the offset after a sequence of conditional fields */
+func endOfRuns(run *conditionalRun, prev *conditionalRun) int {
+	if prev != nil && prev.cond.Bit == run.cond.Bit && prev.cond.Value != run.cond.Value &&
+		prev.length == run.length {
+		return addOffset(run.start, run.length)
+	}
+	return -1
+}
+
+/* variableWidth is used when the offsets are unknown: the width is only fixed if no fields are
+ * conditional or variable */
+func variableWidth(fields []FieldDescriptor) int {
+	width := 0
+	for _, f := range fields {
+		if f.Condition != nil || f.Type == FieldTypeArray {
+			return -1
+		}
+		width = addOffset(width, f.Width)
+	}
+	return width
+}
+
+func describeField(sf reflect.StructField, offset int) FieldDescriptor {
+	fd := FieldDescriptor{
+		Name:   sf.Name,
+		GoType: sf.Type.Name(),
+		Offset: offset,
+	}
+	fd.Width, _ = strconv.Atoi(sf.Tag.Get("aisWidth"))
+
+	if encodeAs, ok := sf.Tag.Lookup("aisEncodeAs"); ok {
+		fd.HasEncodeAs = true
+		fd.EncodeAs, _ = strconv.ParseInt(encodeAs, 10, 64)
+	}
+
+	switch sf.Type.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		fd.Type = FieldTypeSigned
+		fd.Signed = true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		fd.Type = FieldTypeUnsigned
+	case reflect.Bool:
+		fd.Type = FieldTypeBool
+	case reflect.String:
+		fd.Type = FieldTypeString
+	case reflect.Slice:
+		fd.Type = FieldTypeBinary
+		fd.GoType = "[]byte"
+	case reflect.Float32, reflect.Float64:
+		fd.Type = FieldTypeSigned
+		fd.Signed = true
+		switch sf.Type.Name() {
+		case "Field10":
+			fd.Type = FieldTypeUnsigned
+			fd.Signed = false
+			fd.Scale = ScaleField10
+		case "FieldLatLonFine":
+			fd.Scale = ScaleLatLonFine
+		case "FieldLatLonCoarse":
+			fd.Scale = ScaleLatLonCoarse
+		}
+	case reflect.Struct:
+		fd.Type = FieldTypeStruct
+		fd.Fields, fd.Width = describeStruct(sf.Type, offset)
+		fd.Optional = isOptionalStruct(sf.Type)
+	case reflect.Array:
+		et := sf.Type.Elem()
+		fd.Type = FieldTypeArray
+		fd.GoType = "[" + strconv.Itoa(sf.Type.Len()) + "]" + et.Name()
+		fd.Count = sf.Type.Len()
+		fd.Fields, fd.Width = describeStruct(et, offset)
+	}
+
+	return fd
+}
+
+func isOptionalStruct(st reflect.Type) bool {
+	vf, ok := st.FieldByName("Valid")
+	if !ok {
+		return false
+	}
+	_, optional := vf.Tag.Lookup("aisOptional")
+	return optional
+}
@@ -0,0 +1,255 @@
+package ais
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func readDescribeTestMessages(t *testing.T, msgID int) [][]byte {
+	f, err := os.Open(fmt.Sprintf("testmsg/%d.msg", msgID))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var result [][]byte
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		bits := []byte(strings.TrimSpace(scanner.Text()))
+		for i := range bits {
+			bits[i] -= '0'
+		}
+		result = append(result, bits)
+	}
+	return result
+}
+
+func extractDescribed(bits []byte, f *FieldDescriptor) int64 {
+	var v int64
+	for i := 0; i < f.Width; i++ {
+		v = v<<1 | int64(bits[f.Offset+i])
+	}
+	if f.Signed && f.Width > 0 && bits[f.Offset] > 0 {
+		v -= 1 << uint(f.Width)
+	}
+	return v
+}
+
+/* checkDescribed compares the bits at the described offsets with the decoded values */
+func checkDescribed(t *testing.T, bits []byte, val reflect.Value, fields []FieldDescriptor) int {
+	checked := 0
+
+	for i := range fields {
+		f := &fields[i]
+		if f.Offset < 0 || f.Offset+f.Width > len(bits) {
+			continue
+		}
+		if f.Condition != nil && (bits[f.Condition.Bit] > 0) != f.Condition.Value {
+			continue
+		}
+
+		field := val.FieldByName(f.Name)
+		raw := extractDescribed(bits, f)
+
+		switch f.Type {
+		case FieldTypeUnsigned, FieldTypeSigned:
+			var got int64
+			switch field.Kind() {
+			case reflect.Float64:
+				got = int64(field.Float())
+			case reflect.Int8, reflect.Int16, reflect.Int32:
+				got = field.Int()
+			default:
+				got = int64(field.Uint())
+			}
+			if got != raw {
+				t.Error("Wrong value at described offset", f.Name, got, raw)
+			}
+			checked++
+		case FieldTypeBool:
+			if field.Bool() != (raw == 1) {
+				t.Error("Wrong value at described offset", f.Name)
+			}
+			checked++
+		case FieldTypeStruct:
+			checked += checkDescribed(t, bits, field, f.Fields)
+		}
+	}
+
+	return checked
+}
+
+func TestDescribe(t *testing.T) {
+	c := CodecNew(false, false)
+	c.FloatWithoutConversion = true
+
+	for msgID := 1; msgID <= 27; msgID++ {
+		d := Describe(uint8(msgID))
+		if !d.Valid || d.MaxLength == 0 || len(d.Fields) < 4 {
+			t.Fatal("Invalid descriptor", msgID)
+		}
+
+		for _, bits := range readDescribeTestMessages(t, msgID) {
+			p := c.DecodePacket(bits)
+			if p == nil {
+				continue
+			}
+			if checkDescribed(t, bits, reflect.ValueOf(p), d.Fields) < 4 {
+				t.Error("Too few fields checked", msgID)
+			}
+		}
+	}
+
+	if Describe(0).Valid || Describe(28).Valid {
+		t.Error("Descriptor returned for invalid message ID")
+	}
+}
+
+func TestMessageType(t *testing.T) {
+	if MessageType(5) != reflect.TypeOf(ShipStaticData{}) || MessageType(27) != reflect.TypeOf(LongRangeAisBroadcastMessage{}) {
+		t.Error("Wrong message types", MessageType(5), MessageType(27))
+	}
+	if MessageType(0) != nil || MessageType(28) != nil {
+		t.Error("Type returned for invalid message ID")
+	}
+}
+
+func TestDescribeLayout(t *testing.T) {
+	d := Describe(22)
+	if d.Name != "ChannelManagement" || d.MaxLength != 168 {
+		t.Fatal("Wrong message", d.Name, d.MaxLength)
+	}
+
+	/* Area and Unicast share the same bits, so the offsets after them are still known */
+	last := d.Fields[len(d.Fields)-1]
+	if last.Name != "Spare4" || last.Offset+last.Width != 168 || !last.HasEncodeAs {
+		t.Error("Wrong last field", last)
+	}
+
+	area := d.Fields[8]
+	if area.Name != "Area" || area.Offset != 69 || area.Condition == nil || area.Condition.Value ||
+		area.Condition.Bit != 139 || area.Condition.Field != "IsAddressed" {
+		t.Error("Wrong area", area)
+	}
+	if lat := area.Fields[1]; lat.Offset != 87 || lat.Scale != ScaleLatLonCoarse || !lat.Signed {
+		t.Error("Wrong latitude", lat)
+	}
+
+	/* The payload of message 25 starts after optional fields */
+	d = Describe(25)
+	payload := d.Fields[len(d.Fields)-1]
+	if payload.Type != FieldTypeBinary || payload.Offset != -1 || payload.Width != -1 {
+		t.Error("Wrong payload", payload)
+	}
+	if f := d.Fields[5]; f.Name != "DestinationID" || f.Offset != 40 || f.Condition == nil {
+		t.Error("Wrong destination", f)
+	}
+
+	d = Describe(7)
+	dest := d.Fields[4]
+	if dest.Type != FieldTypeArray || dest.Count != 4 || dest.Width != 32 || dest.Offset != 40 {
+		t.Error("Wrong array", dest)
+	}
+
+	d = Describe(15)
+	if s := d.Fields[6]; s.Name != "Station2" || !s.Optional || s.Offset != 108 || s.Width != 52 {
+		t.Error("Wrong optional station", s)
+	}
+}
+
+/* checkSchema verifies that value, as produced by encoding/json, matches the schema */
+func checkSchema(t *testing.T, name string, schema map[string]interface{}, value interface{}) {
+	switch schema["type"] {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			t.Fatal("Not an object", name)
+		}
+		props := schema["properties"].(map[string]interface{})
+		for k, v := range obj {
+			p, ok := props[k]
+			if !ok {
+				t.Fatal("Property not in schema", name, k)
+			}
+			checkSchema(t, name+"."+k, p.(map[string]interface{}), v)
+		}
+		for _, k := range schema["required"].([]interface{}) {
+			if _, ok := obj[k.(string)]; !ok {
+				t.Fatal("Required property missing", name, k)
+			}
+		}
+	case "array":
+		arr, ok := value.([]interface{})
+		if !ok || float64(len(arr)) != schema["maxItems"].(float64) {
+			t.Fatal("Wrong array", name)
+		}
+		for _, v := range arr {
+			checkSchema(t, name, schema["items"].(map[string]interface{}), v)
+		}
+	case "integer", "number":
+		v, ok := value.(float64)
+		if !ok {
+			t.Fatal("Not a number", name)
+		}
+		if c, ok := schema["const"]; ok && c != v {
+			t.Fatal("Wrong constant", name, v)
+		}
+		if min, ok := schema["minimum"]; ok && v < min.(float64) {
+			t.Fatal("Below minimum", name, v)
+		}
+		if max, ok := schema["maximum"]; ok && v > max.(float64) {
+			t.Fatal("Above maximum", name, v)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			t.Fatal("Not a boolean", name)
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			t.Fatal("Not a string", name)
+		}
+		if max, ok := schema["maxLength"]; ok && float64(len(s)) > max.(float64) {
+			t.Fatal("String too long", name, s)
+		}
+	default:
+		t.Fatal("Unknown type", name, schema["type"])
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	c := CodecNew(false, false)
+
+	for msgID := 1; msgID <= 27; msgID++ {
+		data, err := JSONSchema(uint8(msgID))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var schema map[string]interface{}
+		if err := json.Unmarshal(data, &schema); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, bits := range readDescribeTestMessages(t, msgID) {
+			p := c.DecodePacket(bits)
+			if p == nil {
+				continue
+			}
+
+			encoded, _ := json.Marshal(p)
+			var value interface{}
+			json.Unmarshal(encoded, &value)
+			checkSchema(t, fmt.Sprint(msgID), schema, value)
+		}
+	}
+
+	if _, err := JSONSchema(0); err == nil {
+		t.Error("Schema returned for invalid message ID")
+	}
+}
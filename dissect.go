@@ -0,0 +1,215 @@
+package ais
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// FieldSlice describes the bits of a single field found by Dissect
+type FieldSlice struct {
+	// Name is the path of the field, like "Dimension.A" or "Destinations[1].DestinationID"
+	Name string
+	Type FieldType
+
+	// Start is the position of the first bit, End the position after the last bit. End can be
+	// beyond the end of the payload.
+	Start int
+	End   int
+
+	// Bits are the bits of the field that are present in the payload, one byte per bit
+	Bits []byte
+	// Raw is the integer value of the bits, missing bits are taken as zero. It is only set for
+	// numbers and booleans.
+	Raw int64
+	// Value is the converted value: uint64, int64, float64 (scaled like Codec does), bool, string
+	// (including padding) or []byte
+	Value interface{}
+
+	// Overrun is set if the field extends beyond the end of the payload
+	Overrun bool
+	// SpareViolation is set if a field that must have a fixed value, like a spare, has another value
+	SpareViolation bool
+	// Trailing is set for the bits after the last field of the message
+	Trailing bool
+}
+
+// Dissect splits a payload, containing one bit per byte, in its fields. Unlike DecodePacket it
+// does not stop at the first problem: fields beyond the end of the payload are marked as Overrun,
+// fields with an unexpected fixed value as SpareViolation and extra bits are returned as a
+// Trailing slice. This is intended for debugging payloads that do not decode.
+func Dissect(payload []byte) []FieldSlice {
+	d := &dissector{payload: payload}
+
+	st := reflect.TypeOf(Header{})
+	if len(payload) >= 6 {
+		if id := bitsValue(payload[:6]); id >= 1 && id <= 27 {
+			st = msgMap[id].rType
+		}
+	}
+
+	end := d.dissectStruct(st, "", 0)
+
+	if end < len(payload) {
+		d.fields = append(d.fields, FieldSlice{
+			Name:     "Trailing",
+			Type:     FieldTypeBinary,
+			Start:    end,
+			End:      len(payload),
+			Bits:     payload[end:],
+			Value:    append([]byte{}, payload[end:]...),
+			Trailing: true,
+		})
+	}
+
+	return d.fields
+}
+
+type dissector struct {
+	payload []byte
+	fields  []FieldSlice
+}
+
+func bitsValue(bits []byte) int64 {
+	var v int64
+	for _, b := range bits {
+		v = v<<1 | int64(b&1)
+	}
+	return v
+}
+
+func (d *dissector) bits(start int, end int) []byte {
+	if start > len(d.payload) {
+		start = len(d.payload)
+	}
+	if end > len(d.payload) {
+		end = len(d.payload)
+	}
+	return d.payload[start:end]
+}
+
+func (d *dissector) bit(pos int) bool {
+	return pos < len(d.payload) && d.payload[pos] > 0
+}
+
+/* fixedWidth returns the number of bits of the fields of st that are present and not variable */
+func (d *dissector) fixedWidth(st reflect.Type) int {
+	width := 0
+	for i := 0; i < st.NumField(); i++ {
+		sf := st.Field(i)
+		if sf.Name == "Valid" {
+			continue
+		}
+		if c := describeCondition(sf); c != nil && d.bit(c.Bit) != c.Value {
+			continue
+		}
+		if w, _ := strconv.Atoi(sf.Tag.Get("aisWidth")); w > 0 {
+			width += w
+		}
+	}
+	return width
+}
+
+// dissectStruct adds the fields of st starting at offset and returns the offset after them
+func (d *dissector) dissectStruct(st reflect.Type, prefix string, offset int) int {
+	for i := 0; i < st.NumField(); i++ {
+		sf := st.Field(i)
+		if sf.Name == "Valid" {
+			continue
+		}
+		if c := describeCondition(sf); c != nil && d.bit(c.Bit) != c.Value {
+			continue
+		}
+
+		name := prefix + sf.Name
+
+		switch sf.Type.Kind() {
+		case reflect.Struct:
+			/* Optional structs are left out if they do not fit, like the decoder does */
+			if sf.Anonymous {
+				offset = d.dissectStruct(sf.Type, prefix, offset)
+			} else if !isOptionalStruct(sf.Type) || len(d.payload)-offset >= d.fixedWidth(sf.Type) {
+				offset = d.dissectStruct(sf.Type, name+".", offset)
+			}
+
+		case reflect.Array:
+			for k := 0; k < sf.Type.Len(); k++ {
+				/* Only the first element is required, the others are present if they fit */
+				if k > 0 && len(d.payload)-offset < d.fixedWidth(sf.Type.Elem()) {
+					break
+				}
+				offset = d.dissectStruct(sf.Type.Elem(), name+"["+strconv.Itoa(k)+"].", offset)
+			}
+
+		default:
+			width, _ := strconv.Atoi(sf.Tag.Get("aisWidth"))
+			if width < 0 {
+				/* Variable length fields take what the fixed fields leave */
+				width = len(d.payload) - d.fixedWidth(st)
+				if width < 0 {
+					width = 0
+				}
+			}
+
+			offset = d.dissectField(sf, name, offset, width)
+		}
+	}
+
+	return offset
+}
+
+func (d *dissector) dissectField(sf reflect.StructField, name string, offset int, width int) int {
+	fd := describeField(sf, offset)
+	if check, ok := sf.Tag.Lookup("aisCheckValue"); ok {
+		fd.HasEncodeAs = true
+		fd.EncodeAs, _ = strconv.ParseInt(check, 10, 64)
+	}
+
+	bits := d.bits(offset, offset+width)
+	fs := FieldSlice{
+		Name:    name,
+		Type:    fd.Type,
+		Start:   offset,
+		End:     offset + width,
+		Bits:    bits,
+		Overrun: offset+width > len(d.payload),
+	}
+
+	switch fd.Type {
+	case FieldTypeUnsigned, FieldTypeSigned, FieldTypeBool:
+		fs.Raw = bitsValue(bits) << uint(width-len(bits))
+		if fd.Signed && width > 0 && fs.Raw&(1<<uint(width-1)) != 0 {
+			fs.Raw -= 1 << uint(width)
+		}
+
+		switch {
+		case fd.Type == FieldTypeBool:
+			fs.Value = fs.Raw != 0
+		case fd.Scale != ScaleNone:
+			fs.Value = float64(fs.Raw) / fd.Scale.Divisor()
+		case fd.Signed:
+			fs.Value = fs.Raw
+		default:
+			fs.Value = uint64(fs.Raw)
+		}
+
+		fs.SpareViolation = fd.HasEncodeAs && fs.Raw != fd.EncodeAs
+
+	case FieldTypeString:
+		/* Only complete characters are converted, padding is not removed */
+		str := make([]byte, len(bits)/6)
+		for i := range str {
+			c := bitsValue(bits[6*i : 6*i+6])
+			if c < 32 {
+				c += 64
+			}
+			str[i] = byte(c)
+		}
+		fs.Value = string(str)
+
+	case FieldTypeBinary:
+		fs.Value = append([]byte{}, bits...)
+	}
+
+	d.fields = append(d.fields, fs)
+	return offset + width
+}
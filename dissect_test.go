@@ -0,0 +1,110 @@
+package ais
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDissect(t *testing.T) {
+	c := CodecNew(false, false)
+
+	for msgID := 1; msgID <= 27; msgID++ {
+		for _, bits := range readDescribeTestMessages(t, msgID) {
+			if c.DecodePacket(bits) == nil {
+				continue
+			}
+
+			fields := Dissect(bits)
+			pos := 0
+			for _, f := range fields {
+				if f.Start != pos || f.Overrun {
+					t.Fatal("Wrong field", msgID, f)
+				}
+				pos = f.End
+			}
+			if pos != len(bits) {
+				t.Fatal("Bits not covered", msgID, pos, len(bits))
+			}
+		}
+	}
+}
+
+func TestDissectValues(t *testing.T) {
+	c := CodecNew(false, false)
+	bits := readDescribeTestMessages(t, 5)[0]
+	p := c.DecodePacket(bits).(ShipStaticData)
+
+	values := make(map[string]interface{})
+	for _, f := range Dissect(bits) {
+		values[f.Name] = f.Value
+	}
+
+	if values["UserID"] != uint64(p.UserID) || values["Dimension.B"] != uint64(p.Dimension.B) ||
+		values["MaximumStaticDraught"] != float64(p.MaximumStaticDraught) ||
+		strings.TrimRight(values["Name"].(string), "@") != p.Name {
+		t.Error("Wrong values", values, p)
+	}
+}
+
+func TestDissectProblems(t *testing.T) {
+	c := CodecNew(false, false)
+	bits := append([]byte{}, readDescribeTestMessages(t, 1)[0]...)
+
+	/* Set a spare bit and add extra bits after the message */
+	bits[146] = 1
+	fields := Dissect(append(bits[:168:168], 1, 0, 1))
+	last := fields[len(fields)-1]
+	if !last.Trailing || last.Start != 168 || last.End != 171 {
+		t.Error("Wrong trailing bits", last)
+	}
+	for _, f := range fields {
+		if f.Name == "Spare" && !f.SpareViolation {
+			t.Error("Spare violation not detected", f)
+		}
+	}
+
+	fields = Dissect(bits[:100])
+	last = fields[len(fields)-1]
+	if last.Name != "CommunicationState" || !last.Overrun || len(last.Bits) != 0 {
+		t.Error("Overrun not detected", last)
+	}
+	for _, f := range fields {
+		if f.Name == "Latitude" && (!f.Overrun || len(f.Bits) != 11 || f.End != 116) {
+			t.Error("Partial field wrong", f)
+		}
+	}
+
+	/* The decoder gives up on the message the dissector could still split */
+	if c.DecodePacket(bits[:100]) != nil {
+		t.Error("Short message decoded")
+	}
+
+	/* Unknown message IDs only have a header */
+	fields = Dissect([]byte{1, 1, 1, 1, 1, 1, 0, 0})
+	if len(fields) != 3 || !fields[2].Overrun {
+		t.Error("Wrong header dissection", fields)
+	}
+
+	/* Conditional and variable length fields */
+	p := SingleSlotBinaryMessage{
+		Header:             Header{MessageID: 25, UserID: 1},
+		Valid:              true,
+		ApplicationIDValid: true,
+		ApplicationID:      FieldApplicationIdentifier{Valid: true, DesignatedAreaCode: 235, FunctionIdentifier: 10},
+		Payload:            []byte{1, 0, 1, 1},
+	}
+	encoded := c.EncodePacket(p)
+	var names []string
+	for _, f := range Dissect(encoded) {
+		names = append(names, f.Name)
+		if f.Name == "ApplicationID.DesignatedAreaCode" && f.Value != uint64(235) {
+			t.Error("Wrong application ID", f)
+		}
+	}
+	want := []string{"MessageID", "RepeatIndicator", "UserID", "DestinationIDValid", "ApplicationIDValid",
+		"ApplicationID.DesignatedAreaCode", "ApplicationID.FunctionIdentifier", "Payload"}
+	if !reflect.DeepEqual(names[:len(want)], want) {
+		t.Error("Wrong fields", names)
+	}
+}
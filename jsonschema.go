@@ -0,0 +1,195 @@
+package ais
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// jsonSchema is the subset of JSON Schema (draft 7) used to describe packets
+type jsonSchema struct {
+	Schema      string `json:"$schema,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type"`
+
+	Const   interface{} `json:"const,omitempty"`
+	Default interface{} `json:"default,omitempty"`
+	Minimum *float64    `json:"minimum,omitempty"`
+	Maximum *float64    `json:"maximum,omitempty"`
+
+	MaxLength       *int   `json:"maxLength,omitempty"`
+	Pattern         string `json:"pattern,omitempty"`
+	ContentEncoding string `json:"contentEncoding,omitempty"`
+
+	Items    *jsonSchema `json:"items,omitempty"`
+	MinItems *int        `json:"minItems,omitempty"`
+	MaxItems *int        `json:"maxItems,omitempty"`
+
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+}
+
+/* Characters that can be represented in the 6 bit AIS character set */
+const jsonSchemaStringPattern = "^[ -_]*$"
+
+// JSONSchema returns a JSON Schema document describing the encoding/json representation of the
+// packets with the given message ID. Float fields are described after conversion, see
+// Codec.FloatWithoutConversion.
+func JSONSchema(messageID uint8) ([]byte, error) {
+	d := Describe(messageID)
+	if !d.Valid {
+		return nil, fmt.Errorf("ais: unknown message ID [%d]", messageID)
+	}
+
+	s := jsonSchemaStruct(msgMap[messageID].rType, d.Fields)
+	s.Schema = "http://json-schema.org/draft-07/schema#"
+	s.Title = d.Name
+	s.Description = fmt.Sprintf("AIS message %d, at most %d bits", messageID, d.MaxLength)
+
+	id := float64(messageID)
+	s.Properties["MessageID"].Const = id
+	s.Properties["MessageID"].Minimum = nil
+	s.Properties["MessageID"].Maximum = nil
+
+	return json.MarshalIndent(s, "", "  ")
+}
+
+func jsonSchemaFind(fields []FieldDescriptor, name string) *FieldDescriptor {
+	for i := range fields {
+		if fields[i].Name == name {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+func jsonSchemaStruct(st reflect.Type, fields []FieldDescriptor) *jsonSchema {
+	no := false
+	s := &jsonSchema{
+		Type:                 "object",
+		Properties:           make(map[string]*jsonSchema),
+		AdditionalProperties: &no,
+	}
+
+	var add func(st reflect.Type)
+	add = func(st reflect.Type) {
+		for i := 0; i < st.NumField(); i++ {
+			sf := st.Field(i)
+			if sf.Anonymous {
+				add(sf.Type)
+				continue
+			}
+
+			s.Required = append(s.Required, sf.Name)
+			if sf.Name == "Valid" {
+				s.Properties[sf.Name] = &jsonSchema{
+					Type:        "boolean",
+					Description: "Set if the structure was present in the message",
+				}
+				continue
+			}
+
+			s.Properties[sf.Name] = jsonSchemaField(sf.Type, jsonSchemaFind(fields, sf.Name))
+		}
+	}
+	add(st)
+
+	return s
+}
+
+func jsonSchemaRange(s *jsonSchema, f *FieldDescriptor) {
+	var min, max float64
+	if f.Signed {
+		min = -float64(uint64(1) << uint(f.Width-1))
+		max = float64(uint64(1)<<uint(f.Width-1)) - 1
+	} else {
+		max = float64(uint64(1)<<uint(f.Width)) - 1
+	}
+
+	min /= f.Scale.Divisor()
+	max /= f.Scale.Divisor()
+	s.Minimum = &min
+	s.Maximum = &max
+}
+
+func jsonSchemaField(t reflect.Type, f *FieldDescriptor) *jsonSchema {
+	var s *jsonSchema
+
+	switch f.Type {
+	case FieldTypeUnsigned, FieldTypeSigned:
+		s = &jsonSchema{Type: "integer"}
+		if f.Scale != ScaleNone {
+			s.Type = "number"
+		}
+		jsonSchemaRange(s, f)
+	case FieldTypeBool:
+		s = &jsonSchema{Type: "boolean"}
+	case FieldTypeString:
+		s = &jsonSchema{Type: "string", Pattern: jsonSchemaStringPattern}
+		if f.Width >= 0 {
+			n := f.Width / 6
+			s.MaxLength = &n
+		}
+	case FieldTypeBinary:
+		s = &jsonSchema{Type: "string", ContentEncoding: "base64"}
+	case FieldTypeStruct:
+		s = jsonSchemaStruct(t, f.Fields)
+	case FieldTypeArray:
+		n := f.Count
+		s = &jsonSchema{
+			Type:     "array",
+			Items:    jsonSchemaStruct(t.Elem(), f.Fields),
+			MinItems: &n,
+			MaxItems: &n,
+		}
+	}
+
+	if f.HasEncodeAs {
+		s.Default = f.EncodeAs
+		if f.Type == FieldTypeBool {
+			s.Default = f.EncodeAs != 0
+		}
+	}
+	s.Description = jsonSchemaDescription(f)
+
+	return s
+}
+
+func jsonSchemaDescription(f *FieldDescriptor) string {
+	var parts []string
+
+	width := strconv.Itoa(f.Width) + " bits"
+	if f.Width == 1 {
+		width = "1 bit"
+	} else if f.Width < 0 {
+		width = "variable length"
+	}
+	if f.Type == FieldTypeArray {
+		width = strconv.Itoa(f.Count) + " elements of " + width
+	}
+	if f.Offset >= 0 {
+		parts = append(parts, "offset "+strconv.Itoa(f.Offset)+", "+width)
+	} else {
+		parts = append(parts, width)
+	}
+
+	if f.Scale != ScaleNone {
+		parts = append(parts, "raw value divided by "+strconv.FormatFloat(f.Scale.Divisor(), 'f', -1, 64))
+	}
+	if f.Condition != nil {
+		parts = append(parts, fmt.Sprintf("only present if %s is %t", f.Condition.Field, f.Condition.Value))
+	}
+	if f.Optional {
+		parts = append(parts, "optional")
+	}
+	if f.HasEncodeAs {
+		parts = append(parts, "encoded as "+strconv.FormatInt(f.EncodeAs, 10))
+	}
+
+	s := strings.Join(parts, ", ")
+	return strings.ToUpper(s[:1]) + s[1:]
+}
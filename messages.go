@@ -1,4 +1,4 @@
-//go:generate go run ./parser_generator --input=messages.go
+//go:generate go run ./parser_generator --input=messages.go --proto=aisproto
 package ais
 
 import (
@@ -16,6 +16,7 @@ import (
 
 var inputFile = flag.String("input", "", "Input file")
 var outputDir = flag.String("output", "", "Output directory")
+var protoDir = flag.String("proto", "", "Output directory for the protobuf schema and conversion, not generated if empty")
 
 var msgMap = map[int]string{
 	1:  "PositionReport",
@@ -527,4 +528,8 @@ length = ` + strconv.Itoa(field.width) + "\n"
 		panic(err)
 	}
 	log.Println("generated: ", outputPath)
+
+	if *protoDir != "" {
+		generateProto(f, *protoDir)
+	}
 }
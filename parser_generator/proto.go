@@ -0,0 +1,481 @@
+package main
+
+import (
+	"go/ast"
+	"go/format"
+	"go/token"
+	"log"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// protoKind describes how a Go field is represented in protobuf
+type protoKind int
+
+const (
+	protoUnsigned protoKind = iota
+	protoSigned
+	protoBool
+	protoDouble
+	protoString
+	protoBytes
+	protoMessage
+)
+
+type protoField struct {
+	goName   string
+	name     string
+	number   int
+	kind     protoKind
+	typ      string /* Go type, the message name for protoMessage */
+	repeated int    /* Array length, 0 for a single value */
+}
+
+type protoMessageDef struct {
+	name   string
+	doc    string
+	fields []protoField
+}
+
+/* Names that the generic conversion would split in an unexpected place */
+var protoNameReplacer = strings.NewReplacer("AtoN", "Aton")
+
+// protoName converts a Go identifier to the snake case used for protobuf field names
+func protoName(name string) string {
+	runes := []rune(protoNameReplacer.Replace(name))
+
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				sb.WriteByte('_')
+			}
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+
+	return sb.String()
+}
+
+func protoFieldKind(typ string) protoKind {
+	switch typ {
+	case "uint8", "uint16", "uint32":
+		return protoUnsigned
+	case "int16":
+		return protoSigned
+	case "bool":
+		return protoBool
+	case "Field10", "FieldLatLonFine", "FieldLatLonCoarse":
+		return protoDouble
+	case "string":
+		return protoString
+	}
+	return protoMessage
+}
+
+// collectProtoMessages returns a message for every exported struct in the file, in source order
+func collectProtoMessages(f *ast.File) []protoMessageDef {
+	var messages []protoMessageDef
+
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok || !unicode.IsUpper(rune(ts.Name.Name[0])) {
+				continue
+			}
+
+			m := protoMessageDef{name: ts.Name.Name}
+			if gd.Doc != nil {
+				m.doc = gd.Doc.Text()
+			}
+
+			for i, field := range st.Fields.List {
+				pf := protoField{number: i + 1}
+
+				switch t := field.Type.(type) {
+				case *ast.Ident:
+					pf.typ = t.Name
+					pf.kind = protoFieldKind(t.Name)
+				case *ast.ArrayType:
+					elem := t.Elt.(*ast.Ident).Name
+					if t.Len == nil {
+						if elem != "byte" {
+							panic("unhandled slice type: " + elem)
+						}
+						pf.typ = "[]byte"
+						pf.kind = protoBytes
+					} else {
+						n, err := strconv.Atoi(t.Len.(*ast.BasicLit).Value)
+						if err != nil {
+							panic(err)
+						}
+						pf.typ = elem
+						pf.kind = protoFieldKind(elem)
+						pf.repeated = n
+						if pf.kind != protoMessage {
+							panic("unhandled array type: " + elem)
+						}
+					}
+				default:
+					panic("unhandled field type in " + m.name)
+				}
+
+				/* Embedded structs are accessed by their type name */
+				pf.goName = pf.typ
+				if len(field.Names) > 0 {
+					pf.goName = field.Names[0].Name
+				}
+				pf.name = protoName(pf.goName)
+
+				m.fields = append(m.fields, pf)
+			}
+
+			messages = append(messages, m)
+		}
+	}
+
+	return messages
+}
+
+// packetMessages returns the packet message names and the lowest message ID using each
+func packetMessages() ([]string, map[string]int) {
+	var names []string
+	ids := make(map[string]int)
+	for i := 1; i < 28; i++ {
+		name, ok := msgMap[i]
+		if !ok {
+			continue
+		}
+		if _, ok := ids[name]; !ok {
+			ids[name] = i
+			names = append(names, name)
+		}
+	}
+	return names, ids
+}
+
+func protoScalarType(k protoKind) string {
+	switch k {
+	case protoUnsigned:
+		return "uint32"
+	case protoSigned:
+		return "sint32"
+	case protoBool:
+		return "bool"
+	case protoDouble:
+		return "double"
+	case protoString:
+		return "string"
+	case protoBytes:
+		return "bytes"
+	}
+	panic("not a scalar")
+}
+
+func protoComment(doc string, indent string) string {
+	var out string
+	for _, line := range strings.Split(strings.TrimSpace(doc), "\n") {
+		out += strings.TrimRight(indent+"// "+line, " ") + "\n"
+	}
+	return out
+}
+
+func generateProtoSchema(messages []protoMessageDef) string {
+	output := `// WARNING: This file is generated by parser_generator/main.go do not edit directly.
+
+syntax = "proto3";
+
+package ais;
+
+option go_package = "github.com/BertoldVdb/go-ais/aisproto";
+
+`
+
+	names, ids := packetMessages()
+	output += "// Packet contains one of the packet types, selected by the message ID in its header. The field\n"
+	output += "// numbers are the lowest message ID using each type.\n"
+	output += "message Packet {\n  oneof packet {\n"
+	for _, name := range names {
+		output += "    " + name + " " + protoName(name) + " = " + strconv.Itoa(ids[name]) + ";\n"
+	}
+	output += "  }\n}\n"
+
+	for _, m := range messages {
+		output += "\n"
+		if m.doc != "" {
+			output += protoComment(m.doc, "")
+		}
+		output += "message " + m.name + " {\n"
+		for _, f := range m.fields {
+			typ := f.typ
+			if f.kind != protoMessage {
+				typ = protoScalarType(f.kind)
+			}
+			if f.repeated > 0 {
+				typ = "repeated " + typ
+			}
+			output += "  " + typ + " " + f.name + " = " + strconv.Itoa(f.number) + ";\n"
+		}
+		output += "}\n"
+	}
+
+	return output
+}
+
+func generateProtoMarshal(m protoMessageDef) string {
+	output := `
+func marshal` + m.name + `(b []byte, v *ais.` + m.name + `) []byte {
+`
+	for _, f := range m.fields {
+		n := strconv.Itoa(f.number)
+		value := "v." + f.goName
+
+		if f.repeated > 0 {
+			output += `	for i := range ` + value + ` {
+		b = appendMessageField(b, ` + n + `, marshal` + f.typ + `(nil, &` + value + `[i]))
+	}
+`
+			continue
+		}
+
+		switch f.kind {
+		case protoUnsigned:
+			output += `	if ` + value + ` != 0 {
+		b = appendVarintField(b, ` + n + `, uint64(` + value + `))
+	}
+`
+		case protoSigned:
+			output += `	if ` + value + ` != 0 {
+		b = appendVarintField(b, ` + n + `, zigzagEncode(int64(` + value + `)))
+	}
+`
+		case protoBool:
+			output += `	if ` + value + ` {
+		b = appendVarintField(b, ` + n + `, 1)
+	}
+`
+		case protoDouble:
+			output += `	if ` + value + ` != 0 {
+		b = appendFixed64Field(b, ` + n + `, math.Float64bits(float64(` + value + `)))
+	}
+`
+		case protoString, protoBytes:
+			output += `	if len(` + value + `) > 0 {
+		b = appendBytesField(b, ` + n + `, []byte(` + value + `))
+	}
+`
+		case protoMessage:
+			output += `	b = appendMessageField(b, ` + n + `, marshal` + f.typ + `(nil, &` + value + `))
+`
+		}
+	}
+	output += `	return b
+}
+`
+	return output
+}
+
+func generateProtoUnmarshal(m protoMessageDef) string {
+	output := `
+func unmarshal` + m.name + `(data []byte, v *ais.` + m.name + `) error {
+`
+	for _, f := range m.fields {
+		if f.repeated > 0 {
+			output += `	n` + f.goName + ` := 0
+`
+		}
+	}
+
+	for _, f := range m.fields {
+		if f.kind == protoBytes {
+			/* ais.Codec never returns nil slices */
+			output += `	v.` + f.goName + ` = []byte{}
+`
+		}
+	}
+
+	output += `	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return err
+		}
+		data = rest
+
+		switch f.number {
+`
+	for _, f := range m.fields {
+		n := strconv.Itoa(f.number)
+		value := "v." + f.goName
+
+		output += `		case ` + n + `:
+`
+		switch f.kind {
+		case protoUnsigned:
+			output += `			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			` + value + ` = ` + f.typ + `(f.value)
+`
+		case protoSigned:
+			output += `			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			` + value + ` = ` + f.typ + `(zigzagDecode(f.value))
+`
+		case protoBool:
+			output += `			if err := f.expect(wireVarint); err != nil {
+				return err
+			}
+			` + value + ` = f.value != 0
+`
+		case protoDouble:
+			output += `			if err := f.expect(wireFixed64); err != nil {
+				return err
+			}
+			` + value + ` = ais.` + f.typ + `(math.Float64frombits(f.value))
+`
+		case protoString:
+			output += `			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			` + value + ` = string(f.data)
+`
+		case protoBytes:
+			output += `			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+			` + value + ` = append([]byte{}, f.data...)
+`
+		case protoMessage:
+			output += `			if err := f.expect(wireBytes); err != nil {
+				return err
+			}
+`
+			target := "&" + value
+			if f.repeated > 0 {
+				output += `			if n` + f.goName + ` >= len(` + value + `) {
+				return errTooManyElements
+			}
+`
+				target = "&" + value + "[n" + f.goName + "]"
+			}
+			output += `			if err := unmarshal` + f.typ + `(f.data, ` + target + `); err != nil {
+				return err
+			}
+`
+			if f.repeated > 0 {
+				output += `			n` + f.goName + `++
+`
+			}
+		}
+	}
+
+	output += `		}
+	}
+	return nil
+}
+`
+	return output
+}
+
+func generateProtoGo(messages []protoMessageDef) string {
+	output := `// Code generated by parser_generator/main.go. DO NOT EDIT.
+
+package aisproto
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/BertoldVdb/go-ais"
+)
+
+// Marshal encodes a packet as a Packet protobuf message
+func Marshal(p ais.Packet) ([]byte, error) {
+	switch x := p.(type) {
+`
+	names, ids := packetMessages()
+	for _, name := range names {
+		output += `	case ais.` + name + `:
+		return appendMessageField(nil, ` + strconv.Itoa(ids[name]) + `, marshal` + name + `(nil, &x)), nil
+`
+	}
+	output += `	}
+	return nil, fmt.Errorf("aisproto: unsupported packet type [%T]", p)
+}
+
+// Unmarshal decodes a Packet protobuf message
+func Unmarshal(data []byte) (ais.Packet, error) {
+	var p ais.Packet
+
+	for len(data) > 0 {
+		f, rest, err := readField(data)
+		if err != nil {
+			return nil, err
+		}
+		data = rest
+
+		switch f.number {
+`
+	for _, name := range names {
+		output += `		case ` + strconv.Itoa(ids[name]) + `:
+			if err := f.expect(wireBytes); err != nil {
+				return nil, err
+			}
+			var x ais.` + name + `
+			if err := unmarshal` + name + `(f.data, &x); err != nil {
+				return nil, err
+			}
+			p = x
+`
+	}
+	output += `		}
+	}
+
+	if p == nil {
+		return nil, errNoPacket
+	}
+	return p, nil
+}
+`
+
+	for _, m := range messages {
+		output += generateProtoMarshal(m)
+		output += generateProtoUnmarshal(m)
+	}
+
+	return output
+}
+
+// generateProto writes the protobuf schema of all structs in f and the Go conversion functions
+// to dir
+func generateProto(f *ast.File, dir string) {
+	messages := collectProtoMessages(f)
+
+	schemaPath := path.Join(dir, "ais.proto")
+	if err := os.WriteFile(schemaPath, []byte(generateProtoSchema(messages)), 0644); err != nil {
+		panic(err)
+	}
+	log.Println("generated: ", schemaPath)
+
+	goPath := path.Join(dir, "aisproto_gen.go")
+	formatted, err := format.Source([]byte(generateProtoGo(messages)))
+	if err != nil {
+		panic(err)
+	}
+	if err := os.WriteFile(goPath, formatted, 0644); err != nil {
+		panic(err)
+	}
+	log.Println("generated: ", goPath)
+}